@@ -3,15 +3,33 @@ module github.com/go-spring/go-spring
 go 1.12
 
 require (
+	github.com/BurntSushi/toml v0.3.1
 	github.com/DATA-DOG/go-sqlmock v1.4.1
+	github.com/Shopify/sarama v1.29.0
 	github.com/elliotchance/redismock v1.5.3
+	github.com/fsnotify/fsnotify v1.5.4
+	github.com/go-playground/validator/v10 v10.2.0
 	github.com/go-redis/redis v6.15.7+incompatible
 	github.com/go-spring/go-spring-parent v1.0.4
 	github.com/go-spring/go-spring-web v1.0.5-0.20200711043336-1c38fc901565
+	github.com/gofiber/fiber/v2 v2.52.5
+	github.com/golang/protobuf v1.5.2
+	github.com/jackc/pgx/v4 v4.18.1
 	github.com/jinzhu/gorm v1.9.12
 	github.com/labstack/echo v3.3.10+incompatible
-	github.com/magiconair/properties v1.8.1
-	github.com/pkg/errors v0.8.1 // indirect
+	github.com/magiconair/properties v1.8.6
+	github.com/prometheus/client_golang v1.12.2
 	github.com/spf13/cast v1.3.1
-	github.com/spf13/viper v1.6.3
+	github.com/spf13/viper v1.7.0
+	github.com/testcontainers/testcontainers-go v0.14.0
+	go.opentelemetry.io/otel v1.14.0
+	go.opentelemetry.io/otel/exporters/jaeger v1.14.0
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc v1.14.0
+	go.opentelemetry.io/otel/exporters/stdout/stdouttrace v1.14.0
+	go.opentelemetry.io/otel/sdk v1.14.0
+	go.opentelemetry.io/otel/trace v1.14.0
+	go.uber.org/zap v1.21.0
+	golang.org/x/net v0.17.0
+	google.golang.org/grpc v1.53.0
+	gopkg.in/yaml.v2 v2.4.0
 )
@@ -18,6 +18,8 @@ package SpringBoot
 
 import (
 	"flag"
+	"fmt"
+	"io"
 	"os"
 	"regexp"
 	"strings"
@@ -53,9 +55,10 @@ type ApplicationEvent interface {
 
 // application SpringBoot 应用
 type application struct {
-	appCtx      ApplicationContext // 应用上下文
-	cfgLocation []string           // 配置文件目录
-	eventBeans  []ApplicationEvent // 提前缓存，加速退出
+	appCtx      ApplicationContext   // 应用上下文
+	cfgLocation []string             // 配置文件目录
+	sources     *PropertySourceChain // 配置文件目录对应的属性源
+	eventBeans  []ApplicationEvent   // 提前缓存，加速退出
 }
 
 // newApplication application 的构造函数
@@ -66,21 +69,47 @@ func newApplication(appCtx ApplicationContext, cfgLocation ...string) *applicati
 	return &application{
 		appCtx:      appCtx,
 		cfgLocation: cfgLocation,
+		sources:     newPropertySourceChain(cfgLocation),
 	}
 }
 
 // Start 启动 SpringBoot 应用
 func (app *application) Start() {
 
+	publisher := newEventPublisher(app.appCtx)
+
+	// 启动失败时发布应用失败事件，然后继续 panic
+	defer func() {
+		if r := recover(); r != nil {
+			err, ok := r.(error)
+			if !ok {
+				err = fmt.Errorf("%v", r)
+			}
+			publisher.fire(ApplicationFailedEvent{Err: err})
+			panic(r)
+		}
+	}()
+
 	// 准备上下文环境
 	app.prepare()
 
 	// 注册 ApplicationContext
 	app.appCtx.RegisterBean(app.appCtx)
 
+	// 注册事件发布者
+	publisher.register()
+
+	// 预检模式下输出报告后直接退出
+	if dryRun {
+		os.Exit(app.dryRun(os.Stdout))
+	}
+
 	// 依赖注入、属性绑定、Bean 初始化
 	app.appCtx.AutoWireBeans()
 
+	// 注入完成之后收集监听者 Bean，之后的失败事件也会发送给它们
+	publisher.collect()
+
 	var runners []CommandLineRunner
 	app.appCtx.CollectBeans(&runners)
 
@@ -96,6 +125,9 @@ func (app *application) Start() {
 		bean.OnStartApplication(app.appCtx)
 	}
 
+	// 发布应用就绪事件
+	publisher.PublishEvent(ApplicationReadyEvent{Context: app.appCtx})
+
 	SpringLogger.Info("spring boot started")
 }
 
@@ -103,16 +135,9 @@ func (app *application) Start() {
 func (_ *application) loadCmdArgs() SpringCore.Properties {
 	SpringLogger.Debugf("load cmd args")
 	p := SpringCore.NewDefaultProperties()
-	for i := 0; i < len(os.Args); i++ { // 以短线定义的参数才有效
-		if arg := os.Args[i]; strings.HasPrefix(arg, "-") {
-			k, v := arg[1:], ""
-			if i < len(os.Args)-1 && !strings.HasPrefix(os.Args[i+1], "-") {
-				v = os.Args[i+1]
-				i++
-			}
-			SpringLogger.Tracef("%s=%v", k, v)
-			p.SetProperty(k, v)
-		}
+	for k, v := range NewCommandLinePropertySource(os.Args[1:]).Load("") {
+		SpringLogger.Tracef("%s=%v", k, v)
+		p.SetProperty(k, v)
 	}
 	return p
 }
@@ -146,25 +171,69 @@ func (_ *application) loadSystemEnv() SpringCore.Properties {
 	return p
 }
 
-// loadProfileConfig 加载指定环境的配置文件
-func (app *application) loadProfileConfig(profile string) SpringCore.Properties {
-	p := SpringCore.NewDefaultProperties()
-	for _, configLocation := range app.cfgLocation {
-		var result map[string]interface{}
+// newPropertySourceChain 根据配置文件目录创建属性源链，不支持的类型被忽略
+func newPropertySourceChain(cfgLocation []string) *PropertySourceChain {
+	chain := NewPropertySourceChain()
+	for _, configLocation := range cfgLocation {
 		if ss := strings.Split(configLocation, ":"); len(ss) == 1 {
-			result = NewDefaultPropertySource(ss[0]).Load(profile)
+			chain.Add(NewDefaultPropertySource(ss[0]))
 		} else {
 			switch ss[0] {
 			case "k8s": // "k8s:testdata/config/config-map.yaml"
-				result = NewConfigMapPropertySource(ss[1]).Load(profile)
+				chain.Add(NewConfigMapPropertySource(ss[1]))
+			case "file": // "file:/etc/myapp/custom.yaml"
+				chain.Add(NewFilePropertySource(ss[1]))
+			case "ssl": // "ssl:/etc/myapp/server.crt"
+				chain.Add(NewSSLPropertySource(ss[1]))
 			}
 		}
-		for k, v := range result {
+	}
+	return chain
+}
+
+// loadProfileConfig 加载指定环境的配置文件
+func (app *application) loadProfileConfig(profile string) SpringCore.Properties {
+	p := SpringCore.NewDefaultProperties()
+	for k, v := range app.sources.Load(profile) {
+		SpringLogger.Tracef("%s=%v", k, v)
+		p.SetProperty(k, v)
+	}
+	return p
+}
+
+// loadVaultConfig 根据 spring.vault.* 属性加载 Vault 中保存的属性，未配置时返回 nil
+func (app *application) loadVaultConfig(p SpringCore.Properties, profiles ...string) SpringCore.Properties {
+
+	addr := p.GetStringProperty(SpringVaultAddr)
+	if addr == "" {
+		return nil
+	}
+
+	version := 2
+	if v := p.GetIntProperty(SpringVaultVersion); v != 0 {
+		version = int(v)
+	}
+
+	token := p.GetStringProperty(SpringVaultToken)
+	path := p.GetStringProperty(SpringVaultPath)
+	source := NewVaultPropertySource(addr, token, path, version)
+	source.done = app.appCtx.Context().Done()
+
+	paths := []string{""}
+	for _, profile := range profiles {
+		if profile != "" {
+			paths = append(paths, profile)
+		}
+	}
+
+	result := SpringCore.NewDefaultProperties()
+	for _, s := range paths {
+		for k, v := range source.Load(s) {
 			SpringLogger.Tracef("%s=%v", k, v)
-			p.SetProperty(k, v)
+			result.SetProperty(k, v)
 		}
 	}
-	return p
+	return result
 }
 
 // prepare 准备上下文环境
@@ -174,8 +243,8 @@ func (app *application) prepare() {
 	// 1.代码设置
 	// 2.命令行参数
 	// 3.系统环境变量
-	// 4.application-profile.properties
-	// 5.application.properties
+	// 4.application-profile.properties/yaml/toml/json
+	// 5.application.properties/yaml/toml/json
 	// 6.内部默认配置
 
 	// 将通过代码设置的属性值拷贝一份，第 1 层
@@ -196,16 +265,23 @@ func (app *application) prepare() {
 	cmdArgs := app.loadCmdArgs()
 	p.InsertBefore(cmdArgs, sysEnv)
 
-	// 加载特定环境的配置文件，如 application-test.properties
-	profile := app.appCtx.GetProfile()
-	if profile == "" {
+	// 加载特定环境的配置文件，如 application-test.properties，
+	// 激活了多个运行环境时后面的运行环境的配置文件优先级更高
+	if len(app.appCtx.GetProfiles()) == 0 {
 		keys := []string{SpringProfile, SPRING_PROFILE}
-		profile = p.GetStringProperty(keys...)
+		app.appCtx.SetProfile(p.GetStringProperty(keys...))
 	}
-	if profile != "" {
-		app.appCtx.SetProfile(profile) // 第 4 层
+	profiles := app.appCtx.GetProfiles()
+	fileConfig := appConfig
+	for _, profile := range profiles { // 第 4 层
 		profileConfig := app.loadProfileConfig(profile)
-		p.InsertBefore(profileConfig, appConfig)
+		p.InsertBefore(profileConfig, fileConfig)
+		fileConfig = profileConfig
+	}
+
+	// 加载 Vault 中保存的属性，优先级高于配置文件，低于系统环境变量
+	if vaultConfig := app.loadVaultConfig(p, profiles...); vaultConfig != nil {
+		p.InsertBefore(vaultConfig, fileConfig)
 	}
 
 	// 将重组后的属性值写入 SpringContext 属性列表
@@ -222,6 +298,50 @@ func (app *application) prepare() {
 	}
 }
 
+// dryRun 预检所有的 Bean 并输出报告，返回进程的退出码
+func (app *application) dryRun(w io.Writer) int {
+
+	passed, skipped, failed := 0, 0, 0
+
+	fmt.Fprintln(w, "dry run report:")
+	for _, r := range app.appCtx.DryRun() {
+
+		status := "create"
+		if !r.Passed {
+			status = "skip"
+			skipped++
+		} else if len(r.Errors) > 0 {
+			status = "error"
+			failed++
+		} else {
+			passed++
+		}
+
+		fmt.Fprintf(w, "  [%s] %s\n", status, r.Bean.Description())
+		if r.Condition != "" {
+			fmt.Fprintf(w, "      condition: %s\n", r.Condition)
+		}
+		for _, err := range r.Errors {
+			fmt.Fprintf(w, "      error: %s\n", err)
+		}
+	}
+
+	fmt.Fprintf(w, "%d beans would be created, %d skipped, %d failed\n", passed, skipped, failed)
+
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// dependencyGraph 加载属性并预检所有的 Bean，返回 format 格式的 Bean 依赖关系图
+func (app *application) dependencyGraph(format string) ([]byte, error) {
+	app.prepare()
+	app.appCtx.RegisterBean(app.appCtx)
+	registerEventPublisher(app.appCtx)
+	return SpringCore.ExportDependencyGraph(app.appCtx.DryRun(), format)
+}
+
 func (app *application) stopApplication() {
 	for _, bean := range app.eventBeans {
 		bean.OnStopApplication(app.appCtx)
@@ -233,6 +353,10 @@ func (app *application) ShutDown() {
 
 	SpringLogger.Info("spring boot exiting")
 
+	if window := app.appCtx.GracefulStopWindow(); window > 0 {
+		SpringLogger.Infof("graceful stop window %v", window)
+	}
+
 	// OnStopApplication 是否需要有 Timeout 的 Context？
 	// 仔细想想没有必要，程序想要优雅退出就得一直等，等到所有工作
 	// 做完，用户如果等不急了可以使用 kill -9 进行硬杀，也就是
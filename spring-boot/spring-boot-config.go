@@ -17,18 +17,26 @@
 package SpringBoot
 
 import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
 	"os"
 	"path/filepath"
-	"sort"
 	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/go-spring/go-spring-parent/spring-logger"
 	"github.com/go-spring/go-spring-parent/spring-utils"
+	"github.com/go-spring/go-spring/spring-core"
 	"github.com/spf13/viper"
 )
 
-// propertySource 属性源
-type propertySource interface {
+// PropertySource 属性源
+type PropertySource interface {
 	// Name 返回属性源的名称
 	Name() string
 
@@ -36,9 +44,55 @@ type propertySource interface {
 	Load(profile string) map[string]interface{}
 }
 
-// defaultPropertySource 基于默认配置文件的属性源
+// PropertySourceChain 属性源链，按照添加的顺序加载属性源，后面的属性源中相同的属性值
+// 覆盖前面的。
+type PropertySourceChain struct {
+	sources []PropertySource
+}
+
+// NewPropertySourceChain PropertySourceChain 的构造函数
+func NewPropertySourceChain(sources ...PropertySource) *PropertySourceChain {
+	return &PropertySourceChain{
+		sources: sources,
+	}
+}
+
+// Add 在属性源链的末尾添加一个属性源
+func (c *PropertySourceChain) Add(source PropertySource) {
+	c.sources = append(c.sources, source)
+}
+
+// Remove 删除第一个名称为 name 的属性源，返回是否删除了属性源。配置文件目录对应的
+// 默认属性源的名称为空字符串。
+func (c *PropertySourceChain) Remove(name string) bool {
+	for i, source := range c.sources {
+		if source.Name() == name {
+			c.sources = append(c.sources[:i], c.sources[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Load 按照顺序加载所有的属性源并合并，profile 配置文件剖面。
+func (c *PropertySourceChain) Load(profile string) map[string]interface{} {
+	result := make(map[string]interface{})
+	for _, source := range c.sources {
+		for k, v := range source.Load(profile) {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// defaultPropertySource 基于默认配置文件的属性源，支持 application[-profile] 加上
+// .properties、.yaml、.toml 和 .json 扩展名的配置文件，同时存在时按照这个顺序加载，
+// 后加载的文件中相同的属性值覆盖先加载的。
 type defaultPropertySource struct {
 	fileLocation string // 配置文件所在目录
+
+	mutex    sync.Mutex
+	profiles []string // 已经加载过的配置文件剖面，按照加载顺序排列
 }
 
 // NewDefaultPropertySource defaultPropertySource 的构造函数
@@ -56,41 +110,396 @@ func (p *defaultPropertySource) Name() string {
 // Load 加载属性文件，profile 配置文件剖面。
 func (p *defaultPropertySource) Load(profile string) map[string]interface{} {
 
-	fileNamePrefix := "application"
-	if profile != "" {
-		fileNamePrefix += "-" + profile
+	p.mutex.Lock()
+	if !p.loaded(profile) {
+		p.profiles = append(p.profiles, profile)
 	}
+	p.mutex.Unlock()
 
 	result := make(map[string]interface{})
 
 	// 从预定义的文件中加载属性列表
-	for _, ext := range []string{".properties", ".yaml", ".toml"} {
-
-		filename := filepath.Join(p.fileLocation, fileNamePrefix+ext)
+	for _, filename := range p.fileNames(profile) {
 		if _, err := os.Stat(filename); err != nil {
 			continue // 这里不需要警告
 		}
 
 		SpringLogger.Info("load properties from file ", filename)
 
-		v := viper.New()
-		v.SetConfigFile(filename)
+		for key, val := range readConfigProperties(filename) {
+			result[key] = val
+		}
+	}
+
+	return result
+}
+
+// loaded 配置文件剖面是否已经加载过
+func (p *defaultPropertySource) loaded(profile string) bool {
+	for _, s := range p.profiles {
+		if s == profile {
+			return true
+		}
+	}
+	return false
+}
+
+// fileNames 返回配置文件剖面对应的所有预定义文件，文件不一定存在
+func (p *defaultPropertySource) fileNames(profile string) []string {
+
+	fileNamePrefix := "application"
+	if profile != "" {
+		fileNamePrefix += "-" + profile
+	}
+
+	var result []string
+	for _, ext := range []string{".properties", ".yaml", ".toml", ".json"} {
+		result = append(result, filepath.Join(p.fileLocation, fileNamePrefix+ext))
+	}
+	return result
+}
+
+// watchDebounce 文件变化的合并间隔
+const watchDebounce = 100 * time.Millisecond
+
+// Watch 监听已经加载的配置文件，文件变化时重新加载所有已经加载过的配置文件剖面，
+// 合并之后通过 onChange 返回完整的属性列表。短时间内的多次变化只会触发一次回调。
+// 必须在 Load 之后调用，监听在后台进行，ctx 结束时停止监听。
+func (p *defaultPropertySource) Watch(ctx context.Context, onChange func(map[string]interface{})) error {
+
+	p.mutex.Lock()
+	loaded := len(p.profiles) > 0
 
+	// 默认配置总是先加载，然后按照加载顺序覆盖
+	profiles := []string{""}
+	for _, profile := range p.profiles {
+		if profile != "" {
+			profiles = append(profiles, profile)
+		}
+	}
+	p.mutex.Unlock()
+
+	if !loaded {
+		return errors.New("properties not loaded")
+	}
+
+	// 监听目录而不是文件，编辑器保存文件时可能会删除并重新创建文件
+	files := make(map[string]bool)
+	for _, profile := range profiles {
+		for _, filename := range p.fileNames(profile) {
+			files[filepath.Clean(filename)] = true
+		}
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	if err = watcher.Add(p.fileLocation); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		var timer *time.Timer
+		defer func() {
+			if timer != nil {
+				timer.Stop()
+			}
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if !files[filepath.Clean(event.Name)] || event.Op == fsnotify.Chmod {
+					continue
+				}
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(watchDebounce, func() {
+					p.reload(ctx, profiles, onChange)
+				})
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				SpringLogger.Error("watch properties error: ", err)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// reload 重新加载所有配置文件剖面并合并，文件内容有误时打印日志并忽略本次变化
+func (p *defaultPropertySource) reload(ctx context.Context, profiles []string, onChange func(map[string]interface{})) {
+
+	defer func() {
+		if err := recover(); err != nil {
+			SpringLogger.Error("reload properties error: ", err)
+		}
+	}()
+
+	result := make(map[string]interface{})
+	for _, profile := range profiles {
+		for k, v := range p.Load(profile) {
+			result[k] = v
+		}
+	}
+
+	if ctx.Err() == nil {
+		onChange(result)
+	}
+}
+
+// readConfigFile 读取配置文件，.properties 文件需要先合并续行
+func readConfigFile(filename string) *viper.Viper {
+	v := viper.New()
+
+	if ext := filepath.Ext(filename); ext != ".properties" {
+		v.SetConfigFile(filename)
 		err := v.ReadInConfig()
 		SpringUtils.Panic(err).When(err != nil)
+		return v
+	}
 
-		keys := v.AllKeys()
-		sort.Strings(keys)
+	data, err := ioutil.ReadFile(filename)
+	SpringUtils.Panic(err).When(err != nil)
 
-		for _, key := range keys {
-			val := v.Get(key)
-			result[key] = val
+	v.SetConfigType("properties")
+	err = v.ReadConfig(joinContinuationLines(string(data)))
+	SpringUtils.Panic(err).When(err != nil)
+	return v
+}
+
+// readConfigProperties 读取配置文件中的属性值列表，TOML 文件不经过 viper 而是直接解析，
+// 保证嵌套表、内联表和表数组的属性名都按照 YAML 的点号风格展开。
+func readConfigProperties(filename string) map[string]interface{} {
+
+	var result map[string]interface{}
+
+	if filepath.Ext(filename) == ".toml" {
+		result = readTomlFile(filename)
+	} else {
+		result = make(map[string]interface{})
+		v := readConfigFile(filename)
+		for _, key := range v.AllKeys() {
+			result[key] = v.Get(key)
+		}
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	SpringUtils.Panic(err).When(err != nil)
+	return restoreKeyCase(result, string(data), filepath.Ext(filename)[1:])
+}
+
+// restoreKeyCase 使用配置内容中原始的属性名作为属性名，属性源的使用者会把属性名转成小写，
+// 同时按照原始的属性名记录规范化的属性名
+func restoreKeyCase(m map[string]interface{}, content string, configType string) map[string]interface{} {
+	original := SpringCore.OriginalPropertyKeys([]byte(content), configType)
+	result := make(map[string]interface{}, len(m))
+	for key, val := range m {
+		if k, ok := original[key]; ok {
+			key = k
+		}
+		result[key] = val
+	}
+	return result
+}
+
+// readTomlFile 使用 SpringCore.ReadTomlProperties 读取 TOML 文件
+func readTomlFile(filename string) map[string]interface{} {
+	file, err := os.Open(filename)
+	SpringUtils.Panic(err).When(err != nil)
+	defer file.Close()
+
+	result, err := SpringCore.ReadTomlProperties(file)
+	SpringUtils.Panic(err).When(err != nil)
+	return result
+}
+
+// joinContinuationLines 合并 .properties 文件中以反斜杠结尾的续行，行尾的
+// 空白字符会被忽略，续行的前导空白字符会被删除。行尾偶数个反斜杠是转义的
+// 反斜杠，不是续行。注释行不能续行。
+func joinContinuationLines(content string) *strings.Reader {
+	var buf strings.Builder
+
+	continued := false
+	for _, line := range strings.Split(content, "\n") {
+
+		if continued {
+			line = strings.TrimLeft(line, " \t\f")
+		} else if s := strings.TrimLeft(line, " \t\f"); strings.HasPrefix(s, "#") || strings.HasPrefix(s, "!") {
+			buf.WriteString(line)
+			buf.WriteByte('\n')
+			continue
+		}
+
+		// 计算行尾反斜杠的数量
+		trimmed := strings.TrimRight(line, " \t\f\r")
+		n := 0
+		for n < len(trimmed) && trimmed[len(trimmed)-1-n] == '\\' {
+			n++
+		}
+
+		if continued = n%2 == 1; continued {
+			buf.WriteString(trimmed[:len(trimmed)-1])
+		} else {
+			buf.WriteString(line)
+			buf.WriteByte('\n')
 		}
 	}
 
+	return strings.NewReader(buf.String())
+}
+
+// filePropertySource 基于指定文件路径的属性源，根据扩展名选择解析器
+type filePropertySource struct {
+	path string // 配置文件路径
+}
+
+// NewFilePropertySource filePropertySource 的构造函数
+func NewFilePropertySource(path string) *filePropertySource {
+	return &filePropertySource{
+		path: path,
+	}
+}
+
+// Name 返回属性源的名称
+func (p *filePropertySource) Name() string {
+	return "file"
+}
+
+// Load 加载属性文件，profile 配置文件剖面，不为空时在扩展名前面加上 -profile。
+func (p *filePropertySource) Load(profile string) map[string]interface{} {
+
+	filename := p.path
+	if profile != "" {
+		ext := filepath.Ext(filename)
+		filename = strings.TrimSuffix(filename, ext) + "-" + profile + ext
+	}
+
+	if _, err := os.Stat(filename); err != nil {
+		return make(map[string]interface{}) // 这里不需要警告
+	}
+
+	SpringLogger.Info("load properties from file ", filename)
+	return readConfigProperties(filename)
+}
+
+// commandLinePropertySource 基于命令行参数的属性源，支持 --key=value、-key=value、
+// --key value 和 -key value 几种形式，没有值的参数 (如 --feature) 的值为 true，
+// 不以短线开头的参数被忽略，单独的 -- 之后的参数都不再解析。
+type commandLinePropertySource struct {
+	args []string
+}
+
+// NewCommandLinePropertySource commandLinePropertySource 的构造函数，args 为 nil 时使用 os.Args。
+func NewCommandLinePropertySource(args []string) *commandLinePropertySource {
+	if args == nil {
+		args = os.Args[1:]
+	}
+	return &commandLinePropertySource{
+		args: args,
+	}
+}
+
+// Name 返回属性源的名称
+func (p *commandLinePropertySource) Name() string {
+	return "cmd"
+}
+
+// Load 解析命令行参数，忽略 profile 参数
+func (p *commandLinePropertySource) Load(profile string) map[string]interface{} {
+	result := make(map[string]interface{})
+	for i := 0; i < len(p.args); i++ {
+
+		arg := p.args[i]
+		if arg == "--" {
+			break
+		}
+
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+
+		k := strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+		if k == "" || strings.HasPrefix(k, "=") {
+			SpringLogger.Warnf("ignore cmd arg %s", arg)
+			continue
+		}
+
+		var v interface{} = "true"
+		if j := strings.Index(k, "="); j > 0 {
+			k, v = k[:j], k[j+1:]
+		} else if i < len(p.args)-1 && !strings.HasPrefix(p.args[i+1], "-") {
+			v = p.args[i+1]
+			i++
+		}
+		result[k] = v
+	}
+	return result
+}
+
+// memoryPropertySource 基于内存的属性源，不区分配置文件剖面，主要用于测试
+type memoryPropertySource struct {
+	props map[string]interface{}
+}
+
+// NewMemoryPropertySource memoryPropertySource 的构造函数
+func NewMemoryPropertySource(props map[string]interface{}) *memoryPropertySource {
+	p := &memoryPropertySource{
+		props: make(map[string]interface{}),
+	}
+	p.Merge(props)
+	return p
+}
+
+// Name 返回属性源的名称
+func (p *memoryPropertySource) Name() string {
+	return "memory"
+}
+
+// Load 返回属性值的副本，忽略 profile 参数
+func (p *memoryPropertySource) Load(profile string) map[string]interface{} {
+	result := make(map[string]interface{})
+	for k, v := range p.props {
+		result[k] = v
+	}
 	return result
 }
 
+// Set 设置属性值
+func (p *memoryPropertySource) Set(key string, val interface{}) {
+	p.props[key] = val
+}
+
+// Delete 删除属性值
+func (p *memoryPropertySource) Delete(key string) {
+	delete(p.props, key)
+}
+
+// Clear 删除所有的属性值
+func (p *memoryPropertySource) Clear() {
+	p.props = make(map[string]interface{})
+}
+
+// Merge 合并另一组属性值，相同的键使用新值覆盖
+func (p *memoryPropertySource) Merge(other map[string]interface{}) {
+	for k, v := range other {
+		p.props[k] = v
+	}
+}
+
 // configMapPropertySource 基于 k8s ConfigMap 的属性源
 type configMapPropertySource struct {
 	filename string // 配置文件名称
@@ -118,7 +527,8 @@ func (p *configMapPropertySource) Load(profile string) map[string]interface{} {
 	SpringUtils.Panic(err).When(err != nil)
 
 	d := v.Sub("data")
-	if d == nil {
+	b := v.Sub("binaryData")
+	if d == nil && b == nil {
 		return nil
 	}
 
@@ -130,31 +540,66 @@ func (p *configMapPropertySource) Load(profile string) map[string]interface{} {
 	result := make(map[string]interface{})
 
 	for _, ext := range []string{".properties", ".yaml", ".toml"} {
-		if key := profileFileName + ext; d.IsSet(key) {
+		key := profileFileName + ext
+
+		if d != nil && d.IsSet(key) {
 			SpringLogger.Infof("load properties from config-map %s:%s", p.filename, key)
 
 			if val := d.GetString(key); val != "" {
 				p.read(ext, val, result)
 			}
 		}
+
+		if b != nil && b.IsSet(key) {
+			SpringLogger.Infof("load properties from config-map %s:binaryData.%s", p.filename, key)
+
+			if val, ok := p.decode(key, b.GetString(key)); ok && val != "" {
+				p.read(ext, val, result)
+			}
+		}
 	}
 
 	return result
 }
 
+// decode 解码 binaryData 中 base64 编码的值，解码失败或者不是 UTF-8 文本时跳过
+func (p *configMapPropertySource) decode(key string, str string) (string, bool) {
+
+	data, err := base64.StdEncoding.DecodeString(str)
+	if err != nil {
+		SpringLogger.Warnf("skip config-map %s:binaryData.%s, %v", p.filename, key, err)
+		return "", false
+	}
+
+	if !utf8.Valid(data) {
+		SpringLogger.Warnf("skip config-map %s:binaryData.%s, not utf-8 text", p.filename, key)
+		return "", false
+	}
+
+	return string(data), true
+}
+
 func (p *configMapPropertySource) read(ext string, str string, result map[string]interface{}) {
 
-	v := viper.New()
-	v.SetConfigType(ext[1:])
+	m := make(map[string]interface{})
 
-	err := v.ReadConfig(strings.NewReader(str))
-	SpringUtils.Panic(err).When(err != nil)
+	if ext == ".toml" {
+		var err error
+		m, err = SpringCore.ReadTomlProperties(strings.NewReader(str))
+		SpringUtils.Panic(err).When(err != nil)
+	} else {
+		v := viper.New()
+		v.SetConfigType(ext[1:])
+
+		err := v.ReadConfig(strings.NewReader(str))
+		SpringUtils.Panic(err).When(err != nil)
 
-	keys := v.AllKeys()
-	sort.Strings(keys)
+		for _, key := range v.AllKeys() {
+			m[key] = v.Get(key)
+		}
+	}
 
-	for _, key := range keys {
-		val := v.Get(key)
+	for key, val := range restoreKeyCase(m, str, ext[1:]) {
 		result[key] = val
 	}
 }
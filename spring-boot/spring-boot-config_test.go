@@ -17,9 +17,27 @@
 package SpringBoot
 
 import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/go-spring/go-spring/spring-core"
 	"github.com/magiconair/properties/assert"
@@ -86,6 +104,25 @@ func TestConfig(t *testing.T) {
 		assert.Equal(t, app.appCtx.GetProfile(), "dev")
 	})
 
+	t.Run("multiple profiles via env", func(t *testing.T) {
+		os.Clearenv()
+		_ = os.Setenv(SpringProfile, "dev,test")
+		app := startApplication("testdata/config/")
+		assert.Equal(t, app.appCtx.GetProfile(), "dev")
+		assert.Equal(t, app.appCtx.GetProfiles(), []string{"dev", "test"})
+		assert.Equal(t, app.appCtx.GetStringProperty("spring.application.name"), "test.yaml")
+	})
+
+	t.Run("cmd args over config", func(t *testing.T) {
+		os.Clearenv()
+		args := os.Args
+		defer func() { os.Args = args }()
+		os.Args = []string{"app", "--spring.application.name=cmd", "-spring.profile", "dev"}
+		app := startApplication("testdata/config/")
+		assert.Equal(t, app.appCtx.GetProfile(), "dev")
+		assert.Equal(t, app.appCtx.GetStringProperty("spring.application.name"), "cmd")
+	})
+
 	t.Run("default expect system properties", func(t *testing.T) {
 		app := startApplication("testdata/config/")
 		for k, v := range app.appCtx.GetProperties() {
@@ -101,3 +138,773 @@ func TestConfig(t *testing.T) {
 		}
 	})
 }
+
+func TestFilePropertySource(t *testing.T) {
+
+	t.Run("properties", func(t *testing.T) {
+		p := NewFilePropertySource("testdata/file/custom.properties")
+		assert.Equal(t, p.Name(), "file")
+		result := p.Load("")
+		assert.Equal(t, result["custom.name"], "properties")
+		assert.Equal(t, result["custom.port"], "8080")
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		result := NewFilePropertySource("testdata/file/custom.yaml").Load("")
+		assert.Equal(t, result["custom.name"], "yaml")
+		assert.Equal(t, result["custom.port"], 8081)
+	})
+
+	t.Run("toml", func(t *testing.T) {
+		result := NewFilePropertySource("testdata/file/custom.toml").Load("")
+		assert.Equal(t, result["custom.name"], "toml")
+		assert.Equal(t, result["custom.port"], int64(8082))
+	})
+
+	t.Run("profile", func(t *testing.T) {
+		result := NewFilePropertySource("testdata/file/custom.yaml").Load("dev")
+		assert.Equal(t, result, map[string]interface{}{"custom.name": "yaml-dev"})
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		result := NewFilePropertySource("testdata/file/missing.yaml").Load("")
+		assert.Equal(t, result, map[string]interface{}{})
+
+		result = NewFilePropertySource("testdata/file/custom.toml").Load("dev")
+		assert.Equal(t, result, map[string]interface{}{})
+	})
+
+	t.Run("java-style keys", func(t *testing.T) {
+
+		dir, err := ioutil.TempDir("", "file")
+		assert.Equal(t, err, nil)
+		defer os.RemoveAll(dir)
+
+		for name, content := range map[string]string{
+			"custom.yaml":       "server:\n  contextPath: /api\n",
+			"custom.properties": "server.contextPath=/api\n",
+			"custom.toml":       "[server]\ncontextPath = \"/api\"\n",
+		} {
+			filename := filepath.Join(dir, name)
+			err = ioutil.WriteFile(filename, []byte(content), 0644)
+			assert.Equal(t, err, nil)
+
+			p := SpringCore.NewDefaultProperties()
+			for k, v := range NewFilePropertySource(filename).Load("") {
+				p.SetProperty(k, v)
+			}
+			assert.Equal(t, p.GetStringProperty("server.context-path"), "/api")
+			assert.Equal(t, p.GetStringProperty("server.contextpath"), "/api")
+		}
+	})
+
+	t.Run("config location", func(t *testing.T) {
+		os.Clearenv()
+		app := startApplication("testdata/config/", "file:testdata/file/custom.yaml")
+		assert.Equal(t, app.appCtx.GetStringProperty("custom.name"), "yaml")
+	})
+}
+
+func TestMemoryPropertySource(t *testing.T) {
+
+	p := NewMemoryPropertySource(map[string]interface{}{"a": 1})
+	assert.Equal(t, p.Name(), "memory")
+	assert.Equal(t, p.Load(""), map[string]interface{}{"a": 1})
+
+	t.Run("profile", func(t *testing.T) {
+		assert.Equal(t, p.Load("test"), map[string]interface{}{"a": 1})
+	})
+
+	t.Run("set", func(t *testing.T) {
+		p.Set("b", "2")
+		p.Set("a", 3)
+		assert.Equal(t, p.Load(""), map[string]interface{}{"a": 3, "b": "2"})
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		p.Delete("a")
+		p.Delete("c")
+		assert.Equal(t, p.Load(""), map[string]interface{}{"b": "2"})
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		p.Merge(map[string]interface{}{"b": 4, "c": true})
+		assert.Equal(t, p.Load(""), map[string]interface{}{"b": 4, "c": true})
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		p.Clear()
+		assert.Equal(t, p.Load(""), map[string]interface{}{})
+		p.Set("d", 5)
+		assert.Equal(t, p.Load("dev"), map[string]interface{}{"d": 5})
+	})
+
+	t.Run("copy", func(t *testing.T) {
+		props := map[string]interface{}{"e": 6}
+		m := NewMemoryPropertySource(props)
+		props["e"] = 7
+		result := m.Load("")
+		result["f"] = 8
+		assert.Equal(t, m.Load(""), map[string]interface{}{"e": 6})
+	})
+}
+
+func TestCommandLinePropertySource(t *testing.T) {
+
+	t.Run("key=value", func(t *testing.T) {
+		p := NewCommandLinePropertySource([]string{"--server.port=9090", "-a=b=c", "--empty="})
+		assert.Equal(t, p.Load(""), map[string]interface{}{
+			"server.port": "9090",
+			"a":           "b=c",
+			"empty":       "",
+		})
+	})
+
+	t.Run("key value", func(t *testing.T) {
+		p := NewCommandLinePropertySource([]string{"-server.port", "9090", "--name", "app"})
+		assert.Equal(t, p.Load(""), map[string]interface{}{
+			"server.port": "9090",
+			"name":        "app",
+		})
+	})
+
+	t.Run("boolean flags", func(t *testing.T) {
+		p := NewCommandLinePropertySource([]string{"--feature", "--debug", "-port", "8080", "--verbose"})
+		assert.Equal(t, p.Load(""), map[string]interface{}{
+			"feature": "true",
+			"debug":   "true",
+			"port":    "8080",
+			"verbose": "true",
+		})
+	})
+
+	t.Run("unknown flags", func(t *testing.T) {
+		p := NewCommandLinePropertySource([]string{"run", "-", "--=x", "--unknown-flag", "--", "--after=1"})
+		assert.Equal(t, p.Load(""), map[string]interface{}{
+			"unknown-flag": "true",
+		})
+	})
+}
+
+func TestConfigMapPropertySource_BinaryData(t *testing.T) {
+	p := NewConfigMapPropertySource("testdata/config/config-map-binary.yaml")
+
+	result := p.Load("")
+	assert.Equal(t, result["name"], "config-map")
+	assert.Equal(t, result["message"], "msg from config-map:binaryData")
+	assert.Equal(t, result["server.port"], 8080)
+
+	// 不是 UTF-8 文本或者不是 base64 编码的值被跳过
+	assert.Equal(t, p.Load("dev"), map[string]interface{}{})
+	assert.Equal(t, p.Load("test"), map[string]interface{}{})
+}
+
+func TestConfigMapPropertySource_Toml(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "config-map")
+	assert.Equal(t, err, nil)
+	defer os.RemoveAll(dir)
+
+	content := "[server]\nPort = 8080\n\n[[servers]]\nHost = \"a\"\nOpts = { TLS = true }\n"
+
+	configMap := "data:\n  application.toml: |-\n    " + strings.Replace(content, "\n", "\n    ", -1) + "\n"
+	filename := filepath.Join(dir, "config-map.yaml")
+	err = ioutil.WriteFile(filename, []byte(configMap), 0644)
+	assert.Equal(t, err, nil)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "application.toml"), []byte(content), 0644)
+	assert.Equal(t, err, nil)
+
+	// ConfigMap 中的 TOML 和 TOML 文件使用同一个解析器
+	result := NewConfigMapPropertySource(filename).Load("")
+	assert.Equal(t, result, NewDefaultPropertySource(dir).Load(""))
+	assert.Equal(t, result["server.Port"], int64(8080))
+	assert.Equal(t, result["servers.0.Opts.TLS"], true)
+	assert.Equal(t, result["servers"], []interface{}{
+		map[string]interface{}{"host": "a", "opts": map[string]interface{}{"tls": true}},
+	})
+}
+
+func TestPropertySourceChain(t *testing.T) {
+
+	memory := NewMemoryPropertySource(map[string]interface{}{"a": 1})
+	chain := NewPropertySourceChain(NewDefaultPropertySource("testdata/config/"), memory)
+	chain.Add(NewMemoryPropertySource(map[string]interface{}{"a": 2, "b": 2}))
+
+	result := chain.Load("")
+	assert.Equal(t, result["spring.application.name"], "test.properties")
+	assert.Equal(t, result["a"], 2)
+
+	assert.Equal(t, chain.Remove(""), true)
+	assert.Equal(t, chain.Remove(""), false)
+	assert.Equal(t, chain.Load(""), map[string]interface{}{"a": 2, "b": 2})
+
+	assert.Equal(t, chain.Remove("memory"), true)
+	assert.Equal(t, chain.Load(""), map[string]interface{}{"a": 2, "b": 2})
+
+	t.Run("replace default source", func(t *testing.T) {
+		os.Clearenv()
+		ctx := SpringCore.NewDefaultSpringContext()
+		builder := NewApplication().ConfigLocation("testdata/config/")
+		assert.Equal(t, builder.PropertySources().Remove(""), true)
+		builder.PropertySources().Add(NewMemoryPropertySource(map[string]interface{}{"spring.application.name": "memory"}))
+		app := builder.build(&defaultApplicationContext{SpringContext: ctx})
+		app.prepare()
+		assert.Equal(t, ctx.GetStringProperty("spring.application.name"), "memory")
+		assert.Equal(t, ctx.GetProfile(), "")
+	})
+}
+
+func TestDefaultPropertySource_Toml(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "toml")
+	assert.Equal(t, err, nil)
+	defer os.RemoveAll(dir)
+
+	load := func(content string) map[string]interface{} {
+		err := ioutil.WriteFile(filepath.Join(dir, "application.toml"), []byte(content), 0644)
+		assert.Equal(t, err, nil)
+		return NewDefaultPropertySource(dir).Load("")
+	}
+
+	t.Run("nested tables", func(t *testing.T) {
+		result := load("[server]\nname = \"a\"\n[server.http]\nPort = 8080\n")
+		assert.Equal(t, result, map[string]interface{}{
+			"server.name":      "a",
+			"server.http.Port": int64(8080),
+		})
+	})
+
+	t.Run("inline tables", func(t *testing.T) {
+		result := load("pool = { min = 1, max = { value = 10 } }\n")
+		assert.Equal(t, result, map[string]interface{}{
+			"pool.min":       int64(1),
+			"pool.max.value": int64(10),
+		})
+	})
+
+	t.Run("arrays", func(t *testing.T) {
+		result := load("ports = [8080, 8081]\n")
+		assert.Equal(t, result, map[string]interface{}{
+			"ports":   []interface{}{int64(8080), int64(8081)},
+			"ports.0": int64(8080),
+			"ports.1": int64(8081),
+		})
+	})
+
+	t.Run("array of tables", func(t *testing.T) {
+		result := load("[[servers]]\nHost = \"a\"\n[[servers]]\nhost = \"b\"\nOpts = { TLS = true }\n")
+		assert.Equal(t, result, map[string]interface{}{
+			"servers": []interface{}{
+				map[string]interface{}{"host": "a"},
+				map[string]interface{}{"host": "b", "opts": map[string]interface{}{"tls": true}},
+			},
+			"servers.0.Host":     "a",
+			"servers.1.host":     "b",
+			"servers.1.Opts.TLS": true,
+		})
+	})
+
+	t.Run("bind", func(t *testing.T) {
+		p := SpringCore.NewDefaultProperties()
+		for k, v := range load("[[servers]]\nhost = \"a\"\nport = 1\n[[servers]]\nhost = \"b\"\nport = 2\n") {
+			p.SetProperty(k, v)
+		}
+		var servers []struct {
+			Host string `value:"${host}"`
+			Port int    `value:"${port}"`
+		}
+		p.BindProperty("servers", &servers)
+		assert.Equal(t, len(servers), 2)
+		assert.Equal(t, servers[1].Host, "b")
+		assert.Equal(t, servers[1].Port, 2)
+		assert.Equal(t, p.GetStringProperty("servers.0.host"), "a")
+	})
+}
+
+func TestDefaultPropertySource_Json(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "json")
+	assert.Equal(t, err, nil)
+	defer os.RemoveAll(dir)
+
+	write := func(name string, content string) {
+		err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		assert.Equal(t, err, nil)
+	}
+
+	write("application.json", `{"server": {"name": "json", "port": 8080}}`)
+	write("application-dev.json", `{"server": {"port": 9090}}`)
+
+	p := NewDefaultPropertySource(dir)
+	result := p.Load("")
+	assert.Equal(t, result["server.name"], "json")
+	assert.Equal(t, result["server.port"], float64(8080))
+
+	result = p.Load("dev")
+	assert.Equal(t, result, map[string]interface{}{"server.port": float64(9090)})
+
+	os.Clearenv()
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.SetProfile("dev")
+	app := newApplication(&defaultApplicationContext{SpringContext: ctx}, dir)
+	app.prepare()
+	assert.Equal(t, ctx.GetStringProperty("server.name"), "json")
+	assert.Equal(t, ctx.GetIntProperty("server.port"), int64(9090))
+}
+
+func TestDefaultPropertySource_Watch(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "watch")
+	assert.Equal(t, err, nil)
+	defer os.RemoveAll(dir)
+
+	write := func(name string, content string) {
+		err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		assert.Equal(t, err, nil)
+	}
+
+	write("application.properties", "a=1\nb=1\n")
+	write("application-dev.properties", "b=2\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p := NewDefaultPropertySource(dir)
+	onChange := func(map[string]interface{}) {}
+
+	err = p.Watch(ctx, onChange)
+	assert.Equal(t, err.Error(), "properties not loaded")
+
+	p.Load("")
+	p.Load("dev")
+
+	changes := make(chan map[string]interface{}, 10)
+	err = p.Watch(ctx, func(m map[string]interface{}) { changes <- m })
+	assert.Equal(t, err, nil)
+
+	// 连续的变化只触发一次回调
+	write("application.properties", "a=2\nb=1\n")
+	write("application.properties", "a=3\nb=1\n")
+
+	select {
+	case m := <-changes:
+		assert.Equal(t, m, map[string]interface{}{"a": "3", "b": "2"})
+	case <-time.After(3 * time.Second):
+		t.Fatal("onChange not called")
+	}
+
+	select {
+	case m := <-changes:
+		t.Fatalf("unexpected change %v", m)
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	write("application-dev.properties", "b=3\n")
+
+	select {
+	case m := <-changes:
+		assert.Equal(t, m, map[string]interface{}{"a": "3", "b": "3"})
+	case <-time.After(3 * time.Second):
+		t.Fatal("onChange not called")
+	}
+}
+
+func TestVaultPropertySource(t *testing.T) {
+
+	var renewed int32
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/kv/myapp":
+			fmt.Fprint(w, `{"data":{"db.url":"mysql://v1","db.pool":10}}`)
+		case "/v1/secret/data/myapp":
+			fmt.Fprint(w, `{"data":{"data":{"db.url":"mysql://v2","db.user":"app"},"metadata":{"version":3}}}`)
+		case "/v1/secret/data/myapp/dev":
+			fmt.Fprint(w, `{"data":{"data":{"db.url":"mysql://dev"}}}`)
+		case "/v1/auth/token/lookup-self":
+			fmt.Fprint(w, `{"data":{"ttl":1,"renewable":true}}`)
+		case "/v1/auth/token/renew-self":
+			atomic.AddInt32(&renewed, 1)
+			fmt.Fprint(w, `{"auth":{"lease_duration":1,"renewable":true}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	t.Run("kv v1", func(t *testing.T) {
+		p := NewVaultPropertySource(server.URL, "root", "kv/myapp", 1)
+		defer p.Close()
+		assert.Equal(t, p.Name(), "vault")
+		assert.Equal(t, p.Load(""), map[string]interface{}{"db.url": "mysql://v1", "db.pool": float64(10)})
+	})
+
+	t.Run("kv v2", func(t *testing.T) {
+		p := NewVaultPropertySource(server.URL, "root", "/secret/myapp/")
+		defer p.Close()
+		assert.Equal(t, p.Load(""), map[string]interface{}{"db.url": "mysql://v2", "db.user": "app"})
+		assert.Equal(t, p.Load("dev"), map[string]interface{}{"db.url": "mysql://dev"})
+		assert.Equal(t, p.Load("test"), map[string]interface{}{})
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		p := NewVaultPropertySource(server.URL, "guest", "secret/myapp")
+		assert.Panic(t, func() { p.Load("") }, "vault GET .* error: 403 Forbidden")
+	})
+
+	t.Run("renew token", func(t *testing.T) {
+		p := NewVaultPropertySource(server.URL, "root", "secret/myapp")
+		defer p.Close()
+		p.Load("")
+
+		deadline := time.Now().Add(3 * time.Second)
+		for atomic.LoadInt32(&renewed) == 0 && time.Now().Before(deadline) {
+			time.Sleep(50 * time.Millisecond)
+		}
+		assert.Equal(t, atomic.LoadInt32(&renewed) > 0, true)
+	})
+
+	t.Run("boot config", func(t *testing.T) {
+		app := newApplication(&defaultApplicationContext{
+			SpringContext: SpringCore.NewDefaultSpringContext(),
+		})
+		assert.Equal(t, app.loadVaultConfig(SpringCore.NewDefaultProperties(), ""), nil)
+
+		p := SpringCore.NewDefaultProperties()
+		p.SetProperty(SpringVaultAddr, server.URL)
+		p.SetProperty(SpringVaultToken, "root")
+		p.SetProperty(SpringVaultPath, "secret/myapp")
+
+		result := app.loadVaultConfig(p, "dev")
+		assert.Equal(t, result.GetStringProperty("db.url"), "mysql://dev")
+		assert.Equal(t, result.GetStringProperty("db.user"), "app")
+
+		// 容器关闭之后停止令牌的续期
+		start := atomic.LoadInt32(&renewed)
+		deadline := time.Now().Add(3 * time.Second)
+		for atomic.LoadInt32(&renewed) == start && time.Now().Before(deadline) {
+			time.Sleep(50 * time.Millisecond)
+		}
+		assert.Equal(t, atomic.LoadInt32(&renewed) > start, true)
+
+		app.appCtx.Close()
+		stopped := atomic.LoadInt32(&renewed)
+		time.Sleep(1500 * time.Millisecond)
+		assert.Equal(t, atomic.LoadInt32(&renewed) <= stopped+1, true)
+	})
+}
+
+// writeSelfSignedCert 生成一个自签名证书并写入 PEM 格式的文件
+func writeSelfSignedCert(t *testing.T, filename string, notAfter time.Time) {
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Equal(t, err, nil)
+
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1234),
+		Subject:      pkix.Name{CommonName: "go-spring.dev", Organization: []string{"go-spring"}},
+		NotBefore:    time.Now().Add(-time.Hour).UTC().Truncate(time.Second),
+		NotAfter:     notAfter.UTC().Truncate(time.Second),
+		DNSNames:     []string{"go-spring.dev", "www.go-spring.dev"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	assert.Equal(t, err, nil)
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	assert.Equal(t, ioutil.WriteFile(filename, data, 0644), nil)
+}
+
+func TestSSLPropertySource(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "ssl")
+	assert.Equal(t, err, nil)
+	defer os.RemoveAll(dir)
+
+	certFile := filepath.Join(dir, "server.crt")
+	notAfter := time.Now().Add(10 * 24 * time.Hour)
+	writeSelfSignedCert(t, certFile, notAfter)
+
+	t.Run("metadata", func(t *testing.T) {
+		p := NewSSLPropertySource(certFile)
+		assert.Equal(t, p.Name(), "ssl")
+		assert.Equal(t, len(p.Load("dev")), 0)
+
+		result := p.Load("")
+		assert.Equal(t, result["ssl.cert.subject"], "CN=go-spring.dev,O=go-spring")
+		assert.Equal(t, result["ssl.cert.issuer"], "CN=go-spring.dev,O=go-spring")
+		assert.Equal(t, result["ssl.cert.serial-number"], "1234")
+		assert.Equal(t, result["ssl.cert.not-after"], notAfter.UTC().Truncate(time.Second))
+		assert.Equal(t, result["ssl.cert.dns-names"], []string{"go-spring.dev", "www.go-spring.dev"})
+		assert.Equal(t, result["ssl.cert.ip-addresses"], []string{"127.0.0.1"})
+
+		result = NewSSLPropertySource(certFile).Prefix("tls").Load("")
+		assert.Equal(t, result["tls.serial-number"], "1234")
+	})
+
+	t.Run("expiry condition", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		for k, v := range NewSSLPropertySource(certFile).Load("") {
+			ctx.SetProperty(k, v)
+		}
+
+		// 证书在 30 天之内过期
+		cond := SpringCore.NewPropertyValueCondition("ssl.cert.not-after", "$>30*24*3600")
+		assert.Equal(t, cond.Matches(ctx), false)
+
+		cond = SpringCore.NewPropertyValueCondition("ssl.cert.not-after", "$>7*24*3600")
+		assert.Equal(t, cond.Matches(ctx), true)
+	})
+
+	t.Run("invalid file", func(t *testing.T) {
+		filename := filepath.Join(dir, "invalid.crt")
+		assert.Equal(t, ioutil.WriteFile(filename, []byte("invalid"), 0644), nil)
+		assert.Panic(t, func() {
+			NewSSLPropertySource(filename).Load("")
+		}, "parse certificate .* error: .*")
+	})
+}
+
+func TestJoinContinuationLines(t *testing.T) {
+
+	join := func(s string) string {
+		b, err := ioutil.ReadAll(joinContinuationLines(s))
+		assert.Equal(t, err, nil)
+		return string(b)
+	}
+
+	t.Run("single", func(t *testing.T) {
+		assert.Equal(t, join("a=1,\\\n  2\nb=3"), "a=1,2\nb=3\n")
+	})
+
+	t.Run("multiple", func(t *testing.T) {
+		assert.Equal(t, join("url=http://\\\n\texample.com\\\n  /a/b\\\n  ?c=d\n"), "url=http://example.com/a/b?c=d\n\n")
+	})
+
+	t.Run("escaped backslash", func(t *testing.T) {
+		assert.Equal(t, join("path=c:\\\\\nb=2"), "path=c:\\\\\nb=2\n")
+		assert.Equal(t, join("path=c:\\\\\\\n  dir\nb=2"), "path=c:\\\\dir\nb=2\n")
+	})
+
+	t.Run("trailing whitespace", func(t *testing.T) {
+		assert.Equal(t, join("a=1,\\  \t\r\n  2\r\nb=3 "), "a=1,2\r\nb=3 \n")
+	})
+
+	t.Run("comment", func(t *testing.T) {
+		assert.Equal(t, join("# comment \\\na=1"), "# comment \\\na=1\n")
+	})
+
+	t.Run("load", func(t *testing.T) {
+		result := NewFilePropertySource("testdata/file/multiline.properties").Load("")
+		assert.Equal(t, result["list"], "a,b,c")
+		assert.Equal(t, result["url"], "http://example.com/a/b?c=d")
+		assert.Equal(t, result["path"], "c:\\")
+		assert.Equal(t, result["name"], "go-spring")
+	})
+}
+
+type dryRunDao struct{}
+
+type dryRunService struct {
+	Dao *dryRunDao `autowire:""`
+}
+
+func TestDryRun(t *testing.T) {
+
+	t.Run("passed", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBean(new(dryRunDao))
+		ctx.RegisterBean(new(dryRunService))
+		ctx.RegisterBean(new(int)).ConditionOnProperty("int.enable")
+		app := newApplication(&defaultApplicationContext{SpringContext: ctx})
+
+		var buf bytes.Buffer
+		assert.Equal(t, app.dryRun(&buf), 0)
+		assert.Matches(t, buf.String(), "dry run report:\n"+
+			"  \\[create\\] object bean \"\\*SpringBoot.dryRunDao\" .*\n"+
+			"  \\[create\\] object bean \"\\*SpringBoot.dryRunService\" .*\n"+
+			"  \\[skip\\] object bean \"\\*int\" .*\n"+
+			"      condition: \\(property:int.enable\\)\n"+
+			"2 beans would be created, 1 skipped, 0 failed\n")
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBean(new(dryRunService))
+		app := newApplication(&defaultApplicationContext{SpringContext: ctx})
+
+		var buf bytes.Buffer
+		assert.Equal(t, app.dryRun(&buf), 1)
+		assert.Matches(t, buf.String(), "dry run report:\n  \\[error\\] object bean \"\\*SpringBoot.dryRunService\" .*\n"+
+			"      error: can't find bean, .*\n"+
+			"0 beans would be created, 0 skipped, 1 failed\n")
+	})
+}
+
+func TestDependencyGraph(t *testing.T) {
+	os.Clearenv()
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.RegisterBean(new(dryRunDao))
+	ctx.RegisterBean(new(dryRunService))
+	app := newApplication(&defaultApplicationContext{SpringContext: ctx}, "testdata/config/")
+
+	b, err := app.dependencyGraph("dot")
+	assert.Equal(t, err, nil)
+	assert.Matches(t, string(b), `dryRunService" -> ".*dryRunDao:\*SpringBoot.dryRunDao";`)
+	assert.Equal(t, ctx.GetProfile(), "test")
+}
+
+func TestExportDependencyGraph(t *testing.T) {
+	os.Clearenv()
+
+	profile := ctx.GetProfile()
+	beans := len(ctx.GetBeanDefinitions())
+
+	b, err := ExportDependencyGraph("json", "testdata/config/")
+	assert.Equal(t, err, nil)
+	assert.Matches(t, string(b), `"nodes": \[`)
+
+	// 加载属性和预检都在副本上进行，全局的容器不受影响
+	assert.Equal(t, ctx.GetProfile(), profile)
+	assert.Equal(t, len(ctx.GetBeanDefinitions()), beans)
+}
+
+type eventEmitterBean struct {
+	pub ApplicationEventPublisher
+}
+
+func (b *eventEmitterBean) SetEventPublisher(pub ApplicationEventPublisher) {
+	b.pub = pub
+}
+
+type eventListenerBean struct {
+	events []interface{}
+}
+
+func (b *eventListenerBean) OnApplicationEvent(event interface{}) {
+	b.events = append(b.events, event)
+}
+
+func TestEventEmitter(t *testing.T) {
+	appCtx := &defaultApplicationContext{SpringContext: SpringCore.NewDefaultSpringContext()}
+	registerEventPublisher(appCtx)
+
+	emitter := new(eventEmitterBean)
+	appCtx.RegisterBean(emitter)
+
+	listener := new(eventListenerBean)
+	appCtx.RegisterBean(listener).Export((*ApplicationEventListener)(nil))
+
+	appCtx.AutoWireBeans()
+	assert.Equal(t, emitter.pub != nil, true)
+
+	var pub ApplicationEventPublisher
+	assert.Equal(t, appCtx.GetBean(&pub), true)
+	assert.Equal(t, emitter.pub == pub, true)
+
+	emitter.pub.PublishEvent("hello")
+	emitter.pub.PublishEvent(3)
+	assert.Equal(t, listener.events, []interface{}{"hello", 3})
+}
+
+type requiredPropertyBean struct {
+	Addr string `value:"${missing.addr}"`
+}
+
+type panicRunner struct{}
+
+func (r *panicRunner) Run(ctx ApplicationContext) {
+	panic(errors.New("runner failed"))
+}
+
+func TestApplicationEvent(t *testing.T) {
+
+	t.Run("ready", func(t *testing.T) {
+		var ready []ApplicationReadyEvent
+		defer RegisterEventListener(func(e ApplicationReadyEvent) {
+			ready = append(ready, e)
+		})()
+		var failed []ApplicationFailedEvent
+		defer RegisterEventListener(func(e ApplicationFailedEvent) {
+			failed = append(failed, e)
+		})()
+
+		appCtx := &defaultApplicationContext{SpringContext: SpringCore.NewDefaultSpringContext()}
+		listener := new(eventListenerBean)
+		appCtx.RegisterBean(listener).Export((*ApplicationEventListener)(nil))
+
+		app := newApplication(appCtx, "testdata/config/")
+		app.Start()
+
+		assert.Equal(t, len(ready), 1)
+		assert.Equal(t, ready[0].Context == ApplicationContext(appCtx), true)
+		assert.Equal(t, len(failed), 0)
+		assert.Equal(t, listener.events, []interface{}{ready[0]})
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		var ready []ApplicationReadyEvent
+		defer RegisterEventListener(func(e ApplicationReadyEvent) {
+			ready = append(ready, e)
+		})()
+		var failed []ApplicationFailedEvent
+		defer RegisterEventListener(func(e ApplicationFailedEvent) {
+			failed = append(failed, e)
+		})()
+
+		appCtx := &defaultApplicationContext{SpringContext: SpringCore.NewDefaultSpringContext()}
+		appCtx.RegisterBean(new(requiredPropertyBean))
+
+		app := newApplication(appCtx, "testdata/config/")
+		assert.Panic(t, app.Start, "missing.addr")
+
+		assert.Equal(t, len(ready), 0)
+		assert.Equal(t, len(failed), 1)
+		assert.Matches(t, failed[0].Err.Error(), "missing.addr")
+	})
+
+	t.Run("failed after wired", func(t *testing.T) {
+		var failed []ApplicationFailedEvent
+		defer RegisterEventListener(func(e ApplicationFailedEvent) {
+			failed = append(failed, e)
+		})()
+
+		appCtx := &defaultApplicationContext{SpringContext: SpringCore.NewDefaultSpringContext()}
+		listener := new(eventListenerBean)
+		appCtx.RegisterBean(listener).Export((*ApplicationEventListener)(nil))
+		appCtx.RegisterBean(new(panicRunner)).Export((*CommandLineRunner)(nil))
+
+		app := newApplication(appCtx, "testdata/config/")
+		assert.Panic(t, app.Start, "runner failed")
+
+		assert.Equal(t, len(failed), 1)
+		assert.Equal(t, listener.events, []interface{}{failed[0]})
+	})
+
+	t.Run("unregister", func(t *testing.T) {
+		var events []interface{}
+		unregister := RegisterEventListener(func(e interface{}) {
+			events = append(events, e)
+		})
+		fireEventListeners(1)
+		unregister()
+		unregister()
+		fireEventListeners(2)
+		assert.Equal(t, events, []interface{}{1})
+	})
+
+	t.Run("bad listener", func(t *testing.T) {
+		assert.Panic(t, func() {
+			RegisterEventListener(func() {})
+		}, "fn should be func\\(event T\\)")
+	})
+}
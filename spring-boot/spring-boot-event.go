@@ -0,0 +1,166 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package SpringBoot
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+)
+
+// ApplicationReadyEvent 所有 Bean 注入完成、命令行启动器执行完成之后发布的事件
+type ApplicationReadyEvent struct {
+	Context ApplicationContext
+}
+
+// ApplicationFailedEvent 应用启动失败时发布的事件，Err 为导致失败的错误。通过
+// RegisterEventListener 注册的监听函数总能收到该事件，监听者 Bean 只有在注入完成
+// 之后失败时才能收到该事件。
+type ApplicationFailedEvent struct {
+	Err error
+}
+
+// eventListener 通过 RegisterEventListener 注册的监听函数
+type eventListener struct {
+	fn reflect.Value
+}
+
+var (
+	listenerMutex  sync.RWMutex
+	eventListeners []*eventListener
+)
+
+// RegisterEventListener 注册应用事件的监听函数，fn 的形式为 func(event T)，
+// 只有类型可以赋值给 T 的事件才会发送给 fn，返回的函数用于取消注册。
+func RegisterEventListener(fn interface{}) (unregister func()) {
+	t := reflect.TypeOf(fn)
+	if t == nil || t.Kind() != reflect.Func || t.NumIn() != 1 || t.NumOut() != 0 {
+		panic(errors.New("fn should be func(event T)"))
+	}
+
+	l := &eventListener{fn: reflect.ValueOf(fn)}
+
+	listenerMutex.Lock()
+	defer listenerMutex.Unlock()
+	eventListeners = append(eventListeners, l)
+
+	return func() {
+		listenerMutex.Lock()
+		defer listenerMutex.Unlock()
+		for i, c := range eventListeners {
+			if c == l {
+				eventListeners = append(eventListeners[:i:i], eventListeners[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// fireEventListeners 将事件发送给通过 RegisterEventListener 注册的监听函数
+func fireEventListeners(event interface{}) {
+
+	listenerMutex.RLock()
+	listeners := eventListeners
+	listenerMutex.RUnlock()
+
+	v := reflect.ValueOf(event)
+	for _, l := range listeners {
+		if v.Type().AssignableTo(l.fn.Type().In(0)) {
+			l.fn.Call([]reflect.Value{v})
+		}
+	}
+}
+
+// ApplicationEventPublisher 应用事件的发布者
+type ApplicationEventPublisher interface {
+	PublishEvent(event interface{})
+}
+
+// ApplicationEventListener 应用事件的监听者，Bean 需要导出该接口才能收到事件
+type ApplicationEventListener interface {
+	OnApplicationEvent(event interface{})
+}
+
+// EventEmitter 发布事件的 Bean，注入时会自动设置事件发布者，无需通过标签注入
+type EventEmitter interface {
+	SetEventPublisher(pub ApplicationEventPublisher)
+}
+
+// eventPublisher ApplicationEventPublisher 的默认实现，将事件同步发送给所有的监听者
+type eventPublisher struct {
+	_ ApplicationEventPublisher `export:""`
+
+	appCtx    ApplicationContext
+	once      sync.Once
+	listeners []ApplicationEventListener
+}
+
+// newEventPublisher eventPublisher 的构造函数
+func newEventPublisher(appCtx ApplicationContext) *eventPublisher {
+	return &eventPublisher{appCtx: appCtx}
+}
+
+// PublishEvent 发布事件，第一次发布事件时收集所有的监听者，所以需要在注入完成之后调用。
+// 事件同时也会发送给通过 RegisterEventListener 注册的监听函数。
+func (p *eventPublisher) PublishEvent(event interface{}) {
+	p.collect()
+	p.fire(event)
+}
+
+// collect 收集所有的监听者 Bean，只在第一次调用时收集
+func (p *eventPublisher) collect() {
+	p.once.Do(func() {
+		p.appCtx.CollectBeans(&p.listeners)
+	})
+}
+
+// fire 将事件发送给已经收集的监听者 Bean 以及通过 RegisterEventListener 注册的监听函数，
+// 不会收集监听者 Bean，所以可以在注入失败之后调用。
+func (p *eventPublisher) fire(event interface{}) {
+	for _, l := range p.listeners {
+		l.OnApplicationEvent(event)
+	}
+	fireEventListeners(event)
+}
+
+// eventEmitterProcessor 为实现了 EventEmitter 接口的 Bean 设置事件发布者
+type eventEmitterProcessor struct {
+	publisher *eventPublisher
+}
+
+// PostProcessBean 在 Bean 注入完成之后设置事件发布者
+func (p *eventEmitterProcessor) PostProcessBean(v reflect.Value, beanId string) {
+	if !v.CanInterface() {
+		return
+	}
+	if emitter, ok := v.Interface().(EventEmitter); ok {
+		emitter.SetEventPublisher(p.publisher)
+	}
+}
+
+// register 注册事件发布者以及为 EventEmitter 设置发布者的后处理器
+func (p *eventPublisher) register() {
+	p.appCtx.RegisterBean(p)
+	p.appCtx.RegisterBean(&eventEmitterProcessor{p})
+}
+
+// registerEventPublisher 创建并注册事件发布者
+func registerEventPublisher(appCtx ApplicationContext) ApplicationEventPublisher {
+	publisher := newEventPublisher(appCtx)
+	publisher.register()
+	return publisher
+}
@@ -0,0 +1,41 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package SpringBoot
+
+import (
+	"context"
+
+	"github.com/go-spring/go-spring/spring-core"
+)
+
+// HealthChecker 健康检查，检查失败时返回错误，Bean 需要导出该接口才能参与检查
+type HealthChecker interface {
+	Check(ctx context.Context) error
+}
+
+// HealthCheckerFunc 函数形式的 HealthChecker
+type HealthCheckerFunc func(ctx context.Context) error
+
+// Check 执行健康检查
+func (f HealthCheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// RegisterHealthChecker 注册名为 name 的健康检查，检查结果以 name 为键汇总
+func RegisterHealthChecker(name string, checker HealthChecker) *SpringCore.BeanDefinition {
+	return RegisterNameBean(name, checker).Export((*HealthChecker)(nil))
+}
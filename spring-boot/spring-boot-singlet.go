@@ -18,6 +18,7 @@ package SpringBoot
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/go-spring/go-spring/boot-starter"
@@ -46,20 +47,45 @@ func RunApplication(configLocation ...string) {
 
 // AppBuilder application 的构造器
 type AppBuilder struct {
+	cfgLocation []string             // 配置文件目录
+	sources     *PropertySourceChain // 应用的属性源链
 }
 
-// NewApplication AppBuilder 的构造函数
+// NewApplication AppBuilder 的构造函数，使用默认的配置文件目录
 func NewApplication() *AppBuilder {
-	return &AppBuilder{}
+	return new(AppBuilder).ConfigLocation()
 }
 
-// Run 快速启动 SpringBoot 应用
+// ConfigLocation 设置配置文件目录并重新创建属性源链，之前对属性源链的修改失效
+func (cfg *AppBuilder) ConfigLocation(configLocation ...string) *AppBuilder {
+	if len(configLocation) == 0 {
+		configLocation = []string{DefaultConfigLocation}
+	}
+	cfg.cfgLocation = configLocation
+	cfg.sources = newPropertySourceChain(configLocation)
+	return cfg
+}
+
+// PropertySources 返回应用的属性源链，可以在 Run 之前添加或者删除属性源
+func (cfg *AppBuilder) PropertySources() *PropertySourceChain {
+	return cfg.sources
+}
+
+// Run 快速启动 SpringBoot 应用，configLocation 不为空时等同于先调用 ConfigLocation
 func (cfg *AppBuilder) Run(configLocation ...string) {
-	BootStarter.Run(newApplication(
-		&defaultApplicationContext{
-			SpringContext: ctx,
-		}, configLocation...),
-	)
+	if len(configLocation) > 0 {
+		cfg.ConfigLocation(configLocation...)
+	}
+	BootStarter.Run(cfg.build(&defaultApplicationContext{
+		SpringContext: ctx,
+	}))
+}
+
+// build 使用构造器中的配置文件目录和属性源链创建应用
+func (cfg *AppBuilder) build(appCtx ApplicationContext) *application {
+	app := newApplication(appCtx, cfg.cfgLocation...)
+	app.sources = cfg.sources
+	return app
 }
 
 // Exit 退出 SpringBoot 应用
@@ -69,12 +95,17 @@ func Exit() {
 
 //////////////// SpringContext ////////////////////////
 
-// GetProfile 返回运行环境
+// GetProfile 返回第一个激活的运行环境
 func GetProfile() string {
 	return ctx.GetProfile()
 }
 
-// SetProfile 设置运行环境
+// GetProfiles 返回激活的运行环境列表
+func GetProfiles() []string {
+	return ctx.GetProfiles()
+}
+
+// SetProfile 设置运行环境，多个运行环境使用逗号分隔
 func SetProfile(profile string) {
 	ctx.SetProfile(profile)
 }
@@ -89,6 +120,81 @@ func SetAllAccess(allAccess bool) {
 	ctx.SetAllAccess(allAccess)
 }
 
+// dryRun 是否以预检模式启动应用
+var dryRun bool
+
+// SetDryRun 设置是否以预检模式启动应用，预检模式下只加载属性、检查判断条件、
+// 依赖关系和属性绑定，而不会创建 Bean，输出报告后退出，检查失败时退出码为 1。
+func SetDryRun(enable bool) {
+	dryRun = enable
+}
+
+// ExportDependencyGraph 加载属性并预检所有的 Bean，不启动应用而是返回 Bean 的依赖关系图，
+// format 可以是 dot、json 或者 mermaid，可以用于在 CI 中检查依赖关系。加载属性和预检都在
+// 容器的副本上进行，所以之后仍然可以启动应用。
+func ExportDependencyGraph(format string, configLocation ...string) ([]byte, error) {
+	app := newApplication(&defaultApplicationContext{
+		SpringContext: ctx.Copy(),
+	}, configLocation...)
+	return app.dependencyGraph(format)
+}
+
+// StrictMode 返回是否启用严格模式
+func StrictMode() bool {
+	return ctx.StrictMode()
+}
+
+// SetStrictMode 设置是否启用严格模式，严格模式下注册构造函数 Bean 时
+// 会检查其依赖的 Bean 是否已经注册，未注册时立即 panic。
+func SetStrictMode(strict bool) {
+	ctx.SetStrictMode(strict)
+}
+
+// EnableConfigAudit 设置是否启用访问审计，启用后设置了 AuditTrail 的 Bean
+// 在每次被获取时都会记录访问日志。
+func EnableConfigAudit(enable bool) {
+	ctx.EnableConfigAudit(enable)
+}
+
+// SetConstructionTimeoutAction 设置构造函数超时之后的处理方式，默认只打印警告日志
+func SetConstructionTimeoutAction(action SpringCore.ConstructionTimeoutAction) {
+	ctx.SetConstructionTimeoutAction(action)
+}
+
+// Uptime 返回从容器刷新开始到现在经过的时间，容器刷新之前返回 0
+func Uptime() time.Duration {
+	return ctx.Uptime()
+}
+
+// beanNamePrefixes Bean 名称前缀的栈，栈顶为当前使用的前缀
+var beanNamePrefixes []string
+
+// WithBeanNamePrefix 设置 Bean 名称的前缀，之后指定名称注册的 Bean 都会加上该前缀，
+// 例如前缀 "auth" 下注册的 "userService" 的名称为 "auth.userService"。可以嵌套使用。
+func WithBeanNamePrefix(prefix string) {
+	if n := len(beanNamePrefixes); n > 0 {
+		prefix = beanNamePrefixes[n-1] + "." + prefix
+	}
+	beanNamePrefixes = append(beanNamePrefixes, prefix)
+}
+
+// EndBeanNamePrefix 结束当前的 Bean 名称前缀，恢复之前的前缀
+func EndBeanNamePrefix() {
+	n := len(beanNamePrefixes)
+	if n == 0 {
+		panic(errors.New("no bean name prefix"))
+	}
+	beanNamePrefixes = beanNamePrefixes[:n-1]
+}
+
+// prefixBeanName 为 Bean 名称加上当前的前缀
+func prefixBeanName(name string) string {
+	if n := len(beanNamePrefixes); n > 0 && name != "" {
+		return beanNamePrefixes[n-1] + "." + name
+	}
+	return name
+}
+
 // RegisterBean 注册单例 Bean，不指定名称，重复注册会 panic。
 func RegisterBean(bean interface{}) *SpringCore.BeanDefinition {
 	return ctx.RegisterBean(bean)
@@ -96,7 +202,7 @@ func RegisterBean(bean interface{}) *SpringCore.BeanDefinition {
 
 // RegisterNameBean 注册单例 Bean，需指定名称，重复注册会 panic。
 func RegisterNameBean(name string, bean interface{}) *SpringCore.BeanDefinition {
-	return ctx.RegisterNameBean(name, bean)
+	return ctx.RegisterNameBean(prefixBeanName(name), bean)
 }
 
 // RegisterBeanFn 注册单例构造函数 Bean，不指定名称，重复注册会 panic。
@@ -106,7 +212,20 @@ func RegisterBeanFn(fn interface{}, tags ...string) *SpringCore.BeanDefinition {
 
 // RegisterNameBeanFn 注册单例构造函数 Bean，需指定名称，重复注册会 panic。
 func RegisterNameBeanFn(name string, fn interface{}, tags ...string) *SpringCore.BeanDefinition {
-	return ctx.RegisterNameBeanFn(name, fn, tags...)
+	return ctx.RegisterNameBeanFn(prefixBeanName(name), fn, tags...)
+}
+
+// RegisterScopedBeanFn 注册作用域构造函数 Bean，同时注册名为 name-factory 的
+// ScopedBeanFactory 单例，单例 Bean 可以注入工厂在请求中获取当前作用域的实例。
+// 请求作用域由 web 服务器的过滤器在每个请求开始时创建，请求结束时销毁。
+func RegisterScopedBeanFn(name string, scope SpringCore.Scope, fn interface{}, tags ...string) *SpringCore.BeanDefinition {
+	if name == "" {
+		panic(errors.New("scoped bean name can't be empty"))
+	}
+	name = prefixBeanName(name)
+	bd := ctx.RegisterNameBeanFn(name, fn, tags...).Scoped(scope)
+	ctx.RegisterNameBean(name+"-factory", SpringCore.NewScopedBeanFactory(ctx, name))
+	return bd
 }
 
 // RegisterMethodBean 注册成员方法单例 Bean，不指定名称，重复注册会 panic。
@@ -120,7 +239,7 @@ func RegisterMethodBean(selector SpringCore.BeanSelector, method string, tags ..
 // 必须给定方法名而不能通过遍历方法列表比较方法类型的方式获得函数名，因为不同方法的类型可能相同。
 // 而且 interface 的方法类型不带 receiver 而成员方法的类型带有 receiver，两者类型也不好匹配。
 func RegisterNameMethodBean(name string, selector SpringCore.BeanSelector, method string, tags ...string) *SpringCore.BeanDefinition {
-	return ctx.RegisterNameMethodBean(name, selector, method, tags...)
+	return ctx.RegisterNameMethodBean(prefixBeanName(name), selector, method, tags...)
 }
 
 // @Incubate 注册成员方法单例 Bean，不指定名称，重复注册会 panic。
@@ -132,7 +251,7 @@ func RegisterMethodBeanFn(method interface{}, tags ...string) *SpringCore.BeanDe
 // @Incubate 注册成员方法单例 Bean，需指定名称，重复注册会 panic。
 // method 形如 ServerInterface.Consumer (接口) 或 (*Server).Consumer (类型)。
 func RegisterNameMethodBeanFn(name string, method interface{}, tags ...string) *SpringCore.BeanDefinition {
-	return ctx.RegisterNameMethodBeanFn(name, method, tags...)
+	return ctx.RegisterNameMethodBeanFn(prefixBeanName(name), method, tags...)
 }
 
 // WireBean 对外部的 Bean 进行依赖注入和属性绑定
@@ -146,6 +265,38 @@ func GetBean(i interface{}, selector ...SpringCore.BeanSelector) bool {
 	return ctx.GetBean(i, selector...)
 }
 
+// NewScope 创建一个作用域子容器并绑定到 parent 上，返回的 CancelFunc 用于在作用域结束时销毁其中的 Bean。
+func NewScope(parent context.Context, scope SpringCore.Scope) (context.Context, context.CancelFunc) {
+	return ctx.NewScope(parent, scope)
+}
+
+// SessionScope 获取会话 ID 对应的会话作用域子容器，不存在时创建，并将其绑定到 parent 上。
+func SessionScope(parent context.Context, sessionId string) context.Context {
+	return ctx.SessionScope(parent, sessionId)
+}
+
+// DestroySession 销毁会话 ID 对应的会话作用域子容器中的 Bean，找到会话返回 true 否则返回 false。
+func DestroySession(sessionId string) bool {
+	return ctx.DestroySession(sessionId)
+}
+
+// GetScopedBean 获取作用域 Bean，若多于 1 个则 panic；找到返回 true 否则返回 false。
+// 作用域 Bean 在第一次获取时创建，此后在同一作用域内返回同一个实例。
+func GetScopedBean(scopeCtx context.Context, i interface{}, selector ...SpringCore.BeanSelector) bool {
+	return ctx.GetScopedBean(scopeCtx, i, selector...)
+}
+
+// DestroyPrototype 销毁原型 Bean 的实例，找到实例返回 true 否则返回 false。
+func DestroyPrototype(bean interface{}) bool {
+	return ctx.DestroyPrototype(bean)
+}
+
+// ReleaseGoroutineBeans 销毁当前 goroutine 内的 goroutine 作用域 Bean 的实例，
+// 不是通过 SafeGoroutine 启动的 goroutine 需要在开始时 defer 调用。
+func ReleaseGoroutineBeans() {
+	ctx.ReleaseGoroutineBeans()
+}
+
 // FindBean 查询单例 Bean，若多于 1 个则 panic；找到返回 true 否则返回 false。
 // 它和 GetBean 的区别是它在调用后不能保证返回的 Bean 已经完成了注入和绑定过程。
 func FindBean(selector SpringCore.BeanSelector) (*SpringCore.BeanDefinition, bool) {
@@ -163,11 +314,42 @@ func CollectBeans(i interface{}, selectors ...SpringCore.BeanSelector) bool {
 	return ctx.CollectBeans(i, selectors...)
 }
 
-// GetBeanDefinitions 获取所有 Bean 的定义，不能保证解析和注入，请谨慎使用该函数!
+// GetBeanDefinitions 获取所有 Bean 的定义，包括设置了 ExcludeFromScan 的 Bean，
+// 不能保证解析和注入，请谨慎使用该函数!
 func GetBeanDefinitions() []*SpringCore.BeanDefinition {
 	return ctx.GetBeanDefinitions()
 }
 
+// CopyContext 返回全局容器的副本，包含已经注册的属性值和 Bean，只能在启动应用之前调用，
+// 可以用于测试启动器注册的 Bean 而不影响全局容器。
+func CopyContext() SpringCore.SpringContext {
+	return ctx.Copy()
+}
+
+// RefreshBean 重新初始化指定名称的单例 Bean，用于属性值变化之后不重启容器而更新 Bean，
+// Bean 必须是结构体指针并且不能处于循环依赖之中。
+func RefreshBean(name string) error {
+	return ctx.RefreshBean(name)
+}
+
+// RefreshProperties 重新初始化绑定了指定属性的所有单例 Bean，用于属性值变化之后
+// 只刷新受到影响的 Bean。
+func RefreshProperties(keys ...string) error {
+	return ctx.RefreshProperties(keys...)
+}
+
+// ReplaceBean 使用新的构造函数替换设置了 Replaceable 的单例 Bean，新的构造函数必须
+// 返回相同的类型，新的实例通过 WeakReference 或者 GetBean 获取。
+func ReplaceBean(name string, fn interface{}, tags ...string) error {
+	return ctx.ReplaceBean(name, fn, tags...)
+}
+
+// GetAllBeans 按注册顺序获取所有注册过的 Bean 的定义，包括不满足条件而被删除
+// 的 Bean，可以通过 Matched 判断是否满足条件，不包括设置了 ExcludeFromScan 的 Bean。
+func GetAllBeans() []*SpringCore.BeanDefinition {
+	return ctx.GetAllBeans()
+}
+
 // GetProperty 返回 keys 中第一个存在的属性值，属性名称统一转成小写。
 func GetProperty(keys ...string) interface{} {
 	return ctx.GetProperty(keys...)
@@ -0,0 +1,113 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package SpringBoot
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/go-spring/go-spring-parent/spring-logger"
+	"github.com/go-spring/go-spring-parent/spring-utils"
+)
+
+// DefaultSSLPropertyPrefix 证书属性的默认前缀
+const DefaultSSLPropertyPrefix = "ssl.cert"
+
+// sslPropertySource 将 X.509 证书的元数据作为属性的属性源，not-before 和 not-after
+// 属性的值为 time.Time 类型，可以配合 propertyValueCondition 检查证书的有效期。
+type sslPropertySource struct {
+	certFile string // 证书文件，PEM 或者 DER 格式
+	prefix   string // 属性名的前缀
+}
+
+// NewSSLPropertySource sslPropertySource 的构造函数
+func NewSSLPropertySource(certFile string) *sslPropertySource {
+	return &sslPropertySource{
+		certFile: certFile,
+		prefix:   DefaultSSLPropertyPrefix,
+	}
+}
+
+// Prefix 设置属性名的前缀
+func (p *sslPropertySource) Prefix(prefix string) *sslPropertySource {
+	p.prefix = prefix
+	return p
+}
+
+// Name 返回属性源的名称
+func (p *sslPropertySource) Name() string {
+	return "ssl"
+}
+
+// Load 解析证书文件，证书与配置文件剖面无关，所以只在 profile 为空时返回属性。
+func (p *sslPropertySource) Load(profile string) map[string]interface{} {
+	result := make(map[string]interface{})
+	if profile != "" {
+		return result
+	}
+
+	SpringLogger.Info("load properties from certificate ", p.certFile)
+
+	cert := p.parse()
+
+	var ips []string
+	for _, ip := range cert.IPAddresses {
+		ips = append(ips, ip.String())
+	}
+
+	var uris []string
+	for _, uri := range cert.URIs {
+		uris = append(uris, uri.String())
+	}
+
+	result[p.prefix+".subject"] = cert.Subject.String()
+	result[p.prefix+".issuer"] = cert.Issuer.String()
+	result[p.prefix+".serial-number"] = cert.SerialNumber.String()
+	result[p.prefix+".not-before"] = cert.NotBefore
+	result[p.prefix+".not-after"] = cert.NotAfter
+	result[p.prefix+".dns-names"] = cert.DNSNames
+	result[p.prefix+".ip-addresses"] = ips
+	result[p.prefix+".email-addresses"] = cert.EmailAddresses
+	result[p.prefix+".uris"] = uris
+	return result
+}
+
+// parse 解析证书文件，PEM 格式的文件使用第一个 CERTIFICATE 块
+func (p *sslPropertySource) parse() *x509.Certificate {
+
+	data, err := ioutil.ReadFile(p.certFile)
+	SpringUtils.Panic(err).When(err != nil)
+
+	for rest := data; ; {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+		if block.Type == "CERTIFICATE" {
+			data = block.Bytes
+			break
+		}
+	}
+
+	cert, err := x509.ParseCertificate(data)
+	if err != nil {
+		panic(fmt.Errorf("parse certificate %s error: %v", p.certFile, err))
+	}
+	return cert
+}
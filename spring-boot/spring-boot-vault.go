@@ -0,0 +1,202 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package SpringBoot
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-spring/go-spring-parent/spring-logger"
+)
+
+const (
+	SpringVaultAddr    = "spring.vault.addr"       // Vault 服务地址
+	SpringVaultToken   = "spring.vault.token"      // Vault 访问令牌
+	SpringVaultPath    = "spring.vault.path"       // 属性所在的密钥路径，第一段为挂载点
+	SpringVaultVersion = "spring.vault.kv-version" // KV 引擎的版本，默认为 2
+)
+
+// vaultPropertySource 基于 HashiCorp Vault KV 引擎的属性源
+type vaultPropertySource struct {
+	addr    string // 服务地址，如 http://127.0.0.1:8200
+	token   string // 访问令牌
+	path    string // 密钥路径，如 secret/myapp
+	version int    // KV 引擎的版本，1 或者 2
+
+	client *http.Client
+	once   sync.Once
+	stop   chan struct{}
+	done   <-chan struct{} // 所属容器的关闭信号，容器关闭时停止令牌的续期
+}
+
+// NewVaultPropertySource vaultPropertySource 的构造函数，version 为 KV 引擎的版本，
+// 省略时为 2。
+func NewVaultPropertySource(addr, token, path string, version ...int) *vaultPropertySource {
+
+	v := 2
+	if len(version) > 0 {
+		v = version[0]
+	}
+
+	if v != 1 && v != 2 {
+		panic(fmt.Errorf("unsupported vault kv version %d", v))
+	}
+
+	return &vaultPropertySource{
+		addr:    strings.TrimRight(addr, "/"),
+		token:   token,
+		path:    strings.Trim(path, "/"),
+		version: v,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		stop:    make(chan struct{}),
+	}
+}
+
+// Name 返回属性源的名称
+func (p *vaultPropertySource) Name() string {
+	return "vault"
+}
+
+// Load 读取 <path>/<profile> 密钥的数据，profile 为空时读取 <path>，密钥不存在时
+// 返回空的属性列表。第一次加载之后开始在后台续期可续期的令牌，调用 Close 或者所属
+// 容器关闭时停止续期。
+func (p *vaultPropertySource) Load(profile string) map[string]interface{} {
+
+	path := p.path
+	if profile != "" {
+		path += "/" + profile
+	}
+
+	var resp struct {
+		Data map[string]interface{} `json:"data"`
+	}
+
+	if !p.request("GET", p.secretURL(path), &resp) {
+		return make(map[string]interface{})
+	}
+
+	SpringLogger.Info("load properties from vault ", path)
+
+	data := resp.Data
+	if p.version == 2 { // KV v2 的数据保存在 data.data 中
+		data, _ = data["data"].(map[string]interface{})
+	}
+
+	result := make(map[string]interface{})
+	for k, v := range data {
+		result[k] = v
+	}
+
+	p.once.Do(func() { go p.renewToken() })
+	return result
+}
+
+// Close 停止令牌的续期
+func (p *vaultPropertySource) Close() {
+	select {
+	case <-p.stop:
+	default:
+		close(p.stop)
+	}
+}
+
+// secretURL 返回密钥的访问地址，KV v2 需要在挂载点之后插入 data
+func (p *vaultPropertySource) secretURL(path string) string {
+	if p.version == 2 {
+		ss := strings.SplitN(path, "/", 2)
+		if len(ss) == 2 {
+			path = ss[0] + "/data/" + ss[1]
+		} else {
+			path = ss[0] + "/data"
+		}
+	}
+	return p.addr + "/v1/" + path
+}
+
+// request 发送请求并解析响应，资源不存在时返回 false，其他错误 panic
+func (p *vaultPropertySource) request(method string, url string, result interface{}) bool {
+
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		panic(err)
+	}
+	req.Header.Set("X-Vault-Token", p.token)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		panic(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return false
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		panic(fmt.Errorf("vault %s %s error: %s", method, url, resp.Status))
+	}
+
+	if err = json.NewDecoder(resp.Body).Decode(result); err != nil {
+		panic(err)
+	}
+	return true
+}
+
+// tokenInfo 令牌的续期信息
+type tokenInfo struct {
+	TTL       int  `json:"ttl"`
+	Lease     int  `json:"lease_duration"`
+	Renewable bool `json:"renewable"`
+}
+
+// renewToken 在令牌过期之前 (剩余 1/3 有效期时) 续期，令牌不可续期时直接退出
+func (p *vaultPropertySource) renewToken() {
+
+	defer func() {
+		if err := recover(); err != nil {
+			SpringLogger.Error("renew vault token error: ", err)
+		}
+	}()
+
+	var lookup struct {
+		Data tokenInfo `json:"data"`
+	}
+	p.request("GET", p.addr+"/v1/auth/token/lookup-self", &lookup)
+	info := lookup.Data
+
+	for info.Renewable && info.TTL > 0 {
+
+		select {
+		case <-p.stop:
+			return
+		case <-p.done:
+			return
+		case <-time.After(time.Duration(info.TTL) * time.Second * 2 / 3):
+		}
+
+		var renew struct {
+			Auth tokenInfo `json:"auth"`
+		}
+		p.request("POST", p.addr+"/v1/auth/token/renew-self", &renew)
+		info = tokenInfo{TTL: renew.Auth.Lease, Renewable: renew.Auth.Renewable}
+		SpringLogger.Debugf("vault token renewed, ttl %ds", info.TTL)
+	}
+}
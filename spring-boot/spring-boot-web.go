@@ -17,10 +17,16 @@
 package SpringBoot
 
 import (
+	"container/list"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"net/http"
 	"reflect"
 	"runtime"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-spring/go-spring-web/spring-web"
 	"github.com/go-spring/go-spring/spring-core"
@@ -653,3 +659,160 @@ func (f *ConditionalWebFilter) ConditionOnProfile(profile string) *ConditionalWe
 func (f *ConditionalWebFilter) CheckCondition(ctx SpringCore.SpringContext) bool {
 	return f.cond.Matches(ctx)
 }
+
+// scopeFilter 为每个请求创建作用域子容器的过滤器，请求结束时销毁其中的 Bean 以及
+// 处理请求的 goroutine 内的 goroutine 作用域 Bean 的实例
+type scopeFilter struct {
+	ctx   SpringCore.SpringContext
+	scope SpringCore.Scope
+}
+
+// RequestScopeFilter 返回为每个请求创建请求作用域的过滤器
+func RequestScopeFilter(ctx SpringCore.SpringContext) SpringWeb.Filter {
+	return &scopeFilter{ctx: ctx, scope: SpringCore.ScopeRequest}
+}
+
+func (f *scopeFilter) Invoke(webCtx SpringWeb.WebContext, chain SpringWeb.FilterChain) {
+	r := webCtx.Request()
+	scopeCtx, cancel := f.ctx.NewScope(r.Context(), f.scope)
+	defer f.ctx.ReleaseGoroutineBeans()
+	defer cancel()
+	webCtx.SetRequest(r.WithContext(scopeCtx))
+	chain.Next(webCtx)
+}
+
+// SessionCookieName 保存会话 ID 的 Cookie 的默认名称
+const SessionCookieName = "SPRING_SESSION_ID"
+
+const (
+	DefaultSessionExpiry      = 30 * time.Minute // 会话空闲有效期的默认值
+	DefaultSessionMaxSessions = 10000            // 最多保存的会话数量的默认值
+)
+
+// SessionConfig 会话作用域过滤器的配置
+type SessionConfig struct {
+	CookieName  string        // Cookie 名称，默认为 SessionCookieName
+	Secure      bool          // 是否只通过 HTTPS 传输 Cookie，TLS 请求总是设置
+	Expiry      time.Duration // 会话空闲有效期，默认为 DefaultSessionExpiry
+	MaxSessions int           // 最多保存的会话数量，超过时淘汰最久没有访问的会话
+}
+
+// sessionEntry 服务端签发的会话
+type sessionEntry struct {
+	id         string
+	lastAccess time.Time
+}
+
+// sessionScopeFilter 根据 Cookie 中的会话 ID 绑定会话作用域子容器的过滤器，
+// 只接受服务端签发并且没有过期的会话 ID，按照最近访问时间淘汰多余的会话。
+type sessionScopeFilter struct {
+	ctx    SpringCore.SpringContext
+	config SessionConfig
+
+	mutex    sync.Mutex
+	sessions map[string]*list.Element // 会话 ID 到 LRU 链表节点的映射
+	lru      *list.List               // 按照访问时间从近到远排列的会话
+}
+
+// SessionScopeFilter 返回绑定会话作用域的过滤器，Cookie 中没有有效的会话 ID 时签发一个新的会话 ID
+func SessionScopeFilter(ctx SpringCore.SpringContext, config SessionConfig) SpringWeb.Filter {
+	if config.CookieName == "" {
+		config.CookieName = SessionCookieName
+	}
+	if config.Expiry <= 0 {
+		config.Expiry = DefaultSessionExpiry
+	}
+	if config.MaxSessions <= 0 {
+		config.MaxSessions = DefaultSessionMaxSessions
+	}
+	return &sessionScopeFilter{
+		ctx:      ctx,
+		config:   config,
+		sessions: make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+func (f *sessionScopeFilter) Invoke(webCtx SpringWeb.WebContext, chain SpringWeb.FilterChain) {
+
+	var sessionId string
+	if cookie, err := webCtx.Cookie(f.config.CookieName); err == nil {
+		sessionId = cookie.Value
+	}
+
+	sessionId, issued := f.acquire(sessionId, time.Now())
+	if issued {
+		webCtx.SetCookie(&http.Cookie{
+			Name:     f.config.CookieName,
+			Value:    sessionId,
+			Path:     "/",
+			HttpOnly: true,
+			Secure:   f.config.Secure || webCtx.IsTLS(),
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+
+	r := webCtx.Request()
+	webCtx.SetRequest(r.WithContext(f.ctx.SessionScope(r.Context(), sessionId)))
+	chain.Next(webCtx)
+}
+
+// acquire 返回可用的会话 ID，客户端的会话 ID 不是服务端签发的或者已经过期时签发
+// 一个新的会话 ID 并返回 true，同时销毁过期和超出数量限制的会话。
+func (f *sessionScopeFilter) acquire(sessionId string, now time.Time) (string, bool) {
+
+	var evicted []string
+	defer func() {
+		for _, id := range evicted {
+			f.ctx.DestroySession(id)
+		}
+	}()
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	// 从最久没有访问的会话开始清理过期的会话
+	for e := f.lru.Back(); e != nil; e = f.lru.Back() {
+		if s := e.Value.(*sessionEntry); now.Sub(s.lastAccess) > f.config.Expiry {
+			evicted = append(evicted, f.remove(e))
+			continue
+		}
+		break
+	}
+
+	if e, ok := f.sessions[sessionId]; ok {
+		e.Value.(*sessionEntry).lastAccess = now
+		f.lru.MoveToFront(e)
+		return sessionId, false
+	}
+
+	for f.lru.Len() >= f.config.MaxSessions {
+		evicted = append(evicted, f.remove(f.lru.Back()))
+	}
+
+	sessionId = newSessionId()
+	f.sessions[sessionId] = f.lru.PushFront(&sessionEntry{id: sessionId, lastAccess: now})
+	return sessionId, true
+}
+
+// remove 删除 LRU 链表节点对应的会话，返回会话 ID
+func (f *sessionScopeFilter) remove(e *list.Element) string {
+	s := f.lru.Remove(e).(*sessionEntry)
+	delete(f.sessions, s.id)
+	return s.id
+}
+
+// newSessionId 生成随机的会话 ID
+func newSessionId() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// GetRequestBean 获取当前请求上下文中的作用域 Bean (请求作用域或者会话作用域)，
+// 若多于 1 个则 panic；找到返回 true 否则返回 false。
+func GetRequestBean(webCtx SpringWeb.WebContext, i interface{}, selector ...SpringCore.BeanSelector) bool {
+	return ctx.GetScopedBean(webCtx.Request().Context(), i, selector...)
+}
@@ -0,0 +1,200 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package SpringBoot_test
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-spring/go-spring-web/spring-echo"
+	"github.com/go-spring/go-spring-web/spring-web"
+	"github.com/go-spring/go-spring/spring-boot"
+	"github.com/go-spring/go-spring/spring-core"
+	"github.com/labstack/echo"
+	"github.com/magiconair/properties/assert"
+)
+
+type SessionCounter struct {
+	Count int
+}
+
+// serveFilter 通过 echo 处理一个真实的请求，返回响应
+func serveFilter(filter SpringWeb.Filter, fn SpringWeb.HandlerFunc, req *http.Request) *httptest.ResponseRecorder {
+	e := echo.New()
+	e.GET("/", SpringEcho.HandlerWrapper(SpringWeb.FUNC(fn), "", []SpringWeb.Filter{filter}))
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	return w
+}
+
+func TestSessionScopeFilter(t *testing.T) {
+
+	ctx := SpringCore.NewDefaultSpringContext()
+
+	var destroyed int
+	ctx.RegisterBeanFn(func() *SessionCounter {
+		return new(SessionCounter)
+	}).SessionScoped().Destroy(func(c *SessionCounter) {
+		destroyed++
+	})
+	ctx.AutoWireBeans()
+
+	filter := SpringBoot.SessionScopeFilter(ctx, SpringBoot.SessionConfig{MaxSessions: 1})
+	count := func(webCtx SpringWeb.WebContext) {
+		var c *SessionCounter
+		ctx.GetScopedBean(webCtx.Request().Context(), &c)
+		c.Count++
+		webCtx.String(http.StatusOK, "%d", c.Count)
+	}
+
+	request := func(cookie *http.Cookie) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		return req
+	}
+
+	t.Run("issue", func(t *testing.T) {
+
+		w := serveFilter(filter, count, request(nil))
+		assert.Equal(t, w.Body.String(), "1")
+
+		cookies := w.Result().Cookies()
+		assert.Equal(t, len(cookies), 1)
+		session := cookies[0]
+		assert.Equal(t, session.Name, SpringBoot.SessionCookieName)
+		assert.Equal(t, session.HttpOnly, true)
+		assert.Equal(t, session.Secure, false)
+
+		// 服务端签发的会话 ID 可以继续使用
+		w = serveFilter(filter, count, request(session))
+		assert.Equal(t, w.Body.String(), "2")
+		assert.Equal(t, len(w.Result().Cookies()), 0)
+
+		// 客户端伪造的会话 ID 不被接受，超过数量限制时淘汰旧的会话
+		w = serveFilter(filter, count, request(&http.Cookie{Name: SpringBoot.SessionCookieName, Value: "forged"}))
+		assert.Equal(t, w.Body.String(), "1")
+		cookies = w.Result().Cookies()
+		assert.Equal(t, len(cookies), 1)
+		assert.Equal(t, cookies[0].Value != "forged", true)
+		assert.Equal(t, destroyed, 1)
+
+		// 被淘汰的会话 ID 不再有效
+		w = serveFilter(filter, count, request(session))
+		assert.Equal(t, w.Body.String(), "1")
+		assert.Equal(t, len(w.Result().Cookies()), 1)
+		assert.Equal(t, destroyed, 2)
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+
+		expiry := SpringBoot.SessionScopeFilter(ctx, SpringBoot.SessionConfig{Expiry: time.Millisecond})
+		w := serveFilter(expiry, count, request(nil))
+		session := w.Result().Cookies()[0]
+
+		time.Sleep(5 * time.Millisecond)
+
+		w = serveFilter(expiry, count, request(session))
+		assert.Equal(t, w.Body.String(), "1")
+		assert.Equal(t, w.Result().Cookies()[0].Value != session.Value, true)
+	})
+
+	t.Run("secure", func(t *testing.T) {
+
+		req := request(nil)
+		req.TLS = &tls.ConnectionState{}
+		w := serveFilter(filter, count, req)
+		assert.Equal(t, w.Result().Cookies()[0].Secure, true)
+
+		secure := SpringBoot.SessionScopeFilter(ctx, SpringBoot.SessionConfig{Secure: true})
+		w = serveFilter(secure, count, request(nil))
+		assert.Equal(t, w.Result().Cookies()[0].Secure, true)
+	})
+}
+
+type RequestTrace struct {
+	Id       int
+	Released bool
+}
+
+func TestRequestScopeFilter(t *testing.T) {
+
+	ctx := SpringCore.NewDefaultSpringContext()
+
+	var seq int
+	var traces []*RequestTrace
+	ctx.RegisterBeanFn(func() *RequestTrace {
+		seq++
+		r := &RequestTrace{Id: seq}
+		traces = append(traces, r)
+		return r
+	}).RequestScoped().Destroy(func(r *RequestTrace) {
+		r.Released = true
+	})
+	ctx.AutoWireBeans()
+
+	filter := SpringBoot.RequestScopeFilter(ctx)
+	handler := func(webCtx SpringWeb.WebContext) {
+		var r1, r2 *RequestTrace
+		ctx.GetScopedBean(webCtx.Request().Context(), &r1)
+		ctx.GetScopedBean(webCtx.Request().Context(), &r2)
+		assert.Equal(t, r1, r2)
+		assert.Equal(t, r1.Released, false)
+		webCtx.String(http.StatusOK, "%d", r1.Id)
+	}
+
+	// 同一个请求内共享一个实例，不同的请求使用不同的实例，请求结束时销毁
+	w := serveFilter(filter, handler, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, w.Body.String(), "1")
+	assert.Equal(t, len(traces), 1)
+	assert.Equal(t, traces[0].Released, true)
+
+	w = serveFilter(filter, handler, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, w.Body.String(), "2")
+	assert.Equal(t, len(traces), 2)
+	assert.Equal(t, traces[1].Released, true)
+}
+
+type GoroutineBuffer struct {
+	Released bool
+}
+
+func TestRequestScopeFilter_GoroutineBeans(t *testing.T) {
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.RegisterBeanFn(func() *GoroutineBuffer {
+		return new(GoroutineBuffer)
+	}).GoroutineLocal().Destroy(func(b *GoroutineBuffer) {
+		b.Released = true
+	})
+	ctx.AutoWireBeans()
+
+	var buffer *GoroutineBuffer
+	filter := SpringBoot.RequestScopeFilter(ctx)
+	w := serveFilter(filter, func(webCtx SpringWeb.WebContext) {
+		ctx.GetBean(&buffer)
+		webCtx.String(http.StatusOK, "ok")
+	}, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	// 请求结束时销毁处理请求的 goroutine 内的实例
+	assert.Equal(t, w.Body.String(), "ok")
+	assert.Equal(t, buffer.Released, true)
+}
@@ -43,6 +43,7 @@ import (
 	"github.com/jinzhu/gorm"
 	"github.com/labstack/echo"
 	"github.com/labstack/echo/middleware"
+	"github.com/magiconair/properties/assert"
 )
 
 func init() {
@@ -163,6 +164,35 @@ func init() {
 	})
 }
 
+type PrefixService struct {
+	Name string
+}
+
+func TestWithBeanNamePrefix(t *testing.T) {
+
+	newService := func() *PrefixService { return &PrefixService{} }
+
+	SpringBoot.WithBeanNamePrefix("auth")
+	bd := SpringBoot.RegisterNameBeanFn("userService", newService)
+	assert.Equal(t, bd.Name(), "auth.userService")
+
+	SpringBoot.WithBeanNamePrefix("admin")
+	bd = SpringBoot.RegisterNameBeanFn("userService", newService)
+	assert.Equal(t, bd.Name(), "auth.admin.userService")
+	SpringBoot.EndBeanNamePrefix()
+
+	bd = SpringBoot.RegisterNameBean("roleService", &PrefixService{})
+	assert.Equal(t, bd.Name(), "auth.roleService")
+	SpringBoot.EndBeanNamePrefix()
+
+	bd = SpringBoot.RegisterNameBeanFn("userService", newService)
+	assert.Equal(t, bd.Name(), "userService")
+
+	assert.Panic(t, func() {
+		SpringBoot.EndBeanNamePrefix()
+	}, "no bean name prefix")
+}
+
 func TestRunApplication(t *testing.T) {
 
 	// 配置文件里面也指定了 spring.profile 的值
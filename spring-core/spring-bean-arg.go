@@ -17,16 +17,79 @@
 package SpringCore
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"reflect"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/go-spring/go-spring-parent/spring-logger"
+	"github.com/go-spring/go-spring-parent/spring-utils"
 )
 
+// contextType context.Context 的反射类型
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+// weakReferenceType WeakReference 的反射类型
+var weakReferenceType = reflect.TypeOf((*WeakReference)(nil)).Elem()
+
+// WeakReference 弱引用，作为函数参数使用，tag 为目标 Bean 的选择器，可以使用 WeakRef
+// 生成。目标 Bean 存在并且满足条件时先于当前 Bean 完成注入，不存在 (比如条件不满足)
+// 时 Get 返回 nil。它和可空注入的区别是目标 Bean 正在注入时不会形成循环依赖，Get 在
+// 目标 Bean 完成注入之后返回它的值。
+type WeakReference struct {
+	bd *BeanDefinition
+}
+
+// WeakRef 返回弱引用参数的 tag，选择器可以是 BeanId 字符串、reflect.Type 对象、形如
+// (*Type)(nil) 的对象指针或者 *BeanDefinition 对象，类型必须是具体的而不能是接口。
+func WeakRef(selector BeanSelector) string {
+	return ToSingletonTag(selector).String()
+}
+
+// IsPresent 返回目标 Bean 是否存在
+func (ref WeakReference) IsPresent() bool {
+	return ref.bd != nil
+}
+
+// Get 返回目标 Bean 的值，目标 Bean 不存在或者尚未创建时返回 nil
+func (ref WeakReference) Get() interface{} {
+	if ref.bd == nil {
+		return nil
+	}
+	if v := ref.bd.Value(); v.IsValid() && !SpringUtils.IsNil(v) {
+		return v.Interface()
+	}
+	return nil
+}
+
+// lazyProviderType LazyProvider 的反射类型
+var lazyProviderType = reflect.TypeOf((*LazyProvider)(nil)).Elem()
+
+// LazyProvider 延迟初始化的 Bean 的提供者，作为函数参数或者结构体字段使用，tag 为目标
+// Bean 的选择器。注入时不会初始化目标 Bean，首次调用 Get 时才完成初始化，因此启动时
+// 注入的 Bean 需要通过它依赖设置了 Lazy 的 Bean。
+type LazyProvider struct {
+	ctx *defaultSpringContext
+	bd  *BeanDefinition
+}
+
+// IsPresent 返回目标 Bean 是否存在
+func (p LazyProvider) IsPresent() bool {
+	return p.bd != nil
+}
+
+// Get 返回目标 Bean 的值，首次调用时初始化目标 Bean，目标 Bean 不存在时返回 nil
+func (p LazyProvider) Get() interface{} {
+	if p.bd == nil {
+		return nil
+	}
+	return newDefaultBeanAssembly(p.ctx).beanValue(p.bd).Interface()
+}
+
 // fnBindingArg 存储函数的参数绑定
 type fnBindingArg interface {
 	// Get 获取函数参数的绑定值，fileLine 是函数所在文件及其行号，日志使用
@@ -158,18 +221,35 @@ func (arg *fnStringBindingArg) Get(assembly beanAssembly, fileLine string) []ref
 	return result
 }
 
+// hasContextBean 是否存在可以注入的 context.Context 类型的 Bean，存在时按照对象注入语法注入
+func hasContextBean(assembly beanAssembly) bool {
+	_, ok := assembly.springContext().FindBean(assembly.inNamespace((*context.Context)(nil)))
+	return ok
+}
+
 // getArgValue 获取绑定参数值
 func (arg *fnStringBindingArg) getArgValue(v reflect.Value, tag string, assembly beanAssembly, fileLine string) {
 
 	description := fmt.Sprintf("tag:\"%s\" %s", tag, fileLine)
 	SpringLogger.Tracef("get value %s", description)
 
-	if ctx := assembly.springContext(); IsValueType(v.Kind()) { // 值类型，采用属性绑定语法
+	if ctx := assembly.springContext(); v.Type() == contextType && tag == "" && !hasContextBean(assembly) { // 调用上下文
+		v.Set(reflect.ValueOf(assembly.callContext()))
+	} else if v.Type() == weakReferenceType { // 弱引用
+		assembly.wireWeakReference(v, tag, description)
+	} else if v.Type() == lazyProviderType { // 延迟初始化的 Bean 的提供者
+		assembly.wireStructField(v, tag, reflect.Value{}, "")
+	} else if v.Type() == compositeBeansType { // 组合 Bean 的成员
+		v.Set(reflect.ValueOf(assembly.compositeBeans()))
+	} else if _, ok := typeConverters[v.Type()]; !ok && v.Kind() == reflect.Struct { // 结构体
+		v.Set(newFnStructBindingArg(v.Type(), tag).Get(assembly, fileLine)[0])
+	} else if IsValueType(v.Kind()) { // 值类型，采用属性绑定语法
 		if tag == "" {
 			tag = "${}"
 		}
-		bindStructField(ctx, v, tag, bindOption{
+		bindStructField(assembly.properties(), v, tag, bindOption{
 			allAccess: ctx.AllAccess(),
+			validate:  assembly.validating(),
 		})
 	} else { // 引用类型，采用对象注入语法
 		assembly.wireStructField(v, tag, reflect.Value{}, "")
@@ -178,6 +258,48 @@ func (arg *fnStringBindingArg) getArgValue(v reflect.Value, tag string, assembly
 	SpringLogger.Tracef("get value success %s", description)
 }
 
+// fnStructBindingArg 存储结构体形式的函数参数绑定，结构体的字段按照 value 标签
+// 从属性列表中获取值，嵌套结构体以及匿名嵌入的结构体会被递归绑定。设置了 tag 时
+// 按照属性绑定语法绑定 tag 指定的属性前缀，否则从根开始绑定。
+type fnStructBindingArg struct {
+	structType reflect.Type
+	tag        string
+}
+
+// newFnStructBindingArg fnStructBindingArg 的构造函数
+func newFnStructBindingArg(structType reflect.Type, tag string) *fnStructBindingArg {
+	if structType.Kind() != reflect.Struct {
+		panic(fmt.Errorf("%s isn't struct type", structType))
+	}
+	return &fnStructBindingArg{structType: structType, tag: tag}
+}
+
+// Get 获取函数参数的绑定值，fileLine 是函数所在文件及其行号，日志使用
+func (arg *fnStructBindingArg) Get(assembly beanAssembly, fileLine string) []reflect.Value {
+	SpringLogger.Tracef("bind struct %s %s", arg.structType, fileLine)
+
+	ctx := assembly.springContext()
+	v := reflect.New(arg.structType).Elem()
+	opt := bindOption{
+		fieldName: arg.structType.Name(),
+		allAccess: ctx.AllAccess(),
+		validate:  assembly.validating(),
+	}
+
+	if arg.tag != "" {
+		bindStructField(assembly.properties(), v, arg.tag, opt)
+		return []reflect.Value{v}
+	}
+
+	bindStruct(assembly.properties(), v, opt)
+
+	if opt.validate {
+		validateStruct(v, opt)
+	}
+
+	return []reflect.Value{v}
+}
+
 // fnOptionBindingArg 存储 Option 模式函数的参数绑定
 type fnOptionBindingArg struct {
 	options []*optionArg
@@ -185,8 +307,16 @@ type fnOptionBindingArg struct {
 
 // Get 获取函数参数的绑定值，fileLine 是函数所在文件及其行号，日志使用
 func (arg *fnOptionBindingArg) Get(assembly beanAssembly, fileLine string) []reflect.Value {
+
+	// 按照优先级排序，优先级相同时保持注册顺序
+	options := make([]*optionArg, len(arg.options))
+	copy(options, arg.options)
+	sort.SliceStable(options, func(i, j int) bool {
+		return options[i].priority < options[j].priority
+	})
+
 	result := make([]reflect.Value, 0)
-	for _, option := range arg.options {
+	for _, option := range options {
 		if v, ok := option.call(assembly); ok {
 			result = append(result, v)
 		}
@@ -198,16 +328,26 @@ func (arg *fnOptionBindingArg) Get(assembly beanAssembly, fileLine string) []ref
 type optionArg struct {
 	cond *Conditional // 判断条件
 
-	fn  interface{}
-	arg fnBindingArg
+	fn       interface{}
+	arg      fnBindingArg
+	priority int // 执行优先级，越小越先执行
 
 	file string // 注册点所在文件
 	line int    // 注册点所在行数
 }
 
-// 判断是否是合法的 Option 函数，只能有一个返回值
+// 判断是否是合法的 Option 函数，可以有一个返回值，或者两个返回值并且第二个是 error 类型
 func validOptionFunc(fnType reflect.Type) bool {
-	return fnType.Kind() == reflect.Func && fnType.NumOut() == 1
+	if fnType.Kind() != reflect.Func {
+		return false
+	}
+	switch fnType.NumOut() {
+	case 1:
+		return true
+	case 2:
+		return fnType.Out(1).Implements(errorType)
+	}
+	return false
 }
 
 // NewOptionArg optionArg 的构造函数，tags 是 Option 函数的一般参数绑定
@@ -236,7 +376,7 @@ func NewOptionArg(fn interface{}, tags ...string) *optionArg {
 
 	fnType := reflect.TypeOf(fn)
 	if ok := validOptionFunc(fnType); !ok {
-		panic(errors.New("option func must be func(...)option"))
+		panic(errors.New("option func must be func(...)option or func(...)(option, error)"))
 	}
 
 	return &optionArg{
@@ -252,6 +392,12 @@ func (arg *optionArg) FileLine() string {
 	return fmt.Sprintf("%s:%d", arg.file, arg.line)
 }
 
+// Priority 设置 Option 函数的执行优先级，越小越先执行，默认为 0
+func (arg *optionArg) Priority(n int) *optionArg {
+	arg.priority = n
+	return arg
+}
+
 // Or c=a||b
 func (arg *optionArg) Or() *optionArg {
 	arg.cond.Or()
@@ -333,6 +479,13 @@ func (arg *optionArg) call(assembly beanAssembly) (v reflect.Value, ok bool) {
 		fnValue := reflect.ValueOf(arg.fn)
 		in := arg.arg.Get(assembly, arg.FileLine())
 		out := fnValue.Call(in)
+
+		if len(out) == 2 { // 如果有 error 返回则 panic
+			if err := out[1].Interface(); err != nil {
+				panic(fmt.Errorf("option func: \"%s\" return error: %v", arg.FileLine(), err))
+			}
+		}
+
 		v = out[0]
 	}
 
@@ -18,10 +18,14 @@ package SpringCore
 
 import (
 	"container/list"
+	"context"
 	"errors"
 	"fmt"
 	"reflect"
+	"runtime"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/go-spring/go-spring-parent/spring-logger"
 	"github.com/go-spring/go-spring-parent/spring-utils"
@@ -31,6 +35,15 @@ import (
 type beanAssembly interface {
 	springContext() SpringContext
 
+	// properties 返回正在注入的 Bean 使用的属性值列表
+	properties() Properties
+
+	// validating 返回正在注入的 Bean 绑定属性值时是否校验结构体的 validate 标签
+	validating() bool
+
+	// callContext 返回注入到函数 context.Context 参数的值
+	callContext() context.Context
+
 	// wireStructField 对结构体的字段进行绑定
 	wireStructField(v reflect.Value, tag string, parent reflect.Value, field string)
 
@@ -38,9 +51,18 @@ type beanAssembly interface {
 	// 果排序，指定模式会对结果排序。当允许结果为空时返回 false，否则 panic
 	collectBeans(v reflect.Value, tag CollectionTag, field string) bool
 
+	// inNamespace 没有限定命名空间的选择器在当前的命名空间中查找
+	inNamespace(selector BeanSelector) BeanSelector
+
+	// compositeBeans 返回正在注入的组合 Bean 的成员
+	compositeBeans() *CompositeBeans
+
 	// getBeanValue 获取符合要求的 Bean，并且确保 Bean 完成自动注入过程，
 	// 结果最多有一个，否则 panic，当允许结果为空时返回 false，否则 panic
 	getBeanValue(v reflect.Value, tag SingletonTag, parent reflect.Value, field string) bool
+
+	// wireWeakReference 注入弱引用，目标 Bean 存在时先完成它的注入
+	wireWeakReference(v reflect.Value, tag string, field string)
 }
 
 // wiringStack 注入堆栈
@@ -56,14 +78,23 @@ func newWiringStack() *wiringStack {
 
 // pushBack 添加一个 Bean 到尾部
 func (s *wiringStack) pushBack(bd beanDefinition) {
-	SpringLogger.Tracef("wiring %s", bd.Description())
+	SpringLogger.Tracef("wiring %s%s", bd.Description(), traceAttrs(bd))
 	s.stack.PushBack(bd)
 }
 
 // popBack 删除尾部的 Bean
 func (s *wiringStack) popBack() {
 	e := s.stack.Remove(s.stack.Back())
-	SpringLogger.Tracef("wired %s", e.(beanDefinition).Description())
+	bd := e.(beanDefinition)
+	SpringLogger.Tracef("wired %s%s", bd.Description(), traceAttrs(bd))
+}
+
+// traceAttrs 返回跟踪日志中 Bean 实例的附加属性
+func traceAttrs(bd beanDefinition) string {
+	if d, ok := bd.(*BeanDefinition); ok && d.instanceId != "" {
+		return " instance.id=" + d.instanceId
+	}
+	return ""
 }
 
 // path 返回 Bean 注入的路径
@@ -72,6 +103,9 @@ func (s *wiringStack) path() (path string) {
 		w := e.Value.(beanDefinition)
 		path += fmt.Sprintf("=> %s ↩\n", w.Description())
 	}
+	if path == "" { // 注入完成之后的异常没有注入路径
+		return
+	}
 	return path[:len(path)-1]
 }
 
@@ -80,14 +114,23 @@ type defaultBeanAssembly struct {
 	springCtx   *defaultSpringContext
 	wiringStack *wiringStack
 	destroys    *list.List // 具有销毁函数的 Bean 的堆栈
+
+	scopeCtx     context.Context               // 作用域 Bean 所在的上下文
+	lockedScopes map[*scopedContainer]struct{} // 已经加锁的作用域子容器
+	prototypes   map[*BeanDefinition]struct{}  // 正在创建实例的原型 Bean
+	callCtx      context.Context               // 注入到函数 context.Context 参数的值
+	lazyLocked   bool                          // 是否已经持有延迟初始化的锁
+	validated    []*BeanDefinition             // 预检时找到的依赖项
 }
 
 // newDefaultBeanAssembly defaultBeanAssembly 的构造函数
 func newDefaultBeanAssembly(springContext *defaultSpringContext) *defaultBeanAssembly {
 	return &defaultBeanAssembly{
-		springCtx:   springContext,
-		wiringStack: newWiringStack(),
-		destroys:    list.New(),
+		springCtx:    springContext,
+		wiringStack:  newWiringStack(),
+		destroys:     list.New(),
+		lockedScopes: make(map[*scopedContainer]struct{}),
+		prototypes:   make(map[*BeanDefinition]struct{}),
 	}
 }
 
@@ -95,6 +138,110 @@ func (assembly *defaultBeanAssembly) springContext() SpringContext {
 	return assembly.springCtx
 }
 
+// properties 返回正在注入的 Bean 使用的属性值列表，参见 beanProperties
+func (assembly *defaultBeanAssembly) properties() Properties {
+
+	if bd := assembly.wiringBean(); bd != nil {
+		p := bd.beanProperties(assembly.springCtx)
+		return &trackedProperties{Properties: p, bd: bd}
+	}
+
+	var p Properties = assembly.springCtx
+	if key := assembly.springCtx.GetStringProperty(SecretKeyProperty); key != "" {
+		p = newDecryptedProperties(p, key)
+	}
+	return p
+}
+
+// beanProperties 返回 Bean 绑定属性值时使用的属性值列表，只在最外层解密一次属性值
+func (d *BeanDefinition) beanProperties(ctx *defaultSpringContext) Properties {
+	p, key := d.layeredProperties(ctx)
+	if key != "" {
+		p = newDecryptedProperties(p, key)
+	}
+	return p
+}
+
+// layeredProperties 返回没有解密的属性值列表以及解密密钥。设置了父 Bean 的 Bean 在父
+// Bean 的属性值列表之上叠加自己的属性源和环境变量前缀，设置了属性源的 Bean 优先使用
+// 属性源，设置了解密密钥的 Bean 使用自己的密钥代替继承的或者全局的密钥。
+func (d *BeanDefinition) layeredProperties(ctx *defaultSpringContext) (Properties, string) {
+
+	var p Properties = ctx
+	key := ctx.GetStringProperty(SecretKeyProperty)
+
+	if d.parentBd != nil {
+		p, key = d.parentBd.layeredProperties(ctx)
+	}
+
+	if d.props != nil {
+		p = NewPriorityProperties(d.props, p)
+	}
+	if d.envPrefix != "" {
+		p = newEnvProperties(p, d.envPrefix)
+	}
+	if d.secretKey != "" {
+		key = d.secretKey
+	}
+	return p, key
+}
+
+// validating 返回正在注入的 Bean 绑定属性值时是否校验结构体的 validate 标签
+func (assembly *defaultBeanAssembly) validating() bool {
+	bd := assembly.wiringBean()
+	return bd != nil && bd.validate
+}
+
+// callContext 返回注入到函数 context.Context 参数的值，默认为容器的上下文
+func (assembly *defaultBeanAssembly) callContext() context.Context {
+	if assembly.callCtx != nil {
+		return assembly.callCtx
+	}
+	return assembly.springCtx.ctx
+}
+
+// wireWeakReference 注入弱引用，目标 Bean 存在并且满足条件时先完成它的注入，正在注入
+// 的目标 Bean 不会形成循环依赖，目标 Bean 不存在时弱引用为空
+func (assembly *defaultBeanAssembly) wireWeakReference(v reflect.Value, tag string, field string) {
+
+	if tag == "" {
+		panic(fmt.Errorf("weak reference must have a selector, %s", field))
+	}
+
+	bd, ok := assembly.springCtx.FindBean(assembly.inNamespace(tag))
+	if !ok {
+		return
+	}
+
+	if bd.scope == SingletonScope && bd.status != beanStatus_Wiring {
+		assembly.wireBeanDefinition(bd, false)
+	}
+	v.Set(reflect.ValueOf(WeakReference{bd}))
+}
+
+// wireLazyProvider 注入延迟初始化的 Bean 的提供者，不会初始化目标 Bean
+func (assembly *defaultBeanAssembly) wireLazyProvider(v reflect.Value, tag string, field string) {
+
+	t := ParseSingletonTag(tag)
+	if t.BeanName == "" && t.TypeName == "" {
+		panic(fmt.Errorf("lazy provider must have a selector, field: %s", field))
+	}
+
+	selector := SingletonTag{TypeName: t.TypeName, BeanName: t.BeanName}.String()
+	bd, ok := assembly.springCtx.FindBean(assembly.inNamespace(selector))
+	if !ok && !t.Nullable {
+		panic(fmt.Errorf("can't find bean, bean: \"%s\" field: %s", tag, field))
+	}
+
+	p := LazyProvider{ctx: assembly.springCtx}
+	if ok {
+		p.bd = bd
+	}
+
+	v0 := SpringUtils.ValuePatchIf(v, assembly.springCtx.AllAccess())
+	v0.Set(reflect.ValueOf(p))
+}
+
 // getBeanValue 获取符合要求的 Bean，并且确保 Bean 完成自动注入过程，结果最多有一个，否则 panic，当允许结果为空时返回 false，否则 panic
 func (assembly *defaultBeanAssembly) getBeanValue(v reflect.Value, tag SingletonTag, parent reflect.Value, field string) bool {
 
@@ -107,12 +254,156 @@ func (assembly *defaultBeanAssembly) getBeanValue(v reflect.Value, tag Singleton
 		panic(fmt.Errorf("receiver must be ref type, bean: \"%s\" field: %s", tag, field))
 	}
 
+	// 通过指针的指针依赖代理 Bean，不触发代理 Bean 的注入
+	if beanType.Kind() == reflect.Ptr && beanType.Elem().Kind() == reflect.Ptr {
+		if result := assembly.findBean(beanType.Elem(), tag, parent, field); result != nil {
+			if !result.proxy {
+				panic(fmt.Errorf("bean: \"%s\" should be CircularProxy, field: %s", result.BeanId(), field))
+			}
+			assembly.accessBean(result)
+			v0 := SpringUtils.ValuePatchIf(v, assembly.springCtx.AllAccess())
+			v0.Set(result.proxyRef)
+			return true
+		}
+		return false
+	}
+
+	result := assembly.findBean(beanType, tag, parent, field)
+	if result == nil {
+		return false
+	}
+
+	// 启动时注入的 Bean 直接依赖延迟初始化的 Bean 会使延迟失去意义
+	if w := assembly.wiringBean(); result.lazy && w != nil && !w.lazy && !assembly.springCtx.started {
+		panic(fmt.Errorf("lazy bean: \"%s\" can't be injected into bean: \"%s\" at startup, use SpringCore.LazyProvider instead", result.BeanId(), w.BeanId()))
+	}
+
+	assembly.accessBean(result)
+
+	v0 := SpringUtils.ValuePatchIf(v, assembly.springCtx.AllAccess())
+	v0.Set(assembly.beanValue(result))
+	return true
+}
+
+// accessBean 记录 Bean 的访问，包括注入时的依赖关系和访问日志
+func (assembly *defaultBeanAssembly) accessBean(bd *BeanDefinition) {
+	if w := assembly.wiringBean(); w != nil && w.seq > 0 { // 只记录注册过的 Bean
+		assembly.springCtx.addDependency(w, bd)
+	}
+	assembly.auditAccess(bd)
+}
+
+// auditAccess 记录 Bean 的访问日志。注入时调用位置是请求方 Bean 的注册位置，
+// 否则是容器外第一个调用者的位置。
+func (assembly *defaultBeanAssembly) auditAccess(bd *BeanDefinition) {
+
+	if !bd.audit || !assembly.springCtx.audit {
+		return
+	}
+
+	requester, site := "-", ""
+	if w := assembly.wiringBean(); w != nil {
+		requester, site = w.Name(), w.FileLine()
+	} else {
+		site = callSite()
+	}
+
+	SpringLogger.Infof("audit time=%s requester=%s bean=%s site=%s",
+		time.Now().Format(time.RFC3339Nano), requester, bd.Name(), site)
+}
+
+// callSite 返回 spring-core 和 spring-boot 包之外第一个调用者的文件和行号
+func callSite() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		f, more := frames.Next()
+		if !strings.HasPrefix(f.Function, "github.com/go-spring/go-spring/spring-core.") &&
+			!strings.HasPrefix(f.Function, "github.com/go-spring/go-spring/spring-boot.") {
+			return fmt.Sprintf("%s:%d", f.File, f.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// wiringBean 返回正在注入的 Bean，结构体字段和函数返回值向上追溯到所属的 Bean，
+// 不在注入过程中时返回 nil
+func (assembly *defaultBeanAssembly) wiringBean() *BeanDefinition {
+	for e := assembly.wiringStack.stack.Back(); e != nil; e = e.Prev() {
+		switch bd := e.Value.(type) {
+		case *BeanDefinition:
+			return bd
+		case *fnValueBeanDefinition:
+			if d, ok := bd.f.(*BeanDefinition); ok {
+				return d
+			}
+		}
+	}
+	return nil
+}
+
+// namespace 返回当前所在的命名空间，不在注入过程中时为默认的命名空间
+func (assembly *defaultBeanAssembly) namespace() string {
+	if w := assembly.wiringBean(); w != nil {
+		return w.namespace
+	}
+	return ""
+}
+
+// inNamespace 没有限定命名空间的选择器在当前的命名空间中查找
+func (assembly *defaultBeanAssembly) inNamespace(selector BeanSelector) BeanSelector {
+	if _, ok := selector.(*namespacedSelector); ok {
+		return selector
+	}
+	return InNamespace(assembly.namespace(), selector)
+}
+
+// visible Bean 对正在注入的 Bean 是否可见。不同命名空间的 Bean 只有双方都允许跨命名
+// 空间时才可见；ScopedTo 的限制在通过名称查找或者不在注入过程中时不生效。
+func (assembly *defaultBeanAssembly) visible(bd *BeanDefinition, beanName string) bool {
+
+	w := assembly.wiringBean()
+
+	if ns := assembly.namespace(); bd.namespace != ns {
+		if !bd.crossNs || w == nil || !w.crossNs {
+			return false
+		}
+	}
+
+	if len(bd.scopedTo) == 0 || beanName != "" {
+		return true
+	}
+
+	if w == nil {
+		return true
+	}
+
+	// 结构体字段和函数返回值的注入属于所在的注册的 Bean
+	for _, s := range bd.scopedTo {
+		if s == w.Name() {
+			return true
+		}
+	}
+	return false
+}
+
+// recheckCondition 对设置了 ForceConditionRecheck 的 Bean 以及启动时保留的候选 Bean
+// 重新计算判断条件
+func (assembly *defaultBeanAssembly) recheckCondition(bd *BeanDefinition) bool {
+	return bd.recheckCondition(assembly.springCtx)
+}
+
+// findBean 查找和 tag 匹配的唯一 Bean，允许结果为空时没有找到返回 nil，否则 panic
+func (assembly *defaultBeanAssembly) findBean(beanType reflect.Type, tag SingletonTag, parent reflect.Value, field string) *BeanDefinition {
+
 	foundBeans := make([]*BeanDefinition, 0)
 
 	cache := assembly.springCtx.getTypeCacheItem(beanType)
 	for _, bean := range cache.beans {
-		// 不能将自身赋给自身的字段 && 类型全限定名匹配
-		if bean.Value() != parent && bean.Match(tag.TypeName, tag.BeanName) {
+		// 不能将自身赋给自身的字段 && 类型全限定名匹配 && 对当前 Bean 可见
+		if bean.Value() != parent && bean.Match(tag.TypeName, tag.BeanName) && assembly.visible(bean, tag.BeanName) && assembly.recheckCondition(bean) {
 			foundBeans = append(foundBeans, bean)
 		}
 	}
@@ -122,7 +413,7 @@ func (assembly *defaultBeanAssembly) getBeanValue(v reflect.Value, tag Singleton
 		cache = assembly.springCtx.getNameCacheItem(tag.BeanName)
 		for _, b := range cache.beans {
 			// 不能将自身赋给自身的字段 && 类型匹配 && BeanName 匹配
-			if b.Value() != parent && b.Type().AssignableTo(beanType) && b.Match(tag.TypeName, tag.BeanName) {
+			if b.Value() != parent && b.Type().AssignableTo(beanType) && b.Match(tag.TypeName, tag.BeanName) && assembly.recheckCondition(b) {
 				found := false // 对结果进行排重
 				for _, r := range foundBeans {
 					if r == b {
@@ -141,7 +432,7 @@ func (assembly *defaultBeanAssembly) getBeanValue(v reflect.Value, tag Singleton
 	// 没有找到，允许结果为空则返回 false，否则 panic
 	if len(foundBeans) == 0 {
 		if tag.Nullable {
-			return false
+			return nil
 		} else {
 			panic(fmt.Errorf("can't find bean, bean: \"%s\" field: %s type: %s", tag, field, beanType))
 		}
@@ -181,20 +472,44 @@ func (assembly *defaultBeanAssembly) getBeanValue(v reflect.Value, tag Singleton
 		result = primaryBeans[0]
 	}
 
-	// 对找到的 Bean 进行自动注入
-	assembly.wireBeanDefinition(result, false)
+	return result
+}
 
-	v0 := SpringUtils.ValuePatchIf(v, assembly.springCtx.AllAccess())
-	v0.Set(result.Value())
-	return true
+// beanValue 返回完成自动注入的 Bean 的值，非单例作用域的 Bean 从作用域子容器中获取
+func (assembly *defaultBeanAssembly) beanValue(bd *BeanDefinition) reflect.Value {
+	if bd.scope == PrototypeScope {
+		return assembly.prototypeBeanValue(bd)
+	}
+	if bd.scope == GoroutineScope {
+		return assembly.goroutineBeanValue(bd)
+	}
+	if bd.scope != SingletonScope {
+		return assembly.scopedBeanValue(bd)
+	}
+	if bd.ttl > 0 {
+		return assembly.expiringBeanValue(bd)
+	}
+	if bd.memoize != nil {
+		return assembly.memoizedBeanValue(bd)
+	}
+	assembly.wireBeanDefinition(bd, false)
+	v := bd.Value()
+	if w := assembly.wiringBean(); w != nil && w.seq > 0 && !bd.immutable { // 刷新时不能销毁被引用的实例
+		bd.reference(v)
+	}
+	return v
 }
 
-// collectBeans 收集符合要求的 Bean，结果可以是多个。自动模式下不对结果排序，指定模式会对结果排序。当允许结果为空时返回 false，否则 panic
+// collectBeans 收集符合要求的 Bean，结果可以是多个。自动模式下按照 Ordered 设置的位置排序，指定模式会对结果排序。当允许结果为空时返回 false，否则 panic
 func (assembly *defaultBeanAssembly) collectBeans(v reflect.Value, tag CollectionTag, field string) bool {
 
 	t := v.Type()
 	et := t.Elem()
 
+	if t.Kind() == reflect.Map {
+		return assembly.collectBeanMap(v, tag, field)
+	}
+
 	if !IsRefType(et.Kind()) { // 收集模式的数组元素必须是引用类型
 		panic(errors.New("slice item in collection mode should be ref type"))
 	}
@@ -221,6 +536,75 @@ func (assembly *defaultBeanAssembly) collectBeans(v reflect.Value, tag Collectio
 	}
 }
 
+// collectBeanMap 收集符合要求的 Bean 并以 map 的形式返回，map 的键默认为 Bean 的名称，
+// 可以通过 MapKey 自定义，键重复时 panic。当允许结果为空时返回 false，否则 panic
+func (assembly *defaultBeanAssembly) collectBeanMap(v reflect.Value, tag CollectionTag, field string) bool {
+
+	t := v.Type()
+	et := t.Elem()
+
+	if t.Key().Kind() != reflect.String || !IsRefType(et.Kind()) {
+		panic(fmt.Errorf("map in collection mode should be map[string]T and T should be ref type, field: %s", field))
+	}
+
+	// 只在单例类型中查找
+	var found []*BeanDefinition
+	cache := assembly.springCtx.getTypeCacheItem(et)
+
+	if len(tag.Items) == 0 { // 自动模式
+		for _, d := range cache.beans {
+			if assembly.visible(d, "") && assembly.recheckCondition(d) {
+				found = append(found, d)
+			}
+		}
+	} else { // 指定模式
+		for _, item := range tag.Items {
+			n := len(found)
+			for _, d := range cache.beans {
+				if d.Match(item.TypeName, item.BeanName) && assembly.visible(d, item.BeanName) && assembly.recheckCondition(d) {
+					found = append(found, d)
+				}
+			}
+			if len(found) == n && !item.Nullable {
+				panic(fmt.Errorf("can't find bean, bean: \"%s\" type: %s", item, et))
+			}
+		}
+	}
+
+	result := reflect.MakeMap(t)
+	owners := make(map[string]*BeanDefinition)
+
+	for _, d := range found {
+		assembly.accessBean(d)
+		bv := assembly.beanValue(d)
+
+		key := d.Name()
+		if d.mapKey != nil {
+			key = d.mapKey(bv.Interface())
+		}
+
+		if prev, ok := owners[key]; ok {
+			panic(fmt.Errorf("duplicate map key \"%s\" field: %s [( %s ), ( %s )]", key, field, prev.Description(), d.Description()))
+		}
+
+		owners[key] = d
+		result.SetMapIndex(reflect.ValueOf(key).Convert(t.Key()), bv)
+	}
+
+	if result.Len() > 0 {
+		v = SpringUtils.ValuePatchIf(v, assembly.springCtx.AllAccess())
+		v.Set(result)
+		return true
+	}
+
+	// 没有找到，允许结果为空则返回 false，否则 panic
+	if tag.Nullable {
+		return false
+	} else {
+		panic(fmt.Errorf("can't collect any beans: \"%s\" field: %s", tag, field))
+	}
+}
+
 // collectAndSortBeans 收集符合条件的 Bean，并且根据指定的顺序对结果进行排序
 func (assembly *defaultBeanAssembly) collectAndSortBeans(t reflect.Type, et reflect.Type, tag CollectionTag) reflect.Value {
 	result := reflect.MakeSlice(t, 0, len(tag.Items))
@@ -232,7 +616,7 @@ func (assembly *defaultBeanAssembly) collectAndSortBeans(t reflect.Type, et refl
 		// 查找符合条件的单例 Bean
 		var found []*BeanDefinition
 		for _, d := range cache.beans {
-			if d.Match(item.TypeName, item.BeanName) {
+			if d.Match(item.TypeName, item.BeanName) && assembly.visible(d, item.BeanName) && assembly.recheckCondition(d) {
 				found = append(found, d)
 			}
 		}
@@ -253,16 +637,15 @@ func (assembly *defaultBeanAssembly) collectAndSortBeans(t reflect.Type, et refl
 		}
 
 		if len(found) > 0 {
-			d := found[0]
-			assembly.wireBeanDefinition(d, false)
-			result = reflect.Append(result, d.Value())
+			assembly.accessBean(found[0])
+			result = reflect.Append(result, assembly.beanValue(found[0]))
 		}
 	}
 
 	return result // TODO 当收集接口类型的 Bean 时对于没有显式导出接口的 Bean 是否也需要收集？
 }
 
-// autoCollectBeans 收集符合条件的 Bean，不对结果进行排序，不排序是因为目前看起来没有必要
+// autoCollectBeans 收集符合条件的 Bean，数组 Bean 的元素在前，单例 Bean 按照 Ordered 设置的位置排序
 func (assembly *defaultBeanAssembly) autoCollectBeans(t reflect.Type, et reflect.Type) reflect.Value {
 	result := reflect.MakeSlice(t, 0, 0)
 
@@ -286,17 +669,40 @@ func (assembly *defaultBeanAssembly) autoCollectBeans(t reflect.Type, et reflect
 	}
 
 	// 查找可以精确匹配的单例类型
+	var found []*BeanDefinition
 	cache = assembly.springCtx.getTypeCacheItem(et)
 	for _, d := range cache.beans {
+		if assembly.visible(d, "") && assembly.recheckCondition(d) {
+			found = append(found, d)
+		}
+	}
+
+	sortCollectedBeans(found)
 
+	for _, d := range found {
 		// 对找到的 Bean 进行自动注入
-		assembly.wireBeanDefinition(d, false)
-		result = reflect.Append(result, d.Value())
+		assembly.accessBean(d)
+		result = reflect.Append(result, assembly.beanValue(d))
 	}
 
 	return result // TODO 当收集接口类型的 Bean 时对于没有显式导出接口的 Bean 是否也需要收集？
 }
 
+// sortCollectedBeans 对收集到的单例 Bean 排序，设置了位置的 Bean 按照位置排在前面，
+// 其他 Bean 按照注册顺序排在后面。
+func sortCollectedBeans(beans []*BeanDefinition) {
+	sort.SliceStable(beans, func(i, j int) bool {
+		bi, bj := beans[i], beans[j]
+		if bi.ordered != bj.ordered {
+			return bi.ordered
+		}
+		if bi.ordered && bi.position != bj.position {
+			return bi.position < bj.position
+		}
+		return bi.seq < bj.seq
+	})
+}
+
 // wireSliceItem 对 slice 的元素值进行注入
 func (assembly *defaultBeanAssembly) wireSliceItem(v reflect.Value, d beanDefinition) {
 	bd := ValueToBeanDefinition("", v)
@@ -308,6 +714,16 @@ func (assembly *defaultBeanAssembly) wireSliceItem(v reflect.Value, d beanDefini
 // wireBeanDefinition 对特定的 BeanDefinition 进行注入，onlyAutoWire 是否只注入而不进行属性绑定
 func (assembly *defaultBeanAssembly) wireBeanDefinition(bd beanDefinition, onlyAutoWire bool) {
 
+	// 延迟初始化的 Bean 和候选 Bean 可能被并发地首次获取，加锁保证只初始化一次
+	if d, ok := bd.(*BeanDefinition); ok && (d.lazy || d.deferred) && !assembly.lazyLocked {
+		assembly.springCtx.lazyMutex.Lock()
+		assembly.lazyLocked = true
+		defer func() {
+			assembly.lazyLocked = false
+			assembly.springCtx.lazyMutex.Unlock()
+		}()
+	}
+
 	// Bean 是否已删除，已经删除的 Bean 不能再注入
 	if bd.getStatus() == beanStatus_Deleted {
 		panic(fmt.Errorf("bean: \"%s\" have been deleted", bd.BeanId()))
@@ -322,11 +738,11 @@ func (assembly *defaultBeanAssembly) wireBeanDefinition(bd beanDefinition, onlyA
 	// 如果有销毁函数则对其进行排序处理
 	if bd.getDestroy() != nil {
 		if curr, ok := bd.(*BeanDefinition); ok {
-			de := assembly.springCtx.destroyer(curr)
+			var prev *BeanDefinition
 			if i := assembly.destroys.Back(); i != nil {
-				prev := i.Value.(*BeanDefinition)
-				de.After(prev)
+				prev = i.Value.(*BeanDefinition)
 			}
+			assembly.springCtx.addDestroyer(curr, prev)
 			assembly.destroys.PushBack(curr)
 		} else {
 			panic(errors.New("let me known when it happened"))
@@ -338,6 +754,11 @@ func (assembly *defaultBeanAssembly) wireBeanDefinition(bd beanDefinition, onlyA
 		return
 	}
 
+	// 计算实例标识，只在第一次注入时调用一次
+	if d, ok := bd.(*BeanDefinition); ok && d.traceId != nil && bd.getStatus() == beanStatus_Resolved {
+		d.instanceId = d.traceId(assembly.springCtx)
+	}
+
 	// 将当前 Bean 放入注入栈，以便检测循环依赖。
 	assembly.wiringStack.pushBack(bd)
 
@@ -351,9 +772,18 @@ func (assembly *defaultBeanAssembly) wireBeanDefinition(bd beanDefinition, onlyA
 
 	bd.setStatus(beanStatus_Wiring)
 
+	// 保存对象 Bean 注入之前的内容，刷新时以它为模板创建新的实例
+	if d, ok := bd.(*BeanDefinition); ok && d.owner != nil && !d.template.IsValid() {
+		_, ok = d.bean.(*objectBean)
+		if t := d.Type(); ok && t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct {
+			d.template = reflect.New(d.Type().Elem())
+			d.template.Elem().Set(d.Value().Elem())
+		}
+	}
+
 	// 首先对当前 Bean 的间接依赖项进行自动注入
 	for _, selector := range bd.getDependsOn() {
-		if bean, ok := assembly.springCtx.FindBean(selector); !ok {
+		if bean, ok := assembly.springCtx.FindBean(assembly.inNamespace(selector)); !ok {
 			panic(fmt.Errorf("can't find bean: \"%v\"", selector))
 		} else {
 			assembly.wireBeanDefinition(bean, false)
@@ -379,9 +809,14 @@ func (assembly *defaultBeanAssembly) wireBeanDefinition(bd beanDefinition, onlyA
 		panic(errors.New("error spring bean type"))
 	}
 
+	// 使用 Bean 的拦截器包装它的函数字段
+	if d, ok := bd.(*BeanDefinition); ok && len(d.intercept) > 0 {
+		interceptBean(d)
+	}
+
 	// 如果用户设置了初始化函数则执行初始化函数
 	if init := bd.getInit(); init != nil {
-		if err := init.run(assembly); err != nil {
+		if err := assembly.runInit(bd, init); err != nil {
 			panic(err)
 		}
 	}
@@ -393,6 +828,31 @@ func (assembly *defaultBeanAssembly) wireBeanDefinition(bd beanDefinition, onlyA
 	assembly.wiringStack.popBack()
 }
 
+// runInit 执行 Bean 的初始化函数，设置了最大调用次数时失败后间隔一段时间重新调用
+func (assembly *defaultBeanAssembly) runInit(bd beanDefinition, init *runnable) error {
+
+	d, ok := bd.(*BeanDefinition)
+	if !ok || d.retries <= 1 {
+		return init.run(assembly)
+	}
+
+	var err error
+	for i := 1; i <= d.retries; i++ {
+		if err = init.run(assembly); err == nil {
+			return nil
+		}
+		SpringLogger.Warnf("%s init failed (%d/%d): %v", d.Description(), i, d.retries, err)
+		if i < d.retries && d.backoff > 0 {
+			select { // 容器关闭时不再等待重试
+			case <-time.After(d.backoff):
+			case <-assembly.callContext().Done():
+				return err
+			}
+		}
+	}
+	return err
+}
+
 // wireObjectBean 对原始对象进行注入
 func (assembly *defaultBeanAssembly) wireObjectBean(bd beanDefinition, onlyAutoWire bool) {
 	st := bd.Type()
@@ -440,9 +900,10 @@ func (assembly *defaultBeanAssembly) wireObjectBean(bd beanDefinition, onlyAutoW
 				if !onlyAutoWire { // 防止 value 再次解析
 					if tag, ok := ft.Tag.Lookup("value"); ok {
 						fieldOnlyAutoWire = true
-						bindStructField(assembly.springCtx, fv, tag, bindOption{
+						bindStructField(assembly.properties(), fv, tag, bindOption{
 							allAccess: assembly.springCtx.AllAccess(),
 							fieldName: fieldName,
+							validate:  assembly.validating(),
 						})
 					}
 				}
@@ -454,7 +915,11 @@ func (assembly *defaultBeanAssembly) wireObjectBean(bd beanDefinition, onlyAutoW
 
 				// 处理 inject 标签，inject 与 autowire 等价
 				if beanId, ok := ft.Tag.Lookup("inject"); ok {
-					assembly.wireStructField(fv, beanId, sv, fieldName)
+					if beanId == "self" && isSelfReferencing(bd) {
+						wireSelfField(fv, sv, bd, fieldName)
+					} else {
+						assembly.wireStructField(fv, beanId, sv, fieldName)
+					}
 				}
 
 				// 只处理结构体类型的字段，防止递归所以不支持指针结构体字段
@@ -473,8 +938,51 @@ func (assembly *defaultBeanAssembly) wireObjectBean(bd beanDefinition, onlyAutoW
 					}
 				}
 			}
+
+			// 没有 value 标签的字段绑定 PREFIX_FIELD 环境变量
+			if d, ok := bd.(*BeanDefinition); ok && d.envPrefix != "" && !onlyAutoWire {
+				bindEnvFields(assembly.properties(), d.envPrefix, ev, "", bindOption{
+					allAccess: assembly.springCtx.AllAccess(),
+					fieldName: etName,
+				})
+			}
+
+			// 执行 Bean 后处理器
+			for _, p := range assembly.springCtx.processors {
+				p.PostProcessBean(sv, bd.BeanId())
+			}
+		}
+	}
+}
+
+// callConstructor 调用 Bean 函数，设置了构造函数的超时时间时，超时没有返回则打印
+// 警告日志，返回之后根据容器的设置决定是否 panic。超时回调在其他 goroutine 中执行，
+// 所以只使用提前计算好的 BeanId 而不访问 BeanDefinition。
+func (assembly *defaultBeanAssembly) callConstructor(fnValue reflect.Value, in []reflect.Value, bd beanDefinition) []reflect.Value {
+
+	d, ok := bd.(*BeanDefinition)
+	if !ok || d.ctorLimit <= 0 {
+		return fnValue.Call(in)
+	}
+
+	beanId, limit := d.BeanId(), d.ctorLimit
+
+	start := time.Now()
+	timer := time.AfterFunc(limit, func() {
+		SpringLogger.Warnf("bean: \"%s\" construction is slow, elapsed %v exceeds %v",
+			beanId, time.Since(start), limit)
+	})
+
+	out := fnValue.Call(in)
+	timer.Stop()
+
+	if elapsed := time.Since(start); elapsed > limit {
+		SpringLogger.Warnf("bean: \"%s\" construction took %v exceeds %v", beanId, elapsed, limit)
+		if assembly.springCtx.ctorAction == ConstructionTimeoutPanic {
+			panic(fmt.Errorf("bean: \"%s\" construction took %v exceeds %v", beanId, elapsed, limit))
 		}
 	}
+	return out
 }
 
 // wireFunctionBean 对函数定义的 Bean 进行注入
@@ -496,7 +1004,7 @@ func (assembly *defaultBeanAssembly) wireFunctionBean(fnValue reflect.Value, fnB
 	}
 
 	// 调用 Bean 函数
-	out := fnValue.Call(in)
+	out := assembly.callConstructor(fnValue, in, bd)
 
 	// 获取第一个返回值
 	val := out[0]
@@ -507,8 +1015,7 @@ func (assembly *defaultBeanAssembly) wireFunctionBean(fnValue reflect.Value, fnB
 		}
 	}
 
-	// 将函数的返回值赋值给 Bean
-	if IsRefType(val.Kind()) {
+	if IsRefType(val.Kind()) { // 将函数的返回值赋值给 Bean
 		// 如果实现接口的是值类型，那么需要转换成指针类型然后再赋值给接口
 		if val.Kind() == reflect.Interface && IsValueType(val.Elem().Kind()) {
 			ptrVal := reflect.New(val.Elem().Type())
@@ -525,6 +1032,11 @@ func (assembly *defaultBeanAssembly) wireFunctionBean(fnValue reflect.Value, fnB
 		panic(fmt.Errorf("function bean: \"%s\" return nil", bd.FileLine()))
 	}
 
+	// 代理 Bean 的指针的指针指向函数的返回值
+	if d, ok := bd.(*BeanDefinition); ok && d.proxy {
+		d.proxyRef.Elem().Set(fnBean.Value())
+	}
+
 	// 对函数的返回值进行自动注入
 	b := &BeanDefinition{
 		name:   bd.Name(),
@@ -549,13 +1061,15 @@ func (assembly *defaultBeanAssembly) wireStructField(v reflect.Value, tag string
 	if strings.HasPrefix(tag, "${") {
 		s := ""
 		sv := reflect.ValueOf(&s).Elem()
-		bindStructField(assembly.springCtx, sv, tag, bindOption{})
+		bindStructField(assembly.properties(), sv, tag, bindOption{})
 		tag = s
 	}
 
-	if CollectionMode(tag) { // 收集模式，绑定对象必须是数组
-		if v.Type().Kind() != reflect.Slice {
-			panic(fmt.Errorf("field: %s should be slice", field))
+	if v.Type() == lazyProviderType { // 延迟初始化的 Bean 的提供者
+		assembly.wireLazyProvider(v, tag, field)
+	} else if CollectionMode(tag) { // 收集模式，绑定对象必须是数组或者 map
+		if k := v.Type().Kind(); k != reflect.Slice && k != reflect.Map {
+			panic(fmt.Errorf("field: %s should be slice or map", field))
 		}
 		assembly.collectBeans(v, ParseCollectionTag(tag), field)
 	} else { // 单例模式
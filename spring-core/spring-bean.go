@@ -22,6 +22,9 @@ import (
 	"reflect"
 	"runtime"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/go-spring/go-spring-parent/spring-utils"
 )
@@ -123,6 +126,21 @@ func TypeName(typOrPtr TypeOrPtr) string {
 // 对象或者形如 (*error)(nil) 的对象指针，还可以是 *BeanDefinition 对象。
 type BeanSelector interface{}
 
+// namespacedSelector 限定了命名空间的 Bean 选择器
+type namespacedSelector struct {
+	namespace string
+	selector  BeanSelector
+}
+
+// InNamespace 返回限定在命名空间 ns 中查找的 Bean 选择器，FindBean 默认只在
+// 默认的命名空间 (空字符串) 中查找。
+func InNamespace(ns string, selector BeanSelector) BeanSelector {
+	if s, ok := selector.(*namespacedSelector); ok {
+		selector = s.selector
+	}
+	return &namespacedSelector{namespace: ns, selector: selector}
+}
+
 // ToSingletonTag 将 Bean 选择器转换为 SingletonTag 形式。注意该函数仅用
 // 于精确匹配的场景下，也就是说通过类型选择的时候类型必须是具体的，而不能是接口。
 func ToSingletonTag(selector BeanSelector) SingletonTag {
@@ -322,13 +340,7 @@ func newFunctionBean(fnType reflect.Type, withReceiver bool, tags []string) func
 	}
 
 	// 创建 Bean 的值
-	out0 := fnType.Out(0)
-	v := reflect.New(out0)
-
-	// 引用类型去掉一层指针
-	if IsRefType(out0.Kind()) {
-		v = v.Elem()
-	}
+	v := newFunctionBeanValue(fnType.Out(0))
 
 	// 获取 Bean 的类型
 	t := v.Type()
@@ -343,6 +355,18 @@ func newFunctionBean(fnType reflect.Type, withReceiver bool, tags []string) func
 	}
 }
 
+// newFunctionBeanValue 创建用于保存函数返回值的 Bean 的值
+func newFunctionBeanValue(out0 reflect.Type) reflect.Value {
+	v := reflect.New(out0)
+
+	// 引用类型去掉一层指针
+	if IsRefType(out0.Kind()) {
+		v = v.Elem()
+	}
+
+	return v
+}
+
 // constructorBean 以构造函数形式注册的 Bean
 type constructorBean struct {
 	functionBean
@@ -475,21 +499,85 @@ type beanDefinition interface {
 
 // BeanDefinition 用于存储 Bean 的各种元数据
 type BeanDefinition struct {
-	bean   springBean // Bean 的注册形式
-	name   string     // Bean 的名称
-	status beanStatus // Bean 的状态
+	bean    springBean            // Bean 的注册形式
+	name    string                // Bean 的名称
+	aliases []string              // Bean 的别名
+	status  beanStatus            // Bean 的状态
+	owner   *defaultSpringContext // 注册到的容器，用于检查别名冲突
 
 	file string // 注册点所在文件
 	line int    // 注册点所在行数
 
 	cond      *Conditional   // 判断条件
 	primary   bool           // 是否为主版本
+	failFast  bool           // 条件出错时是否包装错误信息并中止启动
+	audit     bool           // 是否记录 Bean 的访问日志
+	excluded  bool           // 是否在 GetBeanDefinitions 的结果中隐藏
+	eager     bool           // 是否在容器刷新的最后强制初始化
+	lazy      bool           // 是否延迟到首次使用时初始化
+	recheck   bool           // 是否在每次获取时重新计算判断条件
+	deferred  bool           // 启动时不满足判断条件而保留的候选 Bean，获取时重新计算判断条件
+	jmx       bool           // 是否通过管理端点暴露属性和操作
+	jmxOps    []string       // 通过管理端点暴露的操作
+	validate  bool           // 绑定属性值时是否校验结构体的 validate 标签
+	selfRef   bool           // 是否向 self 字段注入 Bean 自身
+	immutable bool           // 完成注入之后是否禁止刷新和替换
 	dependsOn []BeanSelector // 间接依赖项
-
-	init    *runnable // 初始化函数
-	destroy *runnable // 销毁函数
+	observing []BeanSelector // 观察的事件源
+	scopedTo  []string       // 可以通过类型注入该 Bean 的 Bean 名称
+	composite []string       // 组合 Bean 的成员名称
+	version   string         // Bean 的版本
+	requires  []beanVersion  // 依赖的 Bean 的最低版本
+	namespace string         // 所属的命名空间
+	props     Properties     // 构造和初始化时优先使用的属性源
+	secretKey string         // 绑定属性值时使用的解密密钥
+	envPrefix string         // 绑定属性值时读取的环境变量前缀
+	parent    string         // 继承属性源的父 Bean 的名称
+	crossNs   bool           // 是否允许跨命名空间注入
+	scope     Scope          // 作用域
+	expiry    time.Duration  // 作用域 Bean 的有效期
+	proxy     bool           // 是否使用代理对象打破循环依赖
+	timeout   time.Duration  // 优雅退出的超时时间
+	ctorLimit time.Duration  // 构造函数的超时时间
+	ttl       time.Duration  // 单例 Bean 的存活时间
+	refresh   time.Duration  // 自动刷新的间隔
+	order     int            // 同一依赖层级内的初始化顺序
+	ordered   bool           // 是否设置了收集时的位置
+	position  int            // 收集到数组中时的位置
+	seq       int            // 注册序号
+
+	singletonKey func(ctx SpringContext) string // 自定义单例的缓存键
+	cacheKey     string                         // 计算出的单例缓存键，不改变 Bean 的名称
+	mapKey       func(bean interface{}) string  // 收集到 map 时使用的键
+	memoize      func(ctx SpringContext) string // 按照缓存键缓存实例
+	traceId      func(ctx SpringContext) string // 计算实例标识的函数
+	instanceId   string                         // 注入时计算出的实例标识
+
+	maxInstances int                  // 原型 Bean 同时存活的最大实例数
+	exhausted    ExhaustedPolicy      // 实例数达到上限之后的处理策略
+	refreshMu    *sync.RWMutex        // 发布刷新后的实例时使用的锁
+	current      *atomic.Value        // 刷新或者替换之后发布的最新实例
+	referenced   map[uintptr]struct{} // 注入到其他 Bean 中的实例，刷新之后不能立即销毁
+	retired      []*runnable          // 仍被其他 Bean 引用的旧实例的销毁函数，容器关闭时调用
+	template     reflect.Value        // 对象 Bean 注入之前的内容，刷新时作为新实例的模板
+	replaceable  bool                 // 是否可以在运行时替换
+
+	init    *runnable     // 初始化函数
+	destroy *runnable     // 销毁函数
+	retries int           // 初始化函数的最大调用次数
+	backoff time.Duration // 初始化函数两次调用之间的间隔
 
 	exports map[reflect.Type]struct{} // 严格导出的接口类型
+
+	propKeys map[string]struct{} // 注入时读取过的属性名，用于属性变化之后找到需要刷新的 Bean
+
+	parentBd *BeanDefinition // 容器刷新时解析出的父 Bean
+
+	proxyRef reflect.Value // 代理 Bean 的指针的指针，函数返回之后指向函数的返回值
+
+	validators []func(bd *BeanDefinition) error // 决议时执行的注册信息校验函数
+
+	intercept []MethodInterceptor // 只对当前 Bean 生效的拦截器
 }
 
 // newBeanDefinition BeanDefinition 的构造函数
@@ -529,18 +617,24 @@ func newBeanDefinition(name string, bean springBean) *BeanDefinition {
 	}
 
 	return &BeanDefinition{
-		bean:    bean,
-		name:    name,
-		status:  beanStatus_Default,
-		file:    file,
-		line:    line,
-		cond:    NewConditional(),
-		exports: make(map[reflect.Type]struct{}),
+		bean:      bean,
+		name:      name,
+		status:    beanStatus_Default,
+		file:      file,
+		line:      line,
+		cond:      NewConditional(),
+		scope:     SingletonScope,
+		refreshMu: new(sync.RWMutex),
+		current:   new(atomic.Value),
+		exports:   make(map[reflect.Type]struct{}),
 	}
 }
 
 // Bean 返回 Bean 的源
 func (d *BeanDefinition) Bean() interface{} {
+	if v, ok := d.latest(); ok {
+		return v.Interface()
+	}
 	return d.bean.Bean()
 }
 
@@ -551,9 +645,21 @@ func (d *BeanDefinition) Type() reflect.Type {
 
 // Value 返回 Bean 的值
 func (d *BeanDefinition) Value() reflect.Value {
+	if v, ok := d.latest(); ok {
+		return v
+	}
 	return d.bean.Value()
 }
 
+// latest 返回刷新或者替换之后发布的最新实例，没有发布过时返回 false
+func (d *BeanDefinition) latest() (reflect.Value, bool) {
+	if d.current == nil {
+		return reflect.Value{}, false
+	}
+	v, ok := d.current.Load().(reflect.Value)
+	return v, ok
+}
+
 // TypeName 返回 Bean 的原始类型的全限定名
 func (d *BeanDefinition) TypeName() string {
 	return d.bean.TypeName()
@@ -574,6 +680,19 @@ func (d *BeanDefinition) FileLine() string {
 	return fmt.Sprintf("%s:%d", d.file, d.line)
 }
 
+// Conditional 返回 Bean 的判断条件
+func (d *BeanDefinition) Conditional() *Conditional {
+	return d.cond
+}
+
+// Matched 返回 Bean 是否满足判断条件，AutoWireBeans 之前总是返回 false
+func (d *BeanDefinition) Matched() bool {
+	if d.deferred { // 候选 Bean 只有在满足条件并完成注入之后才算满足条件
+		return d.status == beanStatus_Wired
+	}
+	return d.status >= beanStatus_Resolved && d.status != beanStatus_Deleted
+}
+
 // springBean 返回 springBean 对象
 func (d *BeanDefinition) springBean() springBean {
 	return d.bean
@@ -614,11 +733,40 @@ func (d *BeanDefinition) getLine() int {
 	return d.line
 }
 
-// Description 返回 Bean 的详细描述
+// Description 返回 Bean 的详细描述，显示名称和 Bean 的名称不同时一并输出显示名称
 func (d *BeanDefinition) Description() string {
+	if s := d.DisplayName(); s != "" && s != d.name {
+		return fmt.Sprintf("%s \"%s\" (%s) %s", d.bean.beanClass(), d.name, s, d.FileLine())
+	}
 	return fmt.Sprintf("%s \"%s\" %s", d.bean.beanClass(), d.name, d.FileLine())
 }
 
+// DisplayName 返回 Bean 在日志中的显示名称，Bean 实现了 fmt.Stringer 接口时使用
+// String() 的返回值，否则使用 Bean 的类型名称，成员方法 Bean 解析之前返回空字符串。
+func (d *BeanDefinition) DisplayName() (name string) {
+
+	if _, ok := d.bean.(*fakeMethodBean); ok {
+		return ""
+	}
+
+	t := d.bean.Type()
+	name = t.String()
+
+	// Bean 尚未创建或者 String() 出错时使用类型名称
+	defer func() {
+		if r := recover(); r != nil {
+			name = t.String()
+		}
+	}()
+
+	if v := d.bean.Value(); v.IsValid() && !(IsRefType(v.Kind()) && v.IsNil()) {
+		if s, ok := v.Interface().(fmt.Stringer); ok {
+			return s.String()
+		}
+	}
+	return name
+}
+
 // Match 测试 Bean 的类型全限定名和 Bean 的名称是否都匹配
 func (d *BeanDefinition) Match(typeName string, beanName string) bool {
 
@@ -630,6 +778,13 @@ func (d *BeanDefinition) Match(typeName string, beanName string) bool {
 	nameIsSame := false
 	if beanName == "" || d.name == beanName {
 		nameIsSame = true
+	} else {
+		for _, alias := range d.aliases {
+			if alias == beanName {
+				nameIsSame = true
+				break
+			}
+		}
 	}
 
 	return typeIsSame && nameIsSame
@@ -665,6 +820,12 @@ func (d *BeanDefinition) ConditionOnProperty(name string) *BeanDefinition {
 	return d
 }
 
+// ConditionOnAnyProperty 为 Bean 设置一个 PropertyAliasCondition
+func (d *BeanDefinition) ConditionOnAnyProperty(names ...string) *BeanDefinition {
+	d.cond.OnAnyProperty(names...)
+	return d
+}
+
 // ConditionOnMissingProperty 为 Bean 设置一个 MissingPropertyCondition
 func (d *BeanDefinition) ConditionOnMissingProperty(name string) *BeanDefinition {
 	d.cond.OnMissingProperty(name)
@@ -702,17 +863,91 @@ func (d *BeanDefinition) ConditionOnMatches(fn ConditionFunc) *BeanDefinition {
 	return d
 }
 
+// OnlyIf 为 Bean 设置一个 FunctionCondition，适用于不需要单独定义 Condition 类型的简单条件
+func (d *BeanDefinition) OnlyIf(fn func(ctx SpringContext) bool) *BeanDefinition {
+	return d.ConditionOn(NewFunctionCondition(fn))
+}
+
+// RolloutPercentage 为 Bean 设置一个 rolloutCondition，用于灰度发布，只有 pct% 的应用
+// 实例会激活该 Bean，实例 ID 通过 spring.instance.id 属性设置，不同 Bean 的灰度结果互不相关。
+func (d *BeanDefinition) RolloutPercentage(pct float64) *BeanDefinition {
+	return d.ConditionOn(newRolloutCondition(pct, d.BeanId()))
+}
+
 // ConditionOnProfile 为 Bean 设置一个 ProfileCondition
 func (d *BeanDefinition) ConditionOnProfile(profile string) *BeanDefinition {
 	d.cond.OnProfile(profile)
 	return d
 }
 
-// checkCondition 检查 Condition 的执行结果，成功返回 true，失败返回 false
+// CircularProxy 允许其他 Bean 通过 **T 类型的字段或者参数循环依赖该 Bean，注入的
+// 指针在函数返回之后指向函数返回的对象，不会拷贝对象，所以函数返回的必须是结构体指针。
+// 通过 **T 注入不会触发该 Bean 的注入，容器完成注入之前不要解引用。
+func (d *BeanDefinition) CircularProxy() *BeanDefinition {
+	switch d.bean.(type) {
+	case *constructorBean, *methodBean, *fakeMethodBean:
+		if t := d.Type(); t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct {
+			d.proxy = true
+			d.proxyRef = reflect.New(t)
+			return d
+		}
+	}
+	panic(fmt.Errorf("circular proxy bean: \"%s\" must be registered by function returning struct pointer", d.BeanId()))
+}
+
+// ConditionOnProfileProperty 为 Bean 设置一个 ProfilePropertyCondition
+func (d *BeanDefinition) ConditionOnProfileProperty(name string) *BeanDefinition {
+	d.cond.OnProfileProperty(name)
+	return d
+}
+
+// checkCondition 检查 Condition 的执行结果，成功返回 true，失败返回 false，
+// 开启 FailFast 时条件的 panic 会附带 Bean 和条件的描述重新 panic。
 func (d *BeanDefinition) checkCondition(ctx SpringContext) bool {
+	if d.failFast {
+		defer func() {
+			if r := recover(); r != nil {
+				panic(fmt.Errorf("%s condition: %s error: %v", d.Description(), d.cond.String(), r))
+			}
+		}()
+	}
 	return d.cond.Matches(ctx)
 }
 
+// recheckCondition 对设置了 ForceConditionRecheck 的 Bean 以及启动时保留的候选 Bean
+// 重新计算判断条件，其他 Bean 直接返回 true
+func (d *BeanDefinition) recheckCondition(ctx SpringContext) bool {
+	return !(d.recheck || d.deferred) || d.checkCondition(ctx)
+}
+
+// EnableJMX 设置是否通过管理端点暴露 Bean 的属性和操作，暴露的属性是带有 `jmx:"expose"`
+// 标签的导出字段，暴露的操作是 operations 中列出的导出方法，没有列出的方法不会被暴露。
+// 访问方式由管理端点的实现决定。
+func (d *BeanDefinition) EnableJMX(enable bool, operations ...string) *BeanDefinition {
+	d.jmx = enable
+	d.jmxOps = operations
+	return d
+}
+
+// JMXEnabled 返回是否通过管理端点暴露 Bean 的属性和操作
+func (d *BeanDefinition) JMXEnabled() bool {
+	return d.jmx
+}
+
+// JMXOperations 返回通过管理端点暴露的操作的方法名称
+func (d *BeanDefinition) JMXOperations() []string {
+	return d.jmxOps
+}
+
+// ForceConditionRecheck 设置是否在每次获取 Bean 时重新计算判断条件，适用于依赖功能开关
+// 等运行时状态的判断条件。判断条件不满足时 GetBean、FindBean 返回 false，注入时视为找不到
+// Bean。容器启动时不满足判断条件的 Bean 不会被删除，而是保留为候选 Bean，条件满足之后
+// 首次获取时才创建。
+func (d *BeanDefinition) ForceConditionRecheck(recheck bool) *BeanDefinition {
+	d.recheck = recheck
+	return d
+}
+
 // Options 设置 Option 模式函数的 Option 参数绑定
 func (d *BeanDefinition) Options(options ...*optionArg) *BeanDefinition {
 	arg := &fnOptionBindingArg{options}
@@ -727,18 +962,207 @@ func (d *BeanDefinition) Options(options ...*optionArg) *BeanDefinition {
 	return d
 }
 
-// DependsOn 设置 Bean 的间接依赖项
+// DependsOn 设置 Bean 的间接依赖项，间接依赖项总是先于当前 Bean 完成注入和初始化，
+// 用于表达不能通过注入关系表达的初始化顺序，间接依赖项之间存在循环时容器刷新会 panic。
 func (d *BeanDefinition) DependsOn(selectors ...BeanSelector) *BeanDefinition {
 	d.dependsOn = append(d.dependsOn, selectors...)
 	return d
 }
 
+// ObservedBy 设置 Bean 观察的事件源，所有单例 Bean 注入完成之后，容器会调用事件源的
+// Subscribe 方法订阅当前 Bean，Subscribe 方法的参数类型必须与当前 Bean 兼容。
+func (d *BeanDefinition) ObservedBy(sources ...BeanSelector) *BeanDefinition {
+	if d.scope != SingletonScope {
+		panic(fmt.Errorf("%s bean: \"%s\" can't be observer", d.scope, d.BeanId()))
+	}
+	d.observing = append(d.observing, sources...)
+	return d
+}
+
+// TraceID 设置计算实例标识的函数，用于区分同一类型的多个实例 (例如按租户创建的实例)。
+// 函数在 Bean 注入时调用一次，结果作为 instance.id 属性输出到 Bean 初始化的跟踪日志。
+func (d *BeanDefinition) TraceID(fn func(ctx SpringContext) string) *BeanDefinition {
+	d.traceId = fn
+	return d
+}
+
+// InstanceID 返回 Bean 的实例标识，没有设置 TraceID 或者尚未注入时返回空字符串
+func (d *BeanDefinition) InstanceID() string {
+	return d.instanceId
+}
+
+// Eager 设置 Bean 在所有单例 Bean 注入完成之后强制初始化，即使没有其他 Bean 依赖它，
+// 适用于注册路由、监听事件等只有副作用的 Bean。被其他 Bean 依赖时仍然随依赖方初始化。
+func (d *BeanDefinition) Eager(eager bool) *BeanDefinition {
+	if d.scope != SingletonScope {
+		panic(fmt.Errorf("%s bean: \"%s\" can't be eager", d.scope, d.BeanId()))
+	}
+	d.eager = eager
+	return d
+}
+
+// Lazy 设置 Bean 延迟到首次使用时初始化，容器启动时不会调用它的构造函数，适用于启动时
+// 可能无法连接远程服务的 Bean。首次通过 GetBean 获取或者被其他 Bean 注入时才完成初始化，
+// 并发的首次获取只会初始化一次。启动时注入的 Bean 需要通过 LazyProvider 依赖它，直接注入会 panic。
+func (d *BeanDefinition) Lazy() *BeanDefinition {
+	if d.scope != SingletonScope {
+		panic(fmt.Errorf("%s bean: \"%s\" can't be lazy", d.scope, d.BeanId()))
+	}
+	d.lazy = true
+	return d
+}
+
+// Versioned 设置 Bean 的版本，版本号形如 1.2.3，可以带 v 前缀
+func (d *BeanDefinition) Versioned(version string) *BeanDefinition {
+	d.version = version
+	return d
+}
+
+// Version 返回 Bean 的版本，没有设置时返回空字符串
+func (d *BeanDefinition) Version() string {
+	return d.version
+}
+
+// beanVersion 依赖的 Bean 的最低版本
+type beanVersion struct {
+	beanName   string
+	minVersion string
+}
+
+// RequiredBeanVersion 要求名为 beanName 的 Bean 的版本不低于 minVersion，否则
+// 容器启动失败，没有设置版本的 Bean 视为不满足要求。
+func (d *BeanDefinition) RequiredBeanVersion(beanName, minVersion string) *BeanDefinition {
+	d.requires = append(d.requires, beanVersion{beanName, minVersion})
+	return d
+}
+
+// MapKey 设置 Bean 被收集到 map[string]T 时使用的键，默认使用 Bean 的名称
+func (d *BeanDefinition) MapKey(fn func(bean interface{}) string) *BeanDefinition {
+	d.mapKey = fn
+	return d
+}
+
+// ScopedTo 限制 Bean 的可见范围，只有名称在 beanNames 中的 Bean 才能通过类型注入该 Bean，
+// 通过名称查找时不受限制。
+func (d *BeanDefinition) ScopedTo(beanNames ...string) *BeanDefinition {
+	d.scopedTo = append(d.scopedTo, beanNames...)
+	return d
+}
+
+// Alias 为 Bean 设置别名，通过别名和通过名称一样可以找到 Bean。别名不能和其他 Bean
+// 的名称或者别名相同，否则在注册时 panic。
+func (d *BeanDefinition) Alias(names ...string) *BeanDefinition {
+	for _, name := range names {
+
+		if name == "" {
+			panic(fmt.Errorf("bean: \"%s\" alias can't be empty", d.BeanId()))
+		}
+
+		if d.hasAlias(name) {
+			continue
+		}
+
+		if d.owner != nil {
+			d.owner.checkAlias(d, name)
+		}
+		d.aliases = append(d.aliases, name)
+	}
+	return d
+}
+
+// hasAlias 返回 Bean 的名称或者别名是否和 name 相同
+func (d *BeanDefinition) hasAlias(name string) bool {
+	if d.name == name {
+		return true
+	}
+	for _, alias := range d.aliases {
+		if alias == name {
+			return true
+		}
+	}
+	return false
+}
+
+// WithPropertySource 设置 Bean 的属性源，Bean 注入 (包括构造函数和初始化函数) 时属性
+// 值优先从 source 中获取，source 中不存在的属性再从容器中获取，不影响其他的 Bean。
+func (d *BeanDefinition) WithPropertySource(source Properties) *BeanDefinition {
+	d.props = source
+	return d
+}
+
+// ParentBean 设置 Bean 继承同一命名空间内名为 name 的父 Bean 绑定属性值时使用的属性
+// 列表，包括父 Bean 的属性源、环境变量前缀和解密密钥。绑定属性值时优先从自己的属性源
+// 中获取，不存在的属性再按照父 Bean 的方式获取，父 Bean 在容器刷新时解析并检查。
+func (d *BeanDefinition) ParentBean(name string) *BeanDefinition {
+	d.parent = name
+	return d
+}
+
+// Namespace 设置 Bean 所属的命名空间，不同命名空间的 Bean 不能互相注入，
+// 除非双方都设置了 CrossNamespace(true)。
+func (d *BeanDefinition) Namespace(ns string) *BeanDefinition {
+	d.namespace = ns
+	return d
+}
+
+// CrossNamespace 设置 Bean 是否允许和其他同样允许跨命名空间的 Bean 互相注入
+func (d *BeanDefinition) CrossNamespace(cross bool) *BeanDefinition {
+	d.crossNs = cross
+	return d
+}
+
 // Primary 设置 Bean 为主版本
 func (d *BeanDefinition) Primary(primary bool) *BeanDefinition {
 	d.primary = primary
 	return d
 }
 
+// ConstructionTimeoutAction 构造函数超时之后的处理方式
+type ConstructionTimeoutAction int
+
+const (
+	ConstructionTimeoutWarn  = ConstructionTimeoutAction(iota) // 打印警告日志
+	ConstructionTimeoutPanic                                   // 构造函数返回之后 panic
+)
+
+// ConstructionTimeout 设置构造函数的超时时间，构造函数超时没有返回时打印警告日志，
+// 可以通过 SetConstructionTimeoutAction 设置为返回之后 panic。只有通过函数注册的
+// Bean 才能设置构造函数的超时时间。
+func (d *BeanDefinition) ConstructionTimeout(timeout time.Duration) *BeanDefinition {
+
+	if timeout <= 0 {
+		panic(fmt.Errorf("bean: \"%s\" construction timeout must be positive", d.BeanId()))
+	}
+
+	switch d.bean.(type) {
+	case *constructorBean, *methodBean, *fakeMethodBean:
+		d.ctorLimit = timeout
+		return d
+	}
+	panic(fmt.Errorf("bean: \"%s\" must be registered by function", d.BeanId()))
+}
+
+// ExcludeFromScan 设置是否在 GetAllBeans 的扫描结果中隐藏 Bean，适用于框架内部
+// 或者测试用的 Bean，隐藏的 Bean 仍然可以通过名称或者类型注入，GetBeanDefinitions
+// 的结果也仍然包括它们。
+func (d *BeanDefinition) ExcludeFromScan(exclude bool) *BeanDefinition {
+	d.excluded = exclude
+	return d
+}
+
+// AuditTrail 设置是否记录 Bean 的访问日志，只有容器启用了 EnableConfigAudit 时才
+// 生效。每次通过注入或者 GetBean 获取 Bean 时记录时间、请求方、Bean 名称和调用位置。
+func (d *BeanDefinition) AuditTrail(enabled bool) *BeanDefinition {
+	d.audit = enabled
+	return d
+}
+
+// FailFast 设置条件计算出错时是否附带 Bean 和条件的描述中止启动，默认直接抛出原始错误
+func (d *BeanDefinition) FailFast(failFast bool) *BeanDefinition {
+	d.failFast = failFast
+	return d
+}
+
 // validLifeCycleFunc 判断是否是合法的用于 Bean 生命周期控制的函数，生命周期函数的要求：
 // 至少一个参数，且第一个参数的类型必须是 Bean 的类型，没有返回值或者只能返回 error 类型值。
 func validLifeCycleFunc(fn interface{}, beanType reflect.Type) (reflect.Type, bool) {
@@ -779,6 +1203,25 @@ func (d *BeanDefinition) Init(fn interface{}, tags ...string) *BeanDefinition {
 	return d
 }
 
+// MaxRetryAttempts 设置初始化函数的最大调用次数，初始化函数返回错误时间隔
+// InitRetryBackoff 之后重新调用，直到成功或者达到最大次数，默认只调用一次。
+func (d *BeanDefinition) MaxRetryAttempts(n int) *BeanDefinition {
+	if n <= 0 {
+		panic(fmt.Errorf("bean: \"%s\" max retry attempts must be positive", d.BeanId()))
+	}
+	d.retries = n
+	return d
+}
+
+// InitRetryBackoff 设置初始化函数两次调用之间的间隔
+func (d *BeanDefinition) InitRetryBackoff(backoff time.Duration) *BeanDefinition {
+	if backoff < 0 {
+		panic(fmt.Errorf("bean: \"%s\" init retry backoff can't be negative", d.BeanId()))
+	}
+	d.backoff = backoff
+	return d
+}
+
 // Destroy 设置 Bean 的销毁函数，tags 是销毁函数的一般参数绑定
 func (d *BeanDefinition) Destroy(fn interface{}, tags ...string) *BeanDefinition {
 
@@ -797,6 +1240,48 @@ func (d *BeanDefinition) Destroy(fn interface{}, tags ...string) *BeanDefinition
 	return d
 }
 
+// GracefulStopTimeout 设置 Bean 优雅退出的超时时间，销毁函数可以通过 context.Context
+// 参数获得带有该超时时间的上下文，超时之后不再等待销毁函数结束。
+func (d *BeanDefinition) GracefulStopTimeout(timeout time.Duration) *BeanDefinition {
+	if timeout <= 0 {
+		panic(fmt.Errorf("bean: \"%s\" graceful stop timeout must be positive", d.BeanId()))
+	}
+	d.timeout = timeout
+	return d
+}
+
+// InitOrder 设置 Bean 的初始化顺序，依赖项总是先于 Bean 初始化，互不依赖的
+// Bean 按照 order 从小到大初始化，order 相同时按照注册顺序初始化，默认为 0。
+func (d *BeanDefinition) InitOrder(order int) *BeanDefinition {
+	d.order = order
+	return d
+}
+
+// Ordered 设置 Bean 在自动模式下被收集到数组中时的位置，按照 position 从小到大排列，
+// position 不需要连续。没有设置位置的 Bean 排在设置了位置的 Bean 之后，按照注册顺序排列。
+func (d *BeanDefinition) Ordered(position int) *BeanDefinition {
+	d.ordered = true
+	d.position = position
+	return d
+}
+
+// StaticValidate 使用 fn 对 Bean 的注册信息进行校验，用于库的作者约束使用方注册的
+// Bean，例如要求结构体带有特定的标签。fn 在容器决议 Bean 时执行，因此能够看到注册链
+// 上所有的设置，替换和刷新 Bean 时也会执行，返回错误时携带注册位置 panic。
+func (d *BeanDefinition) StaticValidate(fn func(bd *BeanDefinition) error) *BeanDefinition {
+	d.validators = append(d.validators, fn)
+	return d
+}
+
+// staticValidate 执行 StaticValidate 设置的校验函数
+func (d *BeanDefinition) staticValidate() {
+	for _, fn := range d.validators {
+		if err := fn(d); err != nil {
+			panic(fmt.Errorf("%s validate error: %v", d.Description(), err))
+		}
+	}
+}
+
 // Export 显式指定 Bean 的导出接口
 func (d *BeanDefinition) Export(exports ...TypeOrPtr) *BeanDefinition {
 	for _, o := range exports { // 使用 map 进行排重
@@ -541,6 +541,40 @@ func TestBeanDefinition_Match(t *testing.T) {
 	}
 }
 
+type displayBean struct {
+	host string
+}
+
+func (b *displayBean) String() string {
+	if b == nil {
+		panic("nil displayBean")
+	}
+	return "display:" + b.host
+}
+
+func TestBeanDefinition_DisplayName(t *testing.T) {
+
+	t.Run("stringer", func(t *testing.T) {
+		bd := SpringCore.ToBeanDefinition("", &displayBean{host: "db"})
+		assert.Equal(t, bd.DisplayName(), "display:db")
+		assert.Matches(t, bd.Description(), "object bean \"\\*SpringCore_test.displayBean\" \\(display:db\\) .*")
+	})
+
+	t.Run("not stringer", func(t *testing.T) {
+		bd := SpringCore.ToBeanDefinition("i", new(int))
+		assert.Equal(t, bd.DisplayName(), "*int")
+		assert.Matches(t, bd.Description(), "object bean \"i\" \\(\\*int\\) .*")
+
+		bd = SpringCore.ToBeanDefinition("", new(int))
+		assert.Matches(t, bd.Description(), "^object bean \"\\*int\" [^(]*$")
+	})
+
+	t.Run("not created", func(t *testing.T) {
+		bd := SpringCore.FnToBeanDefinition("", func() *displayBean { return &displayBean{} })
+		assert.Equal(t, bd.DisplayName(), "*SpringCore_test.displayBean")
+	})
+}
+
 func TestToBeanDefinition(t *testing.T) {
 
 	t.Run("bean can't be nil", func(t *testing.T) {
@@ -0,0 +1,244 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package SpringCore
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BeanPostProcessor Bean 后处理器，在 Bean 的字段注入完成之后、Init 函数执行之前调用。
+// 注册为 Bean 即可生效，容器会在注入其他 Bean 之前先注入所有的后处理器。
+type BeanPostProcessor interface {
+	PostProcessBean(v reflect.Value, beanId string)
+}
+
+var beanPostProcessorType = reflect.TypeOf((*BeanPostProcessor)(nil)).Elem()
+
+// resolveProcessors 注入并收集所有的 Bean 后处理器
+func (ctx *defaultSpringContext) resolveProcessors(assembly *defaultBeanAssembly) {
+
+	var beans []*BeanDefinition
+	for _, bd := range ctx.beanMap {
+		if bd.scope == SingletonScope && bd.Type().Implements(beanPostProcessorType) {
+			beans = append(beans, bd)
+		}
+	}
+
+	// 按照 BeanId 排序，保证执行顺序稳定
+	sort.Slice(beans, func(i, j int) bool {
+		return beans[i].BeanId() < beans[j].BeanId()
+	})
+
+	for _, bd := range beans {
+		assembly.wireBeanDefinition(bd, false)
+		ctx.processors = append(ctx.processors, bd.Bean().(BeanPostProcessor))
+	}
+}
+
+// CacheProvider 缓存提供者，ttl 为 0 表示永不过期
+type CacheProvider interface {
+	Get(key string) (interface{}, bool)
+	Put(key string, value interface{}, ttl time.Duration)
+}
+
+// cacheItem 缓存的值
+type cacheItem struct {
+	value  interface{}
+	expire time.Time // 零值表示永不过期
+}
+
+// memoryCacheProvider 基于内存的缓存提供者，过期的值在下次访问时删除
+type memoryCacheProvider struct {
+	mutex sync.Mutex
+	items map[string]cacheItem
+}
+
+// NewMemoryCacheProvider memoryCacheProvider 的构造函数
+func NewMemoryCacheProvider() *memoryCacheProvider {
+	return &memoryCacheProvider{
+		items: make(map[string]cacheItem),
+	}
+}
+
+// Get 获取缓存的值，值已过期时将其删除并返回 false
+func (p *memoryCacheProvider) Get(key string) (interface{}, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	item, ok := p.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	if !item.expire.IsZero() && !time.Now().Before(item.expire) {
+		delete(p.items, key)
+		return nil, false
+	}
+
+	return item.value, true
+}
+
+// Put 保存缓存的值
+func (p *memoryCacheProvider) Put(key string, value interface{}, ttl time.Duration) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	item := cacheItem{value: value}
+	if ttl > 0 {
+		item.expire = time.Now().Add(ttl)
+	}
+	p.items[key] = item
+}
+
+// CacheResultProcessor 为带有 cache 标签的函数字段缓存返回值，标签格式为
+// `cache:"ttl:5m,key:${arg0}"`，${argN} 会替换为第 N 个参数的值。key 省略时
+// 使用全部参数作为缓存键，ttl 省略时永不过期。函数最后一个返回值为非 nil 的
+// error 时不缓存。
+type CacheResultProcessor struct {
+	Provider CacheProvider `autowire:""`
+}
+
+// cacheTag cache 标签的解析结果
+type cacheTag struct {
+	ttl time.Duration
+	key string
+}
+
+// parseCacheTag 解析 cache 标签，key 中可以包含逗号
+func parseCacheTag(tag string) (result cacheTag) {
+	last := ""
+	for _, s := range strings.Split(tag, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(s, "ttl:"):
+			ttl, err := time.ParseDuration(strings.TrimPrefix(s, "ttl:"))
+			if err != nil {
+				panic(fmt.Errorf("cache tag: \"%s\" error: %v", tag, err))
+			}
+			result.ttl, last = ttl, "ttl"
+		case strings.HasPrefix(s, "key:"):
+			result.key, last = strings.TrimPrefix(s, "key:"), "key"
+		case last == "key":
+			result.key += "," + s
+		default:
+			panic(fmt.Errorf("cache tag: \"%s\" error: unknown option \"%s\"", tag, s))
+		}
+	}
+	return
+}
+
+// cacheArgRegexp 匹配 key 表达式中的 ${argN}
+var cacheArgRegexp = regexp.MustCompile(`\${arg(\d+)}`)
+
+// cacheKey 根据 key 表达式和参数计算缓存键
+func (tag cacheTag) cacheKey(prefix string, args []reflect.Value) string {
+
+	if tag.key == "" {
+		var buf strings.Builder
+		for i, arg := range args {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(fmt.Sprint(arg.Interface()))
+		}
+		return prefix + "(" + buf.String() + ")"
+	}
+
+	return prefix + ":" + cacheArgRegexp.ReplaceAllStringFunc(tag.key, func(s string) string {
+		n, _ := strconv.Atoi(cacheArgRegexp.FindStringSubmatch(s)[1])
+		if n >= len(args) {
+			panic(fmt.Errorf("cache key: \"%s\" arg%d out of range", tag.key, n))
+		}
+		return fmt.Sprint(args[n].Interface())
+	})
+}
+
+// PostProcessBean 使用带缓存的函数替换带有 cache 标签的函数字段
+func (p *CacheResultProcessor) PostProcessBean(v reflect.Value, beanId string) {
+
+	ev := v.Elem()
+	et := ev.Type()
+
+	for i := 0; i < et.NumField(); i++ {
+		ft := et.Field(i)
+
+		s, ok := ft.Tag.Lookup("cache")
+		if !ok {
+			continue
+		}
+
+		fieldName := beanId + ".$" + ft.Name
+
+		if ft.Type.Kind() != reflect.Func {
+			panic(fmt.Errorf("cache field: %s must be func", fieldName))
+		}
+
+		fv := ev.Field(i)
+		if !fv.CanSet() {
+			panic(fmt.Errorf("cache field: %s must be exported", fieldName))
+		}
+
+		if fv.IsNil() {
+			panic(fmt.Errorf("cache field: %s is nil", fieldName))
+		}
+
+		// 复制原函数，防止字段被替换后递归调用自身
+		fn := reflect.ValueOf(fv.Interface())
+		fv.Set(p.cacheFunc(fn, parseCacheTag(s), fieldName))
+	}
+}
+
+// cacheFunc 返回带缓存的函数
+func (p *CacheResultProcessor) cacheFunc(fn reflect.Value, tag cacheTag, fieldName string) reflect.Value {
+
+	fnType := fn.Type()
+	numOut := fnType.NumOut()
+
+	// 最后一个返回值是否为 error
+	withErr := numOut > 0 && fnType.Out(numOut-1) == errorType
+
+	return reflect.MakeFunc(fnType, func(args []reflect.Value) []reflect.Value {
+		key := tag.cacheKey(fieldName, args)
+
+		if r, ok := p.Provider.Get(key); ok {
+			return r.([]reflect.Value)
+		}
+
+		var out []reflect.Value
+		if fnType.IsVariadic() {
+			out = fn.CallSlice(args)
+		} else {
+			out = fn.Call(args)
+		}
+
+		if withErr && !out[numOut-1].IsNil() {
+			return out
+		}
+
+		p.Provider.Put(key, out, tag.ttl)
+		return out
+	})
+}
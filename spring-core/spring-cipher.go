@@ -0,0 +1,103 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package SpringCore
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SecretKeyProperty 全局解密密钥的属性名
+const SecretKeyProperty = "spring.secret.key"
+
+// Cipher 属性值的解密算法，加密的属性值形如 ENC(密文)
+type Cipher interface {
+	Decrypt(key string, cipherText string) (string, error)
+}
+
+// cipher 注册的解密算法
+var cipher Cipher
+
+// RegisterCipher 注册属性值的解密算法
+func RegisterCipher(c Cipher) {
+	cipher = c
+}
+
+// WithSecretKey 设置 Bean 绑定属性值时使用的解密密钥，代替全局的解密密钥，
+// 使得不同的 Bean 可以使用不同的密钥解密属性值。
+func (d *BeanDefinition) WithSecretKey(key string) *BeanDefinition {
+	d.secretKey = key
+	return d
+}
+
+// decryptedProperties 绑定属性值时对加密的属性值进行解密
+type decryptedProperties struct {
+	Properties
+	key string
+}
+
+// newDecryptedProperties decryptedProperties 的构造函数
+func newDecryptedProperties(p Properties, key string) *decryptedProperties {
+	return &decryptedProperties{Properties: p, key: key}
+}
+
+// decrypt 对 ENC(密文) 形式的属性值进行解密，其他属性值原样返回
+func (p *decryptedProperties) decrypt(name string, value interface{}) interface{} {
+
+	s, ok := value.(string)
+	if !ok || !strings.HasPrefix(s, "ENC(") || !strings.HasSuffix(s, ")") {
+		return value
+	}
+
+	if cipher == nil {
+		panic(errors.New("no cipher registered"))
+	}
+
+	v, err := cipher.Decrypt(p.key, s[4:len(s)-1])
+	if err != nil {
+		panic(fmt.Errorf("decrypt property \"%s\" error: %v", name, err))
+	}
+	return v
+}
+
+// GetProperty 返回 keys 中第一个存在的属性值，加密的属性值返回解密之后的值。
+func (p *decryptedProperties) GetProperty(keys ...string) interface{} {
+	for _, key := range keys {
+		if v, ok := p.Properties.GetDefaultProperty(key, nil); ok {
+			return p.decrypt(key, v)
+		}
+	}
+	return nil
+}
+
+// GetDefaultProperty 返回属性值，如果没有找到则使用指定的默认值，加密的属性值返回解密之后的值。
+func (p *decryptedProperties) GetDefaultProperty(key string, def interface{}) (interface{}, bool) {
+	if v, ok := p.Properties.GetDefaultProperty(key, def); ok {
+		return p.decrypt(key, v), true
+	}
+	return def, false
+}
+
+// GetPrefixProperties 返回指定前缀的属性值集合，加密的属性值返回解密之后的值。
+func (p *decryptedProperties) GetPrefixProperties(prefix string) map[string]interface{} {
+	result := p.Properties.GetPrefixProperties(prefix)
+	for k, v := range result {
+		result[k] = p.decrypt(k, v)
+	}
+	return result
+}
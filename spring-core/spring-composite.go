@@ -0,0 +1,164 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package SpringCore
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// compositeBeansType *CompositeBeans 的反射类型
+var compositeBeansType = reflect.TypeOf((*CompositeBeans)(nil))
+
+// CompositeError 组合 Bean 的多个成员返回的错误
+type CompositeError []error
+
+func (e CompositeError) Error() string {
+	s := make([]string, 0, len(e))
+	for _, err := range e {
+		s = append(s, err.Error())
+	}
+	return strings.Join(s, "; ")
+}
+
+// CompositeBeans 组合 Bean 的成员列表，按照 Composite 指定的顺序保存。因为 Go 无法
+// 在运行时生成实现接口的类型，所以组合 Bean 的构造函数需要接收 *CompositeBeans
+// 参数，并返回一个通过 Call 将方法调用转发给所有成员的接口实现。
+type CompositeBeans struct {
+	names  []string
+	values []reflect.Value
+}
+
+// Len 返回成员的数量
+func (c *CompositeBeans) Len() int {
+	return len(c.values)
+}
+
+// Call 按顺序调用所有成员的同名方法。方法的最后一个返回值是 error 时，成员返回的
+// 错误合并为 CompositeError 返回，其他返回值取最后一个没有返回错误的成员的结果，
+// 所有成员都返回错误时为零值。返回的结果不包括最后的 error。
+func (c *CompositeBeans) Call(method string, args ...interface{}) ([]interface{}, error) {
+
+	in := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		in[i] = reflect.ValueOf(arg)
+	}
+
+	var (
+		result []reflect.Value
+		errs   CompositeError
+	)
+
+	for i, v := range c.values {
+
+		m := v.MethodByName(method)
+		if !m.IsValid() {
+			panic(fmt.Errorf("bean: \"%s\" has no method %s", c.names[i], method))
+		}
+
+		mt := m.Type()
+		for j := range in { // nil 参数需要转换为参数类型的零值
+			if !in[j].IsValid() {
+				in[j] = reflect.Zero(mt.In(j))
+			}
+		}
+
+		out := m.Call(in)
+
+		if result == nil { // 初始化为零值
+			result = make([]reflect.Value, 0, len(out))
+			for j := 0; j < len(out); j++ {
+				result = append(result, reflect.Zero(mt.Out(j)))
+			}
+			if n := len(out); n > 0 && mt.Out(n-1) == errorType {
+				result = result[:n-1]
+			}
+		}
+
+		if n := len(out); n > 0 && mt.Out(n-1) == errorType {
+			if err := out[n-1]; !err.IsNil() {
+				errs = append(errs, err.Interface().(error))
+				continue
+			}
+			out = out[:n-1]
+		}
+		copy(result, out)
+	}
+
+	values := make([]interface{}, len(result))
+	for i, v := range result {
+		values[i] = v.Interface()
+	}
+
+	if len(errs) > 0 {
+		return values, errs
+	}
+	return values, nil
+}
+
+// Composite 设置组合 Bean 的成员，组合 Bean 必须通过构造函数注册为接口类型，并且构造
+// 函数有一个 *CompositeBeans 类型的参数，注入时该参数的值是按照名称顺序找到的成员，
+// 成员必须实现组合 Bean 的接口。
+func (d *BeanDefinition) Composite(beanNames ...string) *BeanDefinition {
+
+	b, ok := d.bean.(*constructorBean)
+	if !ok {
+		panic(fmt.Errorf("composite bean: \"%s\" must be registered by constructor", d.BeanId()))
+	}
+
+	if d.Type().Kind() != reflect.Interface {
+		panic(fmt.Errorf("composite bean: \"%s\" must be interface type", d.BeanId()))
+	}
+
+	fnType := reflect.TypeOf(b.fn)
+	for i := 0; i < fnType.NumIn(); i++ {
+		if fnType.In(i) == compositeBeansType {
+			d.composite = beanNames
+			return d
+		}
+	}
+	panic(fmt.Errorf("composite bean: \"%s\" constructor must have a *CompositeBeans argument", d.BeanId()))
+}
+
+// compositeBeans 返回正在注入的组合 Bean 的成员，成员先于组合 Bean 完成注入
+func (assembly *defaultBeanAssembly) compositeBeans() *CompositeBeans {
+
+	bd := assembly.wiringBean()
+	if bd == nil || bd.composite == nil {
+		panic(errors.New("*CompositeBeans argument can only be used by composite bean"))
+	}
+
+	c := &CompositeBeans{}
+	for _, name := range bd.composite {
+
+		b, ok := assembly.springCtx.FindBean(assembly.inNamespace(name))
+		if !ok {
+			panic(fmt.Errorf("can't find composite member bean: \"%s\"", name))
+		}
+
+		if !b.Type().Implements(bd.Type()) {
+			panic(fmt.Errorf("composite member bean: \"%s\" not implement %s", name, bd.Type().String()))
+		}
+
+		assembly.accessBean(b)
+		c.names = append(c.names, name)
+		c.values = append(c.values, assembly.beanValue(b))
+	}
+	return c
+}
@@ -0,0 +1,161 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package SpringCore
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 熔断器的状态
+const (
+	CircuitClosed   = "closed"    // 关闭，条件成立
+	CircuitOpen     = "open"      // 打开，条件不成立
+	CircuitHalfOpen = "half-open" // 半开，条件成立，再次失败时重新打开
+)
+
+// circuitBreaker 记录失败次数的熔断器，保存在容器中，同名的熔断条件共享
+type circuitBreaker struct {
+	mutex    sync.Mutex
+	failures []time.Time // 窗口期内的失败时间
+	openedAt time.Time   // 打开的时间，为零表示没有打开
+	halfOpen time.Time   // 进入半开状态的时间，为零表示不在半开状态
+}
+
+// record 记录一次失败，半开状态下失败时立即重新打开
+func (b *circuitBreaker) record(now time.Time) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.failures = append(b.failures, now)
+	if !b.halfOpen.IsZero() {
+		b.halfOpen = time.Time{}
+		b.openedAt = now
+	}
+}
+
+// reset 清空失败记录并关闭熔断器
+func (b *circuitBreaker) reset() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.failures = nil
+	b.openedAt = time.Time{}
+	b.halfOpen = time.Time{}
+}
+
+// state 根据窗口期内的失败次数计算熔断器的状态。窗口期内失败次数超过 maxFailures
+// 时打开，打开超过 window 之后进入半开状态，半开状态下 window 内没有失败时关闭。
+func (b *circuitBreaker) state(now time.Time, maxFailures int, window time.Duration) string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	var failures []time.Time
+	for _, t := range b.failures {
+		if now.Sub(t) <= window {
+			failures = append(failures, t)
+		}
+	}
+	b.failures = failures
+
+	if !b.halfOpen.IsZero() {
+		if now.Sub(b.halfOpen) < window {
+			return CircuitHalfOpen
+		}
+		b.halfOpen = time.Time{}
+		b.failures = nil
+		return CircuitClosed
+	}
+
+	if !b.openedAt.IsZero() {
+		if now.Sub(b.openedAt) < window {
+			return CircuitOpen
+		}
+		b.openedAt = time.Time{}
+		b.halfOpen = now
+		return CircuitHalfOpen
+	}
+
+	if len(failures) > maxFailures {
+		b.openedAt = now
+		return CircuitOpen
+	}
+	return CircuitClosed
+}
+
+// breakersKey 熔断器集合在容器上下文中的键
+type breakersKey struct{}
+
+// getCircuitBreaker 返回容器中名为 name 的熔断器，不存在时创建
+func getCircuitBreaker(ctx SpringContext, name string) *circuitBreaker {
+	breakers, ok := ctx.Context().Value(breakersKey{}).(*sync.Map)
+	if !ok {
+		panic(errors.New("context has no circuit breakers"))
+	}
+	v, _ := breakers.LoadOrStore(name, &circuitBreaker{})
+	return v.(*circuitBreaker)
+}
+
+// RecordFailure 为名为 name 的熔断器记录一次失败
+func RecordFailure(ctx SpringContext, name string) {
+	getCircuitBreaker(ctx, name).record(time.Now())
+}
+
+// Reset 手动重置名为 name 的熔断器
+func Reset(ctx SpringContext, name string) {
+	getCircuitBreaker(ctx, name).reset()
+}
+
+// circuitBreakerCondition 基于失败率的熔断 Condition 实现
+type circuitBreakerCondition struct {
+	name        string
+	maxFailures int
+	window      time.Duration
+}
+
+// NewCircuitBreakerCondition circuitBreakerCondition 的构造函数，名为 name 的熔断器
+// 在 window 时间内的失败次数超过 maxFailures 时条件不成立，可以用于跳过依赖频繁失败的 Bean。
+func NewCircuitBreakerCondition(name string, maxFailures int, window time.Duration) *circuitBreakerCondition {
+	return &circuitBreakerCondition{name: name, maxFailures: maxFailures, window: window}
+}
+
+// State 返回熔断器当前的状态
+func (c *circuitBreakerCondition) State(ctx SpringContext) string {
+	return getCircuitBreaker(ctx, c.name).state(time.Now(), c.maxFailures, c.window)
+}
+
+// Matches 成功返回 true，失败返回 false
+func (c *circuitBreakerCondition) Matches(ctx SpringContext) bool {
+	return c.State(ctx) != CircuitOpen
+}
+
+// String 返回 Condition 的描述
+func (c *circuitBreakerCondition) String() string {
+	return fmt.Sprintf("circuit-breaker:%s(%d/%v)", c.name, c.maxFailures, c.window)
+}
+
+// ConditionOnCircuitBreaker 返回设置了 circuitBreakerCondition 的 Conditional 对象
+func ConditionOnCircuitBreaker(name string, maxFailures int, window time.Duration) *Conditional {
+	return NewConditional().OnCircuitBreaker(name, maxFailures, window)
+}
+
+// OnCircuitBreaker 设置一个 circuitBreakerCondition
+func (c *Conditional) OnCircuitBreaker(name string, maxFailures int, window time.Duration) *Conditional {
+	return c.OnCondition(NewCircuitBreakerCondition(name, maxFailures, window))
+}
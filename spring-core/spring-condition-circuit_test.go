@@ -0,0 +1,103 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package SpringCore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestCircuitBreaker_State(t *testing.T) {
+
+	window := time.Minute
+	t0 := time.Now()
+	at := func(d time.Duration) time.Time { return t0.Add(d) }
+
+	b := &circuitBreaker{}
+	b.record(at(0))
+	b.record(at(time.Second))
+	assert.Equal(t, b.state(at(2*time.Second), 2, window), CircuitClosed)
+
+	// 窗口期内超过最大失败次数时打开
+	b.record(at(3 * time.Second))
+	assert.Equal(t, b.state(at(4*time.Second), 2, window), CircuitOpen)
+	assert.Equal(t, b.state(at(30*time.Second), 2, window), CircuitOpen)
+
+	// 打开超过窗口期之后半开
+	assert.Equal(t, b.state(at(65*time.Second), 2, window), CircuitHalfOpen)
+
+	// 半开状态下失败立即重新打开
+	b.record(at(70 * time.Second))
+	assert.Equal(t, b.state(at(71*time.Second), 2, window), CircuitOpen)
+
+	// 半开状态下窗口期内没有失败时关闭
+	assert.Equal(t, b.state(at(131*time.Second), 2, window), CircuitHalfOpen)
+	assert.Equal(t, b.state(at(150*time.Second), 2, window), CircuitHalfOpen)
+	assert.Equal(t, b.state(at(192*time.Second), 2, window), CircuitClosed)
+
+	// 过期的失败不计入窗口期
+	b.record(at(200 * time.Second))
+	b.record(at(201 * time.Second))
+	b.record(at(202 * time.Second))
+	assert.Equal(t, b.state(at(300*time.Second), 2, window), CircuitClosed)
+}
+
+func TestCircuitBreakerCondition(t *testing.T) {
+
+	ctx := NewDefaultSpringContext()
+	cond := NewCircuitBreakerCondition("db", 1, time.Minute)
+	assert.Equal(t, cond.String(), "circuit-breaker:db(1/1m0s)")
+	assert.Equal(t, cond.Matches(ctx), true)
+
+	RecordFailure(ctx, "db")
+	RecordFailure(ctx, "cache")
+	assert.Equal(t, cond.Matches(ctx), true)
+
+	RecordFailure(ctx, "db")
+	assert.Equal(t, cond.Matches(ctx), false)
+	assert.Equal(t, cond.State(ctx), CircuitOpen)
+
+	Reset(ctx, "db")
+	assert.Equal(t, cond.State(ctx), CircuitClosed)
+
+	// 熔断打开时跳过降级的 Bean
+	RecordFailure(ctx, "db")
+	RecordFailure(ctx, "db")
+	ctx.RegisterNameBean("degraded", new(int)).ConditionOn(cond)
+	ctx.AutoWireBeans()
+
+	var i *int
+	assert.Equal(t, ctx.GetBean(&i, "degraded"), false)
+}
+
+// wrappedContext 通过嵌入接口包装的容器，和 SpringBoot 的 ApplicationContext 相同
+type wrappedContext struct {
+	SpringContext
+}
+
+func TestCircuitBreakerCondition_Wrapped(t *testing.T) {
+
+	ctx := NewDefaultSpringContext()
+	wrapped := &wrappedContext{ctx}
+
+	cond := NewCircuitBreakerCondition("db", 0, time.Minute)
+	RecordFailure(wrapped, "db")
+	assert.Equal(t, cond.State(ctx), CircuitOpen)
+	assert.Equal(t, cond.State(wrapped), CircuitOpen)
+}
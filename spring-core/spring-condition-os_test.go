@@ -0,0 +1,202 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package SpringCore
+
+import (
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestOSCondition(t *testing.T) {
+
+	defer func() { goos = func() string { return runtime.GOOS } }()
+
+	ctx := NewDefaultSpringContext()
+	cond := NewOSCondition("linux")
+	assert.Equal(t, cond.String(), `os=="linux"`)
+
+	goos = func() string { return "linux" }
+	assert.Equal(t, cond.Matches(ctx), true)
+	assert.Equal(t, ConditionOnOS("Linux").Matches(ctx), true)
+
+	goos = func() string { return "windows" }
+	assert.Equal(t, cond.Matches(ctx), false)
+	assert.Equal(t, ConditionOnOS("windows").Matches(ctx), true)
+}
+
+func TestArchCondition(t *testing.T) {
+
+	defer func() { goarch = func() string { return runtime.GOARCH } }()
+
+	ctx := NewDefaultSpringContext()
+	cond := NewArchCondition("amd64")
+	assert.Equal(t, cond.String(), `arch=="amd64"`)
+
+	goarch = func() string { return "amd64" }
+	assert.Equal(t, cond.Matches(ctx), true)
+
+	goarch = func() string { return "arm64" }
+	assert.Equal(t, cond.Matches(ctx), false)
+	assert.Equal(t, NewConditional().OnOS(runtime.GOOS).And().OnArch("arm64").Matches(ctx), true)
+}
+
+func TestCGOCondition(t *testing.T) {
+
+	defer func() { cgoAvailable = func() bool { return cgoEnabled } }()
+
+	ctx := NewDefaultSpringContext()
+	cond := NewCGOCondition(true)
+	assert.Equal(t, cond.String(), "cgo==true")
+
+	cgoAvailable = func() bool { return true }
+	assert.Equal(t, cond.Matches(ctx), true)
+	assert.Equal(t, ConditionOnCGO(false).Matches(ctx), false)
+
+	cgoAvailable = func() bool { return false }
+	assert.Equal(t, cond.Matches(ctx), false)
+	assert.Equal(t, NewConditional().OnCGO(false).Matches(ctx), true)
+}
+
+func TestGoVersionCondition(t *testing.T) {
+
+	defer func() { goVersionFn = runtime.Version }()
+
+	ctx := NewDefaultSpringContext()
+	cond := NewGoVersionCondition("go1.18")
+	assert.Equal(t, cond.String(), `go-version>="go1.18"`)
+
+	goVersionFn = func() string { return "go1.21.0" }
+	assert.Equal(t, cond.Matches(ctx), true)
+	assert.Equal(t, ConditionOnMinGoVersion("1.21.0").Matches(ctx), true)
+	assert.Equal(t, ConditionOnMinGoVersion("1.21.1").Matches(ctx), false)
+
+	goVersionFn = func() string { return "go1.17.13" }
+	assert.Equal(t, cond.Matches(ctx), false)
+
+	goVersionFn = func() string { return "go1.18rc1" }
+	assert.Equal(t, cond.Matches(ctx), true)
+
+	goVersionFn = func() string { return "go1.10" }
+	assert.Equal(t, NewConditional().OnMinGoVersion("1.9").Matches(ctx), true)
+
+	goVersionFn = func() string { return "devel +b7a9b0f Mon Jan 1 00:00:00 2024" }
+	assert.Equal(t, cond.Matches(ctx), true)
+
+	assert.Panic(t, func() {
+		NewGoVersionCondition("latest")
+	}, "error go version: \"latest\"")
+}
+
+func TestCloudPlatformCondition(t *testing.T) {
+
+	defer func(fn func() string) { cloudPlatform = fn }(cloudPlatform)
+
+	ctx := NewDefaultSpringContext()
+	cond := NewCloudPlatformCondition(CloudPlatformAWS)
+	assert.Equal(t, cond.String(), `cloud-platform=="aws"`)
+
+	cloudPlatform = func() string { return CloudPlatformAWS }
+	assert.Equal(t, cond.Matches(ctx), true)
+	assert.Equal(t, ConditionOnCloudPlatform("none").Matches(ctx), false)
+
+	cloudPlatform = func() string { return CloudPlatformNone }
+	assert.Equal(t, cond.Matches(ctx), false)
+	assert.Equal(t, NewConditional().OnCloudPlatform("none").Matches(ctx), true)
+
+	assert.Panic(t, func() {
+		NewCloudPlatformCondition("aliyun")
+	}, "unknown cloud platform \"aliyun\"")
+}
+
+func TestCloudPlatformDetect(t *testing.T) {
+
+	envs := []string{"AWS_EXECUTION_ENV", "GOOGLE_CLOUD_PROJECT", "WEBSITE_INSTANCE_ID"}
+	platforms := []string{CloudPlatformAWS, CloudPlatformGCP, CloudPlatformAzure}
+
+	for _, env := range envs {
+		if v, ok := os.LookupEnv(env); ok {
+			defer os.Setenv(env, v)
+			os.Unsetenv(env)
+		}
+	}
+
+	assert.Equal(t, cloudPlatform(), CloudPlatformNone)
+
+	for i, env := range envs {
+		os.Setenv(env, "1")
+		assert.Equal(t, cloudPlatform(), platforms[i])
+		os.Unsetenv(env)
+	}
+}
+
+type warmedCache struct{}
+
+func TestUptimeCondition(t *testing.T) {
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	ctx := NewDefaultSpringContext()
+	ctx.clock = func() time.Time { return now }
+
+	cond := NewUptimeCondition(time.Minute)
+	assert.Equal(t, cond.String(), "uptime>=1m0s")
+	assert.Equal(t, ctx.Uptime(), time.Duration(0))
+	assert.Equal(t, cond.Matches(ctx), false)
+
+	// 启动时运行时间不足的 Bean 保留为候选 Bean
+	bd := ctx.RegisterBean(new(warmedCache)).ConditionOn(cond)
+	ctx.RegisterBean(new(int)).ConditionOnProperty("none")
+	ctx.AutoWireBeans()
+	assert.Equal(t, cond.Matches(ctx), false)
+	assert.Equal(t, bd.Matched(), false)
+	assert.Equal(t, len(ctx.GetBeanDefinitions()), 1)
+
+	var c *warmedCache
+	assert.Equal(t, ctx.GetBean(&c), false)
+
+	now = now.Add(30 * time.Second)
+	assert.Equal(t, ctx.Uptime(), 30*time.Second)
+	assert.Equal(t, cond.Matches(ctx), false)
+
+	now = now.Add(30 * time.Second)
+	assert.Equal(t, cond.Matches(ctx), true)
+	assert.Equal(t, ConditionOnMinUptime(2*time.Minute).Matches(ctx), false)
+
+	assert.Equal(t, ctx.GetBean(&c), true)
+	assert.Equal(t, bd.Matched(), true)
+
+	// 判断条件中依赖的 Bean 在决议时不会清除读取过运行时间的记录，Bean 的决议顺序
+	// 是随机的，所以多次验证
+	t.Run("or on bean", func(t *testing.T) {
+		for i := 0; i < 20; i++ {
+			now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+			ctx := NewDefaultSpringContext()
+			ctx.clock = func() time.Time { return now }
+			bd := ctx.RegisterBean(new(warmedCache)).ConditionOn(NewUptimeCondition(time.Minute)).Or().ConditionOnBean((*int)(nil))
+			ctx.RegisterBean(new(int)).ConditionOnProperty("none")
+			ctx.AutoWireBeans()
+			assert.Equal(t, bd.Matched(), false)
+
+			now = now.Add(time.Minute)
+			var c *warmedCache
+			assert.Equal(t, ctx.GetBean(&c), true)
+		}
+	})
+}
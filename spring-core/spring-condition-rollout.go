@@ -0,0 +1,97 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package SpringCore
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-spring/go-spring-parent/spring-logger"
+)
+
+// InstanceIdProperty 应用实例 ID 的属性名
+const InstanceIdProperty = "spring.instance.id"
+
+// rolloutCondition 基于灰度比例的 Condition 实现，生成一个 [0,100) 的随机数，小于 pct 时
+// 条件成立。随机数以实例 ID 和 Bean ID 的哈希值为种子，所以同一个实例上同一个 Bean 的结果
+// 是确定的，不同的灰度 Bean 之间互不相关；未设置实例 ID 时每次启动的结果都是随机的，但是
+// 生成之后会被缓存，所以重新检查条件时结果保持不变。
+type rolloutCondition struct {
+	pct float64
+	key string // 参与哈希的 Bean ID
+
+	mutex   sync.Mutex
+	numbers map[string]float64 // 实例 ID 对应的随机数
+}
+
+// NewRolloutCondition rolloutCondition 的构造函数，pct 的取值范围为 [0,100]
+func NewRolloutCondition(pct float64) *rolloutCondition {
+	return newRolloutCondition(pct, "")
+}
+
+// newRolloutCondition rolloutCondition 的构造函数，key 一般为 Bean ID
+func newRolloutCondition(pct float64, key string) *rolloutCondition {
+	if pct < 0 || pct > 100 {
+		panic(errors.New("rollout percentage should be in [0,100]"))
+	}
+	return &rolloutCondition{
+		pct:     pct,
+		key:     key,
+		numbers: make(map[string]float64),
+	}
+}
+
+// Matches 成功返回 true，失败返回 false
+func (c *rolloutCondition) Matches(ctx SpringContext) bool {
+	return c.number(ctx.GetStringProperty(InstanceIdProperty)) < c.pct
+}
+
+// String 返回 Condition 的描述
+func (c *rolloutCondition) String() string {
+	return "rollout<" + strconv.FormatFloat(c.pct, 'f', -1, 64) + "%"
+}
+
+// number 返回实例 ID 对应的随机数，第一次生成时打印日志，之后使用缓存的结果。
+func (c *rolloutCondition) number(instanceId string) float64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if n, ok := c.numbers[instanceId]; ok {
+		return n
+	}
+
+	n := rolloutNumber(instanceId, c.key)
+	c.numbers[instanceId] = n
+	SpringLogger.Infof("%s instance=%q key=%q number=%.2f active=%v", c, instanceId, c.key, n, n < c.pct)
+	return n
+}
+
+// rolloutNumber 返回实例 ID 和 key 对应的 [0,100) 的随机数，实例 ID 为空时使用当前时间作为种子
+func rolloutNumber(instanceId string, key string) float64 {
+	seed := time.Now().UnixNano()
+	if instanceId != "" {
+		h := fnv.New64a()
+		_, _ = fmt.Fprint(h, instanceId, "/", key)
+		seed = int64(h.Sum64())
+	}
+	return rand.New(rand.NewSource(seed)).Float64() * 100
+}
@@ -18,11 +18,20 @@ package SpringCore
 
 import (
 	"errors"
+	"fmt"
+	"go/ast"
+	"go/constant"
 	"go/token"
 	"go/types"
+	"os"
+	"reflect"
+	"runtime"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
-	"github.com/go-spring/go-spring-parent/spring-const"
+	"github.com/go-spring/go-spring-parent/spring-utils"
 	"github.com/spf13/cast"
 )
 
@@ -32,6 +41,32 @@ type Condition interface {
 	Matches(ctx SpringContext) bool
 }
 
+// conditionString 返回 Condition 的描述，没有实现 fmt.Stringer 接口时返回其类型名称
+func conditionString(cond Condition) string {
+	if s, ok := cond.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return reflect.TypeOf(cond).String()
+}
+
+// selectorString 返回 Bean 选择器的描述
+func selectorString(selector BeanSelector) string {
+	switch e := selector.(type) {
+	case string:
+		return e
+	case *BeanDefinition:
+		return e.BeanId()
+	case reflect.Type:
+		return e.String()
+	default:
+		t := reflect.TypeOf(e)
+		if t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Interface {
+			t = t.Elem() // 接口类型去掉指针
+		}
+		return t.String()
+	}
+}
+
 // ConditionFunc 定义 Condition 接口 Matches 方法的类型
 type ConditionFunc func(ctx SpringContext) bool
 
@@ -50,6 +85,12 @@ func (c *functionCondition) Matches(ctx SpringContext) bool {
 	return c.fn(ctx)
 }
 
+// String 返回 Condition 的描述
+func (c *functionCondition) String() string {
+	_, _, fnName := SpringUtils.FileLine(c.fn)
+	return "func:" + fnName
+}
+
 // notCondition 对 Condition 取反的 Condition 实现
 type notCondition struct {
 	cond Condition
@@ -65,6 +106,11 @@ func (c *notCondition) Matches(ctx SpringContext) bool {
 	return !c.cond.Matches(ctx)
 }
 
+// String 返回 Condition 的描述
+func (c *notCondition) String() string {
+	return "NOT (" + conditionString(c.cond) + ")"
+}
+
 // propertyCondition 基于属性值存在的 Condition 实现
 type propertyCondition struct {
 	name string
@@ -80,6 +126,11 @@ func (c *propertyCondition) Matches(ctx SpringContext) bool {
 	return len(ctx.GetPrefixProperties(c.name)) > 0
 }
 
+// String 返回 Condition 的描述
+func (c *propertyCondition) String() string {
+	return "property:" + c.name
+}
+
 // missingPropertyCondition 基于属性值不存在的 Condition 实现
 type missingPropertyCondition struct {
 	name string
@@ -95,6 +146,37 @@ func (c *missingPropertyCondition) Matches(ctx SpringContext) bool {
 	return len(ctx.GetPrefixProperties(c.name)) == 0
 }
 
+// String 返回 Condition 的描述
+func (c *missingPropertyCondition) String() string {
+	return "missing-property:" + c.name
+}
+
+// propertyAliasCondition 基于多个属性名称中任意一个存在的 Condition 实现，
+// 属性查找本身支持 NormalizePropertyKey 转换之后的名称。
+type propertyAliasCondition struct {
+	names []string
+}
+
+// NewPropertyAliasCondition propertyAliasCondition 的构造函数
+func NewPropertyAliasCondition(names ...string) *propertyAliasCondition {
+	return &propertyAliasCondition{names}
+}
+
+// Matches 成功返回 true，失败返回 false
+func (c *propertyAliasCondition) Matches(ctx SpringContext) bool {
+	for _, name := range c.names {
+		if len(ctx.GetPrefixProperties(name)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// String 返回 Condition 的描述
+func (c *propertyAliasCondition) String() string {
+	return "any-property:" + strings.Join(c.names, "|")
+}
+
 // propertyValueCondition 基于属性值匹配的 Condition 实现
 type propertyValueCondition struct {
 	name           string
@@ -142,7 +224,16 @@ func (c *propertyValueCondition) Matches(ctx SpringContext) bool {
 		return val == expectValue
 	}
 
-	expr := strings.Replace(expectValue, "$", cast.ToString(val), -1)
+	// 时间类型的属性值替换为距离当前时间的秒数，已经过去的时间为负数，
+	// 例如 "$>30*24*3600" 表示 30 天之后的时间。
+	var s string
+	if t, ok := val.(time.Time); ok {
+		s = "(" + strconv.FormatInt(int64(time.Until(t)/time.Second), 10) + ")"
+	} else {
+		s = cast.ToString(val)
+	}
+
+	expr := strings.Replace(expectValue, "$", s, -1)
 	if ret, err := types.Eval(token.NewFileSet(), nil, token.NoPos, expr); err == nil {
 		return ret.Value.String() == "true"
 	} else {
@@ -150,12 +241,21 @@ func (c *propertyValueCondition) Matches(ctx SpringContext) bool {
 	}
 }
 
+// String 返回 Condition 的描述
+func (c *propertyValueCondition) String() string {
+	str := fmt.Sprintf("property:%s==%#v", c.name, c.havingValue)
+	if c.matchIfMissing {
+		str += " matchIfMissing"
+	}
+	return str
+}
+
 // beanCondition 基于 Bean 存在的 Condition 实现
 type beanCondition struct {
 	selector BeanSelector
 }
 
-// NewBeanCondition beanCondition 的构造函数
+// NewBeanCondition beanCondition 的构造函数，使用 InNamespace 在其他命名空间中查找
 func NewBeanCondition(selector BeanSelector) *beanCondition {
 	return &beanCondition{selector}
 }
@@ -166,6 +266,11 @@ func (c *beanCondition) Matches(ctx SpringContext) bool {
 	return ok
 }
 
+// String 返回 Condition 的描述
+func (c *beanCondition) String() string {
+	return "bean:" + selectorString(c.selector)
+}
+
 // missingBeanCondition 基于 Bean 不能存在的 Condition 实现
 type missingBeanCondition struct {
 	selector BeanSelector
@@ -182,19 +287,39 @@ func (c *missingBeanCondition) Matches(ctx SpringContext) bool {
 	return !ok
 }
 
-// expressionCondition 基于表达式的 Condition 实现
+// String 返回 Condition 的描述
+func (c *missingBeanCondition) String() string {
+	return "missing-bean:" + selectorString(c.selector)
+}
+
+// expressionCondition 基于表达式的 Condition 实现，表达式中的变量为属性名，
+// 例如 `server.port>8000 && hasPrefix(app.name,"go")`，结果必须是 bool 类型。
 type expressionCondition struct {
 	expression string
+	expr       ast.Expr
 }
 
-// NewExpressionCondition expressionCondition 的构造函数
+// NewExpressionCondition expressionCondition 的构造函数，表达式语法错误时 panic
 func NewExpressionCondition(expression string) *expressionCondition {
-	return &expressionCondition{expression}
+	expr, err := parseExpression(expression)
+	if err != nil {
+		panic(fmt.Errorf("expression: \"%s\" error: %v", expression, err))
+	}
+	return &expressionCondition{expression, expr}
 }
 
 // Matches 成功返回 true，失败返回 false
 func (c *expressionCondition) Matches(ctx SpringContext) bool {
-	panic(SpringConst.UnimplementedMethod)
+	v := evalExpression(ctx, c.expr)
+	if v.Kind() != constant.Bool {
+		panic(fmt.Errorf("expression: \"%s\" isn't bool type", c.expression))
+	}
+	return constant.BoolVal(v)
+}
+
+// String 返回 Condition 的描述
+func (c *expressionCondition) String() string {
+	return "expression:" + strconv.Quote(c.expression)
 }
 
 // profileCondition 基于运行环境匹配的 Condition 实现
@@ -207,9 +332,241 @@ func NewProfileCondition(profile string) *profileCondition {
 	return &profileCondition{profile}
 }
 
-// Matches 成功返回 true，失败返回 false
+// Matches 成功返回 true，失败返回 false，激活了多个运行环境时匹配其中任意一个
 func (c *profileCondition) Matches(ctx SpringContext) bool {
-	return c.profile == "" || strings.EqualFold(c.profile, ctx.GetProfile())
+	if c.profile == "" {
+		return true
+	}
+	for _, profile := range ctx.GetProfiles() {
+		if strings.EqualFold(c.profile, profile) {
+			return true
+		}
+	}
+	return false
+}
+
+// String 返回 Condition 的描述
+func (c *profileCondition) String() string {
+	return "profile==" + strconv.Quote(c.profile)
+}
+
+// goos 返回当前的操作系统，测试时可以替换
+var goos = func() string { return runtime.GOOS }
+
+// goarch 返回当前的处理器架构，测试时可以替换
+var goarch = func() string { return runtime.GOARCH }
+
+// osCondition 基于操作系统匹配的 Condition 实现
+type osCondition struct {
+	os string
+}
+
+// NewOSCondition osCondition 的构造函数
+func NewOSCondition(os string) *osCondition {
+	return &osCondition{os}
+}
+
+// Matches 成功返回 true，失败返回 false
+func (c *osCondition) Matches(ctx SpringContext) bool {
+	return strings.EqualFold(c.os, goos())
+}
+
+// String 返回 Condition 的描述
+func (c *osCondition) String() string {
+	return "os==" + strconv.Quote(c.os)
+}
+
+// archCondition 基于处理器架构匹配的 Condition 实现
+type archCondition struct {
+	arch string
+}
+
+// NewArchCondition archCondition 的构造函数
+func NewArchCondition(arch string) *archCondition {
+	return &archCondition{arch}
+}
+
+// Matches 成功返回 true，失败返回 false
+func (c *archCondition) Matches(ctx SpringContext) bool {
+	return strings.EqualFold(c.arch, goarch())
+}
+
+// String 返回 Condition 的描述
+func (c *archCondition) String() string {
+	return "arch==" + strconv.Quote(c.arch)
+}
+
+// goVersionFn 返回当前的 Go 版本，测试时可以替换
+var goVersionFn = runtime.Version
+
+// trimGoVersion 将 go1.21.0、1.21.0 或者 go1.22rc1 形式的 Go 版本转换成只有数字
+// 和点号的形式，开发版本等无法识别的版本返回空字符串
+func trimGoVersion(version string) string {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "go")
+	for i, c := range version {
+		if (c < '0' || c > '9') && c != '.' {
+			version = version[:i]
+			break
+		}
+	}
+	return strings.Trim(version, ".")
+}
+
+// goVersionCondition 基于 Go 版本的 Condition 实现，当前版本不低于最低版本时匹配，
+// 无法识别的开发版本视为最新版本
+type goVersionCondition struct {
+	minVersion string
+}
+
+// NewGoVersionCondition goVersionCondition 的构造函数，版本格式错误时 panic
+func NewGoVersionCondition(minVersion string) *goVersionCondition {
+	if trimGoVersion(minVersion) == "" {
+		panic(fmt.Errorf("error go version: \"%s\"", minVersion))
+	}
+	return &goVersionCondition{minVersion}
+}
+
+// Matches 成功返回 true，失败返回 false
+func (c *goVersionCondition) Matches(ctx SpringContext) bool {
+	version := trimGoVersion(goVersionFn())
+	return version == "" || compareVersion(version, trimGoVersion(c.minVersion)) >= 0
+}
+
+// String 返回 Condition 的描述
+func (c *goVersionCondition) String() string {
+	return "go-version>=" + strconv.Quote(c.minVersion)
+}
+
+// uptimeCondition 基于应用运行时间的 Condition 实现，应用运行时间从容器刷新开始计算，
+// 可以用于应用预热之后才生效的 Bean。启动时因为运行时间不足而不满足条件的 Bean 不会被
+// 删除，而是保留为候选 Bean，每次获取时重新计算条件，满足之后才创建。
+type uptimeCondition struct {
+	minUptime time.Duration
+}
+
+// NewUptimeCondition uptimeCondition 的构造函数，应用运行时间不小于 minUptime 时条件成立
+func NewUptimeCondition(minUptime time.Duration) *uptimeCondition {
+	return &uptimeCondition{minUptime}
+}
+
+// Matches 成功返回 true，失败返回 false
+func (c *uptimeCondition) Matches(ctx SpringContext) bool {
+	return ctx.Uptime() >= c.minUptime
+}
+
+// String 返回 Condition 的描述
+func (c *uptimeCondition) String() string {
+	return "uptime>=" + c.minUptime.String()
+}
+
+// cgoAvailable 返回当前程序是否使用 CGO 编译，测试时可以替换
+var cgoAvailable = func() bool { return cgoEnabled }
+
+// cgoCondition 基于是否使用 CGO 编译的 Condition 实现，用于依赖 CGO 或者共享库的 Bean
+type cgoCondition struct {
+	enabled bool
+}
+
+// NewCGOCondition cgoCondition 的构造函数
+func NewCGOCondition(enabled bool) *cgoCondition {
+	return &cgoCondition{enabled}
+}
+
+// Matches 成功返回 true，失败返回 false
+func (c *cgoCondition) Matches(ctx SpringContext) bool {
+	return c.enabled == cgoAvailable()
+}
+
+// String 返回 Condition 的描述
+func (c *cgoCondition) String() string {
+	return "cgo==" + strconv.FormatBool(c.enabled)
+}
+
+// 支持的云平台
+const (
+	CloudPlatformAWS   = "aws"
+	CloudPlatformGCP   = "gcp"
+	CloudPlatformAzure = "azure"
+	CloudPlatformNone  = "none"
+)
+
+// cloudPlatform 通过环境变量检测当前的云平台，测试时可以替换
+var cloudPlatform = func() string {
+	switch {
+	case os.Getenv("AWS_EXECUTION_ENV") != "":
+		return CloudPlatformAWS
+	case os.Getenv("GOOGLE_CLOUD_PROJECT") != "":
+		return CloudPlatformGCP
+	case os.Getenv("WEBSITE_INSTANCE_ID") != "":
+		return CloudPlatformAzure
+	}
+	return CloudPlatformNone
+}
+
+// cloudPlatformCondition 基于云平台匹配的 Condition 实现
+type cloudPlatformCondition struct {
+	platform string
+}
+
+// NewCloudPlatformCondition cloudPlatformCondition 的构造函数
+func NewCloudPlatformCondition(platform string) *cloudPlatformCondition {
+	switch strings.ToLower(platform) {
+	case CloudPlatformAWS, CloudPlatformGCP, CloudPlatformAzure, CloudPlatformNone:
+	default:
+		panic(fmt.Errorf("unknown cloud platform \"%s\"", platform))
+	}
+	return &cloudPlatformCondition{platform}
+}
+
+// Matches 成功返回 true，失败返回 false
+func (c *cloudPlatformCondition) Matches(ctx SpringContext) bool {
+	return strings.EqualFold(c.platform, cloudPlatform())
+}
+
+// String 返回 Condition 的描述
+func (c *cloudPlatformCondition) String() string {
+	return "cloud-platform==" + strconv.Quote(c.platform)
+}
+
+// ActiveProfilesProperty 激活的运行环境列表的属性名
+const ActiveProfilesProperty = "spring.profiles.active"
+
+// profilePropertyCondition 基于激活的运行环境列表匹配的 Condition 实现，激活的
+// 运行环境列表保存在 spring.profiles.active 属性中，可以是数组或者逗号分隔的字符串，
+// 例如 spring.profiles.active=prod,metrics。
+type profilePropertyCondition struct {
+	profile string
+}
+
+// NewProfilePropertyCondition profilePropertyCondition 的构造函数，name 是需要激活的运行环境
+func NewProfilePropertyCondition(name string) *profilePropertyCondition {
+	return &profilePropertyCondition{name}
+}
+
+// Matches 成功返回 true，失败返回 false
+func (c *profilePropertyCondition) Matches(ctx SpringContext) bool {
+
+	var profiles []string
+	switch v := ctx.GetProperty(ActiveProfilesProperty).(type) {
+	case nil:
+		return false
+	case string:
+		profiles = strings.Split(v, ",")
+	default:
+		profiles = cast.ToStringSlice(v)
+	}
+
+	for _, profile := range profiles {
+		if strings.EqualFold(c.profile, strings.TrimSpace(profile)) {
+			return true
+		}
+	}
+	return false
+}
+
+// String 返回 Condition 的描述
+func (c *profilePropertyCondition) String() string {
+	return "active-profile==" + strconv.Quote(c.profile)
 }
 
 // ConditionOp conditionNode 的计算方式
@@ -221,6 +578,19 @@ const (
 	ConditionNone = ConditionOp(3) // 没有一个满足
 )
 
+// String 返回计算方式的描述
+func (op ConditionOp) String() string {
+	switch op {
+	case ConditionOr:
+		return "OR"
+	case ConditionAnd:
+		return "AND"
+	case ConditionNone:
+		return "NONE"
+	}
+	return "ConditionOp(" + strconv.Itoa(int(op)) + ")"
+}
+
 // conditions 基于条件组的 Condition 实现
 type conditions struct {
 	op   ConditionOp
@@ -269,6 +639,41 @@ func (c *conditions) Matches(ctx SpringContext) bool {
 	panic(errors.New("error condition op mode"))
 }
 
+// String 返回 Condition 的描述，形如 AND(a, b)
+func (c *conditions) String() string {
+	ss := make([]string, 0, len(c.cond))
+	for _, c0 := range c.cond {
+		ss = append(ss, conditionString(c0))
+	}
+	return c.op.String() + "(" + strings.Join(ss, ", ") + ")"
+}
+
+// Add 在条件组的末尾添加一个 Condition，用于在容器刷新之前逐步构建条件组
+func (c *conditions) Add(cond Condition) {
+	c.cond = append(c.cond, cond)
+}
+
+// Remove 删除第 i 个 Condition，条件组为空或者 i 越界时返回错误
+func (c *conditions) Remove(i int) error {
+	if len(c.cond) == 0 {
+		return errors.New("no condition")
+	}
+	if i < 0 || i >= len(c.cond) {
+		return fmt.Errorf("condition index %d out of range [0,%d)", i, len(c.cond))
+	}
+	c.cond = append(c.cond[:i:i], c.cond[i+1:]...)
+	return nil
+}
+
+// ReplaceAt 使用 cond 替换第 i 个 Condition，i 越界时返回错误
+func (c *conditions) ReplaceAt(i int, cond Condition) error {
+	if i < 0 || i >= len(c.cond) {
+		return fmt.Errorf("condition index %d out of range [0,%d)", i, len(c.cond))
+	}
+	c.cond[i] = cond
+	return nil
+}
+
 // conditionNode Condition 计算式节点，返回值是 'cond op next'
 type conditionNode struct {
 	cond Condition      // 条件
@@ -341,6 +746,27 @@ func (c *Conditional) Matches(ctx SpringContext) bool {
 	return c.head.Matches(ctx)
 }
 
+// String 返回计算式的描述，形如 (profile=="prod") AND (bean:dataSource) OR (property:feature.x)
+func (c *Conditional) String() string {
+
+	if c.head.cond == nil {
+		return ""
+	}
+
+	var buf strings.Builder
+	for node := c.head; node != nil; node = node.next {
+		buf.WriteString("(")
+		if node.cond != nil {
+			buf.WriteString(conditionString(node.cond))
+		}
+		buf.WriteString(")")
+		if node.next != nil {
+			buf.WriteString(" " + node.op.String() + " ")
+		}
+	}
+	return buf.String()
+}
+
 // Or c=a||b
 func (c *Conditional) Or() *Conditional {
 	node := newConditionNode()
@@ -373,6 +799,46 @@ func (c *Conditional) OnConditionNot(cond Condition) *Conditional {
 	return c.OnCondition(NewNotCondition(cond))
 }
 
+// Negate 返回对整个表达式取反的 Conditional 对象，不修改原来的表达式，之后对原来
+// 的表达式的修改也不会影响取反的结果
+func (c *Conditional) Negate() *Conditional {
+	return NewConditional().OnConditionNot(c.snapshot())
+}
+
+// snapshot 复制表达式的计算节点，副本和原来的表达式互不影响
+func (c *Conditional) snapshot() *Conditional {
+	r := &Conditional{}
+	var prev *conditionNode
+	for node := c.head; node != nil; node = node.next {
+		n := &conditionNode{cond: node.cond, op: node.op}
+		if prev == nil {
+			r.head = n
+		} else {
+			prev.next = n
+		}
+		if node == c.curr {
+			r.curr = n
+		}
+		prev = n
+	}
+	return r
+}
+
+// OnAnyOf 设置一个至少一个满足的 Condition 组
+func (c *Conditional) OnAnyOf(cond ...Condition) *Conditional {
+	return c.OnCondition(NewConditions(ConditionOr, cond...))
+}
+
+// OnAllOf 设置一个所有都要满足的 Condition 组
+func (c *Conditional) OnAllOf(cond ...Condition) *Conditional {
+	return c.OnCondition(NewConditions(ConditionAnd, cond...))
+}
+
+// OnNoneOf 设置一个没有一个满足的 Condition 组
+func (c *Conditional) OnNoneOf(cond ...Condition) *Conditional {
+	return c.OnCondition(NewConditions(ConditionNone, cond...))
+}
+
 // Deprecated: Use "ConditionOnProperty" instead.
 func OnProperty(name string) *Conditional {
 	return ConditionOnProperty(name)
@@ -388,6 +854,16 @@ func (c *Conditional) OnProperty(name string) *Conditional {
 	return c.OnCondition(NewPropertyCondition(name))
 }
 
+// ConditionOnAnyProperty 返回设置了 propertyAliasCondition 的 Conditional 对象
+func ConditionOnAnyProperty(names ...string) *Conditional {
+	return NewConditional().OnAnyProperty(names...)
+}
+
+// OnAnyProperty 设置一个 propertyAliasCondition
+func (c *Conditional) OnAnyProperty(names ...string) *Conditional {
+	return c.OnCondition(NewPropertyAliasCondition(names...))
+}
+
 // Deprecated: Use "ConditionOnMissingProperty" instead.
 func OnMissingProperty(name string) *Conditional {
 	return ConditionOnMissingProperty(name)
@@ -494,3 +970,140 @@ func ConditionOnProfile(profile string) *Conditional {
 func (c *Conditional) OnProfile(profile string) *Conditional {
 	return c.OnCondition(NewProfileCondition(profile))
 }
+
+// ConditionOnProfileProperty 返回设置了 profilePropertyCondition 的 Conditional 对象
+func ConditionOnProfileProperty(name string) *Conditional {
+	return NewConditional().OnProfileProperty(name)
+}
+
+// OnProfileProperty 设置一个 profilePropertyCondition
+func (c *Conditional) OnProfileProperty(name string) *Conditional {
+	return c.OnCondition(NewProfilePropertyCondition(name))
+}
+
+// maxCachedContexts CachingConditional 最多缓存的上下文数量
+const maxCachedContexts = 16
+
+// cachedResult CachingConditional 缓存的计算结果
+type cachedResult struct {
+	version uint64 // 计算时的属性值版本号
+	ok      bool
+}
+
+// CachingConditional 缓存 Conditional 的计算结果，同一个上下文在属性值不变的情况下
+// 只计算一次。缓存以属性值的版本号为键，属性值发生变化 (比如热加载) 之后自动重新计算。
+// 缓存的上下文数量超过上限时清空缓存，也可以调用 Invalidate 手动清除缓存。
+type CachingConditional struct {
+	cond  *Conditional
+	mutex sync.Mutex
+	cache map[SpringContext]cachedResult
+}
+
+// NewCachingConditional CachingConditional 的构造函数
+func NewCachingConditional(cond *Conditional) *CachingConditional {
+	return &CachingConditional{
+		cond:  cond,
+		cache: make(map[SpringContext]cachedResult),
+	}
+}
+
+// Matches 成功返回 true，失败返回 false，同一个上下文在属性值不变的情况下只计算一次
+func (c *CachingConditional) Matches(ctx SpringContext) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	version := ctx.PropertiesVersion()
+	if r, found := c.cache[ctx]; found && r.version == version {
+		return r.ok
+	}
+
+	if _, found := c.cache[ctx]; !found && len(c.cache) >= maxCachedContexts {
+		c.cache = make(map[SpringContext]cachedResult)
+	}
+
+	ok := c.cond.Matches(ctx)
+	c.cache[ctx] = cachedResult{version: version, ok: ok}
+	return ok
+}
+
+// Invalidate 清除所有上下文缓存的计算结果
+func (c *CachingConditional) Invalidate() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.cache = make(map[SpringContext]cachedResult)
+}
+
+// String 返回计算式的描述
+func (c *CachingConditional) String() string {
+	return c.cond.String()
+}
+
+// ConditionOnOS 返回设置了 osCondition 的 Conditional 对象
+func ConditionOnOS(os string) *Conditional {
+	return NewConditional().OnOS(os)
+}
+
+// OnOS 设置一个 osCondition
+func (c *Conditional) OnOS(os string) *Conditional {
+	return c.OnCondition(NewOSCondition(os))
+}
+
+// ConditionOnArch 返回设置了 archCondition 的 Conditional 对象
+func ConditionOnArch(arch string) *Conditional {
+	return NewConditional().OnArch(arch)
+}
+
+// OnArch 设置一个 archCondition
+func (c *Conditional) OnArch(arch string) *Conditional {
+	return c.OnCondition(NewArchCondition(arch))
+}
+
+// ConditionOnMinGoVersion 返回设置了 goVersionCondition 的 Conditional 对象
+func ConditionOnMinGoVersion(version string) *Conditional {
+	return NewConditional().OnMinGoVersion(version)
+}
+
+// OnMinGoVersion 设置一个 goVersionCondition
+func (c *Conditional) OnMinGoVersion(version string) *Conditional {
+	return c.OnCondition(NewGoVersionCondition(version))
+}
+
+// OnMinUptime 设置一个 uptimeCondition
+func (c *Conditional) OnMinUptime(minUptime time.Duration) *Conditional {
+	return c.OnCondition(NewUptimeCondition(minUptime))
+}
+
+// OnRollout 设置一个 rolloutCondition
+func (c *Conditional) OnRollout(pct float64) *Conditional {
+	return c.OnCondition(NewRolloutCondition(pct))
+}
+
+// ConditionOnCGO 返回设置了 cgoCondition 的 Conditional 对象
+func ConditionOnCGO(enabled bool) *Conditional {
+	return NewConditional().OnCGO(enabled)
+}
+
+// OnCGO 设置一个 cgoCondition
+func (c *Conditional) OnCGO(enabled bool) *Conditional {
+	return c.OnCondition(NewCGOCondition(enabled))
+}
+
+// ConditionOnCloudPlatform 返回设置了 cloudPlatformCondition 的 Conditional 对象
+func ConditionOnCloudPlatform(platform string) *Conditional {
+	return NewConditional().OnCloudPlatform(platform)
+}
+
+// OnCloudPlatform 设置一个 cloudPlatformCondition
+func (c *Conditional) OnCloudPlatform(platform string) *Conditional {
+	return c.OnCondition(NewCloudPlatformCondition(platform))
+}
+
+// ConditionOnMinUptime 返回设置了 uptimeCondition 的 Conditional 对象
+func ConditionOnMinUptime(minUptime time.Duration) *Conditional {
+	return NewConditional().OnMinUptime(minUptime)
+}
+
+// ConditionOnRollout 返回设置了 rolloutCondition 的 Conditional 对象
+func ConditionOnRollout(pct float64) *Conditional {
+	return NewConditional().OnRollout(pct)
+}
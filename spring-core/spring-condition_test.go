@@ -17,7 +17,9 @@
 package SpringCore_test
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/go-spring/go-spring/spring-core"
 	"github.com/magiconair/properties/assert"
@@ -54,6 +56,31 @@ func TestPropertyCondition(t *testing.T) {
 	assert.Equal(t, cond.Matches(ctx), false)
 }
 
+func TestPropertyAliasCondition(t *testing.T) {
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.SetProperty("server.context-path", "/api")
+
+	cond := SpringCore.NewPropertyAliasCondition("server.contextPath")
+	assert.Equal(t, cond.Matches(ctx), true)
+
+	cond = SpringCore.NewPropertyAliasCondition("server.path", "server.context_path")
+	assert.Equal(t, cond.Matches(ctx), true)
+
+	cond = SpringCore.NewPropertyAliasCondition("server.path", "server.port")
+	assert.Equal(t, cond.Matches(ctx), false)
+	assert.Equal(t, cond.String(), "any-property:server.path|server.port")
+}
+
+func TestNormalizePropertyKey(t *testing.T) {
+	assert.Equal(t, SpringCore.NormalizePropertyKey("server.contextPath"), "server.context-path")
+	assert.Equal(t, SpringCore.NormalizePropertyKey("Server.ContextPath"), "server.context-path")
+	assert.Equal(t, SpringCore.NormalizePropertyKey("server.context_path"), "server.context-path")
+	assert.Equal(t, SpringCore.NormalizePropertyKey("server.context-path"), "server.context-path")
+	assert.Equal(t, SpringCore.NormalizePropertyKey("http.maxIdleConns2"), "http.max-idle-conns2")
+	assert.Equal(t, SpringCore.NormalizePropertyKey("HTTPServer.readTimeout"), "http-server.read-timeout")
+}
+
 func TestMissingPropertyCondition(t *testing.T) {
 
 	ctx := SpringCore.NewDefaultSpringContext()
@@ -93,6 +120,18 @@ func TestPropertyValueCondition(t *testing.T) {
 
 	cond = SpringCore.NewPropertyValueCondition("str", "\"$\"==\"this is a str\"")
 	assert.Equal(t, cond.Matches(ctx), true)
+	// 时间类型的属性值替换为距离当前时间的秒数
+	ctx.SetProperty("expire", time.Now().Add(10*24*time.Hour))
+
+	cond = SpringCore.NewPropertyValueCondition("expire", "$>30*24*3600")
+	assert.Equal(t, cond.Matches(ctx), false)
+
+	cond = SpringCore.NewPropertyValueCondition("expire", "$>0 && $<=10*24*3600")
+	assert.Equal(t, cond.Matches(ctx), true)
+
+	ctx.SetProperty("expire", time.Now().Add(-time.Hour))
+	cond = SpringCore.NewPropertyValueCondition("expire", "$<0")
+	assert.Equal(t, cond.Matches(ctx), true)
 }
 
 func TestBeanCondition(t *testing.T) {
@@ -125,6 +164,181 @@ func TestMissingBeanCondition(t *testing.T) {
 
 func TestExpressionCondition(t *testing.T) {
 
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.SetProperty("server.port", 8080)
+	ctx.SetProperty("ratio", 0.5)
+	ctx.SetProperty("debug", true)
+	ctx.SetProperty("app.name", "go-spring")
+	ctx.SetProperty("app.version", "3")
+
+	t.Run("numeric", func(t *testing.T) {
+		cond := SpringCore.NewExpressionCondition("server.port>8000")
+		assert.Equal(t, cond.Matches(ctx), true)
+
+		cond = SpringCore.NewExpressionCondition("server.port/2+ratio*2 == 4041")
+		assert.Equal(t, cond.Matches(ctx), true)
+
+		cond = SpringCore.NewExpressionCondition("app.version >= 3 && -ratio < 0")
+		assert.Equal(t, cond.Matches(ctx), true)
+	})
+
+	t.Run("boolean", func(t *testing.T) {
+		cond := SpringCore.NewExpressionCondition("debug")
+		assert.Equal(t, cond.Matches(ctx), true)
+
+		cond = SpringCore.NewExpressionCondition("!debug || (server.port<80)")
+		assert.Equal(t, cond.Matches(ctx), false)
+
+		// 短路求值，不存在的属性不会被访问
+		cond = SpringCore.NewExpressionCondition("debug || missing.key")
+		assert.Equal(t, cond.Matches(ctx), true)
+	})
+
+	t.Run("string", func(t *testing.T) {
+		cond := SpringCore.NewExpressionCondition(`app.name == "go-spring"`)
+		assert.Equal(t, cond.Matches(ctx), true)
+
+		cond = SpringCore.NewExpressionCondition(`hasPrefix(app.name, "go") && !contains(app.name, "java")`)
+		assert.Equal(t, cond.Matches(ctx), true)
+
+		SpringCore.RegisterExpressionFunc("len", func(args ...interface{}) (interface{}, error) {
+			return len(args[0].(string)), nil
+		})
+
+		cond = SpringCore.NewExpressionCondition(`len(app.name) == 9`)
+		assert.Equal(t, cond.Matches(ctx), true)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		assert.Panic(t, func() {
+			SpringCore.NewExpressionCondition("server.port >")
+		}, "expression: \"server.port >\" error: .*")
+
+		assert.Panic(t, func() {
+			SpringCore.NewExpressionCondition("server.port").Matches(ctx)
+		}, "expression: \"server.port\" isn't bool type")
+
+		assert.Panic(t, func() {
+			SpringCore.NewExpressionCondition("missing.key > 1").Matches(ctx)
+		}, "property \"missing.key\" not found")
+	})
+}
+
+func TestProfileCondition(t *testing.T) {
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	assert.Equal(t, ctx.GetProfile(), "")
+	assert.Equal(t, len(ctx.GetProfiles()), 0)
+	assert.Equal(t, SpringCore.NewProfileCondition("prod").Matches(ctx), false)
+	assert.Equal(t, SpringCore.NewProfileCondition("").Matches(ctx), true)
+
+	ctx.SetProfile("prod, metrics,us-east")
+	assert.Equal(t, ctx.GetProfile(), "prod")
+	assert.Equal(t, ctx.GetProfiles(), []string{"prod", "metrics", "us-east"})
+
+	assert.Equal(t, SpringCore.NewProfileCondition("prod").Matches(ctx), true)
+	assert.Equal(t, SpringCore.NewProfileCondition("Metrics").Matches(ctx), true)
+	assert.Equal(t, SpringCore.NewProfileCondition("us-west").Matches(ctx), false)
+	assert.Equal(t, SpringCore.ConditionOnProfile("metrics").And().OnProfile("us-east").Matches(ctx), true)
+}
+
+func TestProfilePropertyCondition(t *testing.T) {
+
+	ctx := SpringCore.NewDefaultSpringContext()
+
+	cond := SpringCore.NewProfilePropertyCondition("prod")
+	assert.Equal(t, cond.Matches(ctx), false)
+
+	t.Run("exact match", func(t *testing.T) {
+		ctx.SetProperty(SpringCore.ActiveProfilesProperty, "prod")
+		assert.Equal(t, cond.Matches(ctx), true)
+	})
+
+	t.Run("list match", func(t *testing.T) {
+		ctx.SetProperty(SpringCore.ActiveProfilesProperty, "dev, prod,metrics")
+		assert.Equal(t, cond.Matches(ctx), true)
+		assert.Equal(t, SpringCore.NewProfilePropertyCondition("metrics").Matches(ctx), true)
+
+		ctx.SetProperty(SpringCore.ActiveProfilesProperty, []interface{}{"prod", "metrics"})
+		assert.Equal(t, cond.Matches(ctx), true)
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		ctx.SetProperty(SpringCore.ActiveProfilesProperty, "dev,production")
+		assert.Equal(t, cond.Matches(ctx), false)
+
+		ctx.SetProperty(SpringCore.ActiveProfilesProperty, []string{"dev"})
+		assert.Equal(t, cond.Matches(ctx), false)
+	})
+
+	t.Run("conditional", func(t *testing.T) {
+		ctx.SetProperty(SpringCore.ActiveProfilesProperty, "prod")
+		c := SpringCore.ConditionOnProfileProperty("prod")
+		assert.Equal(t, c.Matches(ctx), true)
+		assert.Equal(t, c.String(), `(active-profile=="prod")`)
+	})
+}
+
+func TestRolloutCondition(t *testing.T) {
+
+	cond := SpringCore.NewRolloutCondition(30)
+	assert.Equal(t, cond.String(), "rollout<30%")
+
+	active := 0
+	for i := 0; i < 1000; i++ {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.SetProperty(SpringCore.InstanceIdProperty, fmt.Sprintf("instance-%d", i))
+		if ok := cond.Matches(ctx); ok {
+			active++
+			assert.Equal(t, cond.Matches(ctx), true)
+		} else {
+			assert.Equal(t, cond.Matches(ctx), false)
+		}
+		assert.Equal(t, SpringCore.ConditionOnRollout(0).Matches(ctx), false)
+		assert.Equal(t, SpringCore.ConditionOnRollout(100).Matches(ctx), true)
+	}
+	assert.Equal(t, active > 250 && active < 350, true)
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.SetProperty(SpringCore.InstanceIdProperty, "instance-1")
+	ctx.RegisterNameBean("canary", new(BeanZero)).RolloutPercentage(100)
+	ctx.RegisterNameBean("disabled", new(BeanZero)).RolloutPercentage(0)
+	ctx.AutoWireBeans()
+
+	_, ok := ctx.FindBean("canary")
+	assert.Equal(t, ok, true)
+	_, ok = ctx.FindBean("disabled")
+	assert.Equal(t, ok, false)
+
+	t.Run("stable without instance id", func(t *testing.T) {
+		c := SpringCore.NewRolloutCondition(50)
+		ctx := SpringCore.NewDefaultSpringContext()
+		ok := c.Matches(ctx)
+		for i := 0; i < 100; i++ {
+			assert.Equal(t, c.Matches(ctx), ok)
+		}
+	})
+
+	t.Run("beans are independent", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.SetProperty(SpringCore.InstanceIdProperty, "instance-1")
+		for i := 0; i < 100; i++ {
+			ctx.RegisterNameBean(fmt.Sprintf("bean-%d", i), new(BeanZero)).RolloutPercentage(50)
+		}
+		ctx.AutoWireBeans()
+
+		active := 0
+		for i := 0; i < 100; i++ {
+			if _, ok := ctx.FindBean(fmt.Sprintf("bean-%d", i)); ok {
+				active++
+			}
+		}
+		assert.Equal(t, active > 20 && active < 80, true)
+	})
+
+	assert.Panic(t, func() {
+		SpringCore.NewRolloutCondition(120)
+	}, "rollout percentage should be in \\[0,100\\]")
 }
 
 func TestConditional(t *testing.T) {
@@ -213,3 +427,172 @@ func TestNotCondition(t *testing.T) {
 		OnConditionNot(profileCond)
 	assert.Equal(t, cond.Matches(ctx), false)
 }
+
+func TestConditional_Negate(t *testing.T) {
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.SetProperty("a", true)
+
+	cond := SpringCore.ConditionOnProperty("a").Or().OnProperty("b")
+	negated := cond.Negate()
+	assert.Equal(t, negated.Matches(ctx), false)
+	assert.Equal(t, cond.Matches(ctx), true)
+	assert.Equal(t, cond.String(), "(property:a) OR (property:b)")
+
+	cond = SpringCore.ConditionOnProperty("a").And().OnProperty("b")
+	assert.Equal(t, cond.Negate().Matches(ctx), true)
+	assert.Equal(t, cond.Matches(ctx), false)
+
+	// 取反之后再修改原来的表达式不影响取反的结果
+	cond = SpringCore.ConditionOnProperty("a")
+	negated = cond.Negate()
+	cond.OnProperty("b")
+	assert.Equal(t, cond.Matches(ctx), false)
+	assert.Equal(t, negated.Matches(ctx), false)
+	assert.Equal(t, negated.String(), "(NOT ((property:a)))")
+}
+
+func TestConditional_Group(t *testing.T) {
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.SetProperty("a", true)
+	ctx.SetProperty("c", true)
+	ctx.SetProperty("d", true)
+
+	a := SpringCore.NewPropertyCondition("a")
+	b := SpringCore.NewPropertyCondition("b")
+	c := SpringCore.NewPropertyCondition("c")
+	d := SpringCore.NewPropertyCondition("d")
+
+	cond := SpringCore.NewConditional().OnAnyOf(a, b).And().OnAllOf(c, d)
+	assert.Equal(t, cond.Matches(ctx), true)
+
+	cond = SpringCore.NewConditional().OnAnyOf(b, b).And().OnAllOf(c, d)
+	assert.Equal(t, cond.Matches(ctx), false)
+
+	cond = SpringCore.NewConditional().OnAnyOf(a, b).And().OnAllOf(c, b)
+	assert.Equal(t, cond.Matches(ctx), false)
+
+	cond = SpringCore.NewConditional().OnNoneOf(b).And().OnAllOf(a, c, d)
+	assert.Equal(t, cond.Matches(ctx), true)
+
+	cond = SpringCore.NewConditional().OnNoneOf(a, b).Or().OnAnyOf(b)
+	assert.Equal(t, cond.Matches(ctx), false)
+}
+
+func TestConditions_Mutation(t *testing.T) {
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.SetProperty("a", true)
+
+	a := SpringCore.NewPropertyCondition("a")
+	b := SpringCore.NewPropertyCondition("b")
+	c := SpringCore.NewPropertyCondition("c")
+
+	group := SpringCore.NewConditions(SpringCore.ConditionAnd)
+	assert.Equal(t, group.Remove(0).Error(), "no condition")
+
+	group.Add(a)
+	group.Add(b)
+	assert.Equal(t, group.String(), "AND(property:a, property:b)")
+	assert.Equal(t, group.Matches(ctx), false)
+
+	assert.Equal(t, group.Remove(1), nil)
+	assert.Equal(t, group.String(), "AND(property:a)")
+	assert.Equal(t, group.Matches(ctx), true)
+	assert.Matches(t, group.Remove(3).Error(), "out of range")
+
+	group.Add(b)
+	assert.Equal(t, group.ReplaceAt(1, c), nil)
+	assert.Equal(t, group.String(), "AND(property:a, property:c)")
+	assert.Matches(t, group.ReplaceAt(2, b).Error(), "out of range")
+
+	assert.Equal(t, group.Remove(0), nil)
+	assert.Equal(t, group.String(), "AND(property:c)")
+	assert.Equal(t, group.Matches(ctx), false)
+}
+
+func TestCachingConditional(t *testing.T) {
+
+	count := 0
+	cond := SpringCore.NewCachingConditional(SpringCore.ConditionOnMatches(func(ctx SpringCore.SpringContext) bool {
+		count++
+		return ctx.GetBoolProperty("bool")
+	}).And().OnProperty("bool"))
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.SetProperty("bool", true)
+
+	assert.Equal(t, cond.Matches(ctx), true)
+	assert.Equal(t, cond.Matches(ctx), true)
+	assert.Equal(t, count, 1)
+
+	// 不同的上下文分别计算
+	other := SpringCore.NewDefaultSpringContext()
+	assert.Equal(t, cond.Matches(other), false)
+	assert.Equal(t, cond.Matches(other), false)
+	assert.Equal(t, count, 2)
+
+	// 属性值变化之后版本号变化，自动重新计算
+	ctx.SetProperty("bool", false)
+	assert.Equal(t, cond.Matches(ctx), false)
+	assert.Equal(t, cond.Matches(ctx), false)
+	assert.Equal(t, count, 3)
+
+	// 手动清除缓存之后重新计算
+	cond.Invalidate()
+	assert.Equal(t, cond.Matches(ctx), false)
+	assert.Equal(t, count, 4)
+
+	// 缓存的上下文数量有上限，超过之后清空缓存
+	for i := 0; i < 20; i++ {
+		cond.Matches(SpringCore.NewDefaultSpringContext())
+	}
+	assert.Equal(t, count, 24)
+	assert.Equal(t, cond.Matches(ctx), false)
+	assert.Equal(t, count, 25)
+
+	// 刷新属性之后版本号也会增加
+	ctx.AutoWireBeans()
+	version := ctx.PropertiesVersion()
+	assert.Equal(t, ctx.RefreshProperties("bool"), nil)
+	assert.Equal(t, ctx.PropertiesVersion(), version+1)
+	assert.Equal(t, cond.Matches(ctx), false)
+	assert.Equal(t, count, 26)
+
+	assert.Matches(t, cond.String(), `\(func:.*\) AND \(property:bool\)`)
+}
+
+func TestConditional_String(t *testing.T) {
+
+	assert.Equal(t, SpringCore.NewConditional().String(), "")
+
+	cond := SpringCore.ConditionOnProfile("prod")
+	assert.Equal(t, cond.String(), `(profile=="prod")`)
+
+	cond = SpringCore.ConditionOnProfile("prod").
+		OnBean("dataSource").
+		OnPropertyValue("a", "b", SpringCore.MatchIfMissing(true))
+	assert.Equal(t, cond.String(), `(profile=="prod") AND (bean:dataSource) AND (property:a=="b" matchIfMissing)`)
+
+	cond = SpringCore.ConditionOnProperty("feature.x").
+		Or().OnMissingProperty("feature.y").
+		Or().OnMissingBean((*error)(nil))
+	assert.Equal(t, cond.String(), `(property:feature.x) OR (missing-property:feature.y) OR (missing-bean:error)`)
+
+	cond = SpringCore.ConditionOnProfile("prod").
+		OnBean("dataSource").
+		Or().OnProperty("feature.x")
+	assert.Equal(t, cond.String(), `(profile=="prod") AND (bean:dataSource) OR (property:feature.x)`)
+
+	cond = SpringCore.NewConditional().
+		OnConditionNot(SpringCore.NewPropertyValueCondition("int", 3)).
+		OnCondition(SpringCore.NewConditions(SpringCore.ConditionOr,
+			SpringCore.NewExpressionCondition("a>1"),
+			SpringCore.NewBeanCondition((*SpringCore.Runner)(nil)),
+		))
+	assert.Equal(t, cond.String(), `(NOT (property:int==3)) AND (OR(expression:"a>1", bean:*SpringCore.Runner))`)
+
+	fn := SpringCore.NewFunctionCondition(func(ctx SpringCore.SpringContext) bool { return true })
+	assert.Equal(t, fn.String(), "func:TestConditional_String.func1")
+}
@@ -23,8 +23,11 @@ import (
 	"fmt"
 	"reflect"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/go-spring/go-spring-parent/spring-logger"
 	"github.com/go-spring/go-spring-parent/spring-utils"
@@ -42,6 +45,14 @@ func newBeanKey(typ reflect.Type, name string) beanKey {
 	return beanKey{typ: typ, name: name}
 }
 
+// beanKey 返回 Bean 在容器中的键，设置了单例缓存键时使用缓存键代替名称
+func (d *BeanDefinition) beanKey() beanKey {
+	if d.cacheKey != "" {
+		return newBeanKey(d.Type(), d.cacheKey)
+	}
+	return newBeanKey(d.Type(), d.name)
+}
+
 // beanCacheItem BeanCache's item, for type cache or name cache.
 type beanCacheItem struct {
 	beans []*BeanDefinition
@@ -68,11 +79,22 @@ type defaultSpringContext struct {
 	ctx    context.Context
 	cancel context.CancelFunc
 
-	profile   string // 运行环境
-	autoWired bool   // 是否开始自动绑定
-	allAccess bool   // 是否允许注入私有字段
+	profiles  []string // 激活的运行环境列表
+	autoWired bool     // 是否开始自动绑定
+	wired     bool     // 是否已经调用 AutoWireBeans
+	started   bool     // 是否已经完成自动注入
+	allAccess bool     // 是否允许注入私有字段
+	strict    bool     // 是否启用严格模式
+	audit     bool     // 是否启用访问审计
+
+	ctorAction ConstructionTimeoutAction // 构造函数超时之后的处理方式
+	clock      func() time.Time          // 容器使用的时钟，测试时可以替换
+	startTime  time.Time                 // 容器刷新的时间
+	uptimeRead bool                      // 决议判断条件时是否读取过运行时间
 
 	beanMap         map[beanKey]*BeanDefinition // Bean 的集合
+	beanSeq         int                         // Bean 的注册序号
+	allBeans        []*BeanDefinition           // 按注册顺序排列的所有 Bean
 	methodBeans     []*BeanDefinition           // 方法 Beans
 	beanCacheByName map[string]*beanCacheItem
 	beanCacheByType map[reflect.Type]*beanCacheItem
@@ -80,11 +102,29 @@ type defaultSpringContext struct {
 	configers    *list.List // 配置方法集合
 	destroyers   *list.List // 销毁函数集合
 	destroyerMap map[beanKey]*destroyer
+
+	lazyMutex    sync.Mutex // 延迟初始化的 Bean 首次注入时的锁
+	destroyMutex sync.Mutex // 启动之后注入的 Bean 会并发地修改销毁函数集合
+	sorted       bool       // 销毁函数是否已经排序，之后注入的 Bean 直接加入销毁函数集合
+
+	processors []BeanPostProcessor // Bean 后处理器集合
+
+	sessions   sync.Map         // 会话 ID 到会话作用域子容器的映射
+	expiring   *scopedContainer // 设置了存活时间的单例 Bean 的子容器
+	memoized   sync.Map         // 缓存键到设置了缓存键的单例 Bean 的子容器的映射
+	prototypes sync.Map         // 原型 Bean 到其存活实例的映射
+	goroutines sync.Map         // goroutine ID 到 goroutine 作用域子容器的映射
+
+	depMutex     sync.Mutex
+	dependencies map[*BeanDefinition][]*BeanDefinition // 注入时记录的 Bean 之间的依赖关系
+
+	propVersion uint64 // 属性值的版本号
 }
 
 // NewDefaultSpringContext defaultSpringContext 的构造函数
 func NewDefaultSpringContext() *defaultSpringContext {
-	ctx, cancel := context.WithCancel(context.Background())
+	breakers := new(sync.Map)
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), breakersKey{}, breakers))
 	return &defaultSpringContext{
 		ctx:             ctx,
 		cancel:          cancel,
@@ -96,6 +136,7 @@ func NewDefaultSpringContext() *defaultSpringContext {
 		configers:       list.New(),
 		destroyers:      list.New(),
 		destroyerMap:    make(map[beanKey]*destroyer),
+		expiring:        newScopedContainer(SingletonScope),
 	}
 }
 
@@ -104,14 +145,38 @@ func (ctx *defaultSpringContext) Context() context.Context {
 	return ctx.ctx
 }
 
-// GetProfile 返回运行环境
+// GetProfile 返回第一个激活的运行环境，没有激活的运行环境时返回空字符串
 func (ctx *defaultSpringContext) GetProfile() string {
-	return ctx.profile
+	if len(ctx.profiles) > 0 {
+		return ctx.profiles[0]
+	}
+	return ""
+}
+
+// GetProfiles 返回激活的运行环境列表
+func (ctx *defaultSpringContext) GetProfiles() []string {
+	return append([]string(nil), ctx.profiles...)
 }
 
-// SetProfile 设置运行环境
+// SetProfile 设置运行环境，多个运行环境使用逗号分隔，例如 prod,metrics
 func (ctx *defaultSpringContext) SetProfile(profile string) {
-	ctx.profile = profile
+	ctx.profiles = nil
+	for _, s := range strings.Split(profile, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			ctx.profiles = append(ctx.profiles, s)
+		}
+	}
+}
+
+// SetProperty 设置属性值，属性名称统一转成小写，并且增加属性值的版本号。
+func (ctx *defaultSpringContext) SetProperty(key string, value interface{}) {
+	ctx.Properties.SetProperty(key, value)
+	atomic.AddUint64(&ctx.propVersion, 1)
+}
+
+// PropertiesVersion 返回属性值的版本号，每次设置或者刷新属性值之后版本号都会增加
+func (ctx *defaultSpringContext) PropertiesVersion() uint64 {
+	return atomic.LoadUint64(&ctx.propVersion)
 }
 
 // AllAccess 返回是否允许访问私有字段
@@ -124,6 +189,50 @@ func (ctx *defaultSpringContext) SetAllAccess(allAccess bool) {
 	ctx.allAccess = allAccess
 }
 
+// StrictMode 返回是否启用严格模式
+func (ctx *defaultSpringContext) StrictMode() bool {
+	return ctx.strict
+}
+
+// SetStrictMode 设置是否启用严格模式
+func (ctx *defaultSpringContext) SetStrictMode(strict bool) {
+	ctx.strict = strict
+}
+
+// ConfigAudit 返回是否启用了访问审计
+func (ctx *defaultSpringContext) ConfigAudit() bool {
+	return ctx.audit
+}
+
+// EnableConfigAudit 设置是否启用访问审计
+func (ctx *defaultSpringContext) EnableConfigAudit(enable bool) {
+	ctx.audit = enable
+}
+
+// SetConstructionTimeoutAction 设置构造函数超时之后的处理方式，默认只打印警告日志
+func (ctx *defaultSpringContext) SetConstructionTimeoutAction(action ConstructionTimeoutAction) {
+	ctx.ctorAction = action
+}
+
+// now 返回容器时钟的当前时间
+func (ctx *defaultSpringContext) now() time.Time {
+	if ctx.clock != nil {
+		return ctx.clock()
+	}
+	return time.Now()
+}
+
+// Uptime 返回从容器刷新开始到现在经过的时间，容器刷新之前返回 0
+func (ctx *defaultSpringContext) Uptime() time.Duration {
+	if ctx.startTime.IsZero() {
+		return 0
+	}
+	if !ctx.started { // 启动过程中读取运行时间的判断条件在启动之后才有意义
+		ctx.uptimeRead = true
+	}
+	return ctx.now().Sub(ctx.startTime)
+}
+
 // checkAutoWired 检查是否已调用 AutoWireBeans 方法
 func (ctx *defaultSpringContext) checkAutoWired() {
 	if !ctx.autoWired {
@@ -140,7 +249,7 @@ func (ctx *defaultSpringContext) checkRegistration() {
 
 // deleteBeanDefinition 删除 BeanDefinition。
 func (ctx *defaultSpringContext) deleteBeanDefinition(bd *BeanDefinition) {
-	key := newBeanKey(bd.Type(), bd.Name())
+	key := bd.beanKey()
 	bd.status = beanStatus_Deleted
 	delete(ctx.beanMap, key)
 }
@@ -154,7 +263,111 @@ func (ctx *defaultSpringContext) registerBeanDefinition(bd *BeanDefinition) {
 		panic(fmt.Errorf("duplicate registration, bean: \"%s\"", bd.BeanId()))
 	}
 
+	for _, name := range append([]string{bd.name}, bd.aliases...) {
+		ctx.checkAlias(bd, name)
+	}
+	bd.owner = ctx
+
+	ctx.beanSeq++
+	bd.seq = ctx.beanSeq
 	ctx.beanMap[key] = bd
+	ctx.allBeans = append(ctx.allBeans, bd)
+}
+
+// checkAlias 检查 Bean 的名称或者别名是否和其他 Bean 的别名冲突，别名还不能和其他
+// Bean 的名称冲突，冲突时 panic。
+func (ctx *defaultSpringContext) checkAlias(bd *BeanDefinition, name string) {
+	ctx.checkRegistration()
+
+	conflict := func(b *BeanDefinition) bool {
+		if b == bd || name == "" {
+			return false
+		}
+		if name == bd.name { // 名称只需要和别名不冲突
+			for _, alias := range b.aliases {
+				if alias == name {
+					return true
+				}
+			}
+			return false
+		}
+		return b.hasAlias(name)
+	}
+
+	for _, b := range ctx.allBeans {
+		if conflict(b) {
+			panic(fmt.Errorf("alias \"%s\" of bean: \"%s\" conflicts with bean: \"%s\"",
+				name, bd.BeanId(), b.BeanId()))
+		}
+	}
+}
+
+// checkDependencies 检查构造函数 Bean 的依赖是否已经注册，属性绑定、可空、
+// 收集模式以及通过属性值指定名称的参数无法在注册时检查，因此直接跳过。
+func (ctx *defaultSpringContext) checkDependencies(bd *BeanDefinition) {
+
+	arg := bd.bean.(*constructorBean).stringArg
+	fnType := arg.fnType
+
+	var unresolved []string
+	for i, tags := range arg.fnTags {
+
+		if fnType.IsVariadic() && i == fnType.NumIn()-1 {
+			continue // 可变参数
+		}
+
+		var tag string
+		if len(tags) > 0 {
+			tag = tags[0]
+		}
+
+		it := fnType.In(i)
+		if it == weakReferenceType || it == lazyProviderType || it == compositeBeansType || IsValueType(it.Kind()) {
+			continue // 注入时由容器提供，不是注册的 Bean
+		}
+
+		if it == contextType && tag == "" {
+			continue // 调用上下文
+		}
+
+		if strings.HasPrefix(tag, "${") || CollectionMode(tag) {
+			continue
+		}
+
+		t := ParseSingletonTag(tag)
+		if t.Nullable || ctx.hasDependency(it, t) {
+			continue
+		}
+
+		if tag == "" {
+			unresolved = append(unresolved, it.String())
+		} else {
+			unresolved = append(unresolved, fmt.Sprintf("%s \"%s\"", it, tag))
+		}
+	}
+
+	if len(unresolved) > 0 {
+		panic(fmt.Errorf("strict mode: bean: \"%s\" has unresolved dependencies: %s",
+			bd.BeanId(), strings.Join(unresolved, ", ")))
+	}
+}
+
+// hasDependency 返回是否注册了和类型以及 tag 匹配的 Bean
+func (ctx *defaultSpringContext) hasDependency(t reflect.Type, tag SingletonTag) bool {
+	match := func(bd *BeanDefinition) bool {
+		return bd.Type().AssignableTo(t) && bd.Match(tag.TypeName, tag.BeanName)
+	}
+	for _, bd := range ctx.beanMap {
+		if match(bd) {
+			return true
+		}
+	}
+	for _, bd := range ctx.methodBeans {
+		if match(bd) {
+			return true
+		}
+	}
+	return false
 }
 
 // RegisterBean 注册单例 Bean，不指定名称，重复注册会 panic。
@@ -177,6 +390,9 @@ func (ctx *defaultSpringContext) RegisterBeanFn(fn interface{}, tags ...string)
 // RegisterNameBeanFn 注册单例构造函数 Bean，需指定名称，重复注册会 panic。
 func (ctx *defaultSpringContext) RegisterNameBeanFn(name string, fn interface{}, tags ...string) *BeanDefinition {
 	bd := FnToBeanDefinition(name, fn, tags...)
+	if ctx.strict {
+		ctx.checkDependencies(bd)
+	}
 	ctx.registerBeanDefinition(bd)
 	return bd
 }
@@ -262,11 +478,17 @@ func (ctx *defaultSpringContext) GetBean(i interface{}, selector ...BeanSelector
 func (ctx *defaultSpringContext) FindBean(selector BeanSelector) (*BeanDefinition, bool) {
 	ctx.checkAutoWired()
 
+	// 没有限定命名空间时在默认的命名空间中查找
+	ns := ""
+	if s, ok := selector.(*namespacedSelector); ok {
+		ns, selector = s.namespace, s.selector
+	}
+
 	finder := func(fn func(*BeanDefinition) bool) (result []*BeanDefinition) {
 		for _, bean := range ctx.beanMap {
-			if bean.status != beanStatus_Resolving && fn(bean) {
+			if bean.namespace == ns && bean.status != beanStatus_Resolving && fn(bean) {
 				ctx.resolveBean(bean) // 避免 Bean 未被解析
-				if bean.status != beanStatus_Deleted {
+				if bean.status != beanStatus_Deleted && bean.recheckCondition(ctx) {
 					result = append(result, bean)
 				}
 			}
@@ -332,12 +554,13 @@ func (ctx *defaultSpringContext) FindBean(selector BeanSelector) (*BeanDefinitio
 // 符合条件，然后把数组元素拆开一个个放到收集结果里面)。指定模式是指 selectors 参数
 // 不为空，这时候只会收集单例 Bean，而且要求这些单例 Bean 不仅需要满足收集条件，而且
 // 必须满足 selector 条件。另外，自动模式下不对收集结果进行排序，指定模式下根据
-// selectors 列表的顺序对收集结果进行排序。
+// selectors 列表的顺序对收集结果进行排序。i 也可以是 map[string]T 的指针，这时
+// 以 Bean 的名称或者 MapKey 设置的键作为 map 的键。
 func (ctx *defaultSpringContext) CollectBeans(i interface{}, selectors ...BeanSelector) bool {
 	ctx.checkAutoWired()
 
-	if t := reflect.TypeOf(i); t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Slice {
-		panic(errors.New("i must be slice ptr"))
+	if t := reflect.TypeOf(i); t.Kind() != reflect.Ptr || (t.Elem().Kind() != reflect.Slice && t.Elem().Kind() != reflect.Map) {
+		panic(errors.New("i must be slice or map ptr"))
 	}
 
 	tag := CollectionTag{Nullable: true}
@@ -441,12 +664,25 @@ func (ctx *defaultSpringContext) resolveBean(bd *BeanDefinition) {
 		}
 	}
 
-	// 不满足判断条件的则标记为删除状态并删除其注册
-	if ok := bd.checkCondition(ctx); !ok {
-		ctx.deleteBeanDefinition(bd)
-		return
+	// 不满足判断条件的则标记为删除状态并删除其注册。依赖运行时间的判断条件在启动时
+	// 必然不满足，设置了 ForceConditionRecheck 的 Bean 的条件之后可能满足，所以保留
+	// 为候选 Bean，在获取时重新计算判断条件
+	saved := ctx.uptimeRead
+	ctx.uptimeRead = false
+	ok := bd.checkCondition(ctx)
+	uptimeRead := ctx.uptimeRead
+	ctx.uptimeRead = saved || uptimeRead // 嵌套决议的 Bean 不能清除外层 Bean 读取过运行时间的记录
+	if !ok {
+		if !uptimeRead && !bd.recheck {
+			ctx.deleteBeanDefinition(bd)
+			return
+		}
+		bd.deferred = true
 	}
 
+	// 校验 Bean 的注册信息
+	bd.staticValidate()
+
 	// 将符合注册条件的 Bean 放入到缓存里面
 	ctx.typeCache(bd.Type(), bd)
 
@@ -457,6 +693,9 @@ func (ctx *defaultSpringContext) resolveBean(bd *BeanDefinition) {
 
 	// 按照导出类型放入缓存
 	for t := range bd.exports {
+		if t == bd.Type() { // 导出自身的类型时已经缓存过了
+			continue
+		}
 		if bd.Type().Implements(t) {
 			ctx.typeCache(t, bd)
 		} else {
@@ -466,6 +705,9 @@ func (ctx *defaultSpringContext) resolveBean(bd *BeanDefinition) {
 
 	// 按照 Bean 的名字进行缓存
 	ctx.nameCache(bd.name, bd)
+	for _, alias := range bd.aliases {
+		ctx.nameCache(alias, bd)
+	}
 
 	bd.status = beanStatus_Resolved
 }
@@ -563,45 +805,113 @@ func (ctx *defaultSpringContext) runConfigers(assembly *defaultBeanAssembly) {
 	}
 }
 
-func (ctx *defaultSpringContext) destroyer(bd *BeanDefinition) *destroyer {
+// addDestroyer 记录 Bean 的销毁函数，prev 不为空时它的销毁函数先于 bd 的销毁函数调用
+func (ctx *defaultSpringContext) addDestroyer(bd *BeanDefinition, prev *BeanDefinition) {
+	ctx.destroyMutex.Lock()
+	defer ctx.destroyMutex.Unlock()
+
 	k := newBeanKey(bd.Type(), bd.Name())
 	d, ok := ctx.destroyerMap[k]
 	if !ok {
 		d = &destroyer{bean: bd}
 		ctx.destroyerMap[k] = d
+		// 排序之后才注入的 Bean (延迟初始化的 Bean) 先于其他 Bean 销毁
+		if ctx.sorted {
+			ctx.destroyers.PushFront(d)
+		}
 	}
-	return d
+
+	if prev != nil {
+		d.After(prev)
+	}
+}
+
+// sortedDestroyers 返回排好序的销毁函数集合的快照
+func (ctx *defaultSpringContext) sortedDestroyers() []*destroyer {
+	ctx.destroyMutex.Lock()
+	defer ctx.destroyMutex.Unlock()
+
+	result := make([]*destroyer, 0, ctx.destroyers.Len())
+	for i := ctx.destroyers.Front(); i != nil; i = i.Next() {
+		result = append(result, i.Value.(*destroyer))
+	}
+	return result
 }
 
 // sortDestroyers 对销毁函数进行排序
 func (ctx *defaultSpringContext) sortDestroyers() {
+	ctx.destroyMutex.Lock()
+	defer ctx.destroyMutex.Unlock()
+
 	for _, d := range ctx.destroyerMap {
 		ctx.destroyers.PushBack(d)
 	}
 	ctx.destroyers = sort.TripleSorting(ctx.destroyers, getBeforeDestroyers)
+	ctx.sorted = true
 }
 
-// wireBeans 对 Bean 执行自动注入
+// wireBeans 对 Bean 执行自动注入，设置了 Eager 的 Bean 在其他 Bean 之后初始化，
+// 设置了 Lazy 的 Bean 只在被其他 Bean 依赖时初始化
 func (ctx *defaultSpringContext) wireBeans(assembly *defaultBeanAssembly) {
-	for _, bd := range ctx.beanMap {
-		assembly.wireBeanDefinition(bd, false)
+	beans := ctx.sortedSingletons()
+	for _, bd := range beans {
+		if bd.lazy && len(bd.observing) > 0 {
+			panic(fmt.Errorf("lazy bean: \"%s\" can't observe other beans", bd.BeanId()))
+		}
+		if !bd.eager && !bd.lazy && !bd.deferred {
+			assembly.beanValue(bd)
+		}
+	}
+	for _, bd := range beans {
+		if bd.eager && !bd.lazy && !bd.deferred {
+			assembly.beanValue(bd)
+		}
+	}
+}
+
+// subscribeObservers 调用事件源的 Subscribe 方法订阅观察者
+func (ctx *defaultSpringContext) subscribeObservers() {
+	for _, bd := range ctx.sortedSingletons() {
+		for _, selector := range bd.observing {
+
+			source, ok := ctx.FindBean(InNamespace(bd.namespace, selector))
+			if !ok {
+				panic(fmt.Errorf("can't find bean: \"%v\"", selector))
+			}
+
+			m := source.Value().MethodByName("Subscribe")
+			if !m.IsValid() {
+				panic(fmt.Errorf("bean: \"%s\" has no Subscribe method", source.BeanId()))
+			}
+
+			mt := m.Type()
+			if mt.NumIn() != 1 || !bd.Type().AssignableTo(mt.In(0)) {
+				panic(fmt.Errorf("bean: \"%s\" can't subscribe bean: \"%s\"", source.BeanId(), bd.BeanId()))
+			}
+
+			for _, out := range m.Call([]reflect.Value{bd.Value()}) {
+				if out.Type() == errorType && !out.IsNil() {
+					panic(out.Interface())
+				}
+			}
+		}
 	}
 }
 
 // AutoWireBeans 对所有 Bean 进行依赖注入和属性绑定
 func (ctx *defaultSpringContext) AutoWireBeans() {
 
-	if ctx.autoWired {
+	if ctx.wired {
 		panic(errors.New("AutoWireBeans already called"))
 	}
+	ctx.wired = true
+	ctx.startTime = ctx.now()
 
-	// 注册所有的 Method Bean
-	ctx.registerMethodBeans()
-
-	ctx.autoWired = true
-
-	ctx.resolveConfigers()
-	ctx.resolveBeans()
+	ctx.resolve()
+	ctx.checkBeanVersions()
+	ctx.checkPrimaryBeans()
+	ctx.checkDependsOn()
+	ctx.checkImmutableBeans()
 
 	assembly := newDefaultBeanAssembly(ctx)
 
@@ -612,10 +922,160 @@ func (ctx *defaultSpringContext) AutoWireBeans() {
 		}
 	}()
 
+	ctx.resolveProcessors(assembly)
 	ctx.runConfigers(assembly)
 	ctx.wireBeans(assembly)
+	ctx.subscribeObservers()
 
 	ctx.sortDestroyers()
+	ctx.startSessionExpiry()
+	ctx.startAutoRefresh()
+
+	ctx.started = true
+}
+
+// checkPrimaryBeans 检查同一类型 (包括导出的接口) 在同一个命名空间内是否只有一个
+// 主版本，否则即使没有注入点使用该类型也会 panic。
+func (ctx *defaultSpringContext) checkPrimaryBeans() {
+	for t, item := range ctx.beanCacheByType {
+
+		primaryBeans := make(map[string][]string)
+		for _, bd := range item.beans {
+			if bd.primary && bd.status != beanStatus_Deleted {
+				primaryBeans[bd.namespace] = append(primaryBeans[bd.namespace], bd.Description())
+			}
+		}
+
+		for _, beans := range primaryBeans {
+			if len(beans) > 1 {
+				panic(fmt.Errorf("found %d primary beans, type: %s [%s]", len(beans), t, strings.Join(beans, ", ")))
+			}
+		}
+	}
+}
+
+// resolve 注册成员方法 Bean 并对所有的 Bean 和 Config 函数进行决议，只执行一次
+func (ctx *defaultSpringContext) resolve() {
+
+	if ctx.autoWired {
+		return
+	}
+
+	// 注册所有的 Method Bean
+	ctx.registerMethodBeans()
+
+	// 计算自定义的单例缓存键
+	ctx.resolveSingletonKeys()
+
+	ctx.autoWired = true
+
+	ctx.resolveParentBeans()
+	ctx.resolveConfigers()
+	ctx.resolveBeans()
+}
+
+// checkDependsOn 检查通过 DependsOn 设置的间接依赖项是否存在循环，存在时 panic。
+// 结构体 Bean 的循环注入是允许的，但是间接依赖项表示明确的初始化顺序，不能循环。
+// 依赖的 Bean 不存在时跳过，由注入过程报告错误。
+func (ctx *defaultSpringContext) checkDependsOn() {
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+
+	state := make(map[*BeanDefinition]int)
+	var path []*BeanDefinition
+
+	var visit func(bd *BeanDefinition)
+	visit = func(bd *BeanDefinition) {
+
+		switch state[bd] {
+		case visited:
+			return
+		case visiting:
+			var ids []string
+			for i := len(path) - 1; i >= 0; i-- {
+				ids = append([]string{path[i].BeanId()}, ids...)
+				if path[i] == bd {
+					break
+				}
+			}
+			ids = append(ids, bd.BeanId())
+			panic(fmt.Errorf("found circular depends on: %s", strings.Join(ids, " -> ")))
+		}
+
+		state[bd] = visiting
+		path = append(path, bd)
+
+		for _, selector := range bd.dependsOn {
+			if _, ok := selector.(*namespacedSelector); !ok {
+				selector = InNamespace(bd.namespace, selector)
+			}
+			if b, ok := ctx.FindBean(selector); ok {
+				visit(b)
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[bd] = visited
+	}
+
+	for _, bd := range ctx.allBeans {
+		if bd.status != beanStatus_Deleted {
+			visit(bd)
+		}
+	}
+}
+
+// checkBeanVersions 检查 Bean 依赖的其他 Bean 的版本是否满足要求，依赖的 Bean
+// 不存在时跳过，由注入过程报告错误。
+func (ctx *defaultSpringContext) checkBeanVersions() {
+	for _, bd := range ctx.beanMap {
+		for _, r := range bd.requires {
+			b, ok := ctx.FindBean(InNamespace(bd.namespace, r.beanName))
+			if !ok {
+				continue
+			}
+			if b.version == "" || compareVersion(b.version, r.minVersion) < 0 {
+				panic(fmt.Errorf("bean: \"%s\" requires bean: \"%s\" version >= %s but got \"%s\"",
+					bd.BeanId(), b.BeanId(), r.minVersion, b.version))
+			}
+		}
+	}
+}
+
+// compareVersion 按照点号分隔的数字逐段比较版本号，忽略 v 前缀，缺少的段视为 0，
+// 不是数字的段按照字符串比较。
+func compareVersion(a, b string) int {
+
+	as := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bs := strings.Split(strings.TrimPrefix(b, "v"), ".")
+
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		x, y := "0", "0"
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+		if x == y {
+			continue
+		}
+		m, err1 := strconv.Atoi(x)
+		n, err2 := strconv.Atoi(y)
+		if err1 != nil || err2 != nil {
+			return strings.Compare(x, y)
+		}
+		if m < n {
+			return -1
+		}
+		if m > n {
+			return 1
+		}
+	}
+	return 0
 }
 
 // WireBean 对外部的 Bean 进行依赖注入和属性绑定
@@ -644,11 +1104,55 @@ func (ctx *defaultSpringContext) GetBeanDefinitions() []*BeanDefinition {
 	return result
 }
 
+// GetAllBeans 按注册顺序获取所有注册过的 Bean 的定义，包括不满足条件而被删除
+// 的 Bean，可以通过 Matched 判断是否满足条件，不包括设置了 ExcludeFromScan 的 Bean。
+func (ctx *defaultSpringContext) GetAllBeans() []*BeanDefinition {
+	result := make([]*BeanDefinition, 0, len(ctx.allBeans))
+	for _, bd := range ctx.allBeans {
+		if !bd.excluded {
+			result = append(result, bd)
+		}
+	}
+	return result
+}
+
+// resolveParentBeans 在容器刷新时查找通过 ParentBean 设置的父 Bean，父 Bean 必须和
+// 子 Bean 在同一个命名空间内，父 Bean 不存在或者存在循环继承时 panic。不满足条件的
+// Bean 也可以作为父 Bean，所以在所有注册过的 Bean 中查找。
+func (ctx *defaultSpringContext) resolveParentBeans() {
+
+	for _, bd := range ctx.allBeans {
+		if bd.parent == "" {
+			continue
+		}
+		for _, c := range ctx.allBeans {
+			if c.name == bd.parent && c.namespace == bd.namespace {
+				bd.parentBd = c
+				break
+			}
+		}
+		if bd.parentBd == nil {
+			panic(fmt.Errorf("can't find parent bean %q of %s", bd.parent, bd.Description()))
+		}
+	}
+
+	for _, bd := range ctx.allBeans {
+		seen := map[*BeanDefinition]bool{bd: true}
+		for b := bd.parentBd; b != nil; b = b.parentBd {
+			if seen[b] {
+				panic(fmt.Errorf("found circular parent bean %q of %s", bd.parent, bd.Description()))
+			}
+			seen[b] = true
+		}
+	}
+}
+
 // Close 关闭容器上下文，用于通知 Bean 销毁等，该函数可以确保 Bean 的销毁顺序和注入顺序相反。
 func (ctx *defaultSpringContext) Close(beforeDestroy ...func()) {
 
-	// 上下文结束
+	// 上下文结束，唤醒等待原型 Bean 实例的调用者
 	ctx.cancel()
+	ctx.wakePrototypeWaiters()
 
 	// 调用 destroy 之前的钩子函数
 	for _, f := range beforeDestroy {
@@ -662,15 +1166,66 @@ func (ctx *defaultSpringContext) Close(beforeDestroy ...func()) {
 
 	assembly := newDefaultBeanAssembly(ctx)
 
+	// 会话作用域、原型作用域、goroutine 作用域 Bean 以及设置了存活时间或者缓存键的 Bean 可能依赖单例 Bean，所以先销毁
+	ctx.destroySessions(assembly)
+	ctx.expiring.destroyBeans(assembly)
+	ctx.destroyMemoized(assembly)
+	ctx.destroyPrototypes(assembly)
+	ctx.destroyGoroutineBeans(assembly)
+
+	// 销毁函数的 context.Context 参数不能使用已经结束的容器上下文
+	assembly.callCtx = context.Background()
+
 	// 按照顺序执行销毁函数
-	for i := ctx.destroyers.Front(); i != nil; i = i.Next() {
-		d := i.Value.(*destroyer)
-		if err := d.bean.destroy.run(assembly); err != nil {
+	for _, d := range ctx.sortedDestroyers() {
+		if d.bean.timeout > 0 {
+			ctx.destroyWithTimeout(d.bean)
+		} else if err := d.bean.currentDestroy().run(assembly); err != nil {
 			SpringLogger.Error(err)
 		}
+		d.bean.destroyRetired(assembly)
 	}
 }
 
+// destroyWithTimeout 执行设置了超时时间的销毁函数，超时之后不再等待
+func (ctx *defaultSpringContext) destroyWithTimeout(bd *BeanDefinition) {
+
+	c, cancel := context.WithTimeout(context.Background(), bd.timeout)
+	defer cancel()
+
+	// 超时之后销毁函数可能仍在运行，所以使用单独的 assembly
+	assembly := newDefaultBeanAssembly(ctx)
+	assembly.callCtx = c
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				done <- fmt.Errorf("%v", err)
+			}
+		}()
+		done <- bd.currentDestroy().run(assembly)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			SpringLogger.Error(err)
+		}
+	case <-c.Done():
+		SpringLogger.Errorf("bean: \"%s\" destroy timeout after %v", bd.BeanId(), bd.timeout)
+	}
+}
+
+// GracefulStopWindow 返回按顺序销毁所有 Bean 最多需要的时间，即所有 Bean 的超时时间之和
+func (ctx *defaultSpringContext) GracefulStopWindow() time.Duration {
+	var window time.Duration
+	for _, d := range ctx.sortedDestroyers() {
+		window += d.bean.timeout
+	}
+	return window
+}
+
 // Run 根据条件判断是否立即执行一个一次性的任务
 func (ctx *defaultSpringContext) Run(fn interface{}, tags ...string) *Runner {
 	ctx.checkAutoWired()
@@ -706,6 +1261,8 @@ func (ctx *defaultSpringContext) SafeGoroutine(fn GoFunc) {
 			}
 		}()
 
+		defer ctx.ReleaseGoroutineBeans()
+
 		fn()
 	}()
 }
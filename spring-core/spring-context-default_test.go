@@ -18,14 +18,18 @@ package SpringCore_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"image"
+	"os"
 	"reflect"
+	"runtime"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -926,6 +930,33 @@ func TestDefaultSpringContext_DependsOn(t *testing.T) {
 		ctx.RegisterBean(new(BeanFour)).DependsOn(dependsOn...)
 		ctx.AutoWireBeans()
 	})
+
+	var order []string
+	register := func(ctx SpringCore.SpringContext, name string) *SpringCore.BeanDefinition {
+		return ctx.RegisterNameBean(name, new(BeanFour)).Init(func(_ *BeanFour) {
+			order = append(order, name)
+		})
+	}
+
+	t.Run("init order", func(t *testing.T) {
+		order = nil
+		ctx := SpringCore.NewDefaultSpringContext()
+		register(ctx, "repository").DependsOn("migration")
+		register(ctx, "migration").DependsOn("schema")
+		register(ctx, "schema")
+		ctx.AutoWireBeans()
+		assert.Equal(t, order, []string{"schema", "migration", "repository"})
+	})
+
+	t.Run("circular", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		register(ctx, "repository").DependsOn("migration")
+		register(ctx, "migration").DependsOn("schema")
+		register(ctx, "schema").DependsOn("repository")
+		assert.Panic(t, func() {
+			ctx.AutoWireBeans()
+		}, "found circular depends on: .*repository.* -> .*migration.* -> .*schema.* -> .*repository")
+	})
 }
 
 func TestDefaultSpringContext_Primary(t *testing.T) {
@@ -978,6 +1009,21 @@ func TestDefaultSpringContext_Primary(t *testing.T) {
 		ctx.GetBean(&b)
 		assert.Equal(t, b.One.Zero.Int, 6)
 	})
+
+	t.Run("duplicate primary", func(t *testing.T) {
+
+		assert.Panic(t, func() {
+			ctx := SpringCore.NewDefaultSpringContext()
+			ctx.RegisterNameBean("zero_5", &BeanZero{5}).Primary(true)
+			ctx.RegisterNameBean("zero_6", &BeanZero{6}).Primary(true)
+			ctx.AutoWireBeans()
+		}, "found 2 primary beans, type: \\*SpringCore_test.BeanZero \\[.*zero_[56].*, .*zero_[56].*\\]")
+
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterNameBean("zero_5", &BeanZero{5}).Primary(true)
+		ctx.RegisterNameBean("zero_6", &BeanZero{6}).Primary(true).Namespace("tenant")
+		ctx.AutoWireBeans()
+	})
 }
 
 type FuncObj struct {
@@ -1049,6 +1095,26 @@ func TestDefaultSpringContext_ConditionOnMissingBean(t *testing.T) {
 	}
 }
 
+func TestDefaultSpringContext_OnlyIf(t *testing.T) {
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.SetProperty("zero.enabled", true)
+
+	ctx.RegisterBean(&BeanZero{5}).OnlyIf(func(ctx SpringCore.SpringContext) bool {
+		return ctx.GetBoolProperty("zero.enabled")
+	})
+	ctx.RegisterBean(new(BeanOne)).OnlyIf(func(ctx SpringCore.SpringContext) bool {
+		return ctx.GetBoolProperty("one.enabled")
+	})
+	ctx.AutoWireBeans()
+
+	var zero *BeanZero
+	assert.Equal(t, ctx.GetBean(&zero), true)
+
+	var one *BeanOne
+	assert.Equal(t, ctx.GetBean(&one), false)
+}
+
 type Manager interface {
 	Cluster() string
 }
@@ -1441,6 +1507,46 @@ func TestDefaultSpringContext_CollectBeans(t *testing.T) {
 	assert.Equal(t, rcs[0].Endpoints, "redis://127.0.0.1:6379")
 }
 
+type OrderedPlugin interface {
+	PluginName() string
+}
+
+type namedPlugin struct {
+	name string
+}
+
+func (p *namedPlugin) PluginName() string {
+	return p.name
+}
+
+type PluginHost struct {
+	Plugins []OrderedPlugin `autowire:"[]"`
+}
+
+func TestDefaultSpringContext_Ordered(t *testing.T) {
+
+	for i := 0; i < 20; i++ { // 容器内部的缓存是无序的，多次测试保证结果稳定
+
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterNameBean("a", &namedPlugin{"a"}).Export((*OrderedPlugin)(nil))
+		ctx.RegisterNameBean("b", &namedPlugin{"b"}).Export((*OrderedPlugin)(nil)).Ordered(20)
+		ctx.RegisterNameBean("c", &namedPlugin{"c"}).Export((*OrderedPlugin)(nil))
+		ctx.RegisterNameBean("d", &namedPlugin{"d"}).Export((*OrderedPlugin)(nil)).Ordered(-5)
+		ctx.RegisterNameBean("e", &namedPlugin{"e"}).Export((*OrderedPlugin)(nil)).Ordered(100)
+		ctx.RegisterBean(new(PluginHost))
+		ctx.AutoWireBeans()
+
+		var host *PluginHost
+		ctx.GetBean(&host)
+
+		var names []string
+		for _, p := range host.Plugins {
+			names = append(names, p.PluginName())
+		}
+		assert.Equal(t, names, []string{"d", "b", "e", "a", "c"})
+	}
+}
+
 func TestDefaultSpringContext_WireSliceBean(t *testing.T) {
 
 	ctx := SpringCore.NewDefaultSpringContext()
@@ -2923,6 +3029,18 @@ func TestDefaultSpringContext_AutoExport(t *testing.T) {
 		assert.Equal(t, b, s)
 	})
 
+	t.Run("export own type", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterNameBeanFn("s", func() fmt.Stringer {
+			return &AppContext{}
+		}).Export((*fmt.Stringer)(nil))
+		ctx.AutoWireBeans()
+
+		var s fmt.Stringer
+		ok := ctx.GetBean(&s, "s")
+		assert.Equal(t, true, ok)
+	})
+
 	t.Run("unexported but auto match", func(t *testing.T) {
 		b := &AppContext{Context: context.TODO()}
 		ctx := SpringCore.NewDefaultSpringContext()
@@ -3078,3 +3196,3305 @@ func TestDefaultSpringContext_Destroy(t *testing.T) {
 
 	assert.Equal(t, destroyArray, []int{1, 2, 2, 4})
 }
+
+type WeakRefHolder struct {
+	Ref SpringCore.WeakReference
+}
+
+func TestDefaultSpringContext_WeakReference(t *testing.T) {
+
+	t.Run("present", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterNameBean("b", new(ThirdDestroy))
+		ctx.RegisterBeanFn(func(ref SpringCore.WeakReference) *WeakRefHolder {
+			return &WeakRefHolder{ref}
+		}, "b")
+		ctx.AutoWireBeans()
+
+		var h *WeakRefHolder
+		assert.Equal(t, ctx.GetBean(&h), true)
+		assert.Equal(t, h.Ref.IsPresent(), true)
+		_, ok := h.Ref.Get().(*ThirdDestroy)
+		assert.Equal(t, ok, true)
+	})
+
+	t.Run("absent", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterNameBean("b", new(ThirdDestroy)).ConditionOnProperty("b.enable")
+		ctx.RegisterBeanFn(func(ref SpringCore.WeakReference) *WeakRefHolder {
+			return &WeakRefHolder{ref}
+		}, "b")
+		ctx.AutoWireBeans()
+
+		var h *WeakRefHolder
+		assert.Equal(t, ctx.GetBean(&h), true)
+		assert.Equal(t, h.Ref.IsPresent(), false)
+		assert.Equal(t, h.Ref.Get(), nil)
+	})
+
+	t.Run("not created yet", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterNameBeanFn("b", func() *ThirdDestroy { return new(ThirdDestroy) })
+		ctx.RegisterBeanFn(func(ref SpringCore.WeakReference) *WeakRefHolder {
+			_, ok := ref.Get().(*ThirdDestroy) // 存在的目标 Bean 先完成注入
+			assert.Equal(t, ok, true)
+			return &WeakRefHolder{ref}
+		}, "b")
+		ctx.AutoWireBeans()
+
+		var h *WeakRefHolder
+		assert.Equal(t, ctx.GetBean(&h), true)
+		assert.Equal(t, h.Ref.IsPresent(), true)
+		_, ok := h.Ref.Get().(*ThirdDestroy)
+		assert.Equal(t, ok, true)
+	})
+
+	t.Run("type selector", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBeanFn(func() *ThirdDestroy { return new(ThirdDestroy) })
+		ctx.RegisterBeanFn(func(ref SpringCore.WeakReference) *WeakRefHolder {
+			return &WeakRefHolder{ref}
+		}, SpringCore.WeakRef((*ThirdDestroy)(nil)))
+		ctx.AutoWireBeans()
+
+		var h *WeakRefHolder
+		assert.Equal(t, ctx.GetBean(&h), true)
+		_, ok := h.Ref.Get().(*ThirdDestroy)
+		assert.Equal(t, ok, true)
+		assert.Equal(t, SpringCore.WeakRef("b"), "b")
+	})
+
+	t.Run("no selector", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBeanFn(func(ref SpringCore.WeakReference) *WeakRefHolder {
+			return &WeakRefHolder{ref}
+		})
+		assert.Panic(t, func() {
+			ctx.AutoWireBeans()
+		}, "weak reference must have a selector")
+	})
+}
+
+type StructArgEmbedded struct {
+	Port int `value:"${server.port:=8080}"`
+}
+
+type StructArgNested struct {
+	Name string `value:"${name}"`
+}
+
+type StructArg struct {
+	StructArgEmbedded
+
+	Host   string          `value:"${server.host}"`
+	Nested StructArgNested `value:"${db}"`
+	Inner  StructArgNested
+}
+
+func TestFnStructBindingArg(t *testing.T) {
+
+	t.Run("untagged struct", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.SetProperty("server.host", "localhost")
+		ctx.SetProperty("db.name", "mysql")
+		ctx.SetProperty("name", "root")
+
+		var arg StructArg
+		ctx.RegisterBeanFn(func(s StructArg) *int {
+			arg = s
+			return new(int)
+		})
+		ctx.AutoWireBeans()
+
+		assert.Equal(t, arg.Port, 8080)
+		assert.Equal(t, arg.Host, "localhost")
+		assert.Equal(t, arg.Nested.Name, "mysql")
+		assert.Equal(t, arg.Inner.Name, "root")
+	})
+
+	t.Run("tagged struct", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.SetProperty("a.server.host", "127.0.0.1")
+		ctx.SetProperty("a.server.port", 9090)
+		ctx.SetProperty("a.db.name", "redis")
+		ctx.SetProperty("a.name", "admin")
+
+		var arg StructArg
+		ctx.RegisterBeanFn(func(s StructArg) *int {
+			arg = s
+			return new(int)
+		}, "${a}")
+		ctx.AutoWireBeans()
+
+		assert.Equal(t, arg.Port, 9090)
+		assert.Equal(t, arg.Host, "127.0.0.1")
+		assert.Equal(t, arg.Nested.Name, "redis")
+		assert.Equal(t, arg.Inner.Name, "admin")
+	})
+
+	t.Run("missing property", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBeanFn(func(s StructArg) *int {
+			return new(int)
+		})
+		assert.Panic(t, func() {
+			ctx.AutoWireBeans()
+		}, "properties \"server.host\" not config")
+	})
+}
+
+func withClassNameE(className string) (ClassOptionFunc, error) {
+	if className == "" {
+		return nil, errors.New("class name is empty")
+	}
+	return withClassName(className, 1), nil
+}
+
+func TestOptionConstructorArg_Error(t *testing.T) {
+
+	t.Run("return value", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.SetProperty("president", "CaiYuanPei")
+		ctx.RegisterBeanFn(NewClassRoom).Options(
+			SpringCore.NewOptionArg(withClassNameE, "${class_name:=二年级03班}"),
+		)
+		ctx.AutoWireBeans()
+
+		var cls *ClassRoom
+		ctx.GetBean(&cls)
+
+		assert.Equal(t, cls.floor, 1)
+		assert.Equal(t, cls.className, "二年级03班")
+	})
+
+	t.Run("return error", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.SetProperty("president", "CaiYuanPei")
+		ctx.RegisterBeanFn(NewClassRoom).Options(
+			SpringCore.NewOptionArg(withClassNameE, "${class_name:=}"),
+		)
+		assert.Panic(t, func() {
+			ctx.AutoWireBeans()
+		}, `option func: ".*/spring-context-default_test.go:\d+" return error: class name is empty`)
+	})
+
+	t.Run("invalid second return", func(t *testing.T) {
+		assert.Panic(t, func() {
+			SpringCore.NewOptionArg(func() (ClassOptionFunc, int) { return nil, 0 })
+		}, "option func must be func\\(...\\)option or func\\(...\\)\\(option, error\\)")
+	})
+}
+
+type RequestUser struct {
+	Id     int
+	Config *ThirdDestroy `autowire:""`
+}
+
+type RequestService struct {
+	User *RequestUser `autowire:""`
+}
+
+func TestDefaultSpringContext_RequestScoped(t *testing.T) {
+
+	t.Run("request scoped", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+
+		count := 0
+		var destroyed []int
+
+		ctx.RegisterBean(new(ThirdDestroy))
+		ctx.RegisterBeanFn(func() *RequestUser {
+			count++
+			return &RequestUser{Id: count}
+		}).RequestScoped().Destroy(func(u *RequestUser) {
+			destroyed = append(destroyed, u.Id)
+		})
+		ctx.RegisterBeanFn(func() *RequestService {
+			return new(RequestService)
+		}).RequestScoped()
+		ctx.AutoWireBeans()
+
+		assert.Equal(t, count, 0)
+
+		scope1, cancel1 := ctx.NewScope(context.Background(), SpringCore.RequestScope)
+		scope2, cancel2 := ctx.NewScope(context.Background(), SpringCore.RequestScope)
+
+		var u1, u2, u3 *RequestUser
+		assert.Equal(t, ctx.GetScopedBean(scope1, &u1), true)
+		assert.Equal(t, ctx.GetScopedBean(scope1, &u2), true)
+		assert.Equal(t, ctx.GetScopedBean(scope2, &u3), true)
+
+		assert.Equal(t, u1 == u2, true)
+		assert.Equal(t, u1 == u3, false)
+		assert.Equal(t, u1.Id, 1)
+		assert.Equal(t, u3.Id, 2)
+		assert.Equal(t, u1.Config != nil, true)
+
+		var s *RequestService
+		assert.Equal(t, ctx.GetScopedBean(scope1, &s), true)
+		assert.Equal(t, s.User == u1, true)
+
+		cancel1()
+		assert.Equal(t, destroyed, []int{1})
+		assert.Equal(t, scope1.Err(), context.Canceled)
+
+		cancel2()
+		assert.Equal(t, destroyed, []int{1, 2})
+	})
+
+	t.Run("factory", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+
+		var (
+			count     int32
+			collected int32
+		)
+
+		ctx.RegisterBean(new(ThirdDestroy))
+		ctx.RegisterNameBeanFn("user", func() *RequestUser {
+			u := &RequestUser{Id: int(atomic.AddInt32(&count, 1))}
+			runtime.SetFinalizer(u, func(*RequestUser) { atomic.AddInt32(&collected, 1) })
+			return u
+		}).Scoped(SpringCore.ScopeRequest)
+		ctx.RegisterNameBean("factory", SpringCore.NewScopedBeanFactory(ctx, "user"))
+		ctx.AutoWireBeans()
+
+		var factory *SpringCore.ScopedBeanFactory
+		ctx.GetBean(&factory)
+
+		ids := make([]int, 2)
+		var wg sync.WaitGroup
+		for i := range ids {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				scope, cancel := ctx.NewScope(context.Background(), SpringCore.ScopeRequest)
+				defer cancel()
+				var u1, u2 *RequestUser
+				factory.GetBean(scope, &u1)
+				factory.GetBean(scope, &u2)
+				if u1 == u2 {
+					ids[i] = u1.Id
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		assert.Equal(t, ids[0] != 0 && ids[1] != 0, true)
+		assert.Equal(t, ids[0] != ids[1], true)
+
+		for i := 0; i < 50 && atomic.LoadInt32(&collected) < 2; i++ {
+			runtime.GC()
+			time.Sleep(10 * time.Millisecond)
+		}
+		assert.Equal(t, atomic.LoadInt32(&collected), int32(2))
+	})
+
+	t.Run("out of scope", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBean(new(ThirdDestroy))
+		ctx.RegisterBeanFn(func() *RequestUser {
+			return new(RequestUser)
+		}).RequestScoped()
+		ctx.RegisterBean(new(RequestService))
+		assert.Panic(t, func() {
+			ctx.AutoWireBeans()
+		}, "request bean: \".*RequestUser\" can't be used out of its scope")
+	})
+
+	t.Run("object bean", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		assert.Panic(t, func() {
+			ctx.RegisterBean(new(RequestUser)).RequestScoped()
+		}, "request bean: \".*RequestUser\" must be registered by function")
+	})
+}
+
+func TestOptionArg_Priority(t *testing.T) {
+
+	var order []string
+	withOrder := func(name string) func() ClassOptionFunc {
+		return func() ClassOptionFunc {
+			order = append(order, name)
+			return func(opt *ClassOption) {}
+		}
+	}
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.SetProperty("president", "CaiYuanPei")
+	ctx.RegisterBeanFn(NewClassRoom).Options(
+		SpringCore.NewOptionArg(withOrder("a")),
+		SpringCore.NewOptionArg(withOrder("b")).Priority(1),
+		SpringCore.NewOptionArg(withOrder("c")).Priority(-1),
+		SpringCore.NewOptionArg(withOrder("d")),
+		SpringCore.NewOptionArg(withOrder("e")).Priority(-1),
+	)
+	ctx.AutoWireBeans()
+
+	assert.Equal(t, order, []string{"c", "e", "a", "d", "b"})
+}
+
+func TestDefaultSpringContext_SessionScoped(t *testing.T) {
+
+	t.Run("session scoped", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+
+		count := 0
+		var destroyed []int
+
+		ctx.RegisterBean(new(ThirdDestroy))
+		ctx.RegisterBeanFn(func() *RequestUser {
+			count++
+			return &RequestUser{Id: count}
+		}).SessionScoped().Destroy(func(u *RequestUser) {
+			destroyed = append(destroyed, u.Id)
+		})
+		ctx.AutoWireBeans()
+
+		var u1, u2, u3 *RequestUser
+		assert.Equal(t, ctx.GetScopedBean(ctx.SessionScope(context.Background(), "a"), &u1), true)
+		assert.Equal(t, ctx.GetScopedBean(ctx.SessionScope(context.Background(), "a"), &u2), true)
+		assert.Equal(t, ctx.GetScopedBean(ctx.SessionScope(context.Background(), "b"), &u3), true)
+
+		assert.Equal(t, u1 == u2, true)
+		assert.Equal(t, u1 == u3, false)
+
+		ctx.Close()
+		sort.Ints(destroyed)
+		assert.Equal(t, destroyed, []int{1, 2})
+	})
+
+	t.Run("session expiry", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+
+		count := 0
+		destroyed := make(chan int, 2)
+
+		ctx.RegisterBean(new(ThirdDestroy))
+		ctx.RegisterBeanFn(func() *RequestUser {
+			count++
+			return &RequestUser{Id: count}
+		}).SessionScoped().SessionExpiry(20 * time.Millisecond).Destroy(func(u *RequestUser) {
+			destroyed <- u.Id
+		})
+		ctx.AutoWireBeans()
+
+		var u1, u2 *RequestUser
+		assert.Equal(t, ctx.GetScopedBean(ctx.SessionScope(context.Background(), "a"), &u1), true)
+
+		select {
+		case id := <-destroyed:
+			assert.Equal(t, id, 1)
+		case <-time.After(time.Second):
+			t.Fatal("session bean not expired")
+		}
+
+		assert.Equal(t, ctx.GetScopedBean(ctx.SessionScope(context.Background(), "a"), &u2), true)
+		assert.Equal(t, u2.Id, 2)
+
+		ctx.Close()
+	})
+
+	t.Run("expiry without session scope", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		assert.Panic(t, func() {
+			ctx.RegisterBeanFn(func() *RequestUser {
+				return new(RequestUser)
+			}).SessionExpiry(time.Second)
+		}, "isn't session scoped")
+	})
+}
+
+type CacheUserService struct {
+	calls int
+
+	FindUser func(id int) string               `cache:"ttl:5m,key:user-${arg0}"`
+	FindName func(a, b string) (string, error) `cache:""`
+	Expired  func(id int) int                  `cache:"ttl:1ns"`
+}
+
+func NewCacheUserService() *CacheUserService {
+	s := &CacheUserService{}
+	s.FindUser = func(id int) string {
+		s.calls++
+		return "user-" + strconv.Itoa(id)
+	}
+	s.FindName = func(a, b string) (string, error) {
+		s.calls++
+		if a == "" {
+			return "", errors.New("empty")
+		}
+		return a + b, nil
+	}
+	s.Expired = func(id int) int {
+		s.calls++
+		return id
+	}
+	return s
+}
+
+func TestCacheResultProcessor(t *testing.T) {
+
+	t.Run("cache result", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBean(SpringCore.NewMemoryCacheProvider()).Export((*SpringCore.CacheProvider)(nil))
+		ctx.RegisterBean(new(SpringCore.CacheResultProcessor))
+		ctx.RegisterBeanFn(NewCacheUserService)
+		ctx.AutoWireBeans()
+
+		var s *CacheUserService
+		assert.Equal(t, ctx.GetBean(&s), true)
+
+		assert.Equal(t, s.FindUser(1), "user-1")
+		assert.Equal(t, s.FindUser(1), "user-1")
+		assert.Equal(t, s.calls, 1)
+		assert.Equal(t, s.FindUser(2), "user-2")
+		assert.Equal(t, s.calls, 2)
+
+		r, err := s.FindName("a", "b")
+		assert.Equal(t, r, "ab")
+		assert.Equal(t, err, nil)
+		r, err = s.FindName("a", "b")
+		assert.Equal(t, r, "ab")
+		assert.Equal(t, s.calls, 3)
+
+		// 返回 error 时不缓存
+		_, err = s.FindName("", "b")
+		assert.Equal(t, err.Error(), "empty")
+		_, err = s.FindName("", "b")
+		assert.Equal(t, err.Error(), "empty")
+		assert.Equal(t, s.calls, 5)
+
+		// 过期后重新调用
+		assert.Equal(t, s.Expired(3), 3)
+		time.Sleep(time.Millisecond)
+		assert.Equal(t, s.Expired(3), 3)
+		assert.Equal(t, s.calls, 7)
+	})
+
+	t.Run("not func", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBean(SpringCore.NewMemoryCacheProvider()).Export((*SpringCore.CacheProvider)(nil))
+		ctx.RegisterBean(new(SpringCore.CacheResultProcessor))
+		ctx.RegisterBean(&struct {
+			Name string `cache:"ttl:5m"`
+		}{})
+		assert.Panic(t, func() {
+			ctx.AutoWireBeans()
+		}, "cache field: .*\\.\\$Name must be func")
+	})
+
+	t.Run("bad ttl", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBean(SpringCore.NewMemoryCacheProvider()).Export((*SpringCore.CacheProvider)(nil))
+		ctx.RegisterBean(new(SpringCore.CacheResultProcessor))
+		ctx.RegisterBean(&struct {
+			Fn func() int `cache:"ttl:5x"`
+		}{Fn: func() int { return 0 }})
+		assert.Panic(t, func() {
+			ctx.AutoWireBeans()
+		}, "cache tag: \"ttl:5x\" error: .*")
+	})
+}
+
+type StrictDao struct{}
+
+type StrictService struct {
+	dao *StrictDao
+}
+
+func NewStrictService(dao *StrictDao) *StrictService {
+	return &StrictService{dao}
+}
+
+func TestDefaultSpringContext_StrictMode(t *testing.T) {
+
+	t.Run("unresolved", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.SetStrictMode(true)
+		assert.Equal(t, ctx.StrictMode(), true)
+		assert.Panic(t, func() {
+			ctx.RegisterBeanFn(NewStrictService)
+		}, "strict mode: bean: .* has unresolved dependencies: \\*SpringCore_test.StrictDao")
+	})
+
+	t.Run("unresolved name", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.SetStrictMode(true)
+		ctx.RegisterNameBean("a", new(StrictDao))
+		assert.Panic(t, func() {
+			ctx.RegisterBeanFn(NewStrictService, "b")
+		}, "unresolved dependencies: \\*SpringCore_test.StrictDao \"b\"")
+	})
+
+	t.Run("resolved", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.SetStrictMode(true)
+		ctx.RegisterBean(new(StrictDao))
+		ctx.RegisterBeanFn(NewStrictService)
+		ctx.RegisterBeanFn(func(s *StrictService, port int, dao *StrictDao, daos []*StrictDao) *int {
+			return &port
+		}, "", "${port:=8080}", "?", "[]")
+		ctx.AutoWireBeans()
+
+		var s *StrictService
+		assert.Equal(t, ctx.GetBean(&s), true)
+		assert.Equal(t, s.dao != nil, true)
+	})
+
+	t.Run("nullable", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.SetStrictMode(true)
+		ctx.RegisterBeanFn(NewStrictService, "?")
+	})
+
+	t.Run("context", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.SetStrictMode(true)
+		ctx.RegisterBeanFn(func(c context.Context) *StrictDao {
+			assert.Equal(t, c != nil, true)
+			return new(StrictDao)
+		})
+		ctx.AutoWireBeans()
+		assert.Panic(t, func() {
+			ctx.RegisterNameBeanFn("s", func(c context.Context) *StrictService {
+				return new(StrictService)
+			}, "ctx")
+		}, "unresolved dependencies: context.Context \"ctx\"")
+	})
+
+	t.Run("weak reference", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.SetStrictMode(true)
+		ctx.RegisterBeanFn(func(r SpringCore.WeakReference) *StrictService {
+			return new(StrictService)
+		}, "dao")
+		ctx.AutoWireBeans()
+	})
+
+	t.Run("lazy provider", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.SetStrictMode(true)
+		ctx.RegisterBeanFn(func(p SpringCore.LazyProvider) *StrictService {
+			return &StrictService{p.Get().(*StrictDao)}
+		}, "dao")
+		ctx.RegisterNameBean("dao", new(StrictDao))
+		ctx.AutoWireBeans()
+
+		var s *StrictService
+		assert.Equal(t, ctx.GetBean(&s), true)
+		assert.Equal(t, s.dao != nil, true)
+	})
+
+	t.Run("composite beans", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.SetStrictMode(true)
+		ctx.RegisterNameBean("mail", new(MemoryNotifier))
+		ctx.RegisterNameBeanFn("notifier", func(c *SpringCore.CompositeBeans) Notifier {
+			return &compositeNotifier{c}
+		}).Composite("mail")
+		ctx.AutoWireBeans()
+
+		var n Notifier
+		assert.Equal(t, ctx.GetBean(&n), true)
+		assert.Equal(t, n.Send("hello"), nil)
+	})
+
+	t.Run("not strict", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBeanFn(NewStrictService)
+		ctx.RegisterBean(new(StrictDao))
+		ctx.AutoWireBeans()
+	})
+}
+
+type DryRunConfig struct {
+	Port int `value:"${dry.port}"`
+}
+
+type DryRunService struct {
+	Dao    *StrictDao `autowire:""`
+	Config DryRunConfig
+}
+
+func TestDefaultSpringContext_DryRun(t *testing.T) {
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.SetProperty("dry.port", "abc")
+
+	called := false
+	ctx.RegisterBeanFn(func(dao *StrictDao) *StrictService {
+		called = true
+		return &StrictService{dao}
+	})
+	ctx.RegisterBean(new(StrictDao)).ConditionOnProperty("dao.enable")
+	ctx.RegisterBean(new(DryRunService))
+	ctx.RegisterBean(new(int))
+
+	reports := ctx.DryRun()
+	assert.Equal(t, called, false)
+	assert.Equal(t, len(reports), 4)
+
+	result := make(map[string]*SpringCore.BeanReport)
+	for _, r := range reports {
+		result[r.Bean.Name()] = r
+	}
+
+	r := result["*SpringCore_test.StrictDao"]
+	assert.Equal(t, r.Passed, false)
+	assert.Equal(t, r.Condition, "(property:dao.enable)")
+
+	r = result["*SpringCore_test.StrictService"]
+	assert.Equal(t, r.Passed, true)
+	assert.Equal(t, len(r.Errors), 1)
+	assert.Matches(t, r.Errors[0], "can't find bean, bean: \"\" field: tag:\"\" .* type: \\*SpringCore_test.StrictDao")
+
+	r = result["*SpringCore_test.DryRunService"]
+	assert.Equal(t, len(r.Errors), 2)
+	assert.Matches(t, r.Errors[0], "can't find bean, bean: \"\" field: SpringCore_test.DryRunService.\\$Dao .*")
+	assert.Equal(t, r.Errors[1], "property value dry.port isn't int type")
+
+	r = result["*int"]
+	assert.Equal(t, r.Passed, true)
+	assert.Equal(t, len(r.Errors), 0)
+
+	// 预检在副本上进行，之后容器仍然可以使用
+	assert.Equal(t, len(ctx.DryRun()), 4)
+
+	ctx.SetProperty("dao.enable", true)
+	ctx.SetProperty("dry.port", 8080)
+	ctx.AutoWireBeans()
+	assert.Equal(t, called, true)
+
+	assert.Panic(t, func() {
+		ctx.DryRun()
+	}, "AutoWireBeans already called")
+}
+
+func TestExportDependencyGraph(t *testing.T) {
+
+	newReports := func() []*SpringCore.BeanReport {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.SetProperty("dry.port", 8080)
+		ctx.RegisterBean(new(StrictDao))
+		ctx.RegisterBeanFn(NewStrictService).DependsOn("*SpringCore_test.StrictDao")
+		ctx.RegisterBean(new(DryRunService))
+		ctx.RegisterNameBean("skip", new(int)).ConditionOnProperty("skip.enable")
+		return ctx.DryRun()
+	}
+
+	t.Run("dot", func(t *testing.T) {
+		b, err := SpringCore.ExportDependencyGraph(newReports(), "dot")
+		assert.Equal(t, err, nil)
+		dot := string(b)
+		assert.Matches(t, dot, "^digraph beans \\{\n")
+		assert.Matches(t, dot, `"int:skip" \[label="int:skip\\n\(property:skip.enable\)", color=gray\];`)
+		assert.Matches(t, dot, `SpringCore_test.StrictDao:\*SpringCore_test.StrictDao" \[label=".*", color=green\];`)
+		assert.Matches(t, dot, `StrictService:NewStrictService" -> ".*StrictDao:\*SpringCore_test.StrictDao";`)
+		assert.Matches(t, dot, `DryRunService" -> ".*StrictDao:\*SpringCore_test.StrictDao";`)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		b, err := SpringCore.ExportDependencyGraph(newReports(), "json")
+		assert.Equal(t, err, nil)
+
+		var g struct {
+			Nodes []struct {
+				Id     string
+				Passed bool
+			}
+			Edges []struct {
+				From string
+				To   string
+			}
+		}
+		assert.Equal(t, json.Unmarshal(b, &g), nil)
+		assert.Equal(t, len(g.Nodes), 4)
+		assert.Equal(t, g.Nodes[3].Passed, false)
+		assert.Equal(t, len(g.Edges), 2)
+		assert.Matches(t, g.Edges[1].From, "StrictService:NewStrictService$")
+	})
+
+	t.Run("mermaid", func(t *testing.T) {
+		b, err := SpringCore.ExportDependencyGraph(newReports(), "mermaid")
+		assert.Equal(t, err, nil)
+		assert.Matches(t, string(b), "^graph TD\n")
+		assert.Matches(t, string(b), "n3\\[\"int:skip\"\\]:::gray")
+		assert.Matches(t, string(b), "n2 --> n1\n")
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		_, err := SpringCore.ExportDependencyGraph(nil, "svg")
+		assert.Equal(t, err.Error(), "unsupported dependency graph format: svg")
+	})
+
+	t.Run("cycles", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBean(new(GraphCycleA))
+		ctx.RegisterBean(new(GraphCycleB))
+		ctx.RegisterBean(new(StrictDao))
+		ctx.RegisterBean(new(GraphCollector))
+
+		b, err := SpringCore.ExportDependencyGraph(ctx.DryRun(), "json")
+		assert.Equal(t, err, nil)
+
+		var g struct {
+			Edges []struct {
+				From  string
+				To    string
+				Cycle bool
+			}
+			Cycles [][]string
+		}
+		assert.Equal(t, json.Unmarshal(b, &g), nil)
+		assert.Equal(t, len(g.Cycles), 1)
+		assert.Equal(t, len(g.Cycles[0]), 2)
+		assert.Matches(t, g.Cycles[0][0], "GraphCycleA$")
+		assert.Matches(t, g.Cycles[0][1], "GraphCycleB$")
+
+		cycles := 0
+		for _, e := range g.Edges {
+			if e.Cycle {
+				cycles++
+			}
+		}
+		assert.Equal(t, len(g.Edges), 3)
+		assert.Equal(t, cycles, 2)
+		assert.Matches(t, g.Edges[0].From, "GraphCollector$")
+		assert.Matches(t, g.Edges[0].To, "StrictDao$")
+
+		b, err = SpringCore.ExportDependencyGraph(ctx.DryRun(), "dot")
+		assert.Equal(t, err, nil)
+		assert.Matches(t, string(b), `GraphCycleA" -> ".*GraphCycleB" \[color=red\];`)
+		assert.Matches(t, string(b), `// cycle: .*GraphCycleA, .*GraphCycleB\n`)
+
+		b, err = SpringCore.ExportDependencyGraph(ctx.DryRun(), "mermaid")
+		assert.Equal(t, err, nil)
+		assert.Matches(t, string(b), "linkStyle 1,2 stroke:red\n")
+		assert.Matches(t, string(b), "%% cycle: .*GraphCycleA, .*GraphCycleB\n")
+	})
+}
+
+type GraphCycleA struct {
+	B *GraphCycleB `autowire:""`
+}
+
+type GraphCycleB struct {
+	A *GraphCycleA `autowire:""`
+}
+
+type GraphCollector struct {
+	Daos []*StrictDao `autowire:"[]"`
+}
+
+type CircularA struct {
+	B    *CircularB
+	Name string
+	self *CircularA
+}
+
+type CircularB struct {
+	A    **CircularA
+	Name string
+}
+
+func TestDefaultSpringContext_CircularProxy(t *testing.T) {
+
+	newA := func(b *CircularB) *CircularA {
+		a := &CircularA{B: b, Name: "a"}
+		a.self = a
+		return a
+	}
+	newB := func(a **CircularA) *CircularB { return &CircularB{A: a, Name: "b"} }
+
+	t.Run("found circle", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBeanFn(newA)
+		ctx.RegisterBeanFn(func(a *CircularA) *CircularB { return &CircularB{Name: "b"} })
+		assert.Panic(t, func() {
+			ctx.AutoWireBeans()
+		}, "found circle autowire")
+	})
+
+	t.Run("not proxy", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBeanFn(newA)
+		ctx.RegisterBeanFn(newB)
+		assert.Panic(t, func() {
+			ctx.AutoWireBeans()
+		}, "should be CircularProxy")
+	})
+
+	for _, aFirst := range []bool{true, false} {
+		t.Run(fmt.Sprintf("circular proxy a first %v", aFirst), func(t *testing.T) {
+			ctx := SpringCore.NewDefaultSpringContext()
+			if aFirst {
+				ctx.RegisterBeanFn(newA).CircularProxy()
+				ctx.RegisterBeanFn(newB)
+			} else {
+				ctx.RegisterBeanFn(newB)
+				ctx.RegisterBeanFn(newA).CircularProxy()
+			}
+			ctx.AutoWireBeans()
+
+			var a *CircularA
+			var b *CircularB
+			assert.Equal(t, ctx.GetBean(&a), true)
+			assert.Equal(t, ctx.GetBean(&b), true)
+
+			assert.Equal(t, a.B == b, true)
+			assert.Equal(t, *b.A == a, true)
+			assert.Equal(t, a.self == a, true)
+			assert.Equal(t, (*a.B.A).Name, "a")
+			assert.Equal(t, (*b.A).B.Name, "b")
+		})
+	}
+
+	t.Run("not struct pointer", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		assert.Panic(t, func() {
+			ctx.RegisterBean(new(CircularA)).CircularProxy()
+		}, "circular proxy bean: .* must be registered by function returning struct pointer")
+		assert.Panic(t, func() {
+			ctx.RegisterBeanFn(func() int { return 0 }).CircularProxy()
+		}, "circular proxy bean: .* must be registered by function returning struct pointer")
+	})
+}
+
+type StopWorker struct {
+	stopped bool
+}
+
+func TestDefaultSpringContext_GracefulStopTimeout(t *testing.T) {
+
+	t.Run("timeout context", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+
+		fast := new(StopWorker)
+		ctx.RegisterNameBean("fast", fast).Destroy(func(w *StopWorker, c context.Context) {
+			_, ok := c.Deadline()
+			w.stopped = ok && c.Err() == nil
+		}).GracefulStopTimeout(time.Second)
+
+		slowErr := make(chan error, 1)
+		ctx.RegisterNameBean("slow", new(StopWorker)).Destroy(func(w *StopWorker, c context.Context) {
+			<-c.Done()
+			slowErr <- c.Err()
+		}).GracefulStopTimeout(10 * time.Millisecond)
+
+		plain := new(StopWorker)
+		ctx.RegisterNameBean("plain", plain).Destroy(func(w *StopWorker, c context.Context) {
+			_, ok := c.Deadline()
+			w.stopped = !ok && c.Err() == nil
+		})
+
+		ctx.AutoWireBeans()
+		assert.Equal(t, ctx.GracefulStopWindow(), time.Second+10*time.Millisecond)
+
+		ctx.Close()
+		assert.Equal(t, fast.stopped, true)
+		assert.Equal(t, <-slowErr, context.DeadlineExceeded)
+		assert.Equal(t, plain.stopped, true)
+	})
+
+	t.Run("not wait", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+
+		block := make(chan struct{})
+		defer close(block)
+
+		ctx.RegisterBean(new(StopWorker)).Destroy(func(w *StopWorker) {
+			<-block
+		}).GracefulStopTimeout(10 * time.Millisecond)
+
+		ctx.AutoWireBeans()
+
+		start := time.Now()
+		ctx.Close()
+		assert.Equal(t, time.Since(start) < time.Second, true)
+	})
+
+	t.Run("invalid timeout", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		assert.Panic(t, func() {
+			ctx.RegisterBean(new(StopWorker)).GracefulStopTimeout(0)
+		}, "graceful stop timeout must be positive")
+	})
+
+	t.Run("context bean", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+
+		type ctxKey struct{}
+		bean := context.WithValue(context.Background(), ctxKey{}, "bean")
+		ctx.RegisterBeanFn(func() context.Context { return bean })
+
+		var value interface{}
+		ctx.RegisterBeanFn(func(c context.Context) *StopWorker {
+			value = c.Value(ctxKey{})
+			return new(StopWorker)
+		})
+
+		ctx.AutoWireBeans()
+		assert.Equal(t, value, "bean")
+	})
+}
+
+type TenantPool struct {
+	Tenant string
+}
+
+func TestDefaultSpringContext_SingletonKey(t *testing.T) {
+
+	register := func(ctx SpringCore.SpringContext, count *int) {
+		for _, name := range []string{"a", "b"} {
+			key := "tenant." + name
+			ctx.RegisterNameBeanFn(name, func(tenant string) *TenantPool {
+				*count++
+				return &TenantPool{Tenant: tenant}
+			}, "${"+key+"}").SingletonKey(func(ctx SpringCore.SpringContext) string {
+				return ctx.GetStringProperty(key)
+			})
+		}
+	}
+
+	t.Run("different keys", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.SetProperty("tenant.a", "t1")
+		ctx.SetProperty("tenant.b", "t2")
+
+		count := 0
+		register(ctx, &count)
+		ctx.AutoWireBeans()
+
+		var pools []*TenantPool
+		ctx.CollectBeans(&pools)
+		assert.Equal(t, len(pools), 2)
+		assert.Equal(t, count, 2)
+
+		// 缓存键不改变 Bean 的名称
+		var p *TenantPool
+		assert.Equal(t, ctx.GetBean(&p, "b"), true)
+		assert.Equal(t, p.Tenant, "t2")
+		assert.Equal(t, ctx.GetBean(&p, "t2"), false)
+	})
+
+	t.Run("same key", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.SetProperty("tenant.a", "t1")
+		ctx.SetProperty("tenant.b", "t1")
+
+		count := 0
+		register(ctx, &count)
+		ctx.AutoWireBeans()
+
+		var pools []*TenantPool
+		ctx.CollectBeans(&pools)
+		assert.Equal(t, len(pools), 1)
+		assert.Equal(t, count, 1)
+		assert.Equal(t, pools[0].Tenant, "t1")
+	})
+
+	t.Run("empty key", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		count := 0
+		register(ctx, &count)
+		assert.Panic(t, func() {
+			ctx.AutoWireBeans()
+		}, "singleton key can't be empty")
+	})
+
+	t.Run("scoped bean", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		assert.Panic(t, func() {
+			ctx.RegisterBeanFn(func() *TenantPool { return nil }).RequestScoped().SingletonKey(nil)
+		}, "request bean: .* can't have singleton key")
+	})
+}
+
+type OrderedBean struct{}
+
+func TestDefaultSpringContext_InitOrder(t *testing.T) {
+
+	var order []string
+	register := func(ctx SpringCore.SpringContext, name string) *SpringCore.BeanDefinition {
+		return ctx.RegisterNameBeanFn(name, func() *OrderedBean {
+			order = append(order, name)
+			return &OrderedBean{}
+		})
+	}
+
+	t.Run("same level", func(t *testing.T) {
+		order = nil
+		ctx := SpringCore.NewDefaultSpringContext()
+		register(ctx, "a").InitOrder(1)
+		register(ctx, "b")
+		register(ctx, "c").InitOrder(-1)
+		register(ctx, "d")
+		register(ctx, "e")
+		ctx.AutoWireBeans()
+		assert.Equal(t, order, []string{"c", "b", "d", "e", "a"})
+	})
+
+	t.Run("dependency first", func(t *testing.T) {
+		order = nil
+		ctx := SpringCore.NewDefaultSpringContext()
+		register(ctx, "a").DependsOn("b")
+		register(ctx, "b").InitOrder(10)
+		register(ctx, "c").InitOrder(5)
+		ctx.AutoWireBeans()
+		assert.Equal(t, order, []string{"b", "a", "c"})
+	})
+}
+
+type OrderListener interface {
+	OnOrder(id string)
+}
+
+type OrderSource struct {
+	listeners []OrderListener
+}
+
+func (s *OrderSource) Subscribe(l OrderListener) {
+	s.listeners = append(s.listeners, l)
+}
+
+func (s *OrderSource) Emit(id string) {
+	for _, l := range s.listeners {
+		l.OnOrder(id)
+	}
+}
+
+type OrderHandler struct {
+	orders []string
+}
+
+func (h *OrderHandler) OnOrder(id string) {
+	h.orders = append(h.orders, id)
+}
+
+func TestDefaultSpringContext_ObservedBy(t *testing.T) {
+
+	t.Run("subscribe", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterNameBean("source", new(OrderSource))
+		ctx.RegisterNameBeanFn("handler", func() *OrderHandler {
+			return new(OrderHandler)
+		}).ObservedBy("source")
+		ctx.AutoWireBeans()
+
+		var s *OrderSource
+		ctx.GetBean(&s)
+		s.Emit("1")
+
+		var h *OrderHandler
+		ctx.GetBean(&h)
+		assert.Equal(t, h.orders, []string{"1"})
+	})
+
+	t.Run("no subscribe method", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterNameBean("source", new(OrderHandler))
+		ctx.RegisterNameBean("handler", new(OrderHandler)).ObservedBy("source")
+		assert.Panic(t, func() {
+			ctx.AutoWireBeans()
+		}, "bean: .* has no Subscribe method")
+	})
+
+	t.Run("incompatible observer", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterNameBean("source", new(OrderSource))
+		ctx.RegisterNameBean("handler", new(OrderSource)).ObservedBy("source")
+		assert.Panic(t, func() {
+			ctx.AutoWireBeans()
+		}, "bean: .* can't subscribe bean: .*")
+	})
+}
+
+type PaymentChannel struct {
+	Code string
+}
+
+type PaymentRouter struct {
+	Channels map[string]*PaymentChannel `autowire:"[]"`
+	Selected map[string]*PaymentChannel `autowire:"[wechat,bank?]"`
+}
+
+func TestDefaultSpringContext_MapKey(t *testing.T) {
+
+	code := func(bean interface{}) string {
+		return bean.(*PaymentChannel).Code
+	}
+
+	t.Run("map key", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterNameBean("alipay", &PaymentChannel{Code: "ALI"}).MapKey(code)
+		ctx.RegisterNameBean("wechat", &PaymentChannel{Code: "WX"})
+		ctx.RegisterBean(new(PaymentRouter))
+		ctx.AutoWireBeans()
+
+		var r *PaymentRouter
+		ctx.GetBean(&r)
+		assert.Equal(t, len(r.Channels), 2)
+		assert.Equal(t, r.Channels["ALI"].Code, "ALI")
+		assert.Equal(t, r.Channels["wechat"].Code, "WX")
+		assert.Equal(t, len(r.Selected), 1)
+		assert.Equal(t, r.Selected["wechat"].Code, "WX")
+
+		var m map[string]*PaymentChannel
+		ctx.CollectBeans(&m)
+		assert.Equal(t, len(m), 2)
+	})
+
+	t.Run("duplicate key", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterNameBean("alipay", &PaymentChannel{Code: "ALI"}).MapKey(code)
+		ctx.RegisterNameBean("ALI", &PaymentChannel{Code: "WX"})
+		ctx.RegisterBean(new(PaymentRouter))
+		assert.Panic(t, func() {
+			ctx.AutoWireBeans()
+		}, "duplicate map key \"ALI\" field: .*")
+	})
+}
+
+type JwtKeyCache struct {
+	Version int
+}
+
+func TestDefaultSpringContext_ExpiresAfter(t *testing.T) {
+
+	t.Run("expire", func(t *testing.T) {
+		version, destroyed := 0, 0
+
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBeanFn(func() *JwtKeyCache {
+			version++
+			return &JwtKeyCache{Version: version}
+		}).ExpiresAfter(50 * time.Millisecond).Destroy(func(c *JwtKeyCache) {
+			destroyed++
+		})
+		ctx.AutoWireBeans()
+
+		var c *JwtKeyCache
+		assert.Equal(t, ctx.GetBean(&c), true)
+		assert.Equal(t, c.Version, 1)
+
+		ctx.GetBean(&c)
+		assert.Equal(t, c.Version, 1)
+		assert.Equal(t, destroyed, 0)
+
+		time.Sleep(80 * time.Millisecond)
+
+		ctx.GetBean(&c)
+		assert.Equal(t, c.Version, 2)
+		assert.Equal(t, destroyed, 1)
+
+		ctx.Close()
+		assert.Equal(t, destroyed, 2)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+
+		assert.Panic(t, func() {
+			ctx.RegisterBean(new(JwtKeyCache)).ExpiresAfter(time.Minute)
+		}, "expiring bean: .* must be registered by function")
+
+		assert.Panic(t, func() {
+			ctx.RegisterNameBeanFn("a", func() *JwtKeyCache { return nil }).ExpiresAfter(0)
+		}, "bean: .* ttl must be positive")
+
+		assert.Panic(t, func() {
+			ctx.RegisterNameBeanFn("b", func() *JwtKeyCache { return nil }).RequestScoped().ExpiresAfter(time.Minute)
+		}, "request bean: .* can't expire")
+	})
+}
+
+type BaseURLClient struct {
+	BaseURL string
+}
+
+func TestDefaultSpringContext_Memoize(t *testing.T) {
+
+	t.Run("memoize", func(t *testing.T) {
+		created, destroyed := 0, 0
+
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.SetProperty("client.url", "http://a")
+		ctx.RegisterBeanFn(func(url string) *BaseURLClient {
+			created++
+			return &BaseURLClient{BaseURL: url}
+		}, "${client.url}").Memoize(func(ctx SpringCore.SpringContext) string {
+			return ctx.GetStringProperty("client.url")
+		}).Destroy(func(c *BaseURLClient) {
+			destroyed++
+		})
+		ctx.AutoWireBeans()
+		assert.Equal(t, created, 1)
+
+		var c1, c2, c3 *BaseURLClient
+		ctx.GetBean(&c1)
+		assert.Equal(t, c1.BaseURL, "http://a")
+		assert.Equal(t, created, 1)
+
+		ctx.SetProperty("client.url", "http://b")
+		ctx.GetBean(&c2)
+		assert.Equal(t, c2.BaseURL, "http://b")
+		assert.Equal(t, created, 2)
+
+		ctx.SetProperty("client.url", "http://a")
+		ctx.GetBean(&c3)
+		assert.Equal(t, c3 == c1, true)
+		assert.Equal(t, created, 2)
+
+		ctx.Close()
+		assert.Equal(t, destroyed, 2)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+
+		assert.Panic(t, func() {
+			ctx.RegisterBean(new(BaseURLClient)).Memoize(nil)
+		}, "memoized bean: .* must be registered by function")
+
+		ctx = SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBeanFn(func() *BaseURLClient {
+			return new(BaseURLClient)
+		}).Memoize(func(ctx SpringCore.SpringContext) string {
+			return ""
+		})
+		assert.Panic(t, func() {
+			ctx.AutoWireBeans()
+		}, "bean: .* memoize key can't be empty")
+	})
+}
+
+type DbHelper struct{}
+
+type HelperRepository struct {
+	Helper *DbHelper `autowire:"?"`
+	Nested struct {
+		Helper *DbHelper `autowire:"?"`
+	}
+}
+
+type HelperController struct {
+	Helper  *DbHelper   `autowire:"?"`
+	Helpers []*DbHelper `autowire:"[]?"`
+	ByName  *DbHelper   `autowire:"helper?"`
+}
+
+func TestDefaultSpringContext_ScopedTo(t *testing.T) {
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.RegisterNameBean("helper", new(DbHelper)).ScopedTo("repo")
+	ctx.RegisterNameBean("repo", new(HelperRepository))
+	ctx.RegisterNameBean("ctrl", new(HelperController))
+	ctx.AutoWireBeans()
+
+	var repo *HelperRepository
+	ctx.GetBean(&repo)
+	assert.Equal(t, repo.Helper != nil, true)
+	assert.Equal(t, repo.Nested.Helper == repo.Helper, true)
+
+	var ctrl *HelperController
+	ctx.GetBean(&ctrl)
+	assert.Equal(t, ctrl.Helper == nil, true)
+	assert.Equal(t, len(ctrl.Helpers), 0)
+	assert.Equal(t, ctrl.ByName == repo.Helper, true)
+
+	// 不在注入过程中时总是可见
+	var helper *DbHelper
+	assert.Equal(t, ctx.GetBean(&helper), true)
+}
+
+func TestDefaultSpringContext_FailFast(t *testing.T) {
+
+	boom := func(ctx SpringCore.SpringContext) bool {
+		panic(errors.New("boom"))
+	}
+
+	t.Run("default", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterNameBean("i", new(int)).ConditionOnMatches(boom)
+		assert.Panic(t, func() {
+			ctx.AutoWireBeans()
+		}, "^boom$")
+	})
+
+	t.Run("fail fast", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterNameBean("i", new(int)).ConditionOnMatches(boom).FailFast(true)
+		assert.Panic(t, func() {
+			ctx.AutoWireBeans()
+		}, "object bean \"i\" \\(\\*int\\) .* condition: \\(.*\\) error: boom")
+	})
+}
+
+type RouteRegistrar struct {
+	Routes *[]string `autowire:""`
+	Name   string
+}
+
+func (r *RouteRegistrar) register() {
+	*r.Routes = append(*r.Routes, r.Name)
+}
+
+func TestDefaultSpringContext_Eager(t *testing.T) {
+
+	newRegistrar := func(name string) func(routes *[]string) *RouteRegistrar {
+		return func(routes *[]string) *RouteRegistrar {
+			return &RouteRegistrar{Routes: routes, Name: name}
+		}
+	}
+
+	t.Run("eager", func(t *testing.T) {
+		var routes []string
+
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBean(&routes)
+		ctx.RegisterNameBeanFn("a", newRegistrar("a")).Init((*RouteRegistrar).register).Eager(true)
+		ctx.RegisterNameBeanFn("b", newRegistrar("b")).Init((*RouteRegistrar).register)
+		ctx.AutoWireBeans()
+
+		assert.Equal(t, routes, []string{"b", "a"})
+	})
+
+	t.Run("not singleton", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		assert.Panic(t, func() {
+			ctx.RegisterNameBeanFn("a", newRegistrar("a")).RequestScoped().Eager(true)
+		}, "request bean: \".*:a\" can't be eager")
+	})
+}
+
+type TenantStore struct {
+	Tenant string
+}
+
+func TestDefaultSpringContext_TraceID(t *testing.T) {
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.SetProperty("region", "cn")
+
+	calls := 0
+	traceId := func(tenant string) func(ctx SpringCore.SpringContext) string {
+		return func(ctx SpringCore.SpringContext) string {
+			calls++
+			return ctx.GetStringProperty("region") + "/" + tenant
+		}
+	}
+
+	a := ctx.RegisterNameBean("a", &TenantStore{Tenant: "a"}).TraceID(traceId("a"))
+	b := ctx.RegisterNameBean("b", &TenantStore{Tenant: "b"}).TraceID(traceId("b"))
+	c := ctx.RegisterNameBean("c", &TenantStore{Tenant: "c"})
+	assert.Equal(t, a.InstanceID(), "")
+
+	ctx.AutoWireBeans()
+
+	var stores []*TenantStore
+	ctx.CollectBeans(&stores)
+	assert.Equal(t, len(stores), 3)
+
+	assert.Equal(t, a.InstanceID(), "cn/a")
+	assert.Equal(t, b.InstanceID(), "cn/b")
+	assert.Equal(t, c.InstanceID(), "")
+	assert.Equal(t, calls, 2)
+}
+
+type TenantCache struct {
+	Name string
+}
+
+type TenantService struct {
+	Cache *TenantCache `autowire:"?"`
+}
+
+type TenantCacheList struct {
+	Caches []*TenantCache `autowire:"[cache]"`
+}
+
+type TenantCacheMap struct {
+	Caches map[string]*TenantCache `autowire:"[cache]"`
+}
+
+func TestDefaultSpringContext_Namespace(t *testing.T) {
+
+	t.Run("same namespace", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBean(&TenantCache{"order"}).Namespace("order")
+		ctx.RegisterBean(new(TenantService)).Namespace("order")
+		ctx.AutoWireBeans()
+
+		var s *TenantService
+		assert.Equal(t, ctx.GetBean(&s), false)
+
+		bd, ok := ctx.FindBean(SpringCore.InNamespace("order", (*TenantService)(nil)))
+		assert.Equal(t, ok, true)
+		s = bd.Bean().(*TenantService)
+		assert.Equal(t, s.Cache.Name, "order")
+	})
+
+	t.Run("cross namespace", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBean(&TenantCache{"order"}).Namespace("order")
+		ctx.RegisterBean(new(TenantService)).Namespace("user")
+		ctx.AutoWireBeans()
+
+		bd, ok := ctx.FindBean(SpringCore.InNamespace("user", (*TenantService)(nil)))
+		assert.Equal(t, ok, true)
+		assert.Equal(t, bd.Bean().(*TenantService).Cache == nil, true)
+
+		_, ok = ctx.FindBean((*TenantCache)(nil))
+		assert.Equal(t, ok, false)
+	})
+
+	t.Run("both allow cross namespace", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBean(&TenantCache{"order"}).Namespace("order").CrossNamespace(true)
+		ctx.RegisterBean(new(TenantService)).Namespace("user").CrossNamespace(true)
+		ctx.AutoWireBeans()
+
+		bd, ok := ctx.FindBean(SpringCore.InNamespace("user", (*TenantService)(nil)))
+		assert.Equal(t, ok, true)
+		assert.Equal(t, bd.Bean().(*TenantService).Cache.Name, "order")
+	})
+
+	t.Run("default namespace", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBean(&TenantCache{"default"})
+		ctx.RegisterBean(new(TenantService)).Namespace("user")
+		ctx.AutoWireBeans()
+
+		bd, ok := ctx.FindBean(SpringCore.InNamespace("user", (*TenantService)(nil)))
+		assert.Equal(t, ok, true)
+		assert.Equal(t, bd.Bean().(*TenantService).Cache == nil, true)
+	})
+
+	t.Run("collect by name", func(t *testing.T) {
+
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterNameBean("cache", &TenantCache{"order"}).Namespace("order")
+		ctx.RegisterBean(new(TenantCacheList)).Namespace("order")
+		ctx.RegisterBean(new(TenantCacheMap)).Namespace("order")
+		ctx.AutoWireBeans()
+
+		bd, ok := ctx.FindBean(SpringCore.InNamespace("order", (*TenantCacheList)(nil)))
+		assert.Equal(t, ok, true)
+		assert.Equal(t, len(bd.Bean().(*TenantCacheList).Caches), 1)
+
+		for _, bean := range []interface{}{new(TenantCacheList), new(TenantCacheMap)} {
+			ctx = SpringCore.NewDefaultSpringContext()
+			ctx.RegisterNameBean("cache", &TenantCache{"order"}).Namespace("order")
+			ctx.RegisterBean(bean).Namespace("user")
+			assert.Panic(t, func() {
+				ctx.AutoWireBeans()
+			}, "can't find bean, bean: \"cache\" type: \\*SpringCore_test.TenantCache")
+		}
+	})
+}
+
+// auditLogger 记录访问审计日志的 Logger
+type auditLogger struct {
+	SpringLogger.Console
+	lines []string
+}
+
+func (l *auditLogger) Infof(format string, args ...interface{}) {
+	if msg := fmt.Sprintf(format, args...); strings.HasPrefix(msg, "audit ") {
+		l.lines = append(l.lines, msg)
+	}
+}
+
+type AuditedSecret struct{}
+
+type AuditedClient struct {
+	Secret *AuditedSecret `autowire:""`
+}
+
+func TestDefaultSpringContext_AuditTrail(t *testing.T) {
+
+	logger := &auditLogger{}
+	SpringLogger.SetLogger(logger)
+	defer SpringLogger.SetLogger(&SpringLogger.Console{})
+
+	run := func(enable bool) []string {
+		logger.lines = nil
+
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.EnableConfigAudit(enable)
+		ctx.RegisterNameBean("secret", new(AuditedSecret)).AuditTrail(true)
+		ctx.RegisterNameBean("client", new(AuditedClient))
+		ctx.RegisterNameBean("i", new(int))
+		ctx.AutoWireBeans()
+
+		var secret *AuditedSecret
+		ctx.GetBean(&secret)
+
+		var i *int
+		ctx.GetBean(&i)
+		return logger.lines
+	}
+
+	assert.Equal(t, len(run(false)), 0)
+
+	lines := run(true)
+	assert.Equal(t, len(lines), 2)
+	assert.Matches(t, lines[0], "^audit time=.* requester=client bean=secret site=.*spring-context-default_test.go:\\d+$")
+	assert.Matches(t, lines[1], "^audit time=.* requester=- bean=secret site=.*spring-context-default_test.go:\\d+$")
+}
+
+type PooledConn struct {
+	Id int
+}
+
+func TestDefaultSpringContext_MaxInstances(t *testing.T) {
+
+	newCtx := func(policy SpringCore.ExhaustedPolicy, destroyed *int) SpringCore.SpringContext {
+		ctx := SpringCore.NewDefaultSpringContext()
+		seq := 0
+		ctx.RegisterBeanFn(func() *PooledConn {
+			seq++
+			return &PooledConn{Id: seq}
+		}).PrototypeScoped().MaxInstances(2, policy).
+			Destroy(func(*PooledConn) { *destroyed++ })
+		ctx.AutoWireBeans()
+		return ctx
+	}
+
+	assert.Panic(t, func() {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBean(new(PooledConn)).MaxInstances(1)
+	}, "singleton bean: .* can't limit instances")
+
+	t.Run("error", func(t *testing.T) {
+		destroyed := 0
+		ctx := newCtx(SpringCore.ExhaustedError, &destroyed)
+
+		var a, b *PooledConn
+		ctx.GetBean(&a)
+		ctx.GetBean(&b)
+		assert.Equal(t, a.Id, 1)
+		assert.Equal(t, b.Id, 2)
+
+		assert.Panic(t, func() {
+			var c *PooledConn
+			ctx.GetBean(&c)
+		}, "reached max instances 2")
+
+		assert.Equal(t, ctx.DestroyPrototype(a), true)
+		assert.Equal(t, ctx.DestroyPrototype(a), false)
+		assert.Equal(t, destroyed, 1)
+
+		var c *PooledConn
+		ctx.GetBean(&c)
+		assert.Equal(t, c.Id, 3)
+
+		ctx.Close()
+		assert.Equal(t, destroyed, 3)
+	})
+
+	t.Run("return oldest", func(t *testing.T) {
+		destroyed := 0
+		ctx := newCtx(SpringCore.ExhaustedReturnOldest, &destroyed)
+
+		var a, b, c *PooledConn
+		ctx.GetBean(&a)
+		ctx.GetBean(&b)
+		ctx.GetBean(&c)
+		assert.Equal(t, c == a, true)
+	})
+
+	t.Run("return oldest wiring", func(t *testing.T) {
+		creating := make(chan struct{})
+		proceed := make(chan struct{})
+
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBeanFn(func() *PooledConn {
+			close(creating)
+			<-proceed
+			return &PooledConn{Id: 1}
+		}).PrototypeScoped().MaxInstances(1, SpringCore.ExhaustedReturnOldest)
+		ctx.AutoWireBeans()
+
+		ch := make(chan *PooledConn, 2)
+		get := func() {
+			var c *PooledConn
+			ctx.GetBean(&c)
+			ch <- c
+		}
+
+		go get()
+		<-creating
+		go get()
+
+		// 正在注入的实例不会被返回
+		select {
+		case <-ch:
+			t.Fatal("should wait for the wiring instance")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		close(proceed)
+		a, b := <-ch, <-ch
+		assert.Equal(t, a == b, true)
+		assert.Equal(t, a.Id, 1)
+	})
+
+	t.Run("block", func(t *testing.T) {
+		destroyed := 0
+		ctx := newCtx(SpringCore.ExhaustedBlock, &destroyed)
+
+		var a, b *PooledConn
+		ctx.GetBean(&a)
+		ctx.GetBean(&b)
+
+		ch := make(chan *PooledConn)
+		go func() {
+			var c *PooledConn
+			ctx.GetBean(&c)
+			ch <- c
+		}()
+
+		select {
+		case <-ch:
+			t.Fatal("should block")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		ctx.DestroyPrototype(b)
+		assert.Equal(t, (<-ch).Id, 3)
+	})
+
+	t.Run("close", func(t *testing.T) {
+		destroyed := 0
+		ctx := newCtx(SpringCore.ExhaustedBlock, &destroyed)
+
+		var a, b *PooledConn
+		ctx.GetBean(&a)
+		ctx.GetBean(&b)
+
+		ch := make(chan interface{})
+		ctx.SafeGoroutine(func() {
+			defer func() { ch <- recover() }()
+			var c *PooledConn
+			ctx.GetBean(&c)
+		})
+
+		time.Sleep(20 * time.Millisecond)
+		closed := make(chan struct{})
+		go func() {
+			ctx.Close()
+			close(closed)
+		}()
+
+		select {
+		case err := <-ch:
+			assert.Matches(t, fmt.Sprint(err), "spring context have been closed")
+		case <-time.After(time.Second):
+			t.Fatal("waiter should be woken by Close")
+		}
+		<-closed
+		assert.Equal(t, destroyed, 2)
+	})
+
+	t.Run("startup", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBeanFn(func() *PooledConn {
+			return new(PooledConn)
+		}).PrototypeScoped().MaxInstances(1)
+		ctx.RegisterBean(new(PooledConnUser))
+		assert.Panic(t, func() {
+			ctx.AutoWireBeans()
+		}, "reached max instances 1 during startup")
+	})
+
+	t.Run("wire failed", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		fail := true
+		ctx.RegisterBeanFn(func() (*PooledConn, error) {
+			if fail {
+				return nil, errors.New("dial failed")
+			}
+			return new(PooledConn), nil
+		}).PrototypeScoped().MaxInstances(1)
+		ctx.AutoWireBeans()
+
+		assert.Panic(t, func() {
+			var c *PooledConn
+			ctx.GetBean(&c)
+		}, "dial failed")
+
+		fail = false
+		var c *PooledConn
+		ctx.GetBean(&c)
+		assert.Equal(t, c != nil, true)
+	})
+}
+
+type PooledConnUser struct {
+	A *PooledConn `autowire:""`
+	B *PooledConn `autowire:""`
+}
+
+type VersionedApi struct{}
+
+type VersionedClient struct {
+	Api *VersionedApi `autowire:"api"`
+}
+
+func TestDefaultSpringContext_Versioned(t *testing.T) {
+
+	run := func(version, minVersion string) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		api := ctx.RegisterNameBean("api", new(VersionedApi))
+		if version != "" {
+			api.Versioned(version)
+		}
+		ctx.RegisterBean(new(VersionedClient)).RequiredBeanVersion("api", minVersion)
+		ctx.AutoWireBeans()
+
+		for _, bd := range ctx.GetBeanDefinitions() {
+			if bd.Name() == "api" {
+				assert.Equal(t, bd.Version(), version)
+			}
+		}
+	}
+
+	run("1.2.0", "1.2")
+	run("v1.10.0", "1.9.3")
+	run("2.0", "v1.99")
+
+	assert.Panic(t, func() {
+		run("1.2.0", "1.10")
+	}, "bean: .* requires bean: .*:api\" version >= 1.10 but got \"1.2.0\"")
+
+	assert.Panic(t, func() {
+		run("", "1.0")
+	}, "requires bean: .*:api\" version >= 1.0 but got \"\"")
+}
+
+type InfraMock struct{}
+
+type InfraUser struct {
+	ByType *InfraMock `autowire:""`
+	ByName *InfraMock `autowire:"mock"`
+}
+
+func TestDefaultSpringContext_ExcludeFromScan(t *testing.T) {
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.RegisterNameBean("mock", new(InfraMock)).ExcludeFromScan(true)
+	ctx.RegisterNameBean("user", new(InfraUser))
+	ctx.AutoWireBeans()
+
+	for _, bd := range ctx.GetAllBeans() {
+		assert.Equal(t, bd.Name() != "mock", true)
+	}
+	assert.Equal(t, len(ctx.GetAllBeans()), 1)
+	assert.Equal(t, len(ctx.GetBeanDefinitions()), 2)
+
+	var user *InfraUser
+	ctx.GetBean(&user)
+	assert.Equal(t, user.ByType != nil, true)
+	assert.Equal(t, user.ByType == user.ByName, true)
+
+	_, ok := ctx.FindBean("mock")
+	assert.Equal(t, ok, true)
+}
+
+type SlowConn struct{}
+
+// warnLogger 记录警告日志的 Logger，警告日志可能在其他 goroutine 中打印
+type warnLogger struct {
+	SpringLogger.Console
+	mutex sync.Mutex
+	lines []string
+}
+
+func (l *warnLogger) Warnf(format string, args ...interface{}) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func TestDefaultSpringContext_ConstructionTimeout(t *testing.T) {
+
+	newSlowConn := func() *SlowConn {
+		time.Sleep(30 * time.Millisecond)
+		return new(SlowConn)
+	}
+
+	t.Run("warn", func(t *testing.T) {
+		logger := &warnLogger{}
+		SpringLogger.SetLogger(logger)
+		defer SpringLogger.SetLogger(&SpringLogger.Console{})
+
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterNameBeanFn("a", newSlowConn).ConstructionTimeout(10 * time.Millisecond)
+		ctx.RegisterNameBeanFn("b", newSlowConn).ConstructionTimeout(10 * time.Millisecond)
+		ctx.AutoWireBeans()
+
+		var conn *SlowConn
+		assert.Equal(t, ctx.GetBean(&conn, "a"), true)
+
+		logger.mutex.Lock()
+		defer logger.mutex.Unlock()
+		assert.Equal(t, len(logger.lines), 4)
+		for _, name := range []string{"a", "b"} {
+			n := 0
+			for _, line := range logger.lines {
+				if strings.Contains(line, `SpringCore_test.SlowConn:`+name+`" construction`) {
+					n++
+				}
+			}
+			assert.Equal(t, n, 2)
+		}
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.SetConstructionTimeoutAction(SpringCore.ConstructionTimeoutPanic)
+		ctx.RegisterBeanFn(newSlowConn).ConstructionTimeout(10 * time.Millisecond)
+		assert.Panic(t, func() {
+			ctx.AutoWireBeans()
+		}, "construction took .* exceeds 10ms")
+	})
+
+	t.Run("in time", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.SetConstructionTimeoutAction(SpringCore.ConstructionTimeoutPanic)
+		ctx.RegisterBeanFn(newSlowConn).ConstructionTimeout(time.Second)
+		ctx.AutoWireBeans()
+	})
+
+	assert.Panic(t, func() {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBean(new(SlowConn)).ConstructionTimeout(time.Second)
+	}, "must be registered by function")
+}
+
+func TestDefaultSpringContext_GetAllBeans(t *testing.T) {
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.SetProperty("cache.enable", true)
+	ctx.RegisterNameBean("mock", new(InfraMock)).ExcludeFromScan(true)
+	ctx.RegisterNameBean("cache", new(TenantCache)).ConditionOnProperty("cache.enable")
+	ctx.RegisterNameBean("user", new(InfraUser)).ConditionOnMissingProperty("cache.enable")
+	ctx.RegisterNameBeanFn("conn", func() *SlowConn { return new(SlowConn) }).PrototypeScoped()
+
+	for _, bd := range ctx.GetAllBeans() {
+		assert.Equal(t, bd.Matched(), false)
+	}
+
+	ctx.AutoWireBeans()
+
+	beans := ctx.GetAllBeans()
+	assert.Equal(t, len(beans), 3)
+	assert.Equal(t, len(ctx.GetBeanDefinitions()), 3)
+
+	assert.Equal(t, beans[0].Name(), "cache")
+	assert.Equal(t, beans[0].Type(), reflect.TypeOf(new(TenantCache)))
+	assert.Equal(t, beans[0].Scope(), SpringCore.SingletonScope)
+	assert.Equal(t, beans[0].Conditional().String(), "(property:cache.enable)")
+	assert.Equal(t, beans[0].Matched(), true)
+
+	assert.Equal(t, beans[1].Name(), "user")
+	assert.Equal(t, beans[1].Matched(), false)
+
+	assert.Equal(t, beans[2].Name(), "conn")
+	assert.Equal(t, beans[2].Scope(), SpringCore.PrototypeScope)
+	assert.Equal(t, beans[2].Conditional().Empty(), true)
+	assert.Equal(t, beans[2].Matched(), true)
+}
+
+type FlagStore struct {
+	Version int
+	closed  *int32
+	done    int32
+}
+
+func (s *FlagStore) Close() {
+	atomic.AddInt32(s.closed, 1)
+	atomic.StoreInt32(&s.done, 1)
+}
+
+func (s *FlagStore) Closed() bool {
+	return atomic.LoadInt32(&s.done) == 1
+}
+
+type FlagUser struct {
+	Store *FlagStore `autowire:""`
+}
+
+type FlagWatcher struct {
+	Store SpringCore.WeakReference
+}
+
+func TestDefaultSpringContext_AutoRefresh(t *testing.T) {
+
+	var (
+		version int32
+		closed  int32
+	)
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	bd := ctx.RegisterBeanFn(func() *FlagStore {
+		return &FlagStore{Version: int(atomic.AddInt32(&version, 1)), closed: &closed}
+	}).AutoRefresh(10 * time.Millisecond).Destroy((*FlagStore).Close)
+	ctx.RegisterBean(new(FlagUser))
+	ctx.RegisterBeanFn(func(ref SpringCore.WeakReference) *FlagWatcher {
+		return &FlagWatcher{ref}
+	}, "*SpringCore_test.FlagStore")
+	ctx.AutoWireBeans()
+
+	var user *FlagUser
+	ctx.GetBean(&user)
+
+	var watcher *FlagWatcher
+	ctx.GetBean(&watcher)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		locker := bd.RefreshLocker()
+		locker.Lock()
+		v := watcher.Store.Get().(*FlagStore).Version
+		locker.Unlock()
+		if v >= 3 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// 已经注入的指针不会被原地修改，新的实例通过 WeakReference 或者 GetBean 获取
+	var store *FlagStore
+	ctx.GetBean(&store)
+	assert.Equal(t, user.Store.Version, 1)
+	assert.Equal(t, store == watcher.Store.Get().(*FlagStore), true)
+	assert.Equal(t, store.Version >= 3, true)
+
+	// 注入到 FlagUser 中的实例在容器关闭之前不会被销毁
+	assert.Equal(t, user.Store.Closed(), false)
+
+	ctx.Close()
+	assert.Equal(t, int(atomic.LoadInt32(&closed)), int(atomic.LoadInt32(&version)))
+
+	assert.Panic(t, func() {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBean(new(FlagStore)).AutoRefresh(time.Second)
+	}, "must be registered by function")
+
+	assert.Panic(t, func() {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBeanFn(func() int { return 3 }).AutoRefresh(time.Second)
+		ctx.AutoWireBeans()
+	}, "must be pointer to struct")
+}
+
+type RefreshableGreeting struct {
+	Greeting string     `value:"${greeting}"`
+	Store    *FlagStore `autowire:""`
+}
+
+type GreetingUser struct {
+	Service *RefreshableGreeting `autowire:""`
+}
+
+type RefreshCircleA struct {
+	B *RefreshCircleB `autowire:""`
+}
+
+type RefreshCircleB struct {
+	A *RefreshCircleA `autowire:""`
+}
+
+func TestDefaultSpringContext_RefreshBean(t *testing.T) {
+
+	var closed int32
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.SetProperty("greeting", "hello")
+	ctx.SetProperty("enable", true)
+	ctx.RegisterNameBean("greeting", new(RefreshableGreeting)).ConditionOnPropertyValue("enable", true)
+	ctx.RegisterBean(new(GreetingUser))
+	ctx.RegisterBean(&FlagStore{closed: &closed})
+	ctx.RegisterNameBean("a", new(RefreshCircleA))
+	ctx.RegisterNameBean("b", new(RefreshCircleB))
+	ctx.AutoWireBeans()
+
+	var user *GreetingUser
+	ctx.GetBean(&user)
+	assert.Equal(t, user.Service.Greeting, "hello")
+	store := user.Service.Store
+
+	ctx.SetProperty("greeting", "hi")
+	assert.Equal(t, ctx.RefreshBean("greeting"), nil)
+	assert.Equal(t, user.Service.Greeting, "hello")
+
+	var service *RefreshableGreeting
+	ctx.GetBean(&service, "greeting")
+	assert.Equal(t, service.Greeting, "hi")
+	assert.Equal(t, service.Store == store, true)
+
+	ctx.SetProperty("enable", false)
+	err := ctx.RefreshBean("greeting")
+	assert.Matches(t, err.Error(), "doesn't match condition")
+	ctx.GetBean(&service, "greeting")
+	assert.Equal(t, service.Greeting, "hi")
+
+	err = ctx.RefreshBean("a")
+	assert.Matches(t, err.Error(), "found circular dependency: a -> b -> a")
+
+	err = ctx.RefreshBean("none")
+	assert.Matches(t, err.Error(), "can't find bean: \"none\"")
+}
+
+type RefreshablePort struct {
+	Port int `value:"${server.port}"`
+}
+
+func TestDefaultSpringContext_RefreshProperties(t *testing.T) {
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.SetProperty("greeting", "hello")
+	ctx.SetProperty("server.port", 8080)
+	ctx.RegisterNameBean("greeting", new(RefreshableGreeting))
+	ctx.RegisterBean(&FlagStore{})
+	ctx.RegisterBean(new(RefreshablePort))
+	ctx.AutoWireBeans()
+
+	var (
+		service *RefreshableGreeting
+		port    *RefreshablePort
+	)
+
+	ctx.GetBean(&service)
+	ctx.GetBean(&port)
+
+	// 只刷新绑定了变化属性的 Bean
+	ctx.SetProperty("greeting", "hi")
+	ctx.SetProperty("server.port", 9090)
+	assert.Equal(t, ctx.RefreshProperties("greeting"), nil)
+
+	var s *RefreshableGreeting
+	ctx.GetBean(&s)
+	assert.Equal(t, s.Greeting, "hi")
+	assert.Equal(t, s.Store == service.Store, true)
+
+	var p *RefreshablePort
+	ctx.GetBean(&p)
+	assert.Equal(t, p == port, true)
+	assert.Equal(t, p.Port, 8080)
+
+	// 前缀匹配绑定的属性
+	assert.Equal(t, ctx.RefreshProperties("server"), nil)
+	ctx.GetBean(&p)
+	assert.Equal(t, p.Port, 9090)
+
+	ctx.GetBean(&service)
+	assert.Equal(t, ctx.RefreshProperties("none"), nil)
+	ctx.GetBean(&s)
+	assert.Equal(t, s == service, true)
+}
+
+func TestDefaultSpringContext_Immutable(t *testing.T) {
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.SetProperty("greeting", "hello")
+	bd := ctx.RegisterNameBean("greeting", new(RefreshableGreeting)).Immutable()
+	ctx.RegisterBean(&FlagStore{})
+	ctx.AutoWireBeans()
+	assert.Equal(t, bd.IsImmutable(), true)
+
+	var service *RefreshableGreeting
+	ctx.GetBean(&service)
+
+	ctx.SetProperty("greeting", "hi")
+	err := ctx.RefreshBean("greeting")
+	assert.Matches(t, err.Error(), "bean: .*:greeting\" is immutable")
+
+	assert.Equal(t, ctx.RefreshProperties("greeting"), nil)
+	var s *RefreshableGreeting
+	ctx.GetBean(&s)
+	assert.Equal(t, s == service, true)
+	assert.Equal(t, s.Greeting, "hello")
+
+	assert.Panic(t, func() {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBeanFn(func() *FlagStore { return new(FlagStore) }).Replaceable(true).Immutable()
+		ctx.AutoWireBeans()
+	}, "immutable bean: .* can't be refreshed or replaced")
+
+	assert.Panic(t, func() {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBeanFn(func() *FlagStore { return new(FlagStore) }).PrototypeScoped().Immutable()
+	}, "prototype bean: .* can't be immutable")
+}
+
+type Notifier interface {
+	Send(msg string) error
+	Last() (string, error)
+}
+
+type MemoryNotifier struct {
+	Fail bool
+	Sent []string
+}
+
+func (n *MemoryNotifier) Send(msg string) error {
+	if n.Fail {
+		return fmt.Errorf("send %s failed", msg)
+	}
+	n.Sent = append(n.Sent, msg)
+	return nil
+}
+
+func (n *MemoryNotifier) Last() (string, error) {
+	if n.Fail {
+		return "", errors.New("no message")
+	}
+	return n.Sent[len(n.Sent)-1], nil
+}
+
+type compositeNotifier struct {
+	c *SpringCore.CompositeBeans
+}
+
+func (n *compositeNotifier) Send(msg string) error {
+	_, err := n.c.Call("Send", msg)
+	return err
+}
+
+func (n *compositeNotifier) Last() (string, error) {
+	out, err := n.c.Call("Last")
+	return out[0].(string), err
+}
+
+func TestDefaultSpringContext_Composite(t *testing.T) {
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.RegisterNameBean("mail", new(MemoryNotifier))
+	ctx.RegisterNameBean("sms", new(MemoryNotifier))
+	ctx.RegisterNameBean("broken", &MemoryNotifier{Fail: true})
+	ctx.RegisterNameBeanFn("notifier", func(c *SpringCore.CompositeBeans) Notifier {
+		return &compositeNotifier{c}
+	}).Composite("mail", "broken", "sms")
+	ctx.AutoWireBeans()
+
+	var n Notifier
+	ctx.GetBean(&n)
+
+	err := n.Send("hello")
+	assert.Equal(t, err.Error(), "send hello failed")
+	assert.Equal(t, len(err.(SpringCore.CompositeError)), 1)
+
+	var mail, sms *MemoryNotifier
+	ctx.GetBean(&mail, "mail")
+	ctx.GetBean(&sms, "sms")
+	assert.Equal(t, mail.Sent, []string{"hello"})
+	assert.Equal(t, sms.Sent, []string{"hello"})
+
+	sms.Sent = append(sms.Sent, "world")
+	last, err := n.Last()
+	assert.Equal(t, last, "world")
+	assert.Equal(t, err.Error(), "no message")
+
+	assert.Panic(t, func() {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBeanFn(func(c *SpringCore.CompositeBeans) *compositeNotifier {
+			return &compositeNotifier{c}
+		}).Composite("mail")
+	}, "must be interface type")
+
+	assert.Panic(t, func() {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBeanFn(func() Notifier { return nil }).Composite("mail")
+	}, "constructor must have a \\*CompositeBeans argument")
+}
+
+type ValidateConfig struct {
+	Host string `value:"${validate.host}"`
+	Port int    `value:"${validate.port:=8080}"`
+}
+
+type ValidateService struct {
+	Dao    *StrictDao `autowire:""`
+	Config ValidateConfig
+}
+
+func TestDefaultSpringContext_Validate(t *testing.T) {
+
+	t.Run("errors", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBean(new(ValidateService))
+		ctx.RegisterBean(new(StrictDao)).ConditionOnProperty("dao.enable")
+
+		errs := ctx.Validate()
+		assert.Equal(t, len(errs), 2)
+		assert.Matches(t, errs[0].Error(), "ValidateService.* can't find bean, bean: \"\" field: .*\\$Dao .*")
+		assert.Matches(t, errs[1].Error(), "ValidateService.* properties \"validate.host\" not config")
+
+		assert.Panic(t, func() {
+			ctx.RegisterBean(new(StrictDao))
+		}, "bean registration have been frozen")
+	})
+
+	t.Run("passed", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.SetProperty("validate.host", "localhost")
+		ctx.RegisterBean(new(ValidateService))
+		ctx.RegisterBean(new(StrictDao))
+
+		assert.Equal(t, len(ctx.Validate()), 0)
+		ctx.AutoWireBeans()
+
+		var s *ValidateService
+		ctx.GetBean(&s)
+		assert.Equal(t, s.Config.Host, "localhost")
+		assert.Equal(t, s.Config.Port, 8080)
+
+		assert.Panic(t, func() {
+			ctx.Validate()
+		}, "AutoWireBeans already called")
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.SetProperty("validate.host", "localhost")
+		ctx.RegisterBean(new(ValidateService))
+		ctx.RegisterBean(new(StrictDao)).ConditionOnProperty("dao.enable")
+
+		assert.Equal(t, len(ctx.Validate()), 1)
+		assert.Equal(t, len(ctx.DryRun()), 2)
+		assert.Equal(t, len(ctx.Validate()), 1)
+
+		var result []*SpringCore.BeanReport
+		for _, r := range ctx.DryRun() {
+			if len(r.Errors) > 0 {
+				result = append(result, r)
+			}
+		}
+		assert.Equal(t, len(result), 1)
+		assert.Matches(t, result[0].Errors[0], "can't find bean, bean: \"\" field: .*\\$Dao .*")
+
+		assert.Panic(t, func() {
+			ctx.AutoWireBeans()
+		}, "can't find bean, bean: \"\" field: .*\\$Dao .*")
+
+		assert.Panic(t, func() {
+			ctx.DryRun()
+		}, "AutoWireBeans already called")
+	})
+}
+
+type AliasDataSource struct {
+	Url string
+}
+
+type AliasRepository struct {
+	DB      *AliasDataSource `autowire:"db"`
+	Primary *AliasDataSource `autowire:"primary-db"`
+}
+
+func TestDefaultSpringContext_Alias(t *testing.T) {
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.RegisterNameBeanFn("datasource", func() *AliasDataSource {
+		return &AliasDataSource{Url: "mysql://primary"}
+	}).Alias("db", "primary-db")
+	ctx.RegisterNameBean("replica", &AliasDataSource{Url: "mysql://replica"})
+	ctx.RegisterBean(new(AliasRepository))
+
+	assert.Panic(t, func() {
+		ctx.RegisterNameBean("db", new(StrictDao))
+	}, "alias \"db\" of bean: .* conflicts with bean: .*:datasource\"")
+
+	assert.Panic(t, func() {
+		ctx.RegisterNameBean("other", new(StrictDao)).Alias("replica")
+	}, "alias \"replica\" of bean: .*:other\" conflicts with bean: .*:replica\"")
+
+	ctx.AutoWireBeans()
+
+	var repo *AliasRepository
+	ctx.GetBean(&repo)
+	assert.Equal(t, repo.DB.Url, "mysql://primary")
+	assert.Equal(t, repo.DB == repo.Primary, true)
+
+	var ds *AliasDataSource
+	assert.Equal(t, ctx.GetBean(&ds, "primary-db"), true)
+	assert.Equal(t, ds == repo.DB, true)
+}
+
+type ReplaceableCache struct {
+	Kind   string
+	closed *[]string
+}
+
+func (c *ReplaceableCache) Close() {
+	*c.closed = append(*c.closed, c.Kind)
+}
+
+type ReplaceableUser struct {
+	Cache *ReplaceableCache `autowire:""`
+}
+
+func TestDefaultSpringContext_ReplaceBean(t *testing.T) {
+
+	var closed []string
+
+	// 替换之后仍然使用原有 Bean 的属性源
+	source := SpringCore.NewDefaultProperties()
+	source.SetProperty("cache.kind", "redis")
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.SetProperty("cache.kind", "mysql")
+	ctx.RegisterNameBeanFn("cache", func() *ReplaceableCache {
+		return &ReplaceableCache{Kind: "memory", closed: &closed}
+	}).Replaceable(true).Destroy((*ReplaceableCache).Close).WithPropertySource(source)
+	ctx.RegisterBean(new(ReplaceableUser))
+	ctx.RegisterNameBean("fixed", new(StrictDao))
+	ctx.AutoWireBeans()
+
+	var user *ReplaceableUser
+	ctx.GetBean(&user)
+	assert.Equal(t, user.Cache.Kind, "memory")
+
+	err := ctx.ReplaceBean("cache", func(kind string) *ReplaceableCache {
+		return &ReplaceableCache{Kind: kind, closed: &closed}
+	}, "${cache.kind}")
+	assert.Equal(t, err, nil)
+
+	// 已经注入的指针仍然指向旧的实例，旧的实例被引用时不会被销毁
+	assert.Equal(t, user.Cache.Kind, "memory")
+	assert.Equal(t, len(closed), 0)
+
+	var cache *ReplaceableCache
+	ctx.GetBean(&cache)
+	assert.Equal(t, cache.Kind, "redis")
+
+	// 刷新使用新的构造函数，没有被引用的实例立即销毁
+	source.SetProperty("cache.kind", "etcd")
+	assert.Equal(t, ctx.RefreshBean("cache"), nil)
+	ctx.GetBean(&cache)
+	assert.Equal(t, cache.Kind, "etcd")
+	assert.Equal(t, closed, []string{"redis"})
+
+	err = ctx.ReplaceBean("cache", func() *StrictDao { return nil })
+	assert.Matches(t, err.Error(), "can't be replaced by \\*SpringCore_test.StrictDao")
+
+	err = ctx.ReplaceBean("fixed", func() *StrictDao { return new(StrictDao) })
+	assert.Matches(t, err.Error(), "isn't replaceable")
+
+	ctx.Close()
+	assert.Equal(t, closed, []string{"redis", "etcd", "memory"})
+}
+
+type ProtoSession struct {
+	Id      int
+	Inited  bool
+	Service *ProtoService `autowire:""`
+}
+
+type ProtoService struct {
+	Session *ProtoSession `autowire:""`
+}
+
+type ProtoHandler struct {
+	A *ProtoSession `autowire:""`
+	B *ProtoSession `autowire:""`
+}
+
+type GoroutineTx struct {
+	Id int
+}
+
+func TestDefaultSpringContext_GoroutineLocal(t *testing.T) {
+
+	var (
+		mutex     sync.Mutex
+		count     int
+		destroyed []int
+	)
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.RegisterBeanFn(func() *GoroutineTx {
+		mutex.Lock()
+		defer mutex.Unlock()
+		count++
+		return &GoroutineTx{Id: count}
+	}).GoroutineLocal().Destroy(func(s *GoroutineTx) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		destroyed = append(destroyed, s.Id)
+	})
+	ctx.AutoWireBeans()
+
+	var s1, s2 *GoroutineTx
+	ctx.GetBean(&s1)
+	ctx.GetBean(&s2)
+	assert.Equal(t, s1 == s2, true)
+
+	done := make(chan *GoroutineTx)
+	go func() {
+		defer ctx.ReleaseGoroutineBeans()
+		var s *GoroutineTx
+		ctx.GetBean(&s)
+		done <- s
+	}()
+	s3 := <-done
+	assert.Equal(t, s3 != s1, true)
+
+	ctx.SafeGoroutine(func() {
+		var s *GoroutineTx
+		ctx.GetBean(&s)
+	})
+
+	ctx.Close()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	assert.Equal(t, count, 3)
+	assert.Equal(t, len(destroyed), 3)
+	assert.Equal(t, destroyed[len(destroyed)-1], s1.Id)
+
+	assert.Panic(t, func() {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.AutoWireBeans()
+		ctx.NewScope(context.Background(), SpringCore.GoroutineScope)
+	}, "can't create goroutine scope")
+}
+
+func TestDefaultSpringContext_PrototypeScoped(t *testing.T) {
+
+	t.Run("lifecycle", func(t *testing.T) {
+
+		var (
+			count     int
+			destroyed []int
+		)
+
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBeanFn(func() *ProtoSession {
+			count++
+			return &ProtoSession{Id: count}
+		}).PrototypeScoped().Init(func(s *ProtoSession) {
+			s.Inited = true
+		}).Destroy(func(s *ProtoSession) {
+			destroyed = append(destroyed, s.Id)
+		})
+		ctx.RegisterBean(new(ProtoService))
+		ctx.RegisterBean(new(ProtoHandler))
+		ctx.AutoWireBeans()
+
+		var h *ProtoHandler
+		ctx.GetBean(&h)
+		assert.Equal(t, h.A != h.B, true)
+		assert.Equal(t, h.A.Inited && h.B.Inited, true)
+		assert.Equal(t, count, 3)
+
+		assert.Equal(t, ctx.DestroyPrototype(h.A), true)
+		assert.Equal(t, destroyed, []int{h.A.Id})
+
+		ctx.Close()
+		assert.Equal(t, len(destroyed), 3)
+	})
+
+	t.Run("circular", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBeanFn(func() *ProtoSession {
+			return new(ProtoSession)
+		}).PrototypeScoped()
+		ctx.RegisterBeanFn(func() *ProtoService {
+			return new(ProtoService)
+		}).PrototypeScoped()
+		ctx.AutoWireBeans()
+
+		var s *ProtoSession
+		assert.Panic(t, func() {
+			ctx.GetBean(&s)
+		}, "found circle autowire, prototype bean: .*ProtoSession")
+	})
+}
+
+type SourceDataSource struct {
+	Url  string `value:"${db.url}"`
+	User string `value:"${db.user:=root}"`
+}
+
+func TestDefaultSpringContext_WithPropertySource(t *testing.T) {
+
+	source := SpringCore.NewDefaultProperties()
+	source.SetProperty("db.url", "mysql://replica")
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.SetProperty("db.url", "mysql://master")
+	ctx.SetProperty("db.user", "admin")
+
+	ctx.RegisterNameBean("master", new(SourceDataSource))
+	ctx.RegisterNameBeanFn("replica", func(url string) *SourceDataSource {
+		return &SourceDataSource{Url: url}
+	}, "${db.url}").WithPropertySource(source)
+	ctx.AutoWireBeans()
+
+	var master, replica *SourceDataSource
+	ctx.GetBean(&master, "master")
+	ctx.GetBean(&replica, "replica")
+
+	assert.Equal(t, master.Url, "mysql://master")
+	assert.Equal(t, replica.Url, "mysql://replica")
+	assert.Equal(t, replica.User, "admin")
+	assert.Equal(t, ctx.GetStringProperty("db.url"), "mysql://master")
+}
+
+func TestDefaultSpringContext_ParentBean(t *testing.T) {
+
+	base := SpringCore.NewDefaultProperties()
+	base.SetProperty("db.url", "mysql://master")
+	base.SetProperty("db.user", "admin")
+
+	source := SpringCore.NewDefaultProperties()
+	source.SetProperty("db.url", "mysql://replica")
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.RegisterNameBean("primary", new(SourceDataSource)).WithPropertySource(base)
+	ctx.RegisterNameBeanFn("replica", func(url string) *SourceDataSource {
+		return &SourceDataSource{Url: url}
+	}, "${db.url}").WithPropertySource(source).ParentBean("primary")
+	ctx.AutoWireBeans()
+
+	var primary, replica *SourceDataSource
+	ctx.GetBean(&primary, "primary")
+	ctx.GetBean(&replica, "replica")
+
+	assert.Equal(t, primary.Url, "mysql://master")
+	assert.Equal(t, replica.Url, "mysql://replica")
+	assert.Equal(t, replica.User, "admin")
+
+	t.Run("not found", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterNameBean("replica", new(SourceDataSource)).ParentBean("primary")
+		assert.Panic(t, func() {
+			ctx.AutoWireBeans()
+		}, "can't find parent bean \"primary\"")
+	})
+
+	t.Run("circular", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterNameBean("a", new(SourceDataSource)).ParentBean("b")
+		ctx.RegisterNameBean("b", new(SourceDataSource)).ParentBean("a")
+		assert.Panic(t, func() {
+			ctx.AutoWireBeans()
+		}, "found circular parent bean")
+
+		// 子 Bean 不在循环之中
+		ctx = SpringCore.NewDefaultSpringContext()
+		ctx.RegisterNameBean("a", new(SourceDataSource)).ParentBean("b")
+		ctx.RegisterNameBean("b", new(SourceDataSource)).ParentBean("c")
+		ctx.RegisterNameBean("c", new(SourceDataSource)).ParentBean("b")
+		assert.Panic(t, func() {
+			ctx.AutoWireBeans()
+		}, "found circular parent bean")
+	})
+
+	t.Run("validate at refresh", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterNameBeanFn("replica", func() *SourceDataSource {
+			return new(SourceDataSource)
+		}).Lazy().ParentBean("primary")
+		assert.Panic(t, func() {
+			ctx.AutoWireBeans()
+		}, "can't find parent bean \"primary\"")
+	})
+
+	t.Run("namespace", func(t *testing.T) {
+
+		other := SpringCore.NewDefaultProperties()
+		other.SetProperty("db.url", "mysql://other")
+
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterNameBean("primary", new(InfraMock)).WithPropertySource(other).Namespace("other")
+		ctx.RegisterNameBean("primary", new(SourceDataSource)).WithPropertySource(base).Namespace("db")
+		ctx.RegisterNameBean("replica", new(SourceDataSource)).ParentBean("primary").Namespace("db")
+		ctx.AutoWireBeans()
+
+		bd, ok := ctx.FindBean(SpringCore.InNamespace("db", "replica"))
+		assert.Equal(t, ok, true)
+		assert.Equal(t, bd.Bean().(*SourceDataSource).Url, "mysql://master")
+
+		ctx = SpringCore.NewDefaultSpringContext()
+		ctx.RegisterNameBean("primary", new(SourceDataSource)).WithPropertySource(base).Namespace("other")
+		ctx.RegisterNameBean("replica", new(SourceDataSource)).ParentBean("primary")
+		assert.Panic(t, func() {
+			ctx.AutoWireBeans()
+		}, "can't find parent bean \"primary\"")
+	})
+
+	t.Run("env prefix", func(t *testing.T) {
+
+		_ = os.Setenv("PRIMARY_DB_USER", "env-user")
+		defer os.Unsetenv("PRIMARY_DB_USER")
+
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterNameBean("primary", new(SourceDataSource)).WithPropertySource(base).BindEnvironment("primary")
+		ctx.RegisterNameBean("replica", new(SourceDataSource)).WithPropertySource(source).ParentBean("primary")
+		ctx.AutoWireBeans()
+
+		// 继承父 Bean 的环境变量，自己的属性源优先
+		var replica *SourceDataSource
+		ctx.GetBean(&replica, "replica")
+		assert.Equal(t, replica.Url, "mysql://replica")
+		assert.Equal(t, replica.User, "env-user")
+	})
+}
+
+type RetryClient struct {
+	Attempts int
+}
+
+func TestDefaultSpringContext_MaxRetryAttempts(t *testing.T) {
+
+	newContext := func(failures int) SpringCore.SpringContext {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBeanFn(func() *RetryClient {
+			return new(RetryClient)
+		}).Init(func(c *RetryClient) error {
+			if c.Attempts++; c.Attempts <= failures {
+				return errors.New("connection refused")
+			}
+			return nil
+		}).MaxRetryAttempts(3).InitRetryBackoff(time.Millisecond)
+		return ctx
+	}
+
+	t.Run("success", func(t *testing.T) {
+		ctx := newContext(2)
+		ctx.AutoWireBeans()
+
+		var c *RetryClient
+		ctx.GetBean(&c)
+		assert.Equal(t, c.Attempts, 3)
+	})
+
+	t.Run("exhausted", func(t *testing.T) {
+		ctx := newContext(3)
+		assert.Panic(t, func() {
+			ctx.AutoWireBeans()
+		}, "connection refused")
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		c := new(RetryClient)
+		ctx.RegisterBeanFn(func() *RetryClient {
+			return c
+		}).Init(func(c *RetryClient) error {
+			c.Attempts++
+			return errors.New("connection refused")
+		}).MaxRetryAttempts(3).InitRetryBackoff(time.Hour)
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			ctx.Close()
+		}()
+
+		assert.Panic(t, func() {
+			ctx.AutoWireBeans()
+		}, "connection refused")
+		assert.Equal(t, c.Attempts, 1)
+	})
+
+	assert.Panic(t, func() {
+		SpringCore.ToBeanDefinition("", new(RetryClient)).MaxRetryAttempts(0)
+	}, "max retry attempts must be positive")
+}
+
+type LazyPool struct {
+	Destroyed bool
+}
+
+func TestDefaultSpringContext_Lazy(t *testing.T) {
+
+	var count int32
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.RegisterBeanFn(func() *LazyPool {
+		atomic.AddInt32(&count, 1)
+		time.Sleep(10 * time.Millisecond)
+		return new(LazyPool)
+	}).Lazy().Destroy(func(p *LazyPool) {
+		p.Destroyed = true
+	})
+	ctx.AutoWireBeans()
+	assert.Equal(t, atomic.LoadInt32(&count), int32(0))
+
+	var wg sync.WaitGroup
+	pools := make([]*LazyPool, 10)
+	for i := range pools {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ctx.GetBean(&pools[i])
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, atomic.LoadInt32(&count), int32(1))
+	for _, p := range pools {
+		assert.Equal(t, p, pools[0])
+	}
+
+	ctx.Close()
+	assert.Equal(t, pools[0].Destroyed, true)
+
+	assert.Panic(t, func() {
+		SpringCore.FnToBeanDefinition("", func() *LazyPool {
+			return new(LazyPool)
+		}).PrototypeScoped().Lazy()
+	}, "can't be lazy")
+}
+
+type LazyPoolUser struct {
+	Pool SpringCore.LazyProvider `autowire:"*SpringCore_test.LazyPool"`
+	None SpringCore.LazyProvider `autowire:"none?"`
+}
+
+type EagerPoolUser struct {
+	Pool *LazyPool `autowire:""`
+}
+
+func TestDefaultSpringContext_LazyProvider(t *testing.T) {
+
+	var count int32
+	newLazyPool := func() *LazyPool {
+		atomic.AddInt32(&count, 1)
+		return new(LazyPool)
+	}
+
+	t.Run("provider", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBeanFn(newLazyPool).Lazy()
+		ctx.RegisterBean(new(LazyPoolUser))
+		ctx.RegisterNameBeanFn("service", func(p SpringCore.LazyProvider) *int {
+			assert.Equal(t, p.IsPresent(), true)
+			return new(int)
+		}, "*SpringCore_test.LazyPool")
+		ctx.AutoWireBeans()
+		assert.Equal(t, atomic.LoadInt32(&count), int32(0))
+
+		var user *LazyPoolUser
+		ctx.GetBean(&user)
+		assert.Equal(t, user.None.IsPresent(), false)
+		assert.Equal(t, user.None.Get(), nil)
+
+		pool := user.Pool.Get().(*LazyPool)
+		assert.Equal(t, atomic.LoadInt32(&count), int32(1))
+		assert.Equal(t, user.Pool.Get(), pool)
+		assert.Equal(t, atomic.LoadInt32(&count), int32(1))
+	})
+
+	t.Run("eager", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBeanFn(newLazyPool).Lazy()
+		ctx.RegisterBean(new(EagerPoolUser))
+		assert.Panic(t, func() {
+			ctx.AutoWireBeans()
+		}, "lazy bean: .* can't be injected into bean: .* at startup, use SpringCore.LazyProvider instead")
+	})
+
+	t.Run("destroy", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		for i := 0; i < 10; i++ {
+			ctx.RegisterNameBeanFn(fmt.Sprintf("pool-%d", i), newLazyPool).Lazy().
+				Destroy(func(p *LazyPool) { p.Destroyed = true })
+		}
+		ctx.AutoWireBeans()
+
+		var wg sync.WaitGroup
+		pools := make([]*LazyPool, 10)
+		for i := range pools {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				ctx.GetBean(&pools[i], fmt.Sprintf("pool-%d", i))
+			}(i)
+		}
+		wg.Wait()
+
+		ctx.Close()
+		for _, p := range pools {
+			assert.Equal(t, p.Destroyed, true)
+		}
+	})
+}
+
+type FeatureService struct{}
+
+func TestDefaultSpringContext_ForceConditionRecheck(t *testing.T) {
+
+	var enabled int32 = 1
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.RegisterBean(new(FeatureService)).ConditionOnMatches(func(ctx SpringCore.SpringContext) bool {
+		return atomic.LoadInt32(&enabled) == 1
+	}).ForceConditionRecheck(true)
+	ctx.AutoWireBeans()
+
+	var s *FeatureService
+	assert.Equal(t, ctx.GetBean(&s), true)
+
+	atomic.StoreInt32(&enabled, 0)
+	s = nil
+	assert.Equal(t, ctx.GetBean(&s), false)
+	assert.Equal(t, s == nil, true)
+
+	var services []*FeatureService
+	ctx.CollectBeans(&services)
+	assert.Equal(t, len(services), 0)
+
+	_, ok := ctx.FindBean("*SpringCore_test.FeatureService")
+	assert.Equal(t, ok, false)
+
+	atomic.StoreInt32(&enabled, 1)
+	assert.Equal(t, ctx.GetBean(&s), true)
+
+	_, ok = ctx.FindBean("*SpringCore_test.FeatureService")
+	assert.Equal(t, ok, true)
+
+	// 启动时不满足条件的 Bean 保留为候选 Bean，条件满足之后才创建
+	t.Run("deferred", func(t *testing.T) {
+
+		var enabled int32
+
+		ctx := SpringCore.NewDefaultSpringContext()
+		bd := ctx.RegisterBean(new(FeatureService)).ConditionOnMatches(func(ctx SpringCore.SpringContext) bool {
+			return atomic.LoadInt32(&enabled) == 1
+		}).ForceConditionRecheck(true)
+		ctx.AutoWireBeans()
+
+		var s *FeatureService
+		assert.Equal(t, ctx.GetBean(&s), false)
+		assert.Equal(t, bd.Matched(), false)
+
+		atomic.StoreInt32(&enabled, 1)
+		assert.Equal(t, ctx.GetBean(&s), true)
+		assert.Equal(t, bd.Matched(), true)
+	})
+}
+
+type InterceptedGreeter struct {
+	Prefix string
+	Greet  func(names ...string) string
+}
+
+func NewInterceptedGreeter(prefix string) *InterceptedGreeter {
+	g := &InterceptedGreeter{Prefix: prefix}
+	g.Greet = func(names ...string) string {
+		return g.Prefix + " " + strings.Join(names, ",")
+	}
+	return g
+}
+
+func TestDefaultSpringContext_Intercepted(t *testing.T) {
+
+	var calls []string
+
+	logging := SpringCore.MethodInterceptorFunc(func(inv *SpringCore.MethodInvocation) []reflect.Value {
+		calls = append(calls, inv.Method)
+		return inv.Proceed()
+	})
+
+	upper := SpringCore.MethodInterceptorFunc(func(inv *SpringCore.MethodInvocation) []reflect.Value {
+		out := inv.Proceed()
+		return []reflect.Value{reflect.ValueOf(strings.ToUpper(out[0].String()))}
+	})
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.RegisterNameBeanFn("intercepted", NewInterceptedGreeter, "${greeting.hello:=hello}").Intercepted(logging, upper)
+	ctx.RegisterNameBeanFn("plain", NewInterceptedGreeter, "${greeting.hi:=hi}")
+	ctx.AutoWireBeans()
+
+	var intercepted, plain *InterceptedGreeter
+	ctx.GetBean(&intercepted, "intercepted")
+	ctx.GetBean(&plain, "plain")
+
+	assert.Equal(t, intercepted.Greet("a", "b"), "HELLO A,B")
+	assert.Equal(t, plain.Greet("a"), "hi a")
+	assert.Equal(t, calls, []string{"Greet"})
+
+	assert.Panic(t, func() {
+		SpringCore.ToBeanDefinition("", new(int)).Intercepted(logging)
+	}, "must be pointer to struct")
+
+	assert.Panic(t, func() {
+		SpringCore.ToBeanDefinition("", new(FlagStore)).Intercepted(logging)
+	}, "has no func field to intercept")
+}
+
+type SelfGreeting interface {
+	Hello(name string) string
+	Welcome(name string) string
+}
+
+type SelfGreeter struct {
+	self  SelfGreeting `inject:"self"`
+	Greet func(name string) string
+}
+
+func NewSelfGreeter() *SelfGreeter {
+	return &SelfGreeter{Greet: func(name string) string { return "hello " + name }}
+}
+
+func (g *SelfGreeter) Hello(name string) string {
+	return g.Greet(name)
+}
+
+func (g *SelfGreeter) Welcome(name string) string {
+	return g.self.Hello(name) + "!"
+}
+
+func TestDefaultSpringContext_SelfReferencing(t *testing.T) {
+
+	var calls []string
+	logging := SpringCore.MethodInterceptorFunc(func(inv *SpringCore.MethodInvocation) []reflect.Value {
+		calls = append(calls, inv.Method)
+		return inv.Proceed()
+	})
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.RegisterBeanFn(NewSelfGreeter).Intercepted(logging).SelfReferencing(true)
+	ctx.AutoWireBeans()
+
+	var g *SelfGreeter
+	ctx.GetBean(&g)
+	assert.Equal(t, g.Welcome("a"), "hello a!")
+	assert.Equal(t, calls, []string{"Greet"})
+
+	// 刷新之后 self 字段指向新的实例
+	assert.Equal(t, ctx.RefreshBean("NewSelfGreeter"), nil)
+	var fresh *SelfGreeter
+	ctx.GetBean(&fresh)
+	assert.Equal(t, fresh != g, true)
+	assert.Equal(t, fresh.self == SelfGreeting(fresh), true)
+
+	type MismatchedSelf struct {
+		self fmt.Stringer `inject:"self"`
+	}
+
+	type MissingSelf struct {
+		self struct {
+			Hello func() string
+		} `inject:"self"`
+	}
+
+	assert.Panic(t, func() {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBean(new(MissingSelf)).SelfReferencing(true)
+		ctx.AutoWireBeans()
+	}, "has no method Hello func\\(\\) string for self field")
+
+	assert.Panic(t, func() {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBean(new(MismatchedSelf)).SelfReferencing(true)
+		ctx.AutoWireBeans()
+	}, "can't be assigned to self field")
+}
+
+type SelfProxyGreeter struct {
+	self struct {
+		Hello func(name string) string
+	} `inject:"self"`
+	Name string `value:"${greeter.name}"`
+}
+
+func (g *SelfProxyGreeter) Hello(name string) string {
+	return g.Name + " " + name
+}
+
+func (g *SelfProxyGreeter) Welcome(name string) string {
+	return g.self.Hello(name) + "!"
+}
+
+func TestDefaultSpringContext_SelfProxy(t *testing.T) {
+
+	var calls []string
+	logging := SpringCore.MethodInterceptorFunc(func(inv *SpringCore.MethodInvocation) []reflect.Value {
+		calls = append(calls, inv.Method)
+		return inv.Proceed()
+	})
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.SetProperty("greeter.name", "hello")
+	ctx.RegisterNameBean("greeter", new(SelfProxyGreeter)).Intercepted(logging).SelfReferencing(true)
+	ctx.AutoWireBeans()
+
+	var g *SelfProxyGreeter
+	ctx.GetBean(&g)
+	assert.Equal(t, g.Welcome("a"), "hello a!")
+	assert.Equal(t, calls, []string{"Hello"})
+
+	// 刷新之后代理绑定到新的实例上
+	ctx.SetProperty("greeter.name", "hi")
+	assert.Equal(t, ctx.RefreshBean("greeter"), nil)
+	ctx.GetBean(&g)
+	assert.Equal(t, g.Welcome("b"), "hi b!")
+	assert.Equal(t, calls, []string{"Hello", "Hello"})
+}
+
+type RepositoryBean struct {
+	_ struct{} `table:"users"`
+}
+
+func TestDefaultSpringContext_StaticValidate(t *testing.T) {
+
+	tableName := func(bd *SpringCore.BeanDefinition) error {
+		f, ok := bd.Type().Elem().FieldByName("_")
+		if !ok || f.Tag.Get("table") == "" {
+			return errors.New("table name can't be empty")
+		}
+		return nil
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBean(new(RepositoryBean)).StaticValidate(tableName)
+		ctx.AutoWireBeans()
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBean(new(FeatureService)).StaticValidate(tableName)
+		assert.Panic(t, func() {
+			ctx.AutoWireBeans()
+		}, `spring-context-default_test.go:\d+ validate error: table name can't be empty`)
+	})
+
+	// 校验在决议时执行，能够看到 StaticValidate 之后的设置
+	versioned := func(bd *SpringCore.BeanDefinition) error {
+		if bd.Version() == "" {
+			return errors.New("version can't be empty")
+		}
+		return nil
+	}
+
+	t.Run("after setters", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBean(new(RepositoryBean)).StaticValidate(versioned).Versioned("1.0.0")
+		ctx.AutoWireBeans()
+
+		ctx = SpringCore.NewDefaultSpringContext()
+		ctx.RegisterBean(new(RepositoryBean)).StaticValidate(versioned)
+		assert.Panic(t, func() {
+			ctx.DryRun()
+		}, "validate error: version can't be empty")
+	})
+}
+
+// prefixCipher 测试用的解密算法，密文形如 key:明文
+type prefixCipher struct{}
+
+func (prefixCipher) Decrypt(key string, cipherText string) (string, error) {
+	if !strings.HasPrefix(cipherText, key+":") {
+		return "", errors.New("wrong key")
+	}
+	return strings.TrimPrefix(cipherText, key+":"), nil
+}
+
+type TenantDataSource struct {
+	Password string            `value:"${db.password}"`
+	Options  map[string]string `value:"${db.options}"`
+}
+
+func TestDefaultSpringContext_WithSecretKey(t *testing.T) {
+
+	SpringCore.RegisterCipher(prefixCipher{})
+	defer SpringCore.RegisterCipher(nil)
+
+	wireTenant := func() *SpringCore.BeanDefinition {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.SetProperty(SpringCore.SecretKeyProperty, "global")
+		ctx.SetProperty("db.password", "ENC(tenant:secret)")
+		ctx.SetProperty("db.options.token", "ENC(tenant:token)")
+		ctx.SetProperty("db.options.mode", "plain")
+		ctx.SetProperty("app.password", "ENC(global:admin)")
+		ctx.RegisterNameBean("tenant", new(TenantDataSource)).WithSecretKey("tenant")
+		ctx.RegisterBeanFn(func(password string) *FeatureService {
+			assert.Equal(t, password, "admin")
+			return new(FeatureService)
+		}, "${app.password}")
+		ctx.AutoWireBeans()
+		bd, _ := ctx.FindBean("tenant")
+		return bd
+	}
+
+	ds := wireTenant().Bean().(*TenantDataSource)
+	assert.Equal(t, ds.Password, "secret")
+	assert.Equal(t, ds.Options, map[string]string{"token": "token", "mode": "plain"})
+
+	t.Run("parent bean", func(t *testing.T) {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.SetProperty(SpringCore.SecretKeyProperty, "global")
+		ctx.SetProperty("db.password", "ENC(tenant:secret)")
+		ctx.SetProperty("db.options.mode", "plain")
+		ctx.RegisterNameBean("primary", new(StrictDao))
+		ctx.RegisterNameBean("tenant", new(TenantDataSource)).ParentBean("primary").WithSecretKey("tenant")
+		ctx.AutoWireBeans()
+
+		// 父 Bean 的属性值列表不会先用全局密钥解密一次
+		bd, _ := ctx.FindBean("tenant")
+		assert.Equal(t, bd.Bean().(*TenantDataSource).Password, "secret")
+	})
+
+	assert.Panic(t, func() {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.SetProperty(SpringCore.SecretKeyProperty, "global")
+		ctx.SetProperty("db.password", "ENC(tenant:secret)")
+		ctx.RegisterBean(new(TenantDataSource))
+		ctx.AutoWireBeans()
+	}, `decrypt property "db.password" error: wrong key`)
+}
+
+type ValidatedServerConfig struct {
+	Host    string `value:"${host:=}" validate:"required"`
+	Port    int    `value:"${port:=0}" validate:"min=1,max=65535"`
+	Admin   string `value:"${admin:=}" validate:"omitempty,email"`
+	Website string `value:"${website:=}" validate:"omitempty,url"`
+}
+
+type ValidatedServer struct {
+	Config ValidatedServerConfig `value:"${server}"`
+}
+
+func TestDefaultSpringContext_WithValidation(t *testing.T) {
+
+	newContext := func(validate bool, props map[string]interface{}) SpringCore.SpringContext {
+		ctx := SpringCore.NewDefaultSpringContext()
+		for k, v := range props {
+			ctx.SetProperty(k, v)
+		}
+		ctx.RegisterBean(new(ValidatedServer)).WithValidation(validate)
+		return ctx
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		ctx := newContext(true, map[string]interface{}{
+			"server.host":    "localhost",
+			"server.port":    8080,
+			"server.admin":   "admin@example.com",
+			"server.website": "https://example.com",
+		})
+		ctx.AutoWireBeans()
+
+		var s *ValidatedServer
+		ctx.GetBean(&s)
+		assert.Equal(t, s.Config.Port, 8080)
+	})
+
+	t.Run("required and range", func(t *testing.T) {
+		ctx := newContext(true, map[string]interface{}{
+			"server.port": 70000,
+		})
+		assert.Panic(t, func() {
+			ctx.AutoWireBeans()
+		}, `properties "server" validate error: ValidatedServerConfig.Host failed on required; ValidatedServerConfig.Port failed on max=65535`)
+	})
+
+	t.Run("email and url", func(t *testing.T) {
+		ctx := newContext(true, map[string]interface{}{
+			"server.host":    "localhost",
+			"server.port":    8080,
+			"server.admin":   "admin",
+			"server.website": "example",
+		})
+		assert.Panic(t, func() {
+			ctx.AutoWireBeans()
+		}, `ValidatedServerConfig.Admin failed on email; ValidatedServerConfig.Website failed on url`)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		ctx := newContext(false, map[string]interface{}{
+			"server.port": 70000,
+		})
+		ctx.AutoWireBeans()
+	})
+}
+
+type EnvDataSourceConfig struct {
+	Host    string `value:"${host}"`
+	MaxIdle int    `value:"${maxIdle:=2}"`
+}
+
+type EnvDataSource struct {
+	Config EnvDataSourceConfig `value:"${db}"`
+	Url    string              `value:"${db.url:=}"`
+}
+
+func TestDefaultSpringContext_BindEnvironment(t *testing.T) {
+
+	os.Setenv("APP_DB_HOST", "env-host")
+	os.Setenv("APP_DB_MAX_IDLE", "8")
+	defer os.Unsetenv("APP_DB_HOST")
+	defer os.Unsetenv("APP_DB_MAX_IDLE")
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.SetProperty("db.host", "file-host")
+	ctx.SetProperty("db.url", "mysql://file")
+	ctx.RegisterNameBean("env", new(EnvDataSource)).BindEnvironment("app")
+	ctx.RegisterNameBean("file", new(EnvDataSource))
+	ctx.AutoWireBeans()
+
+	var env, file *EnvDataSource
+	ctx.GetBean(&env, "env")
+	ctx.GetBean(&file, "file")
+
+	assert.Equal(t, env.Config.Host, "env-host")
+	assert.Equal(t, env.Config.MaxIdle, 8)
+	assert.Equal(t, env.Url, "mysql://file")
+
+	assert.Equal(t, file.Config.Host, "file-host")
+	assert.Equal(t, file.Config.MaxIdle, 2)
+}
+
+type EnvServer struct {
+	Name    string
+	Port    int
+	Options map[string]string `value:"${options:=}"`
+	Config  struct {
+		Timeout time.Duration
+	}
+	Labels map[string]string
+}
+
+func TestDefaultSpringContext_BindEnvironmentFields(t *testing.T) {
+
+	os.Setenv("SRV_NAME", "env-server")
+	os.Setenv("SRV_PORT", "9090")
+	os.Setenv("SRV_OPTIONS_TIMEOUT", "5s")
+	os.Setenv("SRV_CONFIG_TIMEOUT", "3s")
+	os.Setenv("SRV_LABELS_ZONE", "cn")
+	defer os.Unsetenv("SRV_NAME")
+	defer os.Unsetenv("SRV_PORT")
+	defer os.Unsetenv("SRV_OPTIONS_TIMEOUT")
+	defer os.Unsetenv("SRV_CONFIG_TIMEOUT")
+	defer os.Unsetenv("SRV_LABELS_ZONE")
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.SetProperty("options.retry", "3")
+	ctx.RegisterNameBean("env", &EnvServer{Port: 8080}).BindEnvironment("srv")
+	ctx.RegisterNameBean("file", &EnvServer{Port: 8080})
+	ctx.AutoWireBeans()
+
+	var env, file *EnvServer
+	ctx.GetBean(&env, "env")
+	ctx.GetBean(&file, "file")
+
+	assert.Equal(t, env.Name, "env-server")
+	assert.Equal(t, env.Port, 9090)
+	assert.Equal(t, env.Options, map[string]string{"retry": "3", "timeout": "5s"})
+	assert.Equal(t, env.Config.Timeout, 3*time.Second)
+	assert.Equal(t, env.Labels, map[string]string{"zone": "cn"})
+
+	assert.Equal(t, file.Name, "")
+	assert.Equal(t, file.Port, 8080)
+	assert.Equal(t, file.Options, map[string]string{"retry": "3"})
+	assert.Equal(t, file.Config.Timeout, time.Duration(0))
+	assert.Equal(t, len(file.Labels), 0)
+}
@@ -18,6 +18,7 @@ package SpringCore
 
 import (
 	"context"
+	"time"
 )
 
 type GoFunc func()
@@ -34,13 +35,19 @@ type SpringContext interface {
 	// 属性值列表接口
 	Properties
 
+	// PropertiesVersion 返回属性值的版本号，每次设置或者刷新属性值之后版本号都会增加
+	PropertiesVersion() uint64
+
 	// Context 返回上下文接口
 	Context() context.Context
 
-	// GetProfile 返回运行环境
+	// GetProfile 返回第一个激活的运行环境
 	GetProfile() string
 
-	// SetProfile 设置运行环境
+	// GetProfiles 返回激活的运行环境列表
+	GetProfiles() []string
+
+	// SetProfile 设置运行环境，多个运行环境使用逗号分隔
 	SetProfile(profile string)
 
 	// AllAccess 返回是否允许访问私有字段
@@ -49,6 +56,26 @@ type SpringContext interface {
 	// SetAllAccess 设置是否允许访问私有字段
 	SetAllAccess(allAccess bool)
 
+	// StrictMode 返回是否启用严格模式
+	StrictMode() bool
+
+	// SetStrictMode 设置是否启用严格模式，严格模式下注册构造函数 Bean 时
+	// 会检查其依赖的 Bean 是否已经注册，未注册时立即 panic。
+	SetStrictMode(strict bool)
+
+	// ConfigAudit 返回是否启用了访问审计
+	ConfigAudit() bool
+
+	// EnableConfigAudit 设置是否启用访问审计，启用后设置了 AuditTrail 的 Bean
+	// 在每次被获取时都会记录访问日志。
+	EnableConfigAudit(enable bool)
+
+	// SetConstructionTimeoutAction 设置构造函数超时之后的处理方式，默认只打印警告日志
+	SetConstructionTimeoutAction(action ConstructionTimeoutAction)
+
+	// Uptime 返回从容器刷新开始到现在经过的时间，容器刷新之前返回 0
+	Uptime() time.Duration
+
 	// RegisterBean 注册单例 Bean，不指定名称，重复注册会 panic。
 	RegisterBean(bean interface{}) *BeanDefinition
 
@@ -82,6 +109,20 @@ type SpringContext interface {
 	// AutoWireBeans 对所有 Bean 进行依赖注入和属性绑定
 	AutoWireBeans()
 
+	// GracefulStopWindow 返回按顺序销毁所有 Bean 最多需要的时间
+	GracefulStopWindow() time.Duration
+
+	// DryRun 对所有 Bean 进行预检，不会调用构造函数和初始化函数，预检之后仍然可以调用
+	// AutoWireBeans，没有决议过的容器在副本上进行预检。
+	DryRun() []*BeanReport
+
+	// Copy 返回容器的副本，复制属性值和注册信息，只能在 AutoWireBeans 之前调用。
+	Copy() SpringContext
+
+	// Validate 在 AutoWireBeans 之前检查所有满足条件的 Bean 的依赖和属性绑定，返回发现
+	// 的所有错误，不会调用构造函数和初始化函数，检查之后不能再注册 Bean。
+	Validate() []error
+
 	// WireBean 对外部的 Bean 进行依赖注入和属性绑定
 	WireBean(i interface{})
 
@@ -89,6 +130,26 @@ type SpringContext interface {
 	// 它和 FindBean 的区别是它在调用后能够保证返回的 Bean 已经完成了注入和绑定过程。
 	GetBean(i interface{}, selector ...BeanSelector) bool
 
+	// NewScope 创建一个作用域子容器并绑定到 parent 上，返回的 CancelFunc 用于在作用域结束时销毁其中的 Bean。
+	NewScope(parent context.Context, scope Scope) (context.Context, context.CancelFunc)
+
+	// SessionScope 获取会话 ID 对应的会话作用域子容器，不存在时创建，并将其绑定到 parent 上。
+	SessionScope(parent context.Context, sessionId string) context.Context
+
+	// DestroySession 销毁会话 ID 对应的会话作用域子容器中的 Bean，找到会话返回 true 否则返回 false。
+	DestroySession(sessionId string) bool
+
+	// GetScopedBean 获取作用域 Bean，若多于 1 个则 panic；找到返回 true 否则返回 false。
+	// 作用域 Bean 在第一次获取时创建，此后在同一作用域内返回同一个实例。
+	GetScopedBean(scopeCtx context.Context, i interface{}, selector ...BeanSelector) bool
+
+	// DestroyPrototype 销毁原型 Bean 的实例，找到实例返回 true 否则返回 false。
+	DestroyPrototype(bean interface{}) bool
+
+	// ReleaseGoroutineBeans 销毁当前 goroutine 内的 goroutine 作用域 Bean 的实例，
+	// 不是通过 SafeGoroutine 启动的 goroutine 需要在开始时 defer 调用。
+	ReleaseGoroutineBeans()
+
 	// FindBean 查询单例 Bean，若多于 1 个则 panic；找到返回 true 否则返回 false。
 	// 它和 GetBean 的区别是它在调用后不能保证返回的 Bean 已经完成了注入和绑定过程。
 	FindBean(selector BeanSelector) (*BeanDefinition, bool)
@@ -98,13 +159,30 @@ type SpringContext interface {
 	// 这时候不仅会收集符合条件的单例 Bean，还会收集符合条件的数组 Bean (是指数组的元素
 	// 符合条件，然后把数组元素拆开一个个放到收集结果里面)。指定模式是指 selectors 参数
 	// 不为空，这时候只会收集单例 Bean，而且要求这些单例 Bean 不仅需要满足收集条件，而且
-	// 必须满足 selector 条件。另外，自动模式下不对收集结果进行排序，指定模式下根据
-	// selectors 列表的顺序对收集结果进行排序。
+	// 必须满足 selector 条件。另外，自动模式下单例 Bean 按照 Ordered 设置的位置排序，
+	// 指定模式下根据 selectors 列表的顺序对收集结果进行排序。
 	CollectBeans(i interface{}, selectors ...BeanSelector) bool
 
-	// GetBeanDefinitions 获取所有 Bean 的定义，不能保证解析和注入，请谨慎使用该函数!
+	// GetBeanDefinitions 获取所有 Bean 的定义，包括设置了 ExcludeFromScan 的 Bean，
+	// 不能保证解析和注入，请谨慎使用该函数!
 	GetBeanDefinitions() []*BeanDefinition
 
+	// RefreshBean 重新初始化指定名称的单例 Bean，用于属性值变化之后不重启容器而更新 Bean，
+	// Bean 必须是结构体指针并且不能处于循环依赖之中。
+	RefreshBean(name string) error
+
+	// RefreshProperties 重新初始化绑定了指定属性的所有单例 Bean，用于属性值变化之后
+	// 只刷新受到影响的 Bean。
+	RefreshProperties(keys ...string) error
+
+	// ReplaceBean 使用新的构造函数替换设置了 Replaceable 的单例 Bean，新的构造函数必须
+	// 返回相同的类型，新的实例通过 WeakReference 或者 GetBean 获取。
+	ReplaceBean(name string, fn interface{}, tags ...string) error
+
+	// GetAllBeans 按注册顺序获取所有注册过的 Bean 的定义，包括不满足条件而被删除
+	// 的 Bean，可以通过 Matched 判断是否满足条件，不包括设置了 ExcludeFromScan 的 Bean。
+	GetAllBeans() []*BeanDefinition
+
 	// Close 关闭容器上下文，用于通知 Bean 销毁等。
 	// 该函数可以确保 Bean 的销毁顺序和注入顺序相反。
 	Close(beforeDestroy ...func())
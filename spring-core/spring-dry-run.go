@@ -0,0 +1,360 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package SpringCore
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// BeanReport 预检时单个 Bean 的检查结果
+type BeanReport struct {
+	Bean      *BeanDefinition
+	Condition string            // 判断条件的描述
+	Passed    bool              // 是否满足判断条件，不满足的 Bean 不会被创建
+	Errors    []string          // 依赖注入和属性绑定的错误
+	Depends   []*BeanDefinition // 依赖的 Bean，包括收集模式收集到的 Bean
+}
+
+// Copy 返回容器的副本，副本复制了属性值、容器的设置以及 Bean 和 Config 函数的注册
+// 信息，之后对副本的修改不会影响原容器。只能在 AutoWireBeans 之前调用。
+func (ctx *defaultSpringContext) Copy() SpringContext {
+
+	if ctx.autoWired {
+		panic(errors.New("AutoWireBeans already called"))
+	}
+
+	c := NewDefaultSpringContext()
+	for key, value := range ctx.GetProperties() {
+		c.SetProperty(key, value)
+	}
+
+	c.profiles = append([]string(nil), ctx.profiles...)
+	c.allAccess = ctx.allAccess
+	c.strict = ctx.strict
+	c.audit = ctx.audit
+	c.ctorAction = ctx.ctorAction
+	c.clock = ctx.clock
+
+	beans := make(map[*BeanDefinition]*BeanDefinition)
+	copyBean := func(bd *BeanDefinition) *BeanDefinition {
+		b := *bd
+		b.refreshMu = new(sync.RWMutex)
+		b.current = new(atomic.Value)
+		b.exports = make(map[reflect.Type]struct{})
+		for t := range bd.exports {
+			b.exports[t] = struct{}{}
+		}
+		beans[bd] = &b
+		return &b
+	}
+
+	for _, bd := range ctx.allBeans {
+		c.registerBeanDefinition(copyBean(bd))
+	}
+
+	for _, bd := range ctx.methodBeans {
+		c.methodBeans = append(c.methodBeans, copyBean(bd))
+	}
+
+	// 使用 Bean 作为选择器的成员方法 Bean 需要指向副本中的 Bean
+	for _, bd := range c.methodBeans {
+		bean := *bd.bean.(*fakeMethodBean)
+		if b, ok := bean.selector.(*BeanDefinition); ok {
+			bean.selector = beans[b]
+		}
+		bd.bean = &bean
+	}
+
+	for e := ctx.configers.Front(); e != nil; e = e.Next() {
+		c.configers.PushBack(e.Value)
+	}
+	return c
+}
+
+// DryRun 对所有 Bean 进行预检，包括判断条件、依赖关系和属性绑定，但是不会调用
+// 构造函数和初始化函数，因此 Option 参数也不会被检查。还没有决议的容器在副本上进行
+// 预检，报告中的 Bean 是副本中的 Bean；已经通过 Validate 决议过的容器直接进行预检。
+// 预检之后容器仍然可以调用 AutoWireBeans。
+func (ctx *defaultSpringContext) DryRun() []*BeanReport {
+
+	if ctx.wired {
+		panic(errors.New("AutoWireBeans already called"))
+	}
+
+	if ctx.autoWired {
+		return ctx.dryRun()
+	}
+	return ctx.Copy().(*defaultSpringContext).dryRun()
+}
+
+// dryRun 对容器中所有注册过的 Bean 进行决议和检查，返回按照 BeanId 排序的检查结果
+func (ctx *defaultSpringContext) dryRun() []*BeanReport {
+
+	assembly := ctx.resolveForCheck()
+
+	// 不满足条件的 Bean 会从 beanMap 中删除，所以使用 allBeans
+	beans := make([]*BeanDefinition, len(ctx.allBeans))
+	copy(beans, ctx.allBeans)
+
+	sort.Slice(beans, func(i, j int) bool {
+		return beans[i].BeanId() < beans[j].BeanId()
+	})
+
+	result := make([]*BeanReport, 0, len(beans))
+	for _, bd := range beans {
+		r := &BeanReport{
+			Bean:      bd,
+			Condition: bd.cond.String(),
+			Passed:    bd.status != beanStatus_Deleted,
+		}
+		if r.Passed {
+			assembly.validated = nil
+			r.Errors = assembly.validateBean(bd)
+			r.Depends = assembly.validated
+		}
+		result = append(result, r)
+	}
+	return result
+}
+
+// Validate 在 AutoWireBeans 之前检查所有满足条件的 Bean 的依赖和属性绑定，返回发现
+// 的所有错误，不会调用构造函数和初始化函数。检查需要先对 Bean 进行决议，因此检查之后
+// 不能再注册 Bean，但是仍然可以调用 AutoWireBeans。
+func (ctx *defaultSpringContext) Validate() []error {
+
+	if ctx.wired {
+		panic(errors.New("AutoWireBeans already called"))
+	}
+
+	assembly := ctx.resolveForCheck()
+
+	var result []error
+	for _, bd := range ctx.allBeans {
+		if bd.status == beanStatus_Deleted {
+			continue
+		}
+		for _, err := range assembly.validateBean(bd) {
+			result = append(result, fmt.Errorf("%s: %s", bd.Description(), err))
+		}
+	}
+	return result
+}
+
+// resolveForCheck 对容器进行决议并返回用于检查的 assembly，DryRun 和 Validate 共用
+// 这一个决议过程，决议只执行一次，所以之后仍然可以调用 AutoWireBeans。
+func (ctx *defaultSpringContext) resolveForCheck() *defaultBeanAssembly {
+	ctx.resolve()
+	return newDefaultBeanAssembly(ctx)
+}
+
+// validateBean 检查 Bean 的依赖和属性绑定，返回发现的错误
+func (assembly *defaultBeanAssembly) validateBean(bd *BeanDefinition) (errs []string) {
+
+	check := func(fn func()) {
+		defer func() {
+			if err := recover(); err != nil {
+				errs = append(errs, fmt.Sprint(err))
+			}
+		}()
+		fn()
+	}
+
+	var fnBean *functionBean
+	switch bean := bd.bean.(type) {
+	case *constructorBean:
+		fnBean = &bean.functionBean
+	case *methodBean:
+		fnBean = &bean.functionBean
+	}
+
+	// 检查间接依赖项
+	for _, selector := range bd.getDependsOn() {
+		check(func() {
+			if b, ok := assembly.springCtx.FindBean(assembly.inNamespace(selector)); ok {
+				assembly.validated = append(assembly.validated, b)
+			} else {
+				panic(fmt.Errorf("can't find bean: \"%v\"", selector))
+			}
+		})
+	}
+
+	if fnBean != nil && fnBean.stringArg != nil {
+		assembly.validateFnArgs(fnBean.stringArg, bd.FileLine(), check)
+	}
+
+	// 检查 Bean 的字段，函数 Bean 检查其返回值的字段
+	if t := bd.Type(); t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct {
+		assembly.validateStruct(t.Elem(), t.Elem().String(), check)
+	}
+	return
+}
+
+// validateFnArgs 检查函数参数的依赖和属性绑定
+func (assembly *defaultBeanAssembly) validateFnArgs(arg *fnStringBindingArg, fileLine string, check func(func())) {
+
+	fnType := arg.fnType
+	numIn := fnType.NumIn()
+	if arg.withReceiver {
+		numIn -= 1
+	}
+
+	for i, tags := range arg.fnTags {
+
+		it := fnType.In(i)
+		if arg.withReceiver {
+			it = fnType.In(i + 1)
+		}
+
+		if fnType.IsVariadic() && i == numIn-1 {
+			for _, tag := range tags {
+				assembly.validateArg(it.Elem(), tag, fileLine, check)
+			}
+			continue
+		}
+
+		var tag string
+		if len(tags) > 0 {
+			tag = tags[0]
+		}
+		assembly.validateArg(it, tag, fileLine, check)
+	}
+}
+
+// validateArg 检查单个函数参数，规则和 getArgValue 一致
+func (assembly *defaultBeanAssembly) validateArg(t reflect.Type, tag string, fileLine string, check func(func())) {
+	field := fmt.Sprintf("tag:\"%s\" %s", tag, fileLine)
+
+	if _, ok := typeConverters[t]; !ok && t.Kind() == reflect.Struct && tag == "" {
+		assembly.validateStruct(t, t.String(), check)
+	} else if IsValueType(t.Kind()) {
+		if tag == "" {
+			tag = "${}"
+		}
+		assembly.validateValue(t, tag, field, check)
+	} else if t != weakReferenceType {
+		assembly.validateWire(t, tag, field, check)
+	}
+}
+
+// validateStruct 检查结构体字段的依赖和属性绑定
+func (assembly *defaultBeanAssembly) validateStruct(t reflect.Type, name string, check func(func())) {
+	for i := 0; i < t.NumField(); i++ {
+		ft := t.Field(i)
+		fieldName := name + ".$" + ft.Name
+
+		if tag, ok := ft.Tag.Lookup("value"); ok {
+			assembly.validateValue(ft.Type, tag, fieldName, check)
+			continue
+		}
+
+		if tag, ok := ft.Tag.Lookup("autowire"); ok {
+			assembly.validateWire(ft.Type, tag, fieldName, check)
+		}
+
+		if tag, ok := ft.Tag.Lookup("inject"); ok {
+			assembly.validateWire(ft.Type, tag, fieldName, check)
+		}
+
+		if ft.Type.Kind() == reflect.Struct {
+			assembly.validateStruct(ft.Type, fieldName, check)
+		}
+	}
+}
+
+// validateValue 检查属性绑定，绑定到临时变量上
+func (assembly *defaultBeanAssembly) validateValue(t reflect.Type, tag string, field string, check func(func())) {
+	check(func() {
+		bindStructField(assembly.springCtx, reflect.New(t).Elem(), tag, bindOption{
+			allAccess: true,
+			fieldName: field,
+		})
+	})
+}
+
+// validateWire 检查依赖注入，只查找 Bean 而不创建 Bean
+func (assembly *defaultBeanAssembly) validateWire(t reflect.Type, tag string, field string, check func(func())) {
+	check(func() {
+
+		// tag 预处理，Bean 名称可以通过属性值指定
+		if strings.HasPrefix(tag, "${") {
+			s := ""
+			sv := reflect.ValueOf(&s).Elem()
+			bindStructField(assembly.springCtx, sv, tag, bindOption{})
+			tag = s
+		}
+
+		if CollectionMode(tag) { // 收集模式允许结果为空
+			if k := t.Kind(); k != reflect.Slice && k != reflect.Map {
+				panic(fmt.Errorf("field: %s should be slice or map", field))
+			}
+			found := assembly.collectCandidates(t, ParseCollectionTag(tag))
+			assembly.validated = append(assembly.validated, found...)
+			return
+		}
+
+		if !IsRefType(t.Kind()) {
+			panic(fmt.Errorf("receiver must be ref type, bean: \"%s\" field: %s", tag, field))
+		}
+
+		if b := assembly.findBean(t, ParseSingletonTag(tag), reflect.Value{}, field); b != nil {
+			assembly.validated = append(assembly.validated, b)
+		}
+	})
+}
+
+// collectCandidates 返回收集模式下会被收集的 Bean，只查找而不创建 Bean
+func (assembly *defaultBeanAssembly) collectCandidates(t reflect.Type, tag CollectionTag) []*BeanDefinition {
+
+	var result []*BeanDefinition
+
+	// 自动模式下数组 Bean 的元素也会被收集
+	if t.Kind() == reflect.Slice && len(tag.Items) == 0 {
+		for _, d := range assembly.springCtx.getTypeCacheItem(t).beans {
+			if d.status != beanStatus_Deleted {
+				result = append(result, d)
+			}
+		}
+	}
+
+	cache := assembly.springCtx.getTypeCacheItem(t.Elem())
+
+	if len(tag.Items) == 0 { // 自动模式
+		var found []*BeanDefinition
+		for _, d := range cache.beans {
+			if assembly.visible(d, "") && assembly.recheckCondition(d) {
+				found = append(found, d)
+			}
+		}
+		sortCollectedBeans(found)
+		return append(result, found...)
+	}
+
+	for _, item := range tag.Items { // 指定模式
+		for _, d := range cache.beans {
+			if d.Match(item.TypeName, item.BeanName) {
+				result = append(result, d)
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,116 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package SpringCore
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+)
+
+// extendedUnits time.ParseDuration 不支持的时长单位
+var extendedUnits = map[byte]time.Duration{
+	'd': 24 * time.Hour,
+	'w': 7 * 24 * time.Hour,
+}
+
+// ParseDuration 解析时长字符串，在 time.ParseDuration 的基础上增加了 d (天) 和
+// w (周) 两个单位，例如 "1w2d3h"、"7d"，超出 time.Duration 范围时返回错误。
+func ParseDuration(s string) (time.Duration, error) {
+
+	if !strings.ContainsAny(s, "dw") {
+		return time.ParseDuration(s)
+	}
+
+	invalid := errors.New("time: invalid duration " + strconv.Quote(s))
+
+	str, neg := s, false
+	if str != "" && (str[0] == '-' || str[0] == '+') {
+		neg = str[0] == '-'
+		str = str[1:]
+	}
+
+	var (
+		d    time.Duration
+		rest strings.Builder // 标准单位的部分交给 time.ParseDuration 解析
+	)
+
+	for str != "" {
+
+		i := 0
+		for i < len(str) && (str[i] == '.' || '0' <= str[i] && str[i] <= '9') {
+			i++
+		}
+		if i == 0 {
+			return 0, invalid
+		}
+		num := str[:i]
+
+		j := i
+		for j < len(str) && str[j] != '.' && (str[j] < '0' || str[j] > '9') {
+			j++
+		}
+		unit := str[i:j]
+		str = str[j:]
+
+		if len(unit) == 1 {
+			if u, ok := extendedUnits[unit[0]]; ok {
+				f, err := strconv.ParseFloat(num, 64)
+				if err != nil {
+					return 0, invalid
+				}
+				v := f * float64(u)
+				if v >= math.MaxInt64 || d > math.MaxInt64-time.Duration(v) {
+					return 0, invalid
+				}
+				d += time.Duration(v)
+				continue
+			}
+		}
+
+		rest.WriteString(num)
+		rest.WriteString(unit)
+	}
+
+	if rest.Len() > 0 {
+		r, err := time.ParseDuration(rest.String())
+		if err != nil || d > math.MaxInt64-r {
+			return 0, invalid
+		}
+		d += r
+	}
+
+	if neg {
+		return -d, nil
+	}
+	return d, nil
+}
+
+// toDuration 将属性值转换成时长，字符串使用 ParseDuration 解析，解析失败时 (例如
+// 没有单位的数字) 再使用 cast.ToDuration 转换。
+func toDuration(i interface{}) time.Duration {
+	if s, ok := i.(string); ok {
+		if d, err := ParseDuration(s); err == nil {
+			return d
+		}
+	}
+	return cast.ToDuration(i)
+}
@@ -0,0 +1,175 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package SpringCore
+
+import (
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/go-spring/go-spring-parent/spring-utils"
+)
+
+// BindEnvironment 设置 Bean 绑定属性值时同时从环境变量中读取属性值，属性名按
+// PREFIX_KEY 的规则转换成环境变量名，例如前缀为 app 时 db.maxIdle 对应 APP_DB_MAX_IDLE，
+// 对于该 Bean 来说环境变量的优先级高于配置文件中的属性值。没有 value 标签的字段按照
+// PREFIX_FIELD 的规则绑定环境变量，例如前缀为 app 时 MaxIdle 字段对应 APP_MAX_IDLE。
+func (d *BeanDefinition) BindEnvironment(prefix string) *BeanDefinition {
+	d.envPrefix = prefix
+	return d
+}
+
+// envProperties 绑定属性值时优先使用环境变量中的属性值
+type envProperties struct {
+	Properties
+	prefix string
+}
+
+// newEnvProperties envProperties 的构造函数
+func newEnvProperties(p Properties, prefix string) *envProperties {
+	return &envProperties{Properties: p, prefix: prefix}
+}
+
+// envName 返回属性名对应的环境变量名
+func (p *envProperties) envName(key string) string {
+	key = strings.NewReplacer(".", "_", "-", "_").Replace(NormalizePropertyKey(key))
+	return strings.ToUpper(p.prefix + "_" + key)
+}
+
+// GetProperty 返回 keys 中第一个存在的属性值，环境变量优先。
+func (p *envProperties) GetProperty(keys ...string) interface{} {
+	for _, key := range keys {
+		if v, ok := p.GetDefaultProperty(key, nil); ok {
+			return v
+		}
+	}
+	return nil
+}
+
+// GetDefaultProperty 返回属性值，如果没有找到则使用指定的默认值，环境变量优先。
+func (p *envProperties) GetDefaultProperty(key string, def interface{}) (interface{}, bool) {
+	if v, ok := os.LookupEnv(p.envName(key)); ok {
+		return v, true
+	}
+	return p.Properties.GetDefaultProperty(key, def)
+}
+
+// envValues 返回所有 PREFIX_ 开头的环境变量对应的属性值，能够对应上已有属性名的环境
+// 变量使用已有的属性名，其他的环境变量去掉前缀后转成小写并且将下划线替换成点号。
+func (p *envProperties) envValues() map[string]interface{} {
+
+	keys := make(map[string]string)
+	for key := range p.Properties.GetProperties() {
+		keys[p.envName(key)] = key
+	}
+
+	prefix := strings.ToUpper(p.prefix) + "_"
+	result := make(map[string]interface{})
+
+	for _, env := range os.Environ() {
+		ss := strings.SplitN(env, "=", 2)
+		if len(ss) != 2 || !strings.HasPrefix(ss[0], prefix) {
+			continue
+		}
+		key, ok := keys[ss[0]]
+		if !ok {
+			key = strings.TrimPrefix(ss[0], prefix)
+			key = strings.ToLower(strings.Replace(key, "_", ".", -1))
+		}
+		result[key] = ss[1]
+	}
+	return result
+}
+
+// GetPrefixProperties 返回指定前缀的属性值集合，环境变量优先。
+func (p *envProperties) GetPrefixProperties(prefix string) map[string]interface{} {
+	result := p.Properties.GetPrefixProperties(prefix)
+	prefix = strings.ToLower(prefix)
+	for key, val := range p.envValues() {
+		if key == prefix || strings.HasPrefix(key, prefix+".") {
+			result[key] = val
+		}
+	}
+	return result
+}
+
+// GetProperties 返回所有的属性值，环境变量优先。
+func (p *envProperties) GetProperties() map[string]interface{} {
+	result := make(map[string]interface{})
+	for key, val := range p.Properties.GetProperties() {
+		result[key] = val
+	}
+	for key, val := range p.envValues() {
+		result[key] = val
+	}
+	return result
+}
+
+// bindEnvFields 按照 PREFIX_FIELD 的规则将环境变量绑定到没有 value 标签的字段上，
+// 嵌套的结构体字段按照 PREFIX_FIELD_SUBFIELD 的规则绑定，没有对应环境变量的字段保持不变。
+func bindEnvFields(p Properties, prefix string, v reflect.Value, key string, opt bindOption) {
+	env := newEnvProperties(p, prefix)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		ft := t.Field(i)
+
+		if _, ok := ft.Tag.Lookup("value"); ok {
+			continue
+		}
+		if _, ok := ft.Tag.Lookup("autowire"); ok {
+			continue
+		}
+		if _, ok := ft.Tag.Lookup("inject"); ok {
+			continue
+		}
+
+		fv := SpringUtils.ValuePatchIf(v.Field(i), opt.allAccess)
+		if !fv.CanSet() {
+			continue
+		}
+
+		subKey := NormalizePropertyKey(ft.Name)
+		if key != "" {
+			subKey = key + "." + subKey
+		}
+
+		subOpt := bindOption{
+			fullPropName: subKey,
+			fieldName:    opt.fieldName + ".$" + ft.Name,
+			allAccess:    opt.allAccess,
+		}
+
+		switch ft.Type.Kind() {
+		case reflect.Struct:
+			if _, ok := typeConverters[ft.Type]; !ok {
+				bindEnvFields(p, prefix, fv, subKey, subOpt)
+				continue
+			}
+		case reflect.Map:
+			if len(p.GetPrefixProperties(subKey)) > 0 {
+				bindValue(p, fv, subKey, nil, subOpt)
+			}
+			continue
+		case reflect.Ptr, reflect.Interface, reflect.Func, reflect.Chan:
+			continue
+		}
+
+		if _, ok := os.LookupEnv(env.envName(subKey)); ok {
+			bindValue(p, fv, subKey, nil, subOpt)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package SpringCore
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cast"
+)
+
+// ExpressionFunc 表达式中可以调用的自定义函数，参数和返回值的类型为
+// bool、string、int64 或者 float64。
+type ExpressionFunc func(args ...interface{}) (interface{}, error)
+
+var (
+	expressionFuncMutex sync.RWMutex
+
+	// expressionFuncs 表达式中可以调用的函数
+	expressionFuncs = map[string]ExpressionFunc{
+		"hasPrefix": stringFunc(strings.HasPrefix),
+		"hasSuffix": stringFunc(strings.HasSuffix),
+		"contains":  stringFunc(strings.Contains),
+	}
+)
+
+// stringFunc 将 func(string,string)bool 形式的函数转换为 ExpressionFunc
+func stringFunc(fn func(s, substr string) bool) ExpressionFunc {
+	return func(args ...interface{}) (interface{}, error) {
+		if len(args) != 2 {
+			return nil, fmt.Errorf("expect 2 args but got %d", len(args))
+		}
+		return fn(cast.ToString(args[0]), cast.ToString(args[1])), nil
+	}
+}
+
+// RegisterExpressionFunc 注册表达式中可以调用的自定义函数，重名的函数会被覆盖
+func RegisterExpressionFunc(name string, fn ExpressionFunc) {
+	expressionFuncMutex.Lock()
+	defer expressionFuncMutex.Unlock()
+	expressionFuncs[name] = fn
+}
+
+// getExpressionFunc 获取表达式中调用的函数
+func getExpressionFunc(name string) (ExpressionFunc, bool) {
+	expressionFuncMutex.RLock()
+	defer expressionFuncMutex.RUnlock()
+	fn, ok := expressionFuncs[name]
+	return fn, ok
+}
+
+// parseExpression 解析表达式，语法和 Go 表达式一致，属性名 (可以带点号) 作为变量引用
+// 属性值，支持字面量、一元和二元运算、括号以及函数调用，不支持的语法会返回错误。
+func parseExpression(expression string) (ast.Expr, error) {
+
+	expr, err := parser.ParseExpr(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if err != nil {
+			return false
+		}
+		switch x := n.(type) {
+		case nil, *ast.BasicLit, *ast.Ident, *ast.ParenExpr, *ast.UnaryExpr, *ast.BinaryExpr:
+		case *ast.SelectorExpr:
+			if _, ok := propertyName(x); !ok {
+				err = fmt.Errorf("invalid property reference at %d", x.Pos())
+			}
+			return false
+		case *ast.CallExpr:
+			if _, ok := x.Fun.(*ast.Ident); !ok {
+				err = fmt.Errorf("invalid function call at %d", x.Pos())
+				return false
+			}
+		default:
+			err = fmt.Errorf("unsupported expression %T at %d", n, n.Pos())
+		}
+		return err == nil
+	})
+
+	return expr, err
+}
+
+// propertyName 将形如 a.b.c 的选择表达式转换为属性名
+func propertyName(expr ast.Expr) (string, bool) {
+	switch x := expr.(type) {
+	case *ast.Ident:
+		return x.Name, true
+	case *ast.SelectorExpr:
+		if s, ok := propertyName(x.X); ok {
+			return s + "." + x.Sel.Name, true
+		}
+	}
+	return "", false
+}
+
+// evalExpression 计算表达式的值，属性值从 ctx 中获取
+func evalExpression(ctx SpringContext, expr ast.Expr) constant.Value {
+	switch x := expr.(type) {
+	case *ast.BasicLit:
+		return constant.MakeFromLiteral(x.Value, x.Kind, 0)
+	case *ast.ParenExpr:
+		return evalExpression(ctx, x.X)
+	case *ast.Ident, *ast.SelectorExpr:
+		name, _ := propertyName(x)
+		if name == "true" || name == "false" {
+			return constant.MakeBool(name == "true")
+		}
+		val := ctx.GetProperty(name)
+		if val == nil {
+			panic(fmt.Errorf("property \"%s\" not found", name))
+		}
+		return makeConstant(val)
+	case *ast.UnaryExpr:
+		v := evalExpression(ctx, x.X)
+		if x.Op == token.NOT {
+			v = toKind(v, constant.Bool)
+		} else if v.Kind() == constant.String {
+			v = toNumber(v)
+		}
+		return constant.UnaryOp(x.Op, v, 0)
+	case *ast.BinaryExpr:
+		return evalBinary(ctx, x)
+	case *ast.CallExpr:
+		return evalCall(ctx, x)
+	}
+	panic(fmt.Errorf("unsupported expression %T", expr))
+}
+
+// evalBinary 计算二元表达式，&& 和 || 支持短路求值，类型不同时尝试将字符串转换为另一边的类型
+func evalBinary(ctx SpringContext, x *ast.BinaryExpr) constant.Value {
+
+	if x.Op == token.LAND || x.Op == token.LOR {
+		l := toKind(evalExpression(ctx, x.X), constant.Bool)
+		if constant.BoolVal(l) == (x.Op == token.LOR) {
+			return l
+		}
+		return toKind(evalExpression(ctx, x.Y), constant.Bool)
+	}
+
+	l := evalExpression(ctx, x.X)
+	r := evalExpression(ctx, x.Y)
+
+	if l.Kind() != r.Kind() {
+		if l.Kind() == constant.String {
+			l = toKind(l, r.Kind())
+		} else if r.Kind() == constant.String {
+			r = toKind(r, l.Kind())
+		}
+	}
+
+	switch x.Op {
+	case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+		return constant.MakeBool(constant.Compare(l, x.Op, r))
+	case token.QUO:
+		if l.Kind() == constant.Int && r.Kind() == constant.Int {
+			return constant.BinaryOp(l, token.QUO_ASSIGN, r) // 整数除法
+		}
+	}
+	return constant.BinaryOp(l, x.Op, r)
+}
+
+// evalCall 调用表达式中的自定义函数
+func evalCall(ctx SpringContext, x *ast.CallExpr) constant.Value {
+	name := x.Fun.(*ast.Ident).Name
+
+	fn, ok := getExpressionFunc(name)
+	if !ok {
+		panic(fmt.Errorf("function \"%s\" not found", name))
+	}
+
+	args := make([]interface{}, len(x.Args))
+	for i, arg := range x.Args {
+		args[i] = constantValue(evalExpression(ctx, arg))
+	}
+
+	ret, err := fn(args...)
+	if err != nil {
+		panic(fmt.Errorf("function \"%s\" return error: %v", name, err))
+	}
+	return makeConstant(ret)
+}
+
+// makeConstant 将属性值或者函数返回值转换为常量
+func makeConstant(val interface{}) constant.Value {
+	switch v := val.(type) {
+	case bool:
+		return constant.MakeBool(v)
+	case string:
+		return constant.MakeString(v)
+	case int, int8, int16, int32, int64:
+		return constant.MakeInt64(cast.ToInt64(v))
+	case uint, uint8, uint16, uint32, uint64:
+		return constant.MakeUint64(cast.ToUint64(v))
+	case float32, float64:
+		return constant.MakeFloat64(cast.ToFloat64(v))
+	}
+	return constant.MakeString(cast.ToString(val))
+}
+
+// constantValue 将常量转换为 bool、string、int64 或者 float64
+func constantValue(v constant.Value) interface{} {
+	switch v.Kind() {
+	case constant.Bool:
+		return constant.BoolVal(v)
+	case constant.String:
+		return constant.StringVal(v)
+	case constant.Int:
+		if i, ok := constant.Int64Val(v); ok {
+			return i
+		}
+	}
+	f, _ := constant.Float64Val(constant.ToFloat(v))
+	return f
+}
+
+// toNumber 将字符串常量转换为数值常量
+func toNumber(v constant.Value) constant.Value {
+	s := constant.StringVal(v)
+	if i, err := strconv.ParseInt(s, 0, 64); err == nil {
+		return constant.MakeInt64(i)
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return constant.MakeFloat64(f)
+	}
+	panic(fmt.Errorf("can't convert %q to number", s))
+}
+
+// toKind 将字符串常量转换为指定类型的常量
+func toKind(v constant.Value, kind constant.Kind) constant.Value {
+	if v.Kind() == kind || v.Kind() != constant.String {
+		if kind == constant.Bool && v.Kind() != constant.Bool {
+			panic(fmt.Errorf("%s isn't bool", v))
+		}
+		return v
+	}
+	switch kind {
+	case constant.Bool:
+		b, err := strconv.ParseBool(constant.StringVal(v))
+		if err != nil {
+			panic(fmt.Errorf("can't convert %s to bool", v))
+		}
+		return constant.MakeBool(b)
+	case constant.Int, constant.Float:
+		return toNumber(v)
+	}
+	return v
+}
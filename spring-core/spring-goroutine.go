@@ -0,0 +1,77 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package SpringCore
+
+import (
+	"bytes"
+	"reflect"
+	"runtime"
+	"strconv"
+)
+
+// GoroutineScope goroutine 作用域，每个 goroutine 创建一个实例
+const GoroutineScope = Scope("goroutine")
+
+// GoroutineLocal 设置 Bean 为 goroutine 作用域，同一个 goroutine 内共享一个实例。Go 无法
+// 感知 goroutine 的结束，通过 SafeGoroutine 启动的 goroutine 在结束时销毁其中的实例，其他
+// goroutine 需要 defer 调用 ReleaseGoroutineBeans，剩余的实例在容器关闭时销毁。Web 请求
+// 中的实例由请求作用域的过滤器在请求结束时销毁。注入到单例 Bean 中的是注入时所在
+// goroutine 的实例。
+func (d *BeanDefinition) GoroutineLocal() *BeanDefinition {
+	return d.setScope(GoroutineScope)
+}
+
+// goroutineId 返回当前 goroutine 的 ID，通过解析 runtime.Stack 的输出获得
+func goroutineId() uint64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	if i := bytes.IndexByte(buf, ' '); i > 0 {
+		buf = buf[:i]
+	}
+	id, err := strconv.ParseUint(string(buf), 10, 64)
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// goroutineBeanValue 获取当前 goroutine 内的 Bean 实例，每个 goroutine 对应一个子容器
+func (assembly *defaultBeanAssembly) goroutineBeanValue(bd *BeanDefinition) reflect.Value {
+	v, _ := assembly.springCtx.goroutines.LoadOrStore(goroutineId(), newScopedContainer(GoroutineScope))
+	c := v.(*scopedContainer)
+	defer assembly.lockContainer(c)()
+	return c.getBean(assembly, bd)
+}
+
+// ReleaseGoroutineBeans 销毁当前 goroutine 内的 goroutine 作用域 Bean 的实例
+func (ctx *defaultSpringContext) ReleaseGoroutineBeans() {
+	id := goroutineId()
+	if v, ok := ctx.goroutines.Load(id); ok {
+		ctx.goroutines.Delete(id)
+		v.(*scopedContainer).destroyBeans(newDefaultBeanAssembly(ctx))
+	}
+}
+
+// destroyGoroutineBeans 销毁所有 goroutine 作用域 Bean 的实例
+func (ctx *defaultSpringContext) destroyGoroutineBeans(assembly *defaultBeanAssembly) {
+	ctx.goroutines.Range(func(key, value interface{}) bool {
+		value.(*scopedContainer).destroyBeans(assembly)
+		ctx.goroutines.Delete(key)
+		return true
+	})
+}
@@ -0,0 +1,243 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package SpringCore
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// dependencyGraph 由预检结果生成的 Bean 依赖关系图
+type dependencyGraph struct {
+	Nodes  []*graphNode `json:"nodes"`
+	Edges  []*graphEdge `json:"edges"`
+	Cycles [][]string   `json:"cycles,omitempty"` // 循环依赖的 Bean，每一项是一个环上的所有 Bean
+}
+
+// graphNode 依赖关系图的节点
+type graphNode struct {
+	Id        string   `json:"id"`
+	Condition string   `json:"condition,omitempty"`
+	Passed    bool     `json:"passed"`
+	Errors    []string `json:"errors,omitempty"`
+}
+
+// color 返回节点在 DOT 格式中的颜色，不满足条件的为灰色，检查出错误的为红色
+func (n *graphNode) color() string {
+	if !n.Passed {
+		return "gray"
+	}
+	if len(n.Errors) > 0 {
+		return "red"
+	}
+	return "green"
+}
+
+// graphEdge 依赖关系图的边，From 依赖 To
+type graphEdge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Cycle bool   `json:"cycle,omitempty"` // 是否位于循环依赖的环上
+}
+
+// newDependencyGraph dependencyGraph 的构造函数，重复的依赖只保留一条边
+func newDependencyGraph(reports []*BeanReport) *dependencyGraph {
+	g := &dependencyGraph{}
+	for _, r := range reports {
+		from := r.Bean.BeanId()
+		g.Nodes = append(g.Nodes, &graphNode{
+			Id:        from,
+			Condition: r.Condition,
+			Passed:    r.Passed,
+			Errors:    r.Errors,
+		})
+		edges := make(map[string]bool)
+		for _, d := range r.Depends {
+			if to := d.BeanId(); !edges[to] {
+				edges[to] = true
+				g.Edges = append(g.Edges, &graphEdge{From: from, To: to})
+			}
+		}
+	}
+	g.findCycles()
+	return g
+}
+
+// findCycles 使用 Tarjan 算法查找强连通分量，包含多个节点或者存在自环的强连通分量
+// 就是循环依赖，环上的边会被标记出来。
+func (g *dependencyGraph) findCycles() {
+
+	next := make(map[string][]string)
+	for _, e := range g.Edges {
+		next[e.From] = append(next[e.From], e.To)
+	}
+
+	index := make(map[string]int)
+	lowLink := make(map[string]int)
+	onStack := make(map[string]bool)
+	component := make(map[string]int)
+	var stack []string
+
+	var visit func(id string)
+	visit = func(id string) {
+		index[id] = len(index)
+		lowLink[id] = index[id]
+		stack = append(stack, id)
+		onStack[id] = true
+
+		for _, to := range next[id] {
+			if _, ok := index[to]; !ok {
+				visit(to)
+				if lowLink[to] < lowLink[id] {
+					lowLink[id] = lowLink[to]
+				}
+			} else if onStack[to] && index[to] < lowLink[id] {
+				lowLink[id] = index[to]
+			}
+		}
+
+		if lowLink[id] != index[id] {
+			return
+		}
+
+		var members []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			members = append(members, top)
+			if top == id {
+				break
+			}
+		}
+
+		if len(members) == 1 && !containsId(next[id], id) {
+			return
+		}
+
+		for _, m := range members {
+			component[m] = len(g.Cycles) + 1
+		}
+		sort.Strings(members)
+		g.Cycles = append(g.Cycles, members)
+	}
+
+	for _, n := range g.Nodes {
+		if _, ok := index[n.Id]; !ok {
+			visit(n.Id)
+		}
+	}
+
+	for _, e := range g.Edges {
+		if c := component[e.From]; c > 0 && c == component[e.To] {
+			e.Cycle = true
+		}
+	}
+}
+
+// containsId 返回 ids 中是否包含 id
+func containsId(ids []string, id string) bool {
+	for _, s := range ids {
+		if s == id {
+			return true
+		}
+	}
+	return false
+}
+
+// ExportDependencyGraph 将预检结果导出为 Bean 依赖关系图，format 可以是 dot、json
+// 或者 mermaid，DOT 格式使用节点的颜色表示判断条件和检查的结果。循环依赖的边使用红色
+// 标记，并且所有格式都会输出发现的循环依赖，可以用于在 CI 中禁止循环依赖。
+func ExportDependencyGraph(reports []*BeanReport, format string) ([]byte, error) {
+	g := newDependencyGraph(reports)
+	switch format {
+	case "dot":
+		return g.dot(), nil
+	case "json":
+		return json.MarshalIndent(g, "", "  ")
+	case "mermaid":
+		return g.mermaid(), nil
+	}
+	return nil, fmt.Errorf("unsupported dependency graph format: %s", format)
+}
+
+// dot 返回 Graphviz 的 DOT 格式
+func (g *dependencyGraph) dot() []byte {
+	var buf bytes.Buffer
+	buf.WriteString("digraph beans {\n")
+	for _, n := range g.Nodes {
+		label := n.Id
+		if n.Condition != "" {
+			label += "\n" + n.Condition
+		}
+		fmt.Fprintf(&buf, "  %s [label=%s, color=%s];\n", strconv.Quote(n.Id), strconv.Quote(label), n.color())
+	}
+	for _, e := range g.Edges {
+		if e.Cycle {
+			fmt.Fprintf(&buf, "  %s -> %s [color=red];\n", strconv.Quote(e.From), strconv.Quote(e.To))
+		} else {
+			fmt.Fprintf(&buf, "  %s -> %s;\n", strconv.Quote(e.From), strconv.Quote(e.To))
+		}
+	}
+	for _, c := range g.Cycles {
+		fmt.Fprintf(&buf, "  // cycle: %s\n", strings.Join(c, ", "))
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
+
+// mermaid 返回 Mermaid 的流程图格式，节点使用序号命名
+func (g *dependencyGraph) mermaid() []byte {
+
+	ids := make(map[string]string)
+	nodeId := func(id string) string {
+		if s, ok := ids[id]; ok {
+			return s
+		}
+		s := "n" + strconv.Itoa(len(ids))
+		ids[id] = s
+		return s
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("graph TD\n")
+	for _, n := range g.Nodes {
+		label := strings.Replace(n.Id, `"`, "#quot;", -1)
+		fmt.Fprintf(&buf, "  %s[\"%s\"]:::%s\n", nodeId(n.Id), label, n.color())
+	}
+	var cycleLinks []string
+	for i, e := range g.Edges {
+		fmt.Fprintf(&buf, "  %s --> %s\n", nodeId(e.From), nodeId(e.To))
+		if e.Cycle {
+			cycleLinks = append(cycleLinks, strconv.Itoa(i))
+		}
+	}
+	if len(cycleLinks) > 0 {
+		fmt.Fprintf(&buf, "  linkStyle %s stroke:red\n", strings.Join(cycleLinks, ","))
+	}
+	for _, c := range g.Cycles {
+		fmt.Fprintf(&buf, "  %%%% cycle: %s\n", strings.Join(c, ", "))
+	}
+	buf.WriteString("  classDef green stroke:green\n")
+	buf.WriteString("  classDef gray stroke:gray\n")
+	buf.WriteString("  classDef red stroke:red\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,195 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package SpringCore
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/go-spring/go-spring-parent/spring-utils"
+)
+
+// MethodInvocation 被拦截的函数调用。因为 Go 无法在运行时生成代理类型，所以拦截的是
+// Bean 的导出函数字段以及 self 代理的函数字段，Method 为字段名称。
+type MethodInvocation struct {
+	BeanId string          // Bean 的 ID
+	Method string          // 函数字段的名称
+	Args   []reflect.Value // 调用参数，可变参数为切片
+
+	fn           reflect.Value
+	interceptors []MethodInterceptor
+}
+
+// Proceed 调用下一个拦截器，最后一个拦截器调用原函数
+func (inv *MethodInvocation) Proceed() []reflect.Value {
+
+	if len(inv.interceptors) > 0 {
+		next := *inv
+		next.interceptors = inv.interceptors[1:]
+		return inv.interceptors[0].Invoke(&next)
+	}
+
+	if inv.fn.Type().IsVariadic() {
+		return inv.fn.CallSlice(inv.Args)
+	}
+	return inv.fn.Call(inv.Args)
+}
+
+// MethodInterceptor 函数调用拦截器
+type MethodInterceptor interface {
+	Invoke(inv *MethodInvocation) []reflect.Value
+}
+
+// MethodInterceptorFunc 基于函数的 MethodInterceptor 实现
+type MethodInterceptorFunc func(inv *MethodInvocation) []reflect.Value
+
+// Invoke 调用拦截函数
+func (f MethodInterceptorFunc) Invoke(inv *MethodInvocation) []reflect.Value {
+	return f(inv)
+}
+
+// Intercepted 为 Bean 设置拦截器，拦截器按照顺序包装 Bean 的所有非空的导出函数字段，
+// 只对当前 Bean 生效。包装在字段注入完成之后、Init 函数执行之前进行，Bean 必须是结构体指针。
+// Go 无法在运行时为接口或者方法生成代理，所以直接调用 Bean 的方法不会被拦截，需要拦截方法
+// 调用时通过 SelfReferencing 注入的结构体代理调用。Bean 既没有导出函数字段也没有结构体
+// 类型的 self 字段时 panic。
+func (d *BeanDefinition) Intercepted(interceptors ...MethodInterceptor) *BeanDefinition {
+
+	if len(interceptors) == 0 {
+		panic(errors.New("interceptors can't be empty"))
+	}
+
+	if t := d.Type(); t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		panic(fmt.Errorf("intercepted bean: \"%s\" must be pointer to struct", d.BeanId()))
+	}
+
+	if !hasInterceptableField(d.Type().Elem()) {
+		panic(fmt.Errorf("intercepted bean: \"%s\" has no func field to intercept", d.BeanId()))
+	}
+
+	d.intercept = append(d.intercept, interceptors...)
+	return d
+}
+
+// hasInterceptableField 返回结构体是否有可以被拦截的导出函数字段或者 self 代理字段
+func hasInterceptableField(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		ft := t.Field(i)
+		if ft.Type.Kind() == reflect.Func && ft.PkgPath == "" {
+			return true
+		}
+		if ft.Type.Kind() == reflect.Struct && ft.Tag.Get("inject") == "self" {
+			return true
+		}
+	}
+	return false
+}
+
+// interceptBean 使用拦截器包装 Bean 的导出函数字段
+func interceptBean(bd *BeanDefinition) {
+
+	ev := bd.Value().Elem()
+	et := ev.Type()
+
+	for i := 0; i < et.NumField(); i++ {
+		ft := et.Field(i)
+		fv := ev.Field(i)
+
+		if ft.Type.Kind() != reflect.Func || !fv.CanSet() || fv.IsNil() {
+			continue
+		}
+
+		// 复制原函数，防止字段被替换后递归调用自身
+		fn := reflect.ValueOf(fv.Interface())
+		fv.Set(interceptFunc(bd.BeanId(), ft.Name, fn, bd.intercept))
+	}
+}
+
+// interceptFunc 返回经过拦截器包装的函数
+func interceptFunc(beanId string, method string, fn reflect.Value, interceptors []MethodInterceptor) reflect.Value {
+	return reflect.MakeFunc(fn.Type(), func(args []reflect.Value) []reflect.Value {
+		inv := &MethodInvocation{
+			BeanId:       beanId,
+			Method:       method,
+			Args:         args,
+			fn:           fn,
+			interceptors: interceptors,
+		}
+		return inv.Proceed()
+	})
+}
+
+// SelfReferencing 设置是否向 Bean 中标记为 `inject:"self"` 的字段注入 Bean 自身。字段
+// 可以是私有字段，声明为 Bean 实现的接口类型时注入 Bean 的实例，刷新之后重新注入新的实例；
+// 声明为只有函数字段的结构体时注入一个代理，代理的每个函数字段绑定到 Bean 的同名方法上
+// 并经过 Intercepted 设置的拦截器，Bean 通过该字段调用自己的方法时就能被拦截。
+func (d *BeanDefinition) SelfReferencing(selfRef bool) *BeanDefinition {
+	d.selfRef = selfRef
+	return d
+}
+
+// isSelfReferencing 返回 Bean 是否需要向 self 字段注入自身，函数 Bean 的返回值
+// 使用函数 Bean 的设置。
+func isSelfReferencing(bd beanDefinition) bool {
+	if fbd, ok := bd.(*fnValueBeanDefinition); ok {
+		bd = fbd.f
+	}
+	d, ok := bd.(*BeanDefinition)
+	return ok && d.selfRef
+}
+
+// wireSelfField 向 self 字段注入 Bean 自身，结构体类型的字段注入 Bean 方法的代理
+func wireSelfField(v reflect.Value, self reflect.Value, bd beanDefinition, field string) {
+
+	if v.Kind() == reflect.Struct {
+		SpringUtils.ValuePatchIf(v, true).Set(selfProxy(v.Type(), self, bd, field))
+		return
+	}
+
+	if !self.Type().AssignableTo(v.Type()) {
+		panic(fmt.Errorf("%s can't be assigned to self field: %s", self.Type(), field))
+	}
+	SpringUtils.ValuePatchIf(v, true).Set(self)
+}
+
+// selfProxy 创建 Bean 方法的代理，t 的每个字段都必须是和 Bean 的同名方法类型相同的函数
+func selfProxy(t reflect.Type, self reflect.Value, bd beanDefinition, field string) reflect.Value {
+
+	if fbd, ok := bd.(*fnValueBeanDefinition); ok {
+		bd = fbd.f
+	}
+
+	var interceptors []MethodInterceptor
+	if d, ok := bd.(*BeanDefinition); ok {
+		interceptors = d.intercept
+	}
+
+	proxy := reflect.New(t).Elem()
+	for i := 0; i < t.NumField(); i++ {
+		ft := t.Field(i)
+		m := self.MethodByName(ft.Name)
+		if !m.IsValid() || m.Type() != ft.Type {
+			panic(fmt.Errorf("%s has no method %s %s for self field: %s", self.Type(), ft.Name, ft.Type, field))
+		}
+		if len(interceptors) > 0 {
+			m = interceptFunc(bd.BeanId(), ft.Name, m, interceptors)
+		}
+		SpringUtils.ValuePatchIf(proxy.Field(i), true).Set(m)
+	}
+	return proxy
+}
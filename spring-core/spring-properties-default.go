@@ -17,26 +17,37 @@
 package SpringCore
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"path/filepath"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/BurntSushi/toml"
 	"github.com/go-spring/go-spring-parent/spring-logger"
 	"github.com/go-spring/go-spring-parent/spring-utils"
+	"github.com/magiconair/properties"
 	"github.com/spf13/cast"
 	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
 )
 
 func init() {
 
 	// 注册时长转换函数 string -> time.Duration converter
-	// time units are "ns", "us" (or "µs"), "ms", "s", "m", "h"。
+	// time units are "ns", "us" (or "µs"), "ms", "s", "m", "h", "d", "w"。
 	RegisterTypeConverter(func(s string) time.Duration {
 		r, err := cast.ToDurationE(s)
+		if err != nil {
+			r, err = ParseDuration(s)
+		}
 		SpringUtils.Panic(err).When(err != nil)
 		return r
 	})
@@ -53,55 +64,161 @@ func init() {
 // defaultProperties Properties 的默认实现
 type defaultProperties struct {
 	properties map[string]interface{}
+	normalized map[string]string // 规范化的属性名到实际属性名的映射
 }
 
 // NewDefaultProperties defaultProperties 的构造函数
 func NewDefaultProperties() *defaultProperties {
 	return &defaultProperties{
 		properties: make(map[string]interface{}),
+		normalized: make(map[string]string),
 	}
 }
 
-func (p *defaultProperties) readProperties(reader func(*viper.Viper) error) {
+// newMapProperties 使用已有的属性值集合创建 defaultProperties 对象
+func newMapProperties(m map[string]interface{}) *defaultProperties {
+	p := &defaultProperties{properties: m, normalized: make(map[string]string)}
+	for key := range m {
+		p.normalized[NormalizePropertyKey(key)] = key
+	}
+	return p
+}
 
-	v := viper.New()
-	err := reader(v)
-	SpringUtils.Panic(err).When(err != nil)
+// lookup 返回属性值，属性名称先转成小写进行精确匹配，匹配不到时再按照
+// NormalizePropertyKey 转换之后的名称进行匹配。
+func (p *defaultProperties) lookup(key string) (interface{}, bool) {
+	if v, ok := p.properties[strings.ToLower(key)]; ok {
+		return v, true
+	}
+	if k, ok := p.normalized[NormalizePropertyKey(key)]; ok {
+		v, ok := p.properties[k]
+		return v, ok
+	}
+	return nil, false
+}
 
-	keys := v.AllKeys()
+// setProperties 按照属性名的顺序设置属性值。解析时属性名被转成了小写，所以使用文件中
+// 原始的属性名设置属性值，这样 contextPath 这种写法也能按照规范化的属性名获取。
+func (p *defaultProperties) setProperties(m map[string]interface{}, original map[string]string) {
+
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
 	sort.Strings(keys)
 
 	for _, key := range keys {
-		val := v.Get(key)
+		val := m[key]
+		if k, ok := original[key]; ok {
+			key = k
+		}
 		p.SetProperty(key, val)
 		SpringLogger.Tracef("%s=%v", key, val)
 	}
 }
 
+// readProperties 读取属性列表，TOML 格式使用 ReadTomlProperties 解析，其他格式使用 viper 解析
+func (p *defaultProperties) readProperties(data []byte, configType string) {
+
+	var m map[string]interface{}
+
+	if configType == "toml" {
+		var err error
+		m, err = ReadTomlProperties(bytes.NewReader(data))
+		SpringUtils.Panic(err).When(err != nil)
+	} else {
+		v := viper.New()
+		v.SetConfigType(configType)
+		err := v.ReadConfig(bytes.NewReader(data))
+		SpringUtils.Panic(err).When(err != nil)
+
+		m = make(map[string]interface{})
+		for _, key := range v.AllKeys() {
+			m[key] = v.Get(key)
+		}
+	}
+
+	p.setProperties(m, OriginalPropertyKeys(data, configType))
+}
+
+// OriginalPropertyKeys 返回小写属性名到配置内容中原始属性名的映射。viper 和
+// ReadTomlProperties 都会把属性名转成小写，使用原始属性名设置属性值才能按照规范化
+// 的属性名获取属性值，解析失败时返回空映射。
+func OriginalPropertyKeys(data []byte, configType string) map[string]string {
+
+	var m map[string]interface{}
+
+	switch strings.ToLower(configType) {
+	case "yaml", "yml":
+		var y map[interface{}]interface{}
+		if yaml.Unmarshal(data, &y) == nil {
+			m = cast.ToStringMap(y)
+		}
+	case "json":
+		_ = json.Unmarshal(data, &m)
+	case "toml":
+		_, _ = toml.Decode(string(data), &m)
+	case "properties", "props", "prop":
+		if r, err := properties.Load(data, properties.UTF8); err == nil {
+			m = make(map[string]interface{})
+			for _, key := range r.Keys() {
+				m[key] = nil
+			}
+		}
+	}
+
+	result := make(map[string]string)
+
+	var flatten func(key string, v interface{})
+	flatten = func(key string, v interface{}) {
+		if key != "" {
+			result[strings.ToLower(key)] = key
+			key += "."
+		}
+		switch c := v.(type) {
+		case map[string]interface{}:
+			for k, e := range c {
+				flatten(key+k, e)
+			}
+		case map[interface{}]interface{}:
+			flatten(strings.TrimSuffix(key, "."), cast.ToStringMap(c))
+		case []map[string]interface{}:
+			for i, e := range c {
+				flatten(key+strconv.Itoa(i), e)
+			}
+		case []interface{}:
+			for i, e := range c {
+				flatten(key+strconv.Itoa(i), e)
+			}
+		}
+	}
+
+	flatten("", m)
+	return result
+}
+
 // LoadProperties 加载属性配置文件，支持 properties、yaml 和 toml 三种文件格式。
 func (p *defaultProperties) LoadProperties(filename string) {
 	SpringLogger.Debug("load properties from file: ", filename)
 
-	p.readProperties(func(v *viper.Viper) error {
-		v.SetConfigFile(filename)
-		return v.ReadInConfig()
-	})
+	data, err := ioutil.ReadFile(filename)
+	SpringUtils.Panic(err).When(err != nil)
+	p.readProperties(data, strings.TrimPrefix(filepath.Ext(filename), "."))
 }
 
 // ReadProperties 读取属性配置文件，支持 properties、yaml 和 toml 三种文件格式。
 func (p *defaultProperties) ReadProperties(reader io.Reader, configType string) {
 	SpringLogger.Debug("load properties from reader type: ", configType)
 
-	p.readProperties(func(v *viper.Viper) error {
-		v.SetConfigType(configType)
-		return v.ReadConfig(reader)
-	})
+	data, err := ioutil.ReadAll(reader)
+	SpringUtils.Panic(err).When(err != nil)
+	p.readProperties(data, configType)
 }
 
-// GetProperty 返回 keys 中第一个存在的属性值，属性名称统一转成小写。
+// GetProperty 返回 keys 中第一个存在的属性值，属性名称统一转成小写，并且支持规范化的属性名称。
 func (p *defaultProperties) GetProperty(keys ...string) interface{} {
 	for _, key := range keys {
-		if v, ok := p.properties[strings.ToLower(key)]; ok {
+		if v, ok := p.lookup(key); ok {
 			return v
 		}
 	}
@@ -133,9 +250,9 @@ func (p *defaultProperties) GetStringProperty(keys ...string) string {
 	return cast.ToString(p.GetProperty(keys...))
 }
 
-// GetDurationProperty 返回 keys 中第一个存在的 Duration 类型属性值，属性名称统一转成小写。
+// GetDurationProperty 返回 keys 中第一个存在的 Duration 类型属性值，支持 d 和 w 单位，属性名称统一转成小写。
 func (p *defaultProperties) GetDurationProperty(keys ...string) time.Duration {
-	return cast.ToDuration(p.GetProperty(keys...))
+	return toDuration(p.GetProperty(keys...))
 }
 
 // GetTimeProperty 返回 keys 中第一个存在的 Time 类型的属性值，属性名称统一转成小写。
@@ -145,19 +262,22 @@ func (p *defaultProperties) GetTimeProperty(keys ...string) time.Time {
 
 // SetProperty 设置属性值，属性名称统一转成小写。
 func (p *defaultProperties) SetProperty(key string, value interface{}) {
-	p.properties[strings.ToLower(key)] = value
+	k := strings.ToLower(key)
+	p.properties[k] = value
+	p.normalized[NormalizePropertyKey(key)] = k
 }
 
-// GetDefaultProperty 返回属性值，如果没有找到则使用指定的默认值，属性名称统一转成小写。
+// GetDefaultProperty 返回属性值，如果没有找到则使用指定的默认值，属性名称统一转成小写，并且支持规范化的属性名称。
 func (p *defaultProperties) GetDefaultProperty(key string, def interface{}) (interface{}, bool) {
-	if v, ok := p.properties[strings.ToLower(key)]; ok {
+	if v, ok := p.lookup(key); ok {
 		return v, true
 	}
 	return def, false
 }
 
-// GetPrefixProperties 返回指定前缀的属性值集合，属性名称统一转成小写。
+// GetPrefixProperties 返回指定前缀的属性值集合，属性名称统一转成小写，并且支持规范化的属性名称。
 func (p *defaultProperties) GetPrefixProperties(prefix string) map[string]interface{} {
+	np := NormalizePropertyKey(prefix)
 	prefix = strings.ToLower(prefix)
 	result := make(map[string]interface{})
 	for k, v := range p.properties {
@@ -165,6 +285,18 @@ func (p *defaultProperties) GetPrefixProperties(prefix string) map[string]interf
 			result[k] = v
 		}
 	}
+
+	// 规范化之后匹配的属性使用调用方的前缀作为属性名的前缀
+	for nk, k := range p.normalized {
+		if nk == np || strings.HasPrefix(nk, np+".") {
+			key := prefix + strings.TrimPrefix(nk, np)
+			if _, ok := result[key]; !ok {
+				if _, ok = result[k]; !ok {
+					result[key] = p.properties[k]
+				}
+			}
+		}
+	}
 	return result
 }
 
@@ -179,6 +311,7 @@ type bindOption struct {
 	fullPropName   string // 完整属性名
 	fieldName      string // 结构体字段的名称
 	allAccess      bool   // 私有字段是否绑定
+	validate       bool   // 是否校验结构体的 validate 标签
 }
 
 // bindStruct 对结构体进行属性值绑定
@@ -251,6 +384,11 @@ func bindStructField(p Properties, v reflect.Value, str string, opt bindOption)
 	}
 
 	bindValue(p, v, key, def, opt)
+
+	// 嵌套的结构体由校验器递归校验，所以只在最外层校验
+	if opt.validate {
+		validateStruct(v, opt)
+	}
 }
 
 func getPropertyValue(p Properties, k reflect.Kind, key string, def interface{}, opt bindOption) interface{} {
@@ -445,7 +583,7 @@ func bindValue(p Properties, v reflect.Value, key string, def interface{}, opt b
 					if sv, err := cast.ToStringMapE(si); err == nil {
 						ev := reflect.New(elemType)
 						subFullPropName := fmt.Sprintf("%s[%d]", key, i)
-						bindStruct(&defaultProperties{sv}, ev.Elem(), bindOption{
+						bindStruct(newMapProperties(sv), ev.Elem(), bindOption{
 							fullPropName: subFullPropName,
 							fieldName:    opt.fieldName,
 							allAccess:    opt.allAccess,
@@ -471,7 +609,7 @@ func bindValue(p Properties, v reflect.Value, key string, def interface{}, opt b
 		// 首先处理使用类型转换器的场景
 		if fn, ok := typeConverters[elemType]; ok {
 			if mapValue, err := cast.ToStringMapStringE(propValue); err == nil {
-				prefix := key + "."
+				prefix := strings.ToLower(key) + "."
 				fnValue := reflect.ValueOf(fn)
 				result := reflect.MakeMap(t)
 				for k0, v0 := range mapValue {
@@ -497,7 +635,7 @@ func bindValue(p Properties, v reflect.Value, key string, def interface{}, opt b
 			panic(errors.New("暂未支持"))
 		case reflect.String:
 			if mapValue, err := cast.ToStringMapStringE(propValue); err == nil {
-				prefix := key + "."
+				prefix := strings.ToLower(key) + "."
 				result := make(map[string]string)
 				for k0, v0 := range mapValue {
 					k0 = strings.TrimPrefix(k0, prefix)
@@ -511,7 +649,7 @@ func bindValue(p Properties, v reflect.Value, key string, def interface{}, opt b
 			// 处理结构体字段的场景
 			if mapValue, err := cast.ToStringMapE(propValue); err == nil {
 				temp := make(map[string]map[string]interface{})
-				trimKey := key + "."
+				trimKey := strings.ToLower(key) + "."
 				var ok bool
 
 				// 将一维 map 变成二维 map
@@ -530,7 +668,7 @@ func bindValue(p Properties, v reflect.Value, key string, def interface{}, opt b
 				for k1, v1 := range temp {
 					ev := reflect.New(elemType)
 					subFullPropName := fmt.Sprintf("%s.%s", key, k1)
-					bindStruct(&defaultProperties{v1}, ev.Elem(), bindOption{
+					bindStruct(newMapProperties(v1), ev.Elem(), bindOption{
 						fullPropName: subFullPropName,
 						fieldName:    opt.fieldName,
 						allAccess:    opt.allAccess,
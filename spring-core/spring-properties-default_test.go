@@ -497,6 +497,29 @@ func TestDefaultProperties_ReadProperties_Toml(t *testing.T) {
 			assert.Equal(t, v, expect)
 		}
 	})
+
+	t.Run("mixed case", func(t *testing.T) {
+
+		str := `
+          [Server.HTTP]
+          Port=8080
+
+          [[Servers]]
+          Host="a"
+          Opts={ TLS=true }
+        `
+
+		p := SpringCore.NewDefaultProperties()
+		r := strings.NewReader(str)
+		p.ReadProperties(r, "toml")
+
+		assert.Equal(t, p.GetProperty("server.http.port"), int64(8080))
+		assert.Equal(t, p.GetProperty("servers.0.host"), "a")
+		assert.Equal(t, p.GetProperty("servers.0.opts.tls"), true)
+		assert.Equal(t, p.GetProperty("servers"), []interface{}{
+			map[string]interface{}{"host": "a", "opts": map[string]interface{}{"tls": true}},
+		})
+	})
 }
 
 func PointConverter(val string) image.Point {
@@ -530,6 +553,55 @@ func TestRegisterTypeConverter(t *testing.T) {
 	SpringCore.RegisterTypeConverter(PointConverter)
 }
 
+func TestParseDuration(t *testing.T) {
+
+	data := map[string]time.Duration{
+		"1w2d3h":  7*24*time.Hour + 2*24*time.Hour + 3*time.Hour,
+		"0d":      0,
+		"7d":      7 * 24 * time.Hour,
+		"1.5d":    36 * time.Hour,
+		"-1d30m":  -(24*time.Hour + 30*time.Minute),
+		"1h30m":   90 * time.Minute,
+		"300ms":   300 * time.Millisecond,
+		"2h45m5s": 2*time.Hour + 45*time.Minute + 5*time.Second,
+	}
+
+	for s, expect := range data {
+		d, err := SpringCore.ParseDuration(s)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, d, expect, s)
+	}
+
+	for _, s := range []string{"", "d", "1x", "1dd", "w1", "1d2", "1..5d", "20000w", "15000w15000w", "106751d24h"} {
+		_, err := SpringCore.ParseDuration(s)
+		assert.Equal(t, err != nil, true, s)
+	}
+
+	p := SpringCore.NewDefaultProperties()
+	p.SetProperty("cache.ttl", "7d")
+	p.SetProperty("cache.timeout", "3s")
+
+	var cache struct {
+		TTL     time.Duration `value:"${ttl}"`
+		Timeout time.Duration `value:"${timeout}"`
+	}
+	p.BindProperty("cache", &cache)
+	assert.Equal(t, cache.TTL, 7*24*time.Hour)
+	assert.Equal(t, cache.Timeout, 3*time.Second)
+
+	p.SetProperty("cache.period", "2w")
+	p.SetProperty("cache.delay", 1000)
+	p.SetProperty("cache.overflow", "20000w")
+	assert.Equal(t, p.GetDurationProperty("cache.ttl"), 7*24*time.Hour)
+	assert.Equal(t, p.GetDurationProperty("cache.period"), 14*24*time.Hour)
+	assert.Equal(t, p.GetDurationProperty("cache.timeout"), 3*time.Second)
+	assert.Equal(t, p.GetDurationProperty("cache.delay"), 1000*time.Nanosecond)
+	assert.Equal(t, p.GetDurationProperty("cache.overflow"), time.Duration(0))
+
+	pp := SpringCore.NewPriorityProperties(p, SpringCore.NewDefaultProperties())
+	assert.Equal(t, pp.GetDurationProperty("cache.period"), 14*24*time.Hour)
+}
+
 func TestDefaultProperties_GetProperty(t *testing.T) {
 	p := SpringCore.NewDefaultProperties()
 
@@ -759,3 +831,60 @@ func TestDefaultProperties_StringMapString(t *testing.T) {
 		assert.Equal(t, dbConfig2.DB["d1"].DB, "db1")
 	})
 }
+
+type NormalizedServerConfig struct {
+	ContextPath string            `value:"${server.contextPath}"`
+	MaxIdle     int               `value:"${server.max-idle}"`
+	Headers     map[string]string `value:"${server.extraHeaders}"`
+}
+
+func TestDefaultProperties_NormalizePropertyKey(t *testing.T) {
+
+	p := SpringCore.NewDefaultProperties()
+	p.SetProperty("server.context-path", "/api")
+	p.SetProperty("server.maxIdle", 8)
+	p.SetProperty("server.extra_headers.x-app", "demo")
+
+	t.Run("get", func(t *testing.T) {
+		assert.Equal(t, p.GetStringProperty("server.contextPath"), "/api")
+		assert.Equal(t, p.GetStringProperty("server.context_path"), "/api")
+		assert.Equal(t, p.GetIntProperty("server.max-idle"), int64(8))
+		assert.Equal(t, p.GetIntProperty("server.maxIdle"), int64(8))
+		assert.Equal(t, p.GetProperty("server.missing-key"), nil)
+	})
+
+	t.Run("prefix", func(t *testing.T) {
+		m := p.GetPrefixProperties("server.extraHeaders")
+		assert.Equal(t, m, map[string]interface{}{"server.extraheaders.x-app": "demo"})
+	})
+
+	t.Run("bind", func(t *testing.T) {
+		var c NormalizedServerConfig
+		p.BindProperty("", &c)
+		assert.Equal(t, c.ContextPath, "/api")
+		assert.Equal(t, c.MaxIdle, 8)
+		assert.Equal(t, c.Headers, map[string]string{"x-app": "demo"})
+	})
+
+	t.Run("file", func(t *testing.T) {
+
+		files := map[string]string{
+			"yaml":       "server:\n  contextPath: /api\n  maxIdle: 8\n  extra_headers:\n    x-app: demo\n",
+			"json":       `{"server": {"contextPath": "/api", "maxIdle": 8, "extra_headers": {"x-app": "demo"}}}`,
+			"properties": "server.contextPath=/api\nserver.maxIdle=8\nserver.extra_headers.x-app=demo\n",
+			"toml":       "[server]\ncontextPath = \"/api\"\nmaxIdle = 8\n[server.extra_headers]\nx-app = \"demo\"\n",
+		}
+
+		for configType, content := range files {
+			p := SpringCore.NewDefaultProperties()
+			p.ReadProperties(strings.NewReader(content), configType)
+			assert.Equal(t, p.GetStringProperty("server.context-path"), "/api")
+			assert.Equal(t, p.GetIntProperty("server.max-idle"), int64(8))
+
+			var c NormalizedServerConfig
+			p.BindProperty("", &c)
+			assert.Equal(t, c.ContextPath, "/api")
+			assert.Equal(t, c.Headers, map[string]string{"x-app": "demo"})
+		}
+	})
+}
@@ -79,9 +79,9 @@ func (p *priorityProperties) GetStringProperty(keys ...string) string {
 	return cast.ToString(p.GetProperty(keys...))
 }
 
-// GetDurationProperty 返回 keys 中第一个存在的 Duration 类型属性值，属性名称统一转成小写。
+// GetDurationProperty 返回 keys 中第一个存在的 Duration 类型属性值，支持 d 和 w 单位，属性名称统一转成小写。
 func (p *priorityProperties) GetDurationProperty(keys ...string) time.Duration {
-	return cast.ToDuration(p.GetProperty(keys...))
+	return toDuration(p.GetProperty(keys...))
 }
 
 // GetTimeProperty 返回 keys 中第一个存在的 Time 类型的属性值，属性名称统一转成小写。
@@ -105,7 +105,13 @@ func (p *priorityProperties) GetDefaultProperty(key string, def interface{}) (in
 
 // GetPrefixProperties 返回指定前缀的属性值集合，属性名称统一转成小写。
 func (p *priorityProperties) GetPrefixProperties(prefix string) map[string]interface{} {
-	panic(SpringConst.UnimplementedMethod)
+	properties := p.curr.GetPrefixProperties(prefix)
+	for key, val := range p.next.GetPrefixProperties(prefix) {
+		if _, ok := properties[key]; !ok {
+			properties[key] = val
+		}
+	}
+	return properties
 }
 
 // GetProperties 返回所有的属性值，属性名称统一转成小写。
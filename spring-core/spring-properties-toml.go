@@ -0,0 +1,93 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package SpringCore
+
+import (
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// ReadTomlProperties 读取 TOML 格式的属性列表并展开属性名，表和内联表展开为 a.b.c 的
+// 形式，数组元素展开为 a.0.b 的形式，同时保留数组本身的属性值以便绑定到切片字段上。
+// 所有的属性名以及数组中保留的表的键都转成小写，文件、ConfigMap 以及 Properties 中
+// 的 TOML 都使用这个函数解析。
+func ReadTomlProperties(reader io.Reader) (map[string]interface{}, error) {
+	var m map[string]interface{}
+	if _, err := toml.DecodeReader(reader, &m); err != nil {
+		return nil, err
+	}
+	result := make(map[string]interface{})
+	flattenTomlValue(result, "", m)
+	return result, nil
+}
+
+// flattenTomlValue 将 TOML 的属性值按照点号风格展开到 result 中
+func flattenTomlValue(result map[string]interface{}, key string, value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, val := range v {
+			subKey := strings.ToLower(k)
+			if key != "" {
+				subKey = key + "." + subKey
+			}
+			flattenTomlValue(result, subKey, val)
+		}
+	case []map[string]interface{}: // 表数组
+		arr := make([]interface{}, len(v))
+		for i, val := range v {
+			arr[i] = lowerTomlKeys(val)
+			flattenTomlValue(result, key+"."+strconv.Itoa(i), val)
+		}
+		result[key] = arr
+	case []interface{}:
+		for i, val := range v {
+			flattenTomlValue(result, key+"."+strconv.Itoa(i), val)
+		}
+		result[key] = lowerTomlKeys(v)
+	default:
+		result[key] = v
+	}
+}
+
+// lowerTomlKeys 返回将表的键转成小写之后的属性值的拷贝，递归处理嵌套的表和数组
+func lowerTomlKeys(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			m[strings.ToLower(k)] = lowerTomlKeys(val)
+		}
+		return m
+	case []map[string]interface{}:
+		arr := make([]interface{}, len(v))
+		for i, val := range v {
+			arr[i] = lowerTomlKeys(val)
+		}
+		return arr
+	case []interface{}:
+		arr := make([]interface{}, len(v))
+		for i, val := range v {
+			arr[i] = lowerTomlKeys(val)
+		}
+		return arr
+	default:
+		return v
+	}
+}
@@ -20,7 +20,9 @@ import (
 	"errors"
 	"io"
 	"reflect"
+	"strings"
 	"time"
+	"unicode"
 )
 
 // Properties 定义属性值接口
@@ -33,7 +35,7 @@ type Properties interface {
 	// 支持 properties、yaml 和 toml 三种文件格式。
 	ReadProperties(reader io.Reader, configType string)
 
-	// GetProperty 返回 keys 中第一个存在的属性值，属性名称统一转成小写。
+	// GetProperty 返回 keys 中第一个存在的属性值，属性名称统一转成小写，并且支持规范化的属性名称。
 	GetProperty(keys ...string) interface{}
 
 	// GetBoolProperty 返回 keys 中第一个存在的布尔型属性值，属性名称统一转成小写。
@@ -51,19 +53,19 @@ type Properties interface {
 	// GetStringProperty 返回 keys 中第一个存在的字符串型属性值，属性名称统一转成小写。
 	GetStringProperty(keys ...string) string
 
-	// GetDurationProperty 返回 keys 中第一个存在的 Duration 类型属性值，属性名称统一转成小写。
+	// GetDurationProperty 返回 keys 中第一个存在的 Duration 类型属性值，支持 d 和 w 单位，属性名称统一转成小写。
 	GetDurationProperty(keys ...string) time.Duration
 
 	// GetTimeProperty 返回 keys 中第一个存在的 Time 类型的属性值，属性名称统一转成小写。
 	GetTimeProperty(keys ...string) time.Time
 
-	// GetDefaultProperty 返回属性值，如果没有找到则使用指定的默认值，属性名称统一转成小写。
+	// GetDefaultProperty 返回属性值，如果没有找到则使用指定的默认值，属性名称统一转成小写，并且支持规范化的属性名称。
 	GetDefaultProperty(key string, def interface{}) (interface{}, bool)
 
 	// SetProperty 设置属性值，属性名称统一转成小写。
 	SetProperty(key string, value interface{})
 
-	// GetPrefixProperties 返回指定前缀的属性值集合，属性名称统一转成小写。
+	// GetPrefixProperties 返回指定前缀的属性值集合，属性名称统一转成小写，并且支持规范化的属性名称。
 	GetPrefixProperties(prefix string) map[string]interface{}
 
 	// GetProperties 返回所有的属性值，属性名称统一转成小写。
@@ -103,3 +105,28 @@ func RegisterTypeConverter(fn interface{}) {
 		panic(errors.New("fn must be func(string)type"))
 	}
 }
+
+// NormalizePropertyKey 将属性名称的每一段转换成短横线风格，例如 server.contextPath、
+// Server.ContextPath 和 server.context_path 都转换成 server.context-path。
+func NormalizePropertyKey(key string) string {
+	var sb strings.Builder
+	runes := []rune(key)
+	for i, r := range runes {
+		switch {
+		case r == '_':
+			sb.WriteRune('-')
+		case unicode.IsUpper(r):
+			if i > 0 {
+				prev := runes[i-1]
+				if unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+					(unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+					sb.WriteRune('-')
+				}
+			}
+			sb.WriteRune(unicode.ToLower(r))
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
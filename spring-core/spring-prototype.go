@@ -0,0 +1,220 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package SpringCore
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/go-spring/go-spring-parent/spring-logger"
+)
+
+// ExhaustedPolicy 原型 Bean 的实例数达到上限之后的处理策略
+type ExhaustedPolicy int
+
+const (
+	ExhaustedBlock        = ExhaustedPolicy(iota) // 等待其他实例被销毁
+	ExhaustedError                                // panic
+	ExhaustedReturnOldest                         // 返回最早完成注入的实例
+)
+
+// prototypePool 原型 Bean 存活的实例
+type prototypePool struct {
+	mutex    sync.Mutex
+	live     []*scopedBean // 完成注入的实例，按照完成注入的顺序保存
+	wiring   int           // 正在注入的实例数，同样计入实例数的上限
+	released chan struct{} // 有实例被销毁或者完成注入时关闭，用于唤醒等待的调用者
+}
+
+// newPrototypePool prototypePool 的构造函数
+func newPrototypePool() *prototypePool {
+	return &prototypePool{released: make(chan struct{})}
+}
+
+// notify 唤醒所有等待创建实例的调用者，调用时不能持有锁
+func (p *prototypePool) notify() {
+	p.mutex.Lock()
+	close(p.released)
+	p.released = make(chan struct{})
+	p.mutex.Unlock()
+}
+
+// PrototypeScoped 设置 Bean 为原型作用域，每次注入或者获取都会创建一个新的实例，
+// 实例通过 DestroyPrototype 销毁，没有销毁的实例在容器关闭时销毁。
+func (d *BeanDefinition) PrototypeScoped() *BeanDefinition {
+	return d.setScope(PrototypeScope)
+}
+
+// MaxInstances 设置原型 Bean 同时存活的最大实例数，达到上限之后根据 policy 的
+// 设置等待、panic 或者返回最早完成注入的实例，默认等待。等待在容器关闭时结束并 panic，
+// 容器启动过程中注入的实例数超过上限时直接 panic，因为没有其他调用者能够销毁实例。
+func (d *BeanDefinition) MaxInstances(n int, policy ...ExhaustedPolicy) *BeanDefinition {
+
+	if d.scope != PrototypeScope {
+		panic(fmt.Errorf("%s bean: \"%s\" can't limit instances", d.scope, d.BeanId()))
+	}
+
+	if n <= 0 {
+		panic(fmt.Errorf("bean: \"%s\" max instances must be positive", d.BeanId()))
+	}
+
+	d.maxInstances = n
+	if len(policy) > 0 {
+		d.exhausted = policy[0]
+	}
+	return d
+}
+
+// prototypeBeanValue 创建原型 Bean 的新实例，实例数达到上限时根据策略处理
+func (assembly *defaultBeanAssembly) prototypeBeanValue(bd *BeanDefinition) reflect.Value {
+
+	// 每次注入都会创建新的实例，所以循环依赖无法通过注入栈检测出来
+	if _, ok := assembly.prototypes[bd]; ok {
+		panic(fmt.Errorf("found circle autowire, prototype bean: \"%s\"", bd.BeanId()))
+	}
+
+	ctx := assembly.springCtx
+	v, _ := ctx.prototypes.LoadOrStore(bd, newPrototypePool())
+	p := v.(*prototypePool)
+
+	p.mutex.Lock()
+
+	for {
+		if ctx.ctx.Err() != nil {
+			p.mutex.Unlock()
+			panic(errors.New("spring context have been closed"))
+		}
+		if bd.maxInstances <= 0 || len(p.live)+p.wiring < bd.maxInstances {
+			break
+		}
+		switch bd.exhausted {
+		case ExhaustedError:
+			p.mutex.Unlock()
+			panic(fmt.Errorf("prototype bean: \"%s\" reached max instances %d", bd.BeanId(), bd.maxInstances))
+		case ExhaustedReturnOldest:
+			if len(p.live) > 0 { // 只返回完成注入的实例，否则等待正在注入的实例
+				b := p.live[0]
+				p.mutex.Unlock()
+				return b.bd.Value()
+			}
+		}
+		if !ctx.started {
+			p.mutex.Unlock()
+			panic(fmt.Errorf("prototype bean: \"%s\" reached max instances %d during startup", bd.BeanId(), bd.maxInstances))
+		}
+		released := p.released
+		p.mutex.Unlock()
+		select {
+		case <-released:
+		case <-ctx.ctx.Done():
+		}
+		p.mutex.Lock()
+	}
+
+	b := bd.newScopedInstance()
+	p.wiring++
+	p.mutex.Unlock()
+
+	assembly.prototypes[bd] = struct{}{}
+	defer delete(assembly.prototypes, bd)
+
+	wired := false
+	defer func() { // 完成注入的实例才计入存活的实例，注入失败的实例不再计入实例数
+		p.mutex.Lock()
+		p.wiring--
+		if wired {
+			p.live = append(p.live, b)
+		}
+		p.mutex.Unlock()
+		p.notify()
+	}()
+
+	// 注入的过程中可能需要获取其他的原型 Bean，所以不能持有锁
+	assembly.wireBeanDefinition(b.bd, false)
+	wired = true
+	return b.bd.Value()
+}
+
+// DestroyPrototype 销毁原型 Bean 的实例，执行其销毁函数并唤醒等待创建实例的调用者，
+// 找到实例返回 true 否则返回 false。
+func (ctx *defaultSpringContext) DestroyPrototype(bean interface{}) bool {
+	ctx.checkAutoWired()
+
+	found := false
+	ctx.prototypes.Range(func(key, value interface{}) bool {
+		p := value.(*prototypePool)
+		p.mutex.Lock()
+		for i, b := range p.live {
+			if sameBean(b.bd.Value(), bean) {
+				p.live = append(p.live[:i], p.live[i+1:]...)
+				p.mutex.Unlock()
+				p.notify()
+				if b.destroy != nil {
+					if err := b.destroy.run(newDefaultBeanAssembly(ctx)); err != nil {
+						SpringLogger.Error(err)
+					}
+				}
+				found = true
+				return false
+			}
+		}
+		p.mutex.Unlock()
+		return true
+	})
+	return found
+}
+
+// sameBean 判断 Bean 的值和 bean 是否是同一个实例
+func sameBean(v reflect.Value, bean interface{}) bool {
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	u := reflect.ValueOf(bean)
+	return v.IsValid() && u.IsValid() && v.Type() == u.Type() && v.Pointer() == u.Pointer()
+}
+
+// destroyPrototypes 逆序销毁所有原型 Bean 存活的实例，并唤醒等待创建实例的调用者
+func (ctx *defaultSpringContext) destroyPrototypes(assembly *defaultBeanAssembly) {
+	ctx.prototypes.Range(func(key, value interface{}) bool {
+		p := value.(*prototypePool)
+		p.mutex.Lock()
+		live := p.live
+		p.live = nil
+		p.mutex.Unlock()
+		p.notify()
+
+		for i := len(live) - 1; i >= 0; i-- {
+			if b := live[i]; b.destroy != nil {
+				if err := b.destroy.run(assembly); err != nil {
+					SpringLogger.Error(err)
+				}
+			}
+		}
+		return true
+	})
+}
+
+// wakePrototypeWaiters 唤醒所有等待创建原型 Bean 实例的调用者，容器关闭时调用，
+// 避免等待的 goroutine 阻塞 SafeGoroutine 的退出。
+func (ctx *defaultSpringContext) wakePrototypeWaiters() {
+	ctx.prototypes.Range(func(key, value interface{}) bool {
+		value.(*prototypePool).notify()
+		return true
+	})
+}
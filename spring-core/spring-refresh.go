@@ -0,0 +1,491 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package SpringCore
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-spring/go-spring-parent/spring-logger"
+)
+
+// AutoRefresh 设置单例 Bean 的自动刷新间隔，容器每隔 interval 重新调用构造函数创建
+// 新的实例并发布。已经注入的指针仍然指向旧的实例，需要感知刷新的使用者通过 WeakReference
+// 或者 GetBean 获取最新的实例。仍被其他 Bean 引用的旧实例在容器关闭时才销毁，没有被
+// 引用的旧实例在发布之后立即销毁。Bean 必须是通过函数注册的结构体指针。
+func (d *BeanDefinition) AutoRefresh(interval time.Duration) *BeanDefinition {
+
+	if d.scope != SingletonScope {
+		panic(fmt.Errorf("%s bean: \"%s\" can't auto refresh", d.scope, d.BeanId()))
+	}
+
+	if interval <= 0 {
+		panic(fmt.Errorf("bean: \"%s\" refresh interval must be positive", d.BeanId()))
+	}
+
+	switch d.bean.(type) {
+	case *constructorBean, *methodBean, *fakeMethodBean:
+		d.refresh = interval
+		return d
+	}
+	panic(fmt.Errorf("auto refresh bean: \"%s\" must be registered by function", d.BeanId()))
+}
+
+// RefreshLocker 返回刷新的读锁，持有读锁期间不会发布新的实例，旧的实例也不会被销毁
+func (d *BeanDefinition) RefreshLocker() sync.Locker {
+	return d.refreshMu.RLocker()
+}
+
+// Immutable 设置 Bean 为不可变的单例，表示 Bean 完成初始化之后不再有状态变化。
+// 容器刷新之后不可变的 Bean 不能通过 RefreshBean、RefreshProperties 或者 ReplaceBean
+// 修改，也不能设置自动刷新、存活时间、缓存键或者 Replaceable。
+func (d *BeanDefinition) Immutable() *BeanDefinition {
+	if d.scope != SingletonScope {
+		panic(fmt.Errorf("%s bean: \"%s\" can't be immutable", d.scope, d.BeanId()))
+	}
+	d.immutable = true
+	return d
+}
+
+// IsImmutable 返回 Bean 是否是不可变的单例
+func (d *BeanDefinition) IsImmutable() bool {
+	return d.immutable
+}
+
+// checkImmutableBeans 检查不可变的 Bean 是否设置了会修改实例的选项，存在时 panic
+func (ctx *defaultSpringContext) checkImmutableBeans() {
+	for _, bd := range ctx.beanMap {
+		if !bd.immutable {
+			continue
+		}
+		if bd.refresh > 0 || bd.ttl > 0 || bd.memoize != nil || bd.replaceable {
+			panic(fmt.Errorf("immutable bean: \"%s\" can't be refreshed or replaced", bd.BeanId()))
+		}
+	}
+}
+
+// startAutoRefresh 为设置了自动刷新的 Bean 启动刷新协程，直到容器关闭
+func (ctx *defaultSpringContext) startAutoRefresh() {
+	for _, bd := range ctx.beanMap {
+
+		if bd.refresh <= 0 {
+			continue
+		}
+
+		if t := bd.Type(); t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+			panic(fmt.Errorf("auto refresh bean: \"%s\" must be pointer to struct", bd.BeanId()))
+		}
+
+		b := bd
+		ctx.SafeGoroutine(func() {
+			ticker := time.NewTicker(b.refresh)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.ctx.Done():
+					return
+				case <-ticker.C:
+					if b.status != beanStatus_Wired { // 尚未注入的 Bean 无需刷新
+						continue
+					}
+					if err := ctx.rebuildBean(b); err != nil {
+						SpringLogger.Error(err)
+					}
+				}
+			}
+		})
+	}
+}
+
+// RefreshBean 重新初始化指定名称的单例 Bean，用于属性值变化之后不重启容器而更新 Bean。
+// 刷新过程先重新计算判断条件，然后以注入之前的内容为模板重新绑定属性值和注入依赖 (函数
+// 注册的 Bean 重新调用构造函数)，再发布新的实例并对旧的实例调用销毁函数。Bean 必须是
+// 结构体指针并且不能处于循环依赖之中。
+func (ctx *defaultSpringContext) RefreshBean(name string) (err error) {
+	ctx.checkAutoWired()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	bd, ok := ctx.FindBean(name)
+	if !ok {
+		return fmt.Errorf("can't find bean: \"%s\"", name)
+	}
+
+	return ctx.refreshBean(bd)
+}
+
+// refreshBean 检查 Bean 是否可以刷新，然后重新计算判断条件并重新创建 Bean
+func (ctx *defaultSpringContext) refreshBean(bd *BeanDefinition) error {
+
+	if bd.immutable {
+		return fmt.Errorf("bean: \"%s\" is immutable", bd.BeanId())
+	}
+
+	if bd.scope != SingletonScope || bd.status != beanStatus_Wired {
+		return fmt.Errorf("bean: \"%s\" isn't a wired singleton bean", bd.BeanId())
+	}
+
+	if t := bd.Type(); t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("bean: \"%s\" must be pointer to struct", bd.BeanId())
+	}
+
+	if path := ctx.dependencyCycle(bd); path != nil {
+		names := make([]string, 0, len(path))
+		for _, b := range path {
+			names = append(names, b.Name())
+		}
+		return fmt.Errorf("bean: \"%s\" found circular dependency: %s",
+			bd.BeanId(), strings.Join(names, " -> "))
+	}
+
+	if !bd.checkCondition(ctx) {
+		return fmt.Errorf("bean: \"%s\" doesn't match condition: %s", bd.BeanId(), bd.cond.String())
+	}
+
+	return ctx.rebuildBean(bd)
+}
+
+// RefreshProperties 重新初始化绑定了指定属性的所有单例 Bean，属性名可以是绑定时使用的
+// 属性名，也可以是它的前缀或者子属性名。被依赖的 Bean 先于依赖它的 Bean 刷新，这样后者
+// 可以注入前者的新实例。不可变的 Bean 不会被刷新，刷新失败的 Bean 保留原有实例，所有的
+// 错误合并之后返回。
+func (ctx *defaultSpringContext) RefreshProperties(keys ...string) error {
+	ctx.checkAutoWired()
+	atomic.AddUint64(&ctx.propVersion, 1)
+
+	affected := make(map[*BeanDefinition]bool)
+	for _, bd := range ctx.beanMap {
+		if bd.scope == SingletonScope && bd.status == beanStatus_Wired && !bd.immutable && bd.bindsProperty(keys) {
+			affected[bd] = true
+		}
+	}
+
+	var (
+		msgs    []string
+		visited = make(map[*BeanDefinition]bool)
+		visit   func(bd *BeanDefinition)
+	)
+
+	visit = func(bd *BeanDefinition) {
+		if visited[bd] {
+			return
+		}
+		visited[bd] = true
+		for _, dep := range ctx.beanDependencies(bd) {
+			if affected[dep] {
+				visit(dep)
+			}
+		}
+		err := func() (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("%v", r)
+				}
+			}()
+			return ctx.refreshBean(bd)
+		}()
+		if err != nil {
+			msgs = append(msgs, err.Error())
+		}
+	}
+
+	// 按照注册顺序刷新，保证结果稳定
+	beans := make([]*BeanDefinition, 0, len(affected))
+	for bd := range affected {
+		beans = append(beans, bd)
+	}
+	sort.Slice(beans, func(i, j int) bool { return beans[i].seq < beans[j].seq })
+
+	for _, bd := range beans {
+		visit(bd)
+	}
+
+	if len(msgs) > 0 {
+		return errors.New(strings.Join(msgs, "; "))
+	}
+	return nil
+}
+
+// bindsProperty 返回 Bean 注入时是否读取过 keys 中的属性、它们的前缀或者子属性
+func (d *BeanDefinition) bindsProperty(keys []string) bool {
+	d.refreshMu.RLock()
+	defer d.refreshMu.RUnlock()
+
+	for _, key := range keys {
+		key = NormalizePropertyKey(key)
+		for k := range d.propKeys {
+			if k == key || strings.HasPrefix(key, k+".") || strings.HasPrefix(k, key+".") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// trackedProperties 记录正在注入的 Bean 读取过的属性名
+type trackedProperties struct {
+	Properties
+	bd *BeanDefinition
+}
+
+// track 记录读取过的属性名
+func (p *trackedProperties) track(keys ...string) {
+	p.bd.refreshMu.Lock()
+	defer p.bd.refreshMu.Unlock()
+
+	if p.bd.propKeys == nil {
+		p.bd.propKeys = make(map[string]struct{})
+	}
+	for _, key := range keys {
+		p.bd.propKeys[NormalizePropertyKey(key)] = struct{}{}
+	}
+}
+
+// GetProperty 返回 keys 中第一个存在的属性值，并记录读取过的属性名
+func (p *trackedProperties) GetProperty(keys ...string) interface{} {
+	p.track(keys...)
+	return p.Properties.GetProperty(keys...)
+}
+
+// GetDefaultProperty 返回属性值，如果没有找到则使用指定的默认值，并记录读取过的属性名
+func (p *trackedProperties) GetDefaultProperty(key string, def interface{}) (interface{}, bool) {
+	p.track(key)
+	return p.Properties.GetDefaultProperty(key, def)
+}
+
+// GetPrefixProperties 返回指定前缀的属性值集合，并记录读取过的前缀
+func (p *trackedProperties) GetPrefixProperties(prefix string) map[string]interface{} {
+	p.track(prefix)
+	return p.Properties.GetPrefixProperties(prefix)
+}
+
+// Replaceable 设置 Bean 是否可以在运行时通过 ReplaceBean 替换为其他构造函数创建的实例
+func (d *BeanDefinition) Replaceable(replaceable bool) *BeanDefinition {
+	d.replaceable = replaceable
+	return d
+}
+
+// ReplaceBean 使用新的构造函数替换指定名称的单例 Bean，新的构造函数必须返回相同的
+// 类型。新实例完成注入之后被发布，之后通过 WeakReference 或者 GetBean 获取的是新的
+// 实例，已经注入的指针仍然指向旧的实例，所以被引用的旧实例在容器关闭时才销毁。Bean
+// 必须是设置了 Replaceable 的结构体指针，此后 RefreshBean 和自动刷新也使用新的构造函数。
+func (ctx *defaultSpringContext) ReplaceBean(name string, fn interface{}, tags ...string) (err error) {
+	ctx.checkAutoWired()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	bd, ok := ctx.FindBean(name)
+	if !ok {
+		return fmt.Errorf("can't find bean: \"%s\"", name)
+	}
+
+	if !bd.replaceable {
+		return fmt.Errorf("bean: \"%s\" isn't replaceable", bd.BeanId())
+	}
+
+	if bd.scope != SingletonScope || bd.status != beanStatus_Wired {
+		return fmt.Errorf("bean: \"%s\" isn't a wired singleton bean", bd.BeanId())
+	}
+
+	if t := bd.Type(); t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("bean: \"%s\" must be pointer to struct", bd.BeanId())
+	}
+
+	bean := newConstructorBean(fn, tags)
+	if bean.Type() != bd.Type() {
+		return fmt.Errorf("bean: \"%s\" can't be replaced by %s", bd.BeanId(), bean.Type().String())
+	}
+
+	// 新实例保留原有 Bean 的所有设置，只替换构造函数
+	nbd := bd.instanceOf(bean)
+	nbd.staticValidate()
+
+	assembly := newDefaultBeanAssembly(ctx)
+	b := &scopedBean{bd: nbd, destroy: bd.copyLifecycle(nbd)}
+	assembly.wireBeanDefinition(nbd, false)
+
+	// 后续的刷新使用新的构造函数
+	publishBean(assembly, bd, b, bean)
+
+	SpringLogger.Infof("%s replaced", bd.Description())
+	return nil
+}
+
+// rebuildBean 创建 Bean 的新实例并发布，创建失败时保留原有实例
+func (ctx *defaultSpringContext) rebuildBean(bd *BeanDefinition) (err error) {
+
+	assembly := newDefaultBeanAssembly(ctx)
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s refresh failed: %v", bd.Description(), r)
+		}
+	}()
+
+	b := bd.newRefreshInstance()
+	b.bd.staticValidate()
+	assembly.wireBeanDefinition(b.bd, false)
+	publishBean(assembly, bd, b, nil)
+
+	SpringLogger.Debugf("%s refreshed", bd.Description())
+	return nil
+}
+
+// publishBean 在写锁的保护下发布新的实例，并将容器关闭时的销毁函数换成新实例的销毁
+// 函数。旧的实例没有注入到其他 Bean 中时立即销毁，否则等到容器关闭时再销毁，避免
+// 依赖它的 Bean 使用已经销毁的实例。实例不会被原地修改，因此读取者不会看到修改了一半
+// 的实例。bean 不为 nil 时同时替换 Bean 的注册形式。
+func publishBean(assembly *defaultBeanAssembly, bd *BeanDefinition, b *scopedBean, bean springBean) {
+
+	bd.refreshMu.Lock()
+	_, referenced := bd.referenced[bd.Value().Pointer()]
+	if bean != nil {
+		bd.bean = bean
+	}
+	old := bd.destroy
+	if referenced && old != nil {
+		bd.retired = append(bd.retired, old)
+		old = nil
+	}
+	bd.current.Store(b.bd.Value())
+	bd.destroy = b.destroy
+	bd.propKeys = b.bd.propKeys
+	bd.refreshMu.Unlock()
+
+	if referenced {
+		SpringLogger.Infof("%s old instance is still referenced, destroy it when closing", bd.Description())
+	}
+
+	if old != nil {
+		if err := old.run(assembly); err != nil {
+			SpringLogger.Error(err)
+		}
+	}
+}
+
+// reference 记录注入到其他 Bean 中的实例
+func (d *BeanDefinition) reference(v reflect.Value) {
+	if v.Kind() != reflect.Ptr {
+		return
+	}
+	d.refreshMu.Lock()
+	defer d.refreshMu.Unlock()
+	if d.referenced == nil {
+		d.referenced = make(map[uintptr]struct{})
+	}
+	d.referenced[v.Pointer()] = struct{}{}
+}
+
+// destroyRetired 调用仍被引用的旧实例的销毁函数
+func (d *BeanDefinition) destroyRetired(assembly *defaultBeanAssembly) {
+	d.refreshMu.Lock()
+	retired := d.retired
+	d.retired = nil
+	d.refreshMu.Unlock()
+
+	for i := len(retired) - 1; i >= 0; i-- {
+		if err := retired[i].run(assembly); err != nil {
+			SpringLogger.Error(err)
+		}
+	}
+}
+
+// currentDestroy 返回当前发布的实例的销毁函数，刷新时销毁函数会被替换
+func (d *BeanDefinition) currentDestroy() *runnable {
+	d.refreshMu.RLock()
+	defer d.refreshMu.RUnlock()
+	return d.destroy
+}
+
+// newRefreshInstance 为刷新创建一个新的实例定义，对象 Bean 以注入之前的内容为模板
+func (d *BeanDefinition) newRefreshInstance() *scopedBean {
+
+	if _, ok := d.bean.(*objectBean); !ok {
+		return d.newScopedInstance()
+	}
+
+	v := reflect.New(d.Type().Elem())
+	v.Elem().Set(d.template.Elem())
+
+	bd := d.instanceOf(newObjectBean(v))
+
+	return &scopedBean{bd: bd, destroy: d.copyLifecycle(bd)}
+}
+
+// addDependency 记录 Bean 注入时依赖的其他 Bean
+func (ctx *defaultSpringContext) addDependency(bd *BeanDefinition, dep *BeanDefinition) {
+	ctx.depMutex.Lock()
+	defer ctx.depMutex.Unlock()
+
+	if ctx.dependencies == nil {
+		ctx.dependencies = make(map[*BeanDefinition][]*BeanDefinition)
+	}
+
+	for _, b := range ctx.dependencies[bd] {
+		if b == dep {
+			return
+		}
+	}
+	ctx.dependencies[bd] = append(ctx.dependencies[bd], dep)
+}
+
+// beanDependencies 返回 Bean 注入时依赖的其他 Bean
+func (ctx *defaultSpringContext) beanDependencies(bd *BeanDefinition) []*BeanDefinition {
+	ctx.depMutex.Lock()
+	defer ctx.depMutex.Unlock()
+	return append([]*BeanDefinition(nil), ctx.dependencies[bd]...)
+}
+
+// dependencyCycle 返回从 Bean 出发又回到该 Bean 的依赖路径，不存在时返回 nil
+func (ctx *defaultSpringContext) dependencyCycle(bd *BeanDefinition) []*BeanDefinition {
+	ctx.depMutex.Lock()
+	defer ctx.depMutex.Unlock()
+
+	visited := make(map[*BeanDefinition]bool)
+
+	var visit func(b *BeanDefinition, path []*BeanDefinition) []*BeanDefinition
+	visit = func(b *BeanDefinition, path []*BeanDefinition) []*BeanDefinition {
+		path = append(path, b)
+		for _, dep := range ctx.dependencies[b] {
+			if dep == bd {
+				return append(path, dep)
+			}
+			if !visited[dep] {
+				visited[dep] = true
+				if result := visit(dep, path); result != nil {
+					return result
+				}
+			}
+		}
+		return nil
+	}
+	return visit(bd, nil)
+}
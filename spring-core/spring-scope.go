@@ -0,0 +1,623 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package SpringCore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-spring/go-spring-parent/spring-logger"
+)
+
+// Scope Bean 的作用域
+type Scope string
+
+const (
+	SingletonScope = Scope("singleton") // 单例作用域，容器启动时创建
+	RequestScope   = Scope("request")   // 请求作用域，每个请求创建一个实例
+	SessionScope   = Scope("session")   // 会话作用域，每个会话创建一个实例
+	PrototypeScope = Scope("prototype") // 原型作用域，每次获取都创建一个实例
+
+	// ScopeRequest 即 RequestScope，web 服务器的过滤器在每个请求开始时创建请求作用域
+	ScopeRequest = RequestScope
+)
+
+// SingletonKey 自定义单例的缓存键，默认的缓存键是类型加名称。fn 在注入开始时
+// 根据属性值计算缓存键，Bean 的名称保持不变，同一类型缓存键相同的 Bean 共享同一个
+// 实例 (按照注册顺序保留第一个)，缓存键不同的 Bean 是不同的实例。
+func (d *BeanDefinition) SingletonKey(fn func(ctx SpringContext) string) *BeanDefinition {
+	if d.scope != SingletonScope {
+		panic(fmt.Errorf("%s bean: \"%s\" can't have singleton key", d.scope, d.BeanId()))
+	}
+	d.singletonKey = fn
+	return d
+}
+
+// resolveSingletonKeys 计算自定义的单例缓存键，并按照缓存键重新注册 Bean
+func (ctx *defaultSpringContext) resolveSingletonKeys() {
+
+	var beans []*BeanDefinition
+	for _, bd := range ctx.beanMap {
+		if bd.singletonKey != nil {
+			beans = append(beans, bd)
+		}
+	}
+
+	// 按照注册顺序排序
+	sort.Slice(beans, func(i, j int) bool {
+		return beans[i].seq < beans[j].seq
+	})
+
+	for _, bd := range beans {
+		delete(ctx.beanMap, bd.beanKey())
+	}
+
+	for _, bd := range beans {
+
+		key := bd.singletonKey(ctx)
+		if key == "" {
+			panic(fmt.Errorf("bean: \"%s\" singleton key can't be empty", bd.BeanId()))
+		}
+
+		bd.cacheKey = key
+		beanKey := bd.beanKey()
+
+		if prev, ok := ctx.beanMap[beanKey]; ok {
+			SpringLogger.Infof("%s shares instance with %s", bd.Description(), prev.Description())
+			bd.status = beanStatus_Deleted
+			continue
+		}
+
+		ctx.beanMap[beanKey] = bd
+	}
+}
+
+// sortedSingletons 返回按照初始化顺序排列的单例 Bean，作用域 Bean 在作用域内才创建
+func (ctx *defaultSpringContext) sortedSingletons() []*BeanDefinition {
+
+	var beans []*BeanDefinition
+	for _, bd := range ctx.beanMap {
+		if bd.scope == SingletonScope {
+			beans = append(beans, bd)
+		}
+	}
+
+	sort.Slice(beans, func(i, j int) bool {
+		if beans[i].order != beans[j].order {
+			return beans[i].order < beans[j].order
+		}
+		return beans[i].seq < beans[j].seq
+	})
+	return beans
+}
+
+// scopeKey 作用域子容器在 context.Context 中的键
+type scopeKey string
+
+// scopedBean 作用域内创建的 Bean 实例
+type scopedBean struct {
+	bd         *BeanDefinition
+	destroy    *runnable
+	created    time.Time // 创建的时间
+	lastAccess time.Time // 最后一次访问的时间
+}
+
+// scopedContainer 作用域子容器，保存作用域内创建的 Bean 实例
+type scopedContainer struct {
+	scope  Scope
+	mutex  sync.Mutex
+	beans  map[*BeanDefinition]*scopedBean
+	order  []*scopedBean // 按照创建顺序保存，销毁时逆序执行
+	closed bool          // 是否已经关闭，关闭后不能再使用
+
+	lastAccess time.Time // 最后一次访问的时间
+}
+
+// newScopedContainer scopedContainer 的构造函数
+func newScopedContainer(scope Scope) *scopedContainer {
+	return &scopedContainer{
+		scope:      scope,
+		beans:      make(map[*BeanDefinition]*scopedBean),
+		lastAccess: time.Now(),
+	}
+}
+
+// getBean 获取作用域内的 Bean 实例，不存在时创建，调用者需要持有锁
+func (c *scopedContainer) getBean(assembly *defaultBeanAssembly, bd *BeanDefinition) reflect.Value {
+
+	if c.closed {
+		panic(fmt.Errorf("%s scope have been closed", c.scope))
+	}
+
+	c.lastAccess = time.Now()
+
+	if b, ok := c.beans[bd]; ok { // 正在注入的 Bean 再次注入会检测出循环依赖
+		b.lastAccess = time.Now()
+		assembly.wireBeanDefinition(b.bd, false)
+		return b.bd.Value()
+	}
+
+	b := bd.newScopedInstance()
+	b.created = time.Now()
+	b.lastAccess = b.created
+	c.beans[bd] = b
+	c.order = append(c.order, b)
+
+	assembly.wireBeanDefinition(b.bd, false)
+	return b.bd.Value()
+}
+
+// destroyBeans 逆序执行作用域内所有 Bean 的销毁函数
+func (c *scopedContainer) destroyBeans(assembly *defaultBeanAssembly) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for i := len(c.order) - 1; i >= 0; i-- {
+		if b := c.order[i]; b.destroy != nil {
+			if err := b.destroy.run(assembly); err != nil {
+				SpringLogger.Error(err)
+			}
+		}
+	}
+
+	c.beans = make(map[*BeanDefinition]*scopedBean)
+	c.order = nil
+}
+
+// expireBeans 销毁超过有效期没有访问的 Bean，没有剩余 Bean 并且空闲超过 idle 时关闭子容器并返回 true
+func (c *scopedContainer) expireBeans(assembly *defaultBeanAssembly, now time.Time, idle time.Duration) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var order []*scopedBean
+	for _, b := range c.order {
+		if expiry := b.bd.expiry; expiry > 0 && now.Sub(b.lastAccess) > expiry {
+			delete(c.beans, c.origin(b))
+			if b.destroy != nil {
+				if err := b.destroy.run(assembly); err != nil {
+					SpringLogger.Error(err)
+				}
+			}
+		} else {
+			order = append(order, b)
+		}
+	}
+
+	c.order = order
+	c.closed = len(order) == 0 && now.Sub(c.lastAccess) > idle
+	return c.closed
+}
+
+// evictBean 销毁 Bean 在子容器内的实例，调用者需要持有锁
+func (c *scopedContainer) evictBean(assembly *defaultBeanAssembly, bd *BeanDefinition) {
+
+	b, ok := c.beans[bd]
+	if !ok {
+		return
+	}
+
+	delete(c.beans, bd)
+	for i, v := range c.order {
+		if v == b {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
+
+	if b.destroy != nil {
+		if err := b.destroy.run(assembly); err != nil {
+			SpringLogger.Error(err)
+		}
+	}
+}
+
+// origin 返回作用域内 Bean 实例对应的原始定义
+func (c *scopedContainer) origin(b *scopedBean) *BeanDefinition {
+	for bd, v := range c.beans {
+		if v == b {
+			return bd
+		}
+	}
+	return nil
+}
+
+// newScopedInstance 为作用域 Bean 创建一个新的实例定义，实例的销毁函数由作用域子容器负责调用
+func (d *BeanDefinition) newScopedInstance() *scopedBean {
+
+	var bean springBean
+
+	switch b := d.bean.(type) {
+	case *constructorBean:
+		c := *b
+		c.rValue = newFunctionBeanValue(reflect.TypeOf(b.fn).Out(0))
+		bean = &c
+	case *methodBean:
+		m := *b
+		method, _ := b.parent.Type().MethodByName(b.method)
+		m.rValue = newFunctionBeanValue(method.Type.Out(0))
+		bean = &m
+	default:
+		panic(fmt.Errorf("%s bean: \"%s\" must be registered by function", d.scope, d.BeanId()))
+	}
+
+	bd := d.instanceOf(bean)
+	return &scopedBean{bd: bd, destroy: d.copyLifecycle(bd)}
+}
+
+// instanceOf 以当前定义为模板复制一个新的实例定义，bean 为新实例的注册形式。新定义
+// 保留所有的设置，只重置状态、注册序号、生命周期函数以及运行时的数据，这样新增的设置
+// 不需要再逐个复制。
+func (d *BeanDefinition) instanceOf(bean springBean) *BeanDefinition {
+	bd := *d
+	bd.bean = bean
+	bd.status = beanStatus_Resolved
+	bd.seq = 0
+	bd.init, bd.destroy = nil, nil
+	bd.instanceId = ""
+	bd.propKeys = nil
+	bd.refreshMu = new(sync.RWMutex)
+	bd.current = new(atomic.Value)
+	bd.referenced, bd.retired = nil, nil
+	return &bd
+}
+
+// copyLifecycle 将初始化函数复制到新的实例定义上，并返回新实例的销毁函数，
+// 新实例的销毁函数由创建者负责调用
+func (d *BeanDefinition) copyLifecycle(bd *BeanDefinition) *runnable {
+
+	if d.init != nil {
+		init := *d.init
+		init.receiver = bd.Value()
+		bd.init = &init
+	}
+
+	if d.destroy != nil {
+		r := *d.destroy
+		r.receiver = bd.Value()
+		return &r
+	}
+	return nil
+}
+
+// RequestScoped 设置 Bean 为请求作用域，每个请求都会创建一个新的实例，请求结束时销毁
+func (d *BeanDefinition) RequestScoped() *BeanDefinition {
+	return d.setScope(RequestScope)
+}
+
+// SessionScoped 设置 Bean 为会话作用域，同一个会话的多个请求共享一个实例
+func (d *BeanDefinition) SessionScoped() *BeanDefinition {
+	return d.setScope(SessionScope)
+}
+
+// SessionExpiry 设置会话作用域 Bean 的有效期，超过有效期没有访问的实例会被自动销毁
+func (d *BeanDefinition) SessionExpiry(expiry time.Duration) *BeanDefinition {
+	if d.scope != SessionScope {
+		panic(fmt.Errorf("bean: \"%s\" isn't session scoped", d.BeanId()))
+	}
+	d.expiry = expiry
+	return d
+}
+
+// ExpiresAfter 设置单例 Bean 的存活时间，超过存活时间之后再次获取 Bean 时先销毁旧的实例
+// 然后创建新的实例。已经注入到其他 Bean 中的实例不会被替换，需要通过 GetBean 获取最新
+// 的实例。只有通过函数注册的 Bean 才能设置存活时间。
+func (d *BeanDefinition) ExpiresAfter(ttl time.Duration) *BeanDefinition {
+
+	if d.scope != SingletonScope {
+		panic(fmt.Errorf("%s bean: \"%s\" can't expire", d.scope, d.BeanId()))
+	}
+
+	if ttl <= 0 {
+		panic(fmt.Errorf("bean: \"%s\" ttl must be positive", d.BeanId()))
+	}
+
+	switch d.bean.(type) {
+	case *constructorBean, *methodBean, *fakeMethodBean:
+		d.ttl = ttl
+		return d
+	}
+	panic(fmt.Errorf("expiring bean: \"%s\" must be registered by function", d.BeanId()))
+}
+
+// expiringBeanValue 获取设置了存活时间的 Bean 的值，过期的实例先销毁再重新创建
+func (assembly *defaultBeanAssembly) expiringBeanValue(bd *BeanDefinition) reflect.Value {
+
+	c := assembly.springCtx.expiring
+	defer assembly.lockContainer(c)()
+
+	if b, ok := c.beans[bd]; ok && time.Since(b.created) > bd.ttl {
+		SpringLogger.Debugf("%s expired", bd.Description())
+		c.evictBean(assembly, bd)
+	}
+
+	return c.getBean(assembly, bd)
+}
+
+// lockContainer 对子容器加锁并返回释放锁的函数，同一个 assembly 只加锁一次，
+// 由最外层的调用释放锁，内层调用返回的函数什么也不做。
+func (assembly *defaultBeanAssembly) lockContainer(c *scopedContainer) func() {
+	if _, ok := assembly.lockedScopes[c]; ok {
+		return func() {}
+	}
+	c.mutex.Lock()
+	assembly.lockedScopes[c] = struct{}{}
+	return func() {
+		delete(assembly.lockedScopes, c)
+		c.mutex.Unlock()
+	}
+}
+
+// Memoize 为单例 Bean 设置缓存键，每次获取 Bean 时根据属性值计算缓存键，缓存键相同时
+// 返回同一个实例，缓存键不同时创建新的实例，所有的实例在容器关闭时销毁。已经注入到
+// 其他 Bean 中的实例不会被替换。只有通过函数注册的 Bean 才能设置缓存键。
+func (d *BeanDefinition) Memoize(fn func(ctx SpringContext) string) *BeanDefinition {
+
+	if d.scope != SingletonScope {
+		panic(fmt.Errorf("%s bean: \"%s\" can't be memoized", d.scope, d.BeanId()))
+	}
+
+	switch d.bean.(type) {
+	case *constructorBean, *methodBean, *fakeMethodBean:
+		d.memoize = fn
+		return d
+	}
+	panic(fmt.Errorf("memoized bean: \"%s\" must be registered by function", d.BeanId()))
+}
+
+// memoizedBeanValue 获取设置了缓存键的 Bean 的值，每个缓存键对应一个子容器
+func (assembly *defaultBeanAssembly) memoizedBeanValue(bd *BeanDefinition) reflect.Value {
+
+	key := bd.memoize(assembly.springCtx)
+	if key == "" {
+		panic(fmt.Errorf("bean: \"%s\" memoize key can't be empty", bd.BeanId()))
+	}
+
+	v, _ := assembly.springCtx.memoized.LoadOrStore(key, newScopedContainer(SingletonScope))
+	c := v.(*scopedContainer)
+	defer assembly.lockContainer(c)()
+
+	return c.getBean(assembly, bd)
+}
+
+// destroyMemoized 销毁所有设置了缓存键的 Bean 的实例
+func (ctx *defaultSpringContext) destroyMemoized(assembly *defaultBeanAssembly) {
+	ctx.memoized.Range(func(key, value interface{}) bool {
+		value.(*scopedContainer).destroyBeans(assembly)
+		ctx.memoized.Delete(key)
+		return true
+	})
+}
+
+// Scope 返回 Bean 的作用域
+func (d *BeanDefinition) Scope() Scope {
+	return d.scope
+}
+
+// setScope 设置 Bean 的作用域，非单例作用域的 Bean 只能通过函数注册
+func (d *BeanDefinition) setScope(scope Scope) *BeanDefinition {
+	switch d.bean.(type) {
+	case *constructorBean, *methodBean, *fakeMethodBean:
+		d.scope = scope
+		return d
+	}
+	panic(fmt.Errorf("%s bean: \"%s\" must be registered by function", scope, d.BeanId()))
+}
+
+// Scoped 设置 Bean 的作用域，除了预定义的作用域之外还可以是通过 NewScope 创建的
+// 自定义作用域，非单例作用域的 Bean 只能通过函数注册
+func (d *BeanDefinition) Scoped(scope Scope) *BeanDefinition {
+	if scope == "" {
+		panic(fmt.Errorf("bean: \"%s\" scope can't be empty", d.BeanId()))
+	}
+	return d.setScope(scope)
+}
+
+// ScopedBeanFactory 作用域 Bean 的工厂，本身是单例，可以注入到单例 Bean 中，
+// 然后在请求处理的过程中按需获取当前作用域内的实例。
+type ScopedBeanFactory struct {
+	ctx      SpringContext
+	selector BeanSelector
+}
+
+// NewScopedBeanFactory ScopedBeanFactory 的构造函数
+func NewScopedBeanFactory(ctx SpringContext, selector BeanSelector) *ScopedBeanFactory {
+	return &ScopedBeanFactory{ctx: ctx, selector: selector}
+}
+
+// GetBean 获取 scopeCtx 对应的作用域内的实例，不存在时创建；找到返回 true 否则返回 false。
+func (f *ScopedBeanFactory) GetBean(scopeCtx context.Context, i interface{}) bool {
+	return f.ctx.GetScopedBean(scopeCtx, i, f.selector)
+}
+
+// NewScope 创建一个作用域子容器并绑定到 parent 上，返回的 CancelFunc 用于在作用域结束时销毁其中的 Bean
+func (ctx *defaultSpringContext) NewScope(parent context.Context, scope Scope) (context.Context, context.CancelFunc) {
+	ctx.checkAutoWired()
+
+	if scope == SingletonScope || scope == GoroutineScope {
+		panic(fmt.Errorf("can't create %s scope", scope))
+	}
+
+	c := newScopedContainer(scope)
+	scopeCtx, cancel := context.WithCancel(context.WithValue(parent, scopeKey(scope), c))
+
+	return scopeCtx, func() {
+		cancel()
+		c.destroyBeans(newDefaultBeanAssembly(ctx))
+	}
+}
+
+// SessionScope 获取会话 ID 对应的会话作用域子容器，不存在时创建，并将其绑定到 parent 上
+func (ctx *defaultSpringContext) SessionScope(parent context.Context, sessionId string) context.Context {
+	ctx.checkAutoWired()
+
+	for {
+		v, _ := ctx.sessions.LoadOrStore(sessionId, newScopedContainer(SessionScope))
+		c := v.(*scopedContainer)
+
+		c.mutex.Lock()
+		closed := c.closed
+		c.lastAccess = time.Now()
+		c.mutex.Unlock()
+
+		if closed { // 子容器已经过期，删除后重新创建
+			ctx.sessions.Delete(sessionId)
+			continue
+		}
+
+		return context.WithValue(parent, scopeKey(SessionScope), c)
+	}
+}
+
+// DestroySession 销毁会话 ID 对应的会话作用域子容器中的 Bean，找到会话返回 true 否则返回 false
+func (ctx *defaultSpringContext) DestroySession(sessionId string) bool {
+	v, ok := ctx.sessions.Load(sessionId)
+	if !ok {
+		return false
+	}
+	ctx.sessions.Delete(sessionId)
+
+	c := v.(*scopedContainer)
+	c.destroyBeans(newDefaultBeanAssembly(ctx))
+
+	c.mutex.Lock()
+	c.closed = true
+	c.mutex.Unlock()
+	return true
+}
+
+// expireSessions 定期销毁过期的会话作用域 Bean，直到容器关闭，idle 是空闲会话的有效期
+func (ctx *defaultSpringContext) expireSessions(idle time.Duration) {
+
+	ticker := time.NewTicker(idle / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.ctx.Done():
+			return
+		case now := <-ticker.C:
+			assembly := newDefaultBeanAssembly(ctx)
+			ctx.sessions.Range(func(key, value interface{}) bool {
+				if value.(*scopedContainer).expireBeans(assembly, now, idle) {
+					ctx.sessions.Delete(key)
+				}
+				return true
+			})
+		}
+	}
+}
+
+// startSessionExpiry 存在设置了有效期的会话作用域 Bean 时启动清理协程
+func (ctx *defaultSpringContext) startSessionExpiry() {
+
+	var idle time.Duration // 取最短的有效期
+	for _, bd := range ctx.beanMap {
+		if bd.scope == SessionScope && bd.expiry > 0 {
+			if idle == 0 || bd.expiry < idle {
+				idle = bd.expiry
+			}
+		}
+	}
+
+	if idle > 0 {
+		ctx.SafeGoroutine(func() { ctx.expireSessions(idle) })
+	}
+}
+
+// destroySessions 销毁所有会话作用域 Bean
+func (ctx *defaultSpringContext) destroySessions(assembly *defaultBeanAssembly) {
+
+	ctx.sessions.Range(func(key, value interface{}) bool {
+		c := value.(*scopedContainer)
+		c.destroyBeans(assembly)
+		ctx.sessions.Delete(key)
+		return true
+	})
+}
+
+// GetScopedBean 获取作用域 Bean，若多于 1 个则 panic；找到返回 true 否则返回 false。
+// 作用域 Bean 在第一次获取时创建，此后在同一作用域内返回同一个实例。
+func (ctx *defaultSpringContext) GetScopedBean(scopeCtx context.Context, i interface{}, selector ...BeanSelector) bool {
+
+	if i == nil {
+		panic(errors.New("i can't be nil"))
+	}
+
+	ctx.checkAutoWired()
+
+	// 使用指针才能够对外赋值
+	if reflect.TypeOf(i).Kind() != reflect.Ptr {
+		panic(errors.New("i must be pointer"))
+	}
+
+	s := BeanSelector("")
+	if len(selector) > 0 {
+		s = selector[0]
+	}
+
+	tag := ToSingletonTag(s)
+	tag.Nullable = true
+
+	v := reflect.ValueOf(i)
+	w := newDefaultBeanAssembly(ctx)
+	w.scopeCtx = scopeCtx
+
+	defer w.unlockScopes()
+
+	defer func() { // 捕获自动注入过程中的异常，打印错误日志然后重新抛出
+		if err := recover(); err != nil {
+			SpringLogger.Errorf("%v ↩\n%s", err, w.wiringStack.path())
+			panic(err)
+		}
+	}()
+
+	return w.getBeanValue(v.Elem(), tag, reflect.Value{}, "")
+}
+
+// scopedBeanValue 获取作用域 Bean 的值，同一个 assembly 对每个作用域子容器只加锁一次
+func (assembly *defaultBeanAssembly) scopedBeanValue(bd *BeanDefinition) reflect.Value {
+
+	var c *scopedContainer
+	if assembly.scopeCtx != nil {
+		c, _ = assembly.scopeCtx.Value(scopeKey(bd.scope)).(*scopedContainer)
+	}
+
+	if c == nil {
+		panic(fmt.Errorf("%s bean: \"%s\" can't be used out of its scope", bd.scope, bd.BeanId()))
+	}
+
+	if _, ok := assembly.lockedScopes[c]; !ok {
+		c.mutex.Lock()
+		assembly.lockedScopes[c] = struct{}{}
+	}
+
+	return c.getBean(assembly, bd)
+}
+
+// unlockScopes 释放 assembly 持有的作用域子容器的锁
+func (assembly *defaultBeanAssembly) unlockScopes() {
+	for c := range assembly.lockedScopes {
+		c.mutex.Unlock()
+	}
+	assembly.lockedScopes = make(map[*scopedContainer]struct{})
+}
@@ -0,0 +1,64 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package SpringCore
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// structValidator 校验结构体的 validate 标签
+var structValidator = validator.New()
+
+// WithValidation 设置 Bean 绑定属性值时是否校验结构体的 validate 标签，例如
+// `validate:"required,min=1,max=100"`，校验失败时列出所有不满足约束的字段。
+// 只对绑定目标是结构体的属性生效，标签语法参见 go-playground/validator。
+func (d *BeanDefinition) WithValidation(validate bool) *BeanDefinition {
+	d.validate = validate
+	return d
+}
+
+// validateStruct 校验绑定了属性值的结构体，校验失败时 panic
+func validateStruct(v reflect.Value, opt bindOption) {
+
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	err := structValidator.Struct(v.Interface())
+	if err == nil {
+		return
+	}
+
+	errs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		panic(fmt.Errorf("%s properties \"%s\" validate error: %v", opt.fieldName, opt.fullPropName, err))
+	}
+
+	msg := make([]string, 0, len(errs))
+	for _, e := range errs {
+		constraint := e.Tag()
+		if e.Param() != "" {
+			constraint += "=" + e.Param()
+		}
+		msg = append(msg, fmt.Sprintf("%s failed on %s", e.Namespace(), constraint))
+	}
+	panic(fmt.Errorf("%s properties \"%s\" validate error: %s", opt.fieldName, opt.fullPropName, strings.Join(msg, "; ")))
+}
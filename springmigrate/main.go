@@ -0,0 +1,282 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// springmigrate 将从 Java Spring 迁移过来的 spring:"${...}" 标签改写为
+// go-spring 的 value:"${...}" 标签。默认只输出 diff，使用 -w 直接改写源文件。
+//
+//	springmigrate [-w] path ...
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var write = flag.Bool("w", false, "write result to source file instead of stdout")
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: springmigrate [-w] path ...")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	exitCode := 0
+	for _, path := range flag.Args() {
+		if err := walk(path); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exitCode = 1
+		}
+	}
+	os.Exit(exitCode)
+}
+
+// walk 处理文件或者目录下所有的 go 源文件
+func walk(root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if name := info.Name(); path != root && (name == "vendor" || strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+		return processFile(path, info.Mode())
+	})
+}
+
+// processFile 处理单个源文件，输出 diff 或者改写文件
+func processFile(filename string, mode os.FileMode) error {
+
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	dst, err := migrate(filename, src)
+	if err != nil {
+		return err
+	}
+
+	if bytes.Equal(src, dst) {
+		return nil
+	}
+
+	if *write {
+		return ioutil.WriteFile(filename, dst, mode)
+	}
+
+	fmt.Print(diff(filename, src, dst))
+	return nil
+}
+
+// edit 一次源码替换
+type edit struct {
+	start, end int
+	text       string
+}
+
+// migrate 改写源码中所有 spring:"${...}" 形式的结构体标签
+func migrate(filename string, src []byte) ([]byte, error) {
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	var edits []edit
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		field, ok := n.(*ast.Field)
+		if !ok || field.Tag == nil {
+			return true
+		}
+
+		newTag, changed, err0 := rewriteTag(field.Tag.Value)
+		if err0 != nil {
+			pos := fset.Position(field.Tag.Pos())
+			err = fmt.Errorf("%s: %v", pos, err0)
+			return false
+		}
+
+		if changed {
+			edits = append(edits, edit{
+				start: fset.Position(field.Tag.Pos()).Offset,
+				end:   fset.Position(field.Tag.End()).Offset,
+				text:  newTag,
+			})
+		}
+		return true
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(edits, func(i, j int) bool {
+		return edits[i].start > edits[j].start
+	})
+
+	dst := append([]byte(nil), src...)
+	for _, e := range edits {
+		dst = append(dst[:e.start], append([]byte(e.text), dst[e.end:]...)...)
+	}
+	return dst, nil
+}
+
+// tagPair 结构体标签中的一个键值对
+type tagPair struct {
+	key   string
+	value string
+}
+
+// parseTag 按顺序解析结构体标签的键值对，语法和 reflect.StructTag 一致
+func parseTag(tag string) ([]tagPair, error) {
+	var result []tagPair
+	for tag != "" {
+
+		// 跳过前导空格
+		i := 0
+		for i < len(tag) && tag[i] == ' ' {
+			i++
+		}
+		tag = tag[i:]
+		if tag == "" {
+			break
+		}
+
+		// 键是非控制字符、非空格、非引号、非冒号的字符
+		i = 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			return nil, fmt.Errorf("bad syntax for struct tag %q", tag)
+		}
+		key := tag[:i]
+		tag = tag[i+1:]
+
+		// 值是带引号的字符串
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			return nil, fmt.Errorf("bad syntax for struct tag value %q", tag)
+		}
+		value, err := strconv.Unquote(tag[:i+1])
+		if err != nil {
+			return nil, err
+		}
+		tag = tag[i+1:]
+
+		result = append(result, tagPair{key, value})
+	}
+	return result, nil
+}
+
+// rewriteTag 将标签字面量中的 spring:"${...}" 改写为 value:"${...}"
+func rewriteTag(lit string) (string, bool, error) {
+
+	tag, err := strconv.Unquote(lit)
+	if err != nil {
+		return "", false, err
+	}
+
+	pairs, err := parseTag(tag)
+	if err != nil {
+		return "", false, err
+	}
+
+	index := -1
+	hasValue := false
+	for i, p := range pairs {
+		switch p.key {
+		case "value":
+			hasValue = true
+		case "spring":
+			if strings.HasPrefix(p.value, "${") && strings.HasSuffix(p.value, "}") {
+				index = i
+			}
+		}
+	}
+
+	if index < 0 {
+		return lit, false, nil
+	}
+
+	if hasValue {
+		return "", false, fmt.Errorf("tag %s has both spring and value", lit)
+	}
+
+	pairs[index].key = "value"
+
+	var buf strings.Builder
+	for i, p := range pairs {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(p.key)
+		buf.WriteByte(':')
+		buf.WriteString(strconv.Quote(p.value))
+	}
+
+	s := buf.String()
+	if strings.HasPrefix(lit, "`") && !strings.Contains(s, "`") {
+		return "`" + s + "`", true, nil
+	}
+	return strconv.Quote(s), true, nil
+}
+
+// diff 生成按行对比的差异，标签改写不会增删行，所以逐行对比即可
+func diff(filename string, src, dst []byte) string {
+
+	srcLines := strings.Split(string(src), "\n")
+	dstLines := strings.Split(string(dst), "\n")
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "--- %s\n+++ %s\n", filename, filename)
+	for i := 0; i < len(srcLines) && i < len(dstLines); i++ {
+		if srcLines[i] != dstLines[i] {
+			fmt.Fprintf(&buf, "@@ -%d +%d @@\n-%s\n+%s\n", i+1, i+1, srcLines[i], dstLines[i])
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,74 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestRewriteTag(t *testing.T) {
+
+	data := []struct {
+		lit     string
+		expect  string
+		changed bool
+	}{
+		{"`spring:\"${a.b}\"`", "`value:\"${a.b}\"`", true},
+		{"`json:\"a\" spring:\"${a:=1}\"`", "`json:\"a\" value:\"${a:=1}\"`", true},
+		{"\"spring:\\\"${a}\\\"\"", "\"value:\\\"${a}\\\"\"", true},
+		{"`spring:\"a\"`", "`spring:\"a\"`", false},
+		{"`value:\"${a}\"`", "`value:\"${a}\"`", false},
+		{"`json:\"a\"`", "`json:\"a\"`", false},
+	}
+
+	for _, d := range data {
+		s, changed, err := rewriteTag(d.lit)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, s, d.expect)
+		assert.Equal(t, changed, d.changed)
+	}
+
+	_, _, err := rewriteTag("`spring:\"${a}\" value:\"${b}\"`")
+	assert.Equal(t, err.Error(), "tag `spring:\"${a}\" value:\"${b}\"` has both spring and value")
+}
+
+func TestMigrate(t *testing.T) {
+
+	src := "package foo\n\ntype Config struct {\n" +
+		"\tHost string `spring:\"${server.host}\"`\n" +
+		"\tPort int    `json:\"port\" spring:\"${server.port:=8080}\"`\n" +
+		"\tName string `json:\"name\"`\n" +
+		"}\n"
+
+	dst, err := migrate("foo.go", []byte(src))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, string(dst), "package foo\n\ntype Config struct {\n"+
+		"\tHost string `value:\"${server.host}\"`\n"+
+		"\tPort int    `json:\"port\" value:\"${server.port:=8080}\"`\n"+
+		"\tName string `json:\"name\"`\n"+
+		"}\n")
+
+	assert.Equal(t, diff("foo.go", []byte(src), dst), "--- foo.go\n+++ foo.go\n"+
+		"@@ -4 +4 @@\n"+
+		"-\tHost string `spring:\"${server.host}\"`\n"+
+		"+\tHost string `value:\"${server.host}\"`\n"+
+		"@@ -5 +5 @@\n"+
+		"-\tPort int    `json:\"port\" spring:\"${server.port:=8080}\"`\n"+
+		"+\tPort int    `json:\"port\" value:\"${server.port:=8080}\"`\n")
+}
@@ -0,0 +1,352 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ActuatorStarter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-spring/go-spring-parent/spring-logger"
+	"github.com/go-spring/go-spring-parent/spring-utils"
+	"github.com/go-spring/go-spring/spring-boot"
+	"github.com/go-spring/go-spring/spring-core"
+)
+
+func init() {
+	SpringBoot.RegisterNameBeanFn("actuator-server", NewActuatorServer).
+		ConditionOnPropertyValue("actuator.enable", true, SpringCore.MatchIfMissing(true)).
+		Export((*SpringBoot.ApplicationEvent)(nil))
+}
+
+// ActuatorConfig 管理服务配置
+type ActuatorConfig struct {
+	Host            string        `value:"${actuator.host:=127.0.0.1}"`      // 管理服务的地址，默认只监听本机
+	Port            int           `value:"${actuator.port:=8889}"`           // 管理服务的端口
+	ShutdownTimeout time.Duration `value:"${actuator.shutdown-timeout:=5s}"` // 关闭服务的超时时间
+}
+
+// replacement 替换 Bean 时使用的构造函数
+type replacement struct {
+	fn   interface{}
+	tags []string
+}
+
+var (
+	replacementsMutex sync.RWMutex
+	replacements      = make(map[string]map[string]replacement)
+)
+
+// RegisterReplacement 为 Bean 注册一个名为 variant 的替换构造函数，因为不能在运行时
+// 通过网络传递代码，所以管理端点只能按照名称选择预先注册的构造函数。
+func RegisterReplacement(beanName string, variant string, fn interface{}, tags ...string) {
+	replacementsMutex.Lock()
+	defer replacementsMutex.Unlock()
+
+	m, ok := replacements[beanName]
+	if !ok {
+		m = make(map[string]replacement)
+		replacements[beanName] = m
+	}
+
+	if _, ok = m[variant]; ok {
+		panic(fmt.Errorf("duplicate replacement \"%s\" for bean: \"%s\"", variant, beanName))
+	}
+	m[variant] = replacement{fn: fn, tags: tags}
+}
+
+// getReplacement 获取 Bean 的替换构造函数
+func getReplacement(beanName string, variant string) (replacement, bool) {
+	replacementsMutex.RLock()
+	defer replacementsMutex.RUnlock()
+	r, ok := replacements[beanName][variant]
+	return r, ok
+}
+
+// ReplaceRequest 替换 Bean 的请求
+type ReplaceRequest struct {
+	Variant string `json:"variant"`
+}
+
+// ReplaceResponse 替换 Bean 的结果
+type ReplaceResponse struct {
+	Bean    string `json:"bean"`
+	Variant string `json:"variant,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ManageResponse 访问 Bean 的属性或者操作失败时的结果
+type ManageResponse struct {
+	Bean   string `json:"bean"`
+	Member string `json:"member"`
+	Error  string `json:"error"`
+}
+
+// ActuatorServer 管理服务，开发环境下通过 POST /actuator/beans/{name} 将设置了
+// Replaceable 的 Bean 替换为预先注册的构造函数创建的实例；对于设置了 EnableJMX 的 Bean，
+// 通过 GET /actuator/beans/{name}/{member} 读取带有 `jmx:"expose"` 标签的字段或者调用
+// 暴露的有返回值的无参方法；通过 POST 调用暴露的方法，请求体是 JSON 数组形式的参数列表。
+// member 是字段或者方法名称的短横线形式。服务默认只监听本机地址。
+type ActuatorServer struct {
+	config ActuatorConfig
+	server *http.Server
+	ctx    SpringCore.SpringContext
+}
+
+// NewActuatorServer ActuatorServer 的构造函数
+func NewActuatorServer(config ActuatorConfig) *ActuatorServer {
+	s := &ActuatorServer{config: config}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/actuator/beans/", s.handleBean)
+	addr := net.JoinHostPort(config.Host, strconv.Itoa(config.Port))
+	s.server = &http.Server{Addr: addr, Handler: mux}
+	return s
+}
+
+// handleBean 根据路径分发替换 Bean 和访问 Bean 的属性或者操作的请求
+func (s *ActuatorServer) handleBean(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/actuator/beans/")
+	if i := strings.Index(name, "/"); i > 0 {
+		s.manageBean(w, r, name[:i], name[i+1:])
+	} else {
+		s.replaceBean(w, r, name)
+	}
+}
+
+// replaceBean 处理替换 Bean 的请求
+func (s *ActuatorServer) replaceBean(w http.ResponseWriter, r *http.Request, name string) {
+
+	resp := &ReplaceResponse{Bean: name}
+
+	writeJSON := func(code int) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(resp)
+	}
+
+	if r.Method != http.MethodPost {
+		resp.Error = "method not allowed"
+		writeJSON(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req ReplaceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp.Error = err.Error()
+		writeJSON(http.StatusBadRequest)
+		return
+	}
+	resp.Variant = req.Variant
+
+	if !s.devProfile() {
+		resp.Error = "replacing bean is only allowed in dev profile"
+		writeJSON(http.StatusForbidden)
+		return
+	}
+
+	rep, ok := getReplacement(name, req.Variant)
+	if !ok {
+		resp.Error = fmt.Sprintf("can't find replacement \"%s\" for bean: \"%s\"", req.Variant, name)
+		writeJSON(http.StatusNotFound)
+		return
+	}
+
+	if err := s.ctx.ReplaceBean(name, rep.fn, rep.tags...); err != nil {
+		resp.Error = err.Error()
+		writeJSON(http.StatusConflict)
+		return
+	}
+
+	SpringLogger.Infof("bean: \"%s\" replaced by \"%s\"", name, req.Variant)
+	writeJSON(http.StatusOK)
+}
+
+// devProfile 返回是否激活了开发环境
+func (s *ActuatorServer) devProfile() bool {
+	for _, profile := range s.ctx.GetProfiles() {
+		if profile == "dev" {
+			return true
+		}
+	}
+	return false
+}
+
+// manageBean 处理读取 Bean 的属性或者调用 Bean 的方法的请求，结果以 JSON 格式返回
+func (s *ActuatorServer) manageBean(w http.ResponseWriter, r *http.Request, name string, member string) {
+
+	writeJSON := func(code int, v interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(v)
+	}
+
+	fail := func(code int, err error) {
+		writeJSON(code, &ManageResponse{Bean: name, Member: member, Error: err.Error()})
+	}
+
+	bd, ok := s.ctx.FindBean(name)
+	if !ok || !bd.JMXEnabled() {
+		fail(http.StatusNotFound, fmt.Errorf("bean: \"%s\" isn't managed", name))
+		return
+	}
+
+	var args []json.RawMessage
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&args); err != nil && err != io.EOF {
+			fail(http.StatusBadRequest, err)
+			return
+		}
+	default:
+		fail(http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+
+	// 防止读取到刷新或者替换了一半的实例
+	locker := bd.RefreshLocker()
+	locker.Lock()
+	defer locker.Unlock()
+
+	result, code, err := invokeMember(bd.Value(), member, r.Method == http.MethodGet, bd.JMXOperations(), args)
+	if err != nil {
+		fail(code, err)
+		return
+	}
+	writeJSON(http.StatusOK, result)
+}
+
+// jmxExposed 返回字段是否带有 `jmx:"expose"` 标签
+func jmxExposed(f reflect.StructField) bool {
+	for _, s := range strings.Split(f.Tag.Get("jmx"), ",") {
+		if strings.TrimSpace(s) == "expose" {
+			return true
+		}
+	}
+	return false
+}
+
+// isGetter 返回方法是否是可以通过 GET 调用的有返回值的无参方法
+func isGetter(mt reflect.Type) bool {
+	return mt.NumIn() == 0 && mt.NumOut() > 0
+}
+
+// invokeMember 读取 Bean 的属性或者调用 Bean 暴露的方法，read 为 true 时只能读取属性
+// 或者调用有返回值的无参方法，失败时返回 HTTP 状态码和错误
+func invokeMember(v reflect.Value, member string, read bool, operations []string, args []json.RawMessage) (result interface{}, code int, err error) {
+
+	defer func() {
+		if r := recover(); r != nil {
+			result, code, err = nil, http.StatusInternalServerError, fmt.Errorf("%v", r)
+		}
+	}()
+
+	// 读取属性，只能访问带有 jmx 标签的导出字段
+	if ev := reflect.Indirect(v); read && ev.Kind() == reflect.Struct {
+		et := ev.Type()
+		for i := 0; i < et.NumField(); i++ {
+			f := et.Field(i)
+			if f.PkgPath == "" && jmxExposed(f) && SpringCore.NormalizePropertyKey(f.Name) == member {
+				return ev.Field(i).Interface(), http.StatusOK, nil
+			}
+		}
+	}
+
+	var m reflect.Value
+
+	for _, op := range operations {
+		if SpringCore.NormalizePropertyKey(op) == member {
+			m = v.MethodByName(op)
+			break
+		}
+	}
+
+	if !m.IsValid() {
+		return nil, http.StatusNotFound, fmt.Errorf("can't find attribute or operation: \"%s\"", member)
+	}
+
+	mt := m.Type()
+	if read && !isGetter(mt) {
+		return nil, http.StatusMethodNotAllowed, fmt.Errorf("operation: \"%s\" must be invoked by POST", member)
+	}
+	if len(args) != mt.NumIn() || mt.IsVariadic() {
+		return nil, http.StatusBadRequest, fmt.Errorf("operation: \"%s\" needs %d args", member, mt.NumIn())
+	}
+
+	in := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		av := reflect.New(mt.In(i))
+		if err = json.Unmarshal(arg, av.Interface()); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		in[i] = av.Elem()
+	}
+
+	out := m.Call(in)
+
+	// 最后一个返回值是 error 时作为调用的错误
+	if n := len(out); n > 0 && mt.Out(n-1) == errorType {
+		if e := out[n-1]; !e.IsNil() {
+			return nil, http.StatusInternalServerError, e.Interface().(error)
+		}
+		out = out[:n-1]
+	}
+
+	switch len(out) {
+	case 0:
+		return nil, http.StatusOK, nil
+	case 1:
+		return out[0].Interface(), http.StatusOK, nil
+	}
+
+	values := make([]interface{}, len(out))
+	for i, o := range out {
+		values[i] = o.Interface()
+	}
+	return values, http.StatusOK, nil
+}
+
+// errorType error 的反射类型
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// OnStartApplication 应用启动的事件
+func (s *ActuatorServer) OnStartApplication(ctx SpringBoot.ApplicationContext) {
+	s.ctx = ctx
+	SpringLogger.Infof("actuator server started on %s", s.server.Addr)
+	ctx.SafeGoroutine(func() {
+		err := s.server.ListenAndServe()
+		SpringLogger.Infof("exit actuator server on %s return %s", s.server.Addr, SpringUtils.ErrorToString(err))
+	})
+}
+
+// OnStopApplication 应用停止的事件
+func (s *ActuatorServer) OnStopApplication(ctx SpringBoot.ApplicationContext) {
+	c, cancel := context.WithTimeout(context.Background(), s.config.ShutdownTimeout)
+	defer cancel()
+	err := s.server.Shutdown(c)
+	SpringLogger.Infof("shutdown actuator server on %s return %s", s.server.Addr, SpringUtils.ErrorToString(err))
+}
@@ -0,0 +1,185 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ActuatorStarter
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-spring/go-spring/spring-core"
+	"github.com/magiconair/properties/assert"
+)
+
+type greeter struct {
+	Words string
+}
+
+type greeterUser struct {
+	Greeter *greeter `autowire:"greeter"`
+}
+
+func TestActuatorServer_ReplaceBean(t *testing.T) {
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.SetProfile("dev")
+	ctx.RegisterNameBeanFn("greeter", func() *greeter {
+		return &greeter{Words: "hello"}
+	}).Replaceable(true)
+	ctx.RegisterNameBean("fixed", &greeter{Words: "fixed"})
+	ctx.RegisterBean(new(greeterUser))
+	ctx.AutoWireBeans()
+
+	RegisterReplacement("greeter", "loud", func() *greeter {
+		return &greeter{Words: "HELLO"}
+	})
+	RegisterReplacement("fixed", "loud", func() *greeter {
+		return &greeter{Words: "FIXED"}
+	})
+
+	s := NewActuatorServer(ActuatorConfig{})
+	s.ctx = ctx
+
+	post := func(method, name, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, "/actuator/beans/"+name, strings.NewReader(body))
+		s.server.Handler.ServeHTTP(w, r)
+		return w
+	}
+
+	var user *greeterUser
+	ctx.GetBean(&user)
+	assert.Equal(t, user.Greeter.Words, "hello")
+
+	w := post(http.MethodPost, "greeter", `{"variant":"loud"}`)
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, strings.TrimSpace(w.Body.String()), `{"bean":"greeter","variant":"loud"}`)
+	assert.Equal(t, user.Greeter.Words, "hello")
+
+	var g *greeter
+	ctx.GetBean(&g, "greeter")
+	assert.Equal(t, g.Words, "HELLO")
+
+	w = post(http.MethodPost, "greeter", `{"variant":"quiet"}`)
+	assert.Equal(t, w.Code, http.StatusNotFound)
+
+	w = post(http.MethodPost, "fixed", `{"variant":"loud"}`)
+	assert.Equal(t, w.Code, http.StatusConflict)
+	assert.Matches(t, w.Body.String(), "isn't replaceable")
+
+	w = post(http.MethodGet, "greeter", "")
+	assert.Equal(t, w.Code, http.StatusMethodNotAllowed)
+
+	// 只有开发环境才能替换 Bean
+	ctx.SetProfile("test")
+	w = post(http.MethodPost, "greeter", `{"variant":"loud"}`)
+	assert.Equal(t, w.Code, http.StatusForbidden)
+	assert.Matches(t, w.Body.String(), "only allowed in dev profile")
+
+	assert.Panic(t, func() {
+		RegisterReplacement("greeter", "loud", func() *greeter { return nil })
+	}, "duplicate replacement \"loud\" for bean: \"greeter\"")
+}
+
+type connPool struct {
+	MaxIdle int `jmx:"expose"`
+	Secret  string
+	size    int
+}
+
+func (p *connPool) GetPoolSize() int {
+	return p.size
+}
+
+func (p *connPool) PoolSize() int {
+	return p.size
+}
+
+func (p *connPool) Close() {
+	p.size = 0
+}
+
+func (p *connPool) Resize(size int) error {
+	if size <= 0 {
+		return errors.New("size must be positive")
+	}
+	p.size = size
+	return nil
+}
+
+func TestActuatorServer_ManageBean(t *testing.T) {
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.RegisterNameBean("db", &connPool{MaxIdle: 2, Secret: "pwd", size: 8}).EnableJMX(true, "GetPoolSize", "PoolSize", "Resize")
+	ctx.RegisterNameBean("cache", &connPool{size: 4})
+	ctx.AutoWireBeans()
+
+	s := NewActuatorServer(ActuatorConfig{Host: "127.0.0.1"})
+	assert.Equal(t, s.server.Addr, "127.0.0.1:0")
+	s.ctx = ctx
+
+	call := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, "/actuator/beans/"+path, strings.NewReader(body))
+		s.server.Handler.ServeHTTP(w, r)
+		return w
+	}
+
+	w := call(http.MethodGet, "db/get-pool-size", "")
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, strings.TrimSpace(w.Body.String()), "8")
+
+	w = call(http.MethodGet, "db/max-idle", "")
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, strings.TrimSpace(w.Body.String()), "2")
+
+	w = call(http.MethodGet, "db/secret", "")
+	assert.Equal(t, w.Code, http.StatusNotFound)
+
+	w = call(http.MethodPost, "db/resize", "[16]")
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, strings.TrimSpace(w.Body.String()), "null")
+
+	w = call(http.MethodGet, "db/get-pool-size", "")
+	assert.Equal(t, strings.TrimSpace(w.Body.String()), "16")
+
+	// 暴露的有返回值的无参方法都可以通过 GET 调用
+	w = call(http.MethodGet, "db/pool-size", "")
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, strings.TrimSpace(w.Body.String()), "16")
+
+	// GET 只能读取属性或者调用有返回值的无参方法
+	w = call(http.MethodGet, "db/resize", "")
+	assert.Equal(t, w.Code, http.StatusMethodNotAllowed)
+
+	// 没有暴露的方法不能调用
+	w = call(http.MethodPost, "db/close", "[]")
+	assert.Equal(t, w.Code, http.StatusNotFound)
+
+	w = call(http.MethodPost, "db/resize", "[0]")
+	assert.Equal(t, w.Code, http.StatusInternalServerError)
+	assert.Matches(t, w.Body.String(), "size must be positive")
+
+	w = call(http.MethodPost, "db/resize", "[]")
+	assert.Equal(t, w.Code, http.StatusBadRequest)
+
+	w = call(http.MethodGet, "cache/get-pool-size", "")
+	assert.Equal(t, w.Code, http.StatusNotFound)
+	assert.Matches(t, w.Body.String(), "isn't managed")
+}
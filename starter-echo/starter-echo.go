@@ -17,20 +17,30 @@
 package EchoStarter
 
 import (
+	"context"
+	"net/http"
+	"strings"
+
 	"github.com/go-spring/go-spring-web/spring-echo"
 	"github.com/go-spring/go-spring-web/spring-web"
 	"github.com/go-spring/go-spring/spring-boot"
 	"github.com/go-spring/go-spring/spring-core"
 	"github.com/go-spring/go-spring/starter-web"
+	"github.com/labstack/echo"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 func init() {
 
+	WebStarter.RegisterEngine("echo")
+
 	SpringBoot.RegisterNameBeanFn("echo-web-container", func(config WebStarter.WebServerConfig) SpringWeb.WebContainer {
 		return SpringEcho.NewContainer(SpringWeb.ContainerConfig{
 			Port: config.Port,
 		})
-	}).ConditionOnPropertyValue("web.server.enable", true, SpringCore.MatchIfMissing(true))
+	}).ConditionOnPropertyValue("web.server.enable", true, SpringCore.MatchIfMissing(true)).
+		ConditionOn(WebStarter.OnEngine("echo"))
 
 	SpringBoot.RegisterNameBeanFn("echo-ssl-web-container", func(config WebStarter.WebServerConfig) SpringWeb.WebContainer {
 		return SpringEcho.NewContainer(SpringWeb.ContainerConfig{
@@ -39,5 +49,52 @@ func init() {
 			KeyFile:   config.SSLKey,
 			CertFile:  config.SSLCert,
 		})
-	}).ConditionOnPropertyValue("web.server.ssl.enable", true)
+	}).ConditionOnPropertyValue("web.server.ssl.enable", true).
+		ConditionOn(WebStarter.OnEngine("echo"))
+
+	SpringBoot.RegisterNameBeanFn("echo-h2c-web-container", func(config WebStarter.WebServerConfig) SpringWeb.WebContainer {
+		return newH2CContainer(SpringWeb.ContainerConfig{
+			Port: config.H2CPort,
+		})
+	}).ConditionOnPropertyValue("web.server.h2c.enable", true).
+		ConditionOn(WebStarter.OnEngine("echo"))
+}
+
+// h2cHandledKey 标记请求已经由 h2c 处理过，防止重复处理
+type h2cHandledKey struct{}
+
+// newH2CContainer 创建支持 HTTP/2 明文传输 (h2c) 的 echo 容器，同时支持 prior
+// knowledge 和 Upgrade 两种方式，不是 h2c 的请求仍然使用 HTTP/1.1 处理。
+func newH2CContainer(config SpringWeb.ContainerConfig) *SpringEcho.Container {
+
+	e := echo.New()
+	e.HideBanner = true
+
+	// echo 启动时会替换 http.Server 的 Handler，所以在 Pre 中间件中处理 h2c 请求
+	handler := h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), h2cHandledKey{}, true)
+		e.ServeHTTP(w, r.WithContext(ctx))
+	}), &http2.Server{})
+
+	e.Pre(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if r := c.Request(); r.Context().Value(h2cHandledKey{}) == nil && isH2CRequest(r) {
+				handler.ServeHTTP(c.Response(), r)
+				return nil
+			}
+			return next(c)
+		}
+	})
+
+	c := SpringEcho.NewContainer(config)
+	c.SetEchoServer(e)
+	return c
+}
+
+// isH2CRequest 是否是 h2c 的连接前言或者升级请求
+func isH2CRequest(r *http.Request) bool {
+	if r.Method == "PRI" && r.URL.Path == "*" && r.Proto == "HTTP/2.0" {
+		return true
+	}
+	return strings.EqualFold(r.Header.Get("Upgrade"), "h2c")
 }
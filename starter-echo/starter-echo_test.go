@@ -0,0 +1,85 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package EchoStarter
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-spring/go-spring-web/spring-web"
+	"github.com/magiconair/properties/assert"
+	"golang.org/x/net/http2"
+)
+
+func TestH2CContainer(t *testing.T) {
+
+	// 获取一个空闲的端口
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Equal(t, err, nil)
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	c := newH2CContainer(SpringWeb.ContainerConfig{Port: port})
+	c.HandleGet("/proto", SpringWeb.HTTP(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Proto)
+	}))
+	c.Start()
+	defer c.Stop(context.Background())
+
+	url := fmt.Sprintf("http://127.0.0.1:%d/proto", port)
+
+	// 等待容器启动
+	for i := 0; i < 50; i++ {
+		if conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port)); err == nil {
+			conn.Close()
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	get := func(client *http.Client) (string, int) {
+		resp, err := client.Get(url)
+		assert.Equal(t, err, nil)
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.Equal(t, err, nil)
+		return string(body), resp.ProtoMajor
+	}
+
+	// 使用 prior knowledge 方式发送 HTTP/2 请求
+	h2Client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLS: func(network, addr string, cfg *tls.Config) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+	}}
+
+	body, major := get(h2Client)
+	assert.Equal(t, body, "HTTP/2.0")
+	assert.Equal(t, major, 2)
+
+	// 普通的 HTTP/1.1 请求不受影响
+	body, major = get(http.DefaultClient)
+	assert.Equal(t, body, "HTTP/1.1")
+	assert.Equal(t, major, 1)
+}
@@ -0,0 +1,140 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package FiberStarter
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-spring/go-spring-parent/spring-logger"
+	"github.com/go-spring/go-spring-parent/spring-utils"
+	"github.com/go-spring/go-spring-web/spring-web"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+)
+
+// Container 适配 fiber 的 Web 容器，由 fiber 负责路由和收发请求
+type Container struct {
+	*SpringWeb.BaseWebContainer
+	fiberConfig FiberConfig
+	fiberApp    *fiber.App
+}
+
+// NewContainer Container 的构造函数
+func NewContainer(config SpringWeb.ContainerConfig, fiberConfig FiberConfig) *Container {
+
+	app := fiber.New(fiber.Config{
+		ReadTimeout:           fiberConfig.ReadTimeout,
+		WriteTimeout:          fiberConfig.WriteTimeout,
+		IdleTimeout:           fiberConfig.IdleTimeout,
+		BodyLimit:             fiberConfig.BodyLimit,
+		Concurrency:           fiberConfig.Concurrency,
+		ReadBufferSize:        fiberConfig.ReadBufferSize,
+		DisableStartupMessage: true,
+		Immutable:             true,
+	})
+
+	// fasthttp 不会恢复处理函数的 panic，防止单个请求导致进程退出
+	app.Use(recover.New())
+
+	return &Container{
+		BaseWebContainer: SpringWeb.NewBaseWebContainer(config),
+		fiberConfig:      fiberConfig,
+		fiberApp:         app,
+	}
+}
+
+// App 返回 fiber 应用，可以在容器启动之前添加 fiber 原生的中间件
+func (c *Container) App() *fiber.App {
+	return c.fiberApp
+}
+
+// Start 启动 Web 容器，非阻塞
+func (c *Container) Start() {
+
+	c.PreStart()
+
+	var cFilters []SpringWeb.Filter
+
+	if f := c.GetLoggerFilter(); f != nil {
+		cFilters = append(cFilters, f)
+	}
+
+	if f := c.GetRecoveryFilter(); f != nil {
+		cFilters = append(cFilters, f)
+	}
+
+	cFilters = append(cFilters, c.GetFilters()...)
+
+	// 映射 Web 处理函数，容器级别的过滤器排在路由的过滤器之前
+	for _, mapper := range c.Mappers() {
+		c.PrintMapper(mapper)
+
+		// fiber 的地址风格和 echo 相同
+		path, wildCardName := SpringWeb.ToPathStyle(mapper.Path(), SpringWeb.EchoPathStyle)
+		filters := append(append([]SpringWeb.Filter(nil), cFilters...), mapper.Filters()...)
+		fn := HandlerWrapper(mapper.Handler(), wildCardName, filters)
+
+		for _, method := range SpringWeb.GetMethod(mapper.Method()) {
+			c.fiberApp.Add(method, path, fn)
+		}
+	}
+
+	// 路由不存在时也会调用容器级别的过滤器
+	c.fiberApp.Use(HandlerWrapper(SpringWeb.FUNC(notFound), "", cFilters))
+
+	go func() {
+		var err error
+		if cfg := c.Config(); cfg.EnableSSL {
+			err = c.fiberApp.ListenTLS(c.Address(), cfg.CertFile, cfg.KeyFile)
+		} else {
+			err = c.fiberApp.Listen(c.Address())
+		}
+		SpringLogger.Infof("exit fiber server on %s return %s", c.Address(), SpringUtils.ErrorToString(err))
+	}()
+}
+
+// Stop 停止 Web 容器，阻塞，等待正在处理的请求结束，最多等待 ShutdownTimeout。
+func (c *Container) Stop(ctx context.Context) {
+
+	if timeout := c.fiberConfig.ShutdownTimeout; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	err := c.fiberApp.ShutdownWithContext(ctx)
+	SpringLogger.Infof("shutdown fiber server on %s return %s", c.Address(), SpringUtils.ErrorToString(err))
+}
+
+// notFound 路由不存在时的处理函数
+func notFound(ctx SpringWeb.WebContext) {
+	r := ctx.Request()
+	ctx.String(http.StatusNotFound, "Cannot %s %s", r.Method, r.URL.Path)
+}
+
+// HandlerWrapper Web 处理函数包装器
+func HandlerWrapper(fn SpringWeb.Handler, wildCardName string, filters []SpringWeb.Filter) fiber.Handler {
+	return func(fiberCtx *fiber.Ctx) error {
+		webCtx := WebContext(fiberCtx)
+		if webCtx == nil {
+			webCtx = NewContext(fn, wildCardName, fiberCtx)
+		}
+		SpringWeb.InvokeHandler(webCtx, fn, filters)
+		return nil
+	}
+}
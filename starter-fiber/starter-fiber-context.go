@@ -0,0 +1,493 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package FiberStarter
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/go-spring/go-spring-parent/spring-const"
+	"github.com/go-spring/go-spring-parent/spring-logger"
+	"github.com/go-spring/go-spring-parent/spring-utils"
+	"github.com/go-spring/go-spring-web/spring-web"
+	"github.com/gofiber/fiber/v2"
+)
+
+// validator 绑定请求参数之后使用的校验器
+var validator = SpringWeb.NewBuiltInValidator()
+
+// FiberContext 将 SpringWeb.WebContext 转换为 *fiber.Ctx
+func FiberContext(webCtx SpringWeb.WebContext) *fiber.Ctx {
+	return webCtx.NativeContext().(*fiber.Ctx)
+}
+
+// WebContext 将 *fiber.Ctx 转换为 SpringWeb.WebContext
+func WebContext(fiberCtx *fiber.Ctx) SpringWeb.WebContext {
+	if webCtx := fiberCtx.Locals(SpringWeb.WebContextKey); webCtx != nil {
+		return webCtx.(SpringWeb.WebContext)
+	}
+	return nil
+}
+
+// Context 适配 fiber 的 Web 上下文
+type Context struct {
+	// LoggerContext 日志接口上下文
+	SpringLogger.LoggerContext
+
+	// fiberContext fiber 上下文对象
+	fiberContext *fiber.Ctx
+
+	// handlerFunc Web 处理函数
+	handlerFunc SpringWeb.Handler
+
+	// wildCardName 通配符的名称
+	wildCardName string
+
+	// request 按需转换出的 net/http 请求
+	request *http.Request
+
+	// writer 按需创建的 net/http 响应
+	writer *responseWriter
+}
+
+// NewContext Context 的构造函数
+func NewContext(fn SpringWeb.Handler, wildCardName string, fiberCtx *fiber.Ctx) *Context {
+
+	logCtx := SpringLogger.NewDefaultLoggerContext(fiberCtx.UserContext())
+
+	webCtx := &Context{
+		LoggerContext: logCtx,
+		fiberContext:  fiberCtx,
+		handlerFunc:   fn,
+		wildCardName:  wildCardName,
+	}
+
+	webCtx.Set(SpringWeb.WebContextKey, webCtx)
+	return webCtx
+}
+
+// NativeContext 返回封装的底层上下文对象
+func (ctx *Context) NativeContext() interface{} {
+	return ctx.fiberContext
+}
+
+// Get retrieves data from the context.
+func (ctx *Context) Get(key string) interface{} {
+	return ctx.fiberContext.Locals(key)
+}
+
+// Set saves data in the context.
+func (ctx *Context) Set(key string, val interface{}) {
+	ctx.fiberContext.Locals(key, val)
+}
+
+// Request returns `*http.Request`.
+func (ctx *Context) Request() *http.Request {
+	if ctx.request == nil {
+		r, err := newRequest(ctx.fiberContext)
+		SpringUtils.Panic(err).When(err != nil)
+		ctx.request = r
+	}
+	return ctx.request
+}
+
+// SetRequest sets `*http.Request`.
+func (ctx *Context) SetRequest(r *http.Request) {
+	ctx.request = r
+	ctx.fiberContext.SetUserContext(r.Context())
+}
+
+// IsTLS returns true if HTTP connection is TLS otherwise false.
+func (ctx *Context) IsTLS() bool {
+	return ctx.fiberContext.Context().IsTLS()
+}
+
+// IsWebSocket returns true if HTTP connection is WebSocket otherwise false.
+func (ctx *Context) IsWebSocket() bool {
+	upgrade := ctx.GetHeader(fiber.HeaderUpgrade)
+	return strings.ToLower(upgrade) == "websocket"
+}
+
+// Scheme returns the HTTP protocol scheme, `http` or `https`.
+func (ctx *Context) Scheme() string {
+	return ctx.fiberContext.Protocol()
+}
+
+// ClientIP implements a best effort algorithm to return the real client IP.
+func (ctx *Context) ClientIP() string {
+	if ip := ctx.GetHeader(fiber.HeaderXForwardedFor); ip != "" {
+		return strings.TrimSpace(strings.Split(ip, ",")[0])
+	}
+	if ip := ctx.GetHeader("X-Real-IP"); ip != "" {
+		return ip
+	}
+	return ctx.fiberContext.IP()
+}
+
+// Path returns the registered path for the handler.
+func (ctx *Context) Path() string {
+	return ctx.fiberContext.Route().Path
+}
+
+// Handler returns the matched handler by router.
+func (ctx *Context) Handler() SpringWeb.Handler {
+	return ctx.handlerFunc
+}
+
+// ContentType returns the Content-Type header of the request.
+func (ctx *Context) ContentType() string {
+	s := ctx.GetHeader(fiber.HeaderContentType)
+	if i := strings.IndexAny(s, " ;"); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// GetHeader returns value from request headers.
+func (ctx *Context) GetHeader(key string) string {
+	return ctx.fiberContext.Get(key)
+}
+
+// GetRawData return stream data.
+func (ctx *Context) GetRawData() ([]byte, error) {
+	return append([]byte(nil), ctx.fiberContext.Body()...), nil
+}
+
+// PathParam returns path parameter by name.
+func (ctx *Context) PathParam(name string) string {
+	if name == ctx.wildCardName {
+		name = "*"
+	}
+	return ctx.fiberContext.Params(name)
+}
+
+// PathParamNames returns path parameter names.
+func (ctx *Context) PathParamNames() []string {
+	return ctx.fiberContext.Route().Params
+}
+
+// PathParamValues returns path parameter values.
+func (ctx *Context) PathParamValues() []string {
+	var values []string
+	for _, name := range ctx.PathParamNames() {
+		values = append(values, ctx.fiberContext.Params(name))
+	}
+	return values
+}
+
+// QueryParam returns the query param for the provided name.
+func (ctx *Context) QueryParam(name string) string {
+	return ctx.fiberContext.Query(name)
+}
+
+// QueryParams returns the query parameters as `url.Values`.
+func (ctx *Context) QueryParams() url.Values {
+	values, _ := url.ParseQuery(ctx.QueryString())
+	return values
+}
+
+// QueryString returns the URL query string.
+func (ctx *Context) QueryString() string {
+	return string(ctx.fiberContext.Request().URI().QueryString())
+}
+
+// FormValue returns the form field value for the provided name.
+func (ctx *Context) FormValue(name string) string {
+	return ctx.fiberContext.FormValue(name)
+}
+
+// FormParams returns the form parameters as `url.Values`.
+func (ctx *Context) FormParams() (url.Values, error) {
+	if strings.HasPrefix(ctx.ContentType(), fiber.MIMEMultipartForm) {
+		form, err := ctx.MultipartForm()
+		if err != nil {
+			return nil, err
+		}
+		return form.Value, nil
+	}
+	values := make(url.Values)
+	ctx.fiberContext.Request().PostArgs().VisitAll(func(key, value []byte) {
+		values.Add(string(key), string(value))
+	})
+	return values, nil
+}
+
+// FormFile returns the multipart form file for the provided name.
+func (ctx *Context) FormFile(name string) (*multipart.FileHeader, error) {
+	return ctx.fiberContext.FormFile(name)
+}
+
+// SaveUploadedFile uploads the form file to specific dst.
+func (ctx *Context) SaveUploadedFile(file *multipart.FileHeader, dst string) error {
+
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, src)
+	return err
+}
+
+// MultipartForm returns the multipart form.
+func (ctx *Context) MultipartForm() (*multipart.Form, error) {
+	return ctx.fiberContext.MultipartForm()
+}
+
+// Cookie returns the named cookie provided in the request.
+func (ctx *Context) Cookie(name string) (*http.Cookie, error) {
+	for _, cookie := range ctx.Cookies() {
+		if cookie.Name == name {
+			return cookie, nil
+		}
+	}
+	return nil, http.ErrNoCookie
+}
+
+// Cookies returns the HTTP cookies sent with the request.
+func (ctx *Context) Cookies() []*http.Cookie {
+	var cookies []*http.Cookie
+	ctx.fiberContext.Request().Header.VisitAllCookie(func(key, value []byte) {
+		cookies = append(cookies, &http.Cookie{Name: string(key), Value: string(value)})
+	})
+	return cookies
+}
+
+// Bind binds the request body into provided type `i`.
+func (ctx *Context) Bind(i interface{}) error {
+	if err := ctx.fiberContext.BodyParser(i); err != nil {
+		return err
+	}
+	return validator.Validate(i)
+}
+
+// ResponseWriter returns `http.ResponseWriter`.
+func (ctx *Context) ResponseWriter() http.ResponseWriter {
+	if ctx.writer == nil {
+		ctx.writer = &responseWriter{fiberCtx: ctx.fiberContext, header: make(http.Header)}
+	}
+	return ctx.writer
+}
+
+// Status sets the HTTP response code.
+func (ctx *Context) Status(code int) {
+	ctx.fiberContext.Status(code)
+}
+
+// Header is a intelligent shortcut for c.Writer.Header().Set(key, value).
+func (ctx *Context) Header(key, value string) {
+	ctx.fiberContext.Set(key, value)
+}
+
+// SetCookie adds a `Set-Cookie` header in HTTP response.
+func (ctx *Context) SetCookie(cookie *http.Cookie) {
+	ctx.fiberContext.Response().Header.Add(fiber.HeaderSetCookie, cookie.String())
+}
+
+// NoContent sends a response with no body and a status code.
+func (ctx *Context) NoContent(code int) {
+	ctx.fiberContext.Status(code)
+	ctx.fiberContext.Response().ResetBody()
+}
+
+// String writes the given string into the response body.
+func (ctx *Context) String(code int, format string, values ...interface{}) {
+	ctx.Blob(code, fiber.MIMETextPlainCharsetUTF8, []byte(fmt.Sprintf(format, values...)))
+}
+
+// HTML sends an HTTP response with status code.
+func (ctx *Context) HTML(code int, html string) {
+	ctx.HTMLBlob(code, []byte(html))
+}
+
+// HTMLBlob sends an HTTP blob response with status code.
+func (ctx *Context) HTMLBlob(code int, b []byte) {
+	ctx.Blob(code, fiber.MIMETextHTMLCharsetUTF8, b)
+}
+
+// JSON sends a JSON response with status code.
+func (ctx *Context) JSON(code int, i interface{}) {
+	b, err := json.Marshal(i)
+	SpringUtils.Panic(err).When(err != nil)
+	ctx.JSONBlob(code, b)
+}
+
+// JSONPretty sends a pretty-print JSON with status code.
+func (ctx *Context) JSONPretty(code int, i interface{}, indent string) {
+	b, err := json.MarshalIndent(i, "", indent)
+	SpringUtils.Panic(err).When(err != nil)
+	ctx.JSONBlob(code, b)
+}
+
+// JSONBlob sends a JSON blob response with status code.
+func (ctx *Context) JSONBlob(code int, b []byte) {
+	ctx.Blob(code, fiber.MIMEApplicationJSONCharsetUTF8, b)
+}
+
+// JSONP sends a JSONP response with status code.
+func (ctx *Context) JSONP(code int, callback string, i interface{}) {
+	b, err := json.Marshal(i)
+	SpringUtils.Panic(err).When(err != nil)
+	ctx.JSONPBlob(code, callback, b)
+}
+
+// JSONPBlob sends a JSONP blob response with status code.
+func (ctx *Context) JSONPBlob(code int, callback string, b []byte) {
+	body := make([]byte, 0, len(callback)+len(b)+3)
+	body = append(append(append(append(body, callback...), '('), b...), ");"...)
+	ctx.Blob(code, fiber.MIMEApplicationJavaScriptCharsetUTF8, body)
+}
+
+// XML sends an XML response with status code.
+func (ctx *Context) XML(code int, i interface{}) {
+	b, err := xml.Marshal(i)
+	SpringUtils.Panic(err).When(err != nil)
+	ctx.XMLBlob(code, b)
+}
+
+// XMLPretty sends a pretty-print XML with status code.
+func (ctx *Context) XMLPretty(code int, i interface{}, indent string) {
+	b, err := xml.MarshalIndent(i, "", indent)
+	SpringUtils.Panic(err).When(err != nil)
+	ctx.XMLBlob(code, b)
+}
+
+// XMLBlob sends an XML blob response with status code.
+func (ctx *Context) XMLBlob(code int, b []byte) {
+	ctx.Blob(code, fiber.MIMEApplicationXMLCharsetUTF8, append([]byte(xml.Header), b...))
+}
+
+// Blob sends a blob response with status code and content type.
+func (ctx *Context) Blob(code int, contentType string, b []byte) {
+	ctx.fiberContext.Set(fiber.HeaderContentType, contentType)
+	err := ctx.fiberContext.Status(code).Send(b)
+	SpringUtils.Panic(err).When(err != nil)
+}
+
+// Stream sends a streaming response with status code and content type.
+func (ctx *Context) Stream(code int, contentType string, r io.Reader) {
+	ctx.fiberContext.Set(fiber.HeaderContentType, contentType)
+	err := ctx.fiberContext.Status(code).SendStream(r)
+	SpringUtils.Panic(err).When(err != nil)
+}
+
+// File sends a response with the content of the file.
+func (ctx *Context) File(file string) {
+	err := ctx.fiberContext.SendFile(file)
+	SpringUtils.Panic(err).When(err != nil)
+}
+
+// Attachment sends a response as attachment
+func (ctx *Context) Attachment(file string, name string) {
+	ctx.contentDisposition(file, name, "attachment")
+}
+
+// Inline sends a response as inline
+func (ctx *Context) Inline(file string, name string) {
+	ctx.contentDisposition(file, name, "inline")
+}
+
+// contentDisposition 设置 Content-Disposition 之后发送文件
+func (ctx *Context) contentDisposition(file, name, dispositionType string) {
+	ctx.fiberContext.Set(fiber.HeaderContentDisposition, fmt.Sprintf("%s; filename=%q", dispositionType, name))
+	ctx.File(file)
+}
+
+// Redirect redirects the request to a provided URL with status code.
+func (ctx *Context) Redirect(code int, url string) {
+	err := ctx.fiberContext.Redirect(url, code)
+	SpringUtils.Panic(err).When(err != nil)
+}
+
+// SSEvent writes a Server-Sent Event into the body stream.
+func (ctx *Context) SSEvent(name string, message interface{}) {
+	panic(SpringConst.UnimplementedMethod)
+}
+
+// newRequest 将 fiber 的请求复制为 net/http 的请求。fiber 的请求数据在处理函数返回
+// 之后会被复用，复制之后请求可以在处理函数之外安全使用。
+func newRequest(fiberCtx *fiber.Ctx) (*http.Request, error) {
+
+	fr := fiberCtx.Request()
+	body := append([]byte(nil), fiberCtx.Body()...)
+
+	r, err := http.NewRequest(string(fr.Header.Method()), string(fr.RequestURI()), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	r.Proto = string(fr.Header.Protocol())
+	r.ProtoMajor, r.ProtoMinor, _ = http.ParseHTTPVersion(r.Proto)
+	r.RequestURI = string(fr.RequestURI())
+	r.Host = string(fr.Host())
+	r.RemoteAddr = fiberCtx.Context().RemoteAddr().String()
+	r.TLS = fiberCtx.Context().TLSConnectionState()
+
+	fr.Header.VisitAll(func(key, value []byte) {
+		r.Header.Add(string(key), string(value))
+	})
+
+	return r.WithContext(fiberCtx.UserContext()), nil
+}
+
+// responseWriter 将 fiber 的响应适配为 http.ResponseWriter
+type responseWriter struct {
+	fiberCtx    *fiber.Ctx
+	header      http.Header
+	wroteHeader bool
+}
+
+// Header 返回响应头，写入状态码时复制到 fiber 的响应中
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+// WriteHeader 写入状态码和响应头，只有第一次调用有效
+func (w *responseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	for key, values := range w.header {
+		w.fiberCtx.Response().Header.Del(key)
+		for _, value := range values {
+			w.fiberCtx.Response().Header.Add(key, value)
+		}
+	}
+	w.fiberCtx.Status(code)
+}
+
+// Write 写入响应体，没有写入状态码时使用 200
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.fiberCtx.Write(b)
+}
@@ -0,0 +1,63 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package FiberStarter
+
+import (
+	"time"
+
+	"github.com/go-spring/go-spring-web/spring-web"
+	"github.com/go-spring/go-spring/spring-boot"
+	"github.com/go-spring/go-spring/spring-core"
+	"github.com/go-spring/go-spring/starter-web"
+)
+
+func init() {
+
+	WebStarter.RegisterEngine("fiber")
+
+	SpringBoot.RegisterNameBeanFn("fiber-web-container", func(config WebStarter.WebServerConfig, fiberConfig FiberConfig) SpringWeb.WebContainer {
+		return NewContainer(SpringWeb.ContainerConfig{
+			Port:         config.Port,
+			ReadTimeout:  fiberConfig.ReadTimeout,
+			WriteTimeout: fiberConfig.WriteTimeout,
+		}, fiberConfig)
+	}).ConditionOnPropertyValue("web.server.enable", true, SpringCore.MatchIfMissing(true)).
+		ConditionOn(WebStarter.OnEngine("fiber"))
+
+	SpringBoot.RegisterNameBeanFn("fiber-ssl-web-container", func(config WebStarter.WebServerConfig, fiberConfig FiberConfig) SpringWeb.WebContainer {
+		return NewContainer(SpringWeb.ContainerConfig{
+			EnableSSL:    true,
+			Port:         config.SSLPort,
+			KeyFile:      config.SSLKey,
+			CertFile:     config.SSLCert,
+			ReadTimeout:  fiberConfig.ReadTimeout,
+			WriteTimeout: fiberConfig.WriteTimeout,
+		}, fiberConfig)
+	}).ConditionOnPropertyValue("web.server.ssl.enable", true).
+		ConditionOn(WebStarter.OnEngine("fiber"))
+}
+
+// FiberConfig fiber 的调优配置
+type FiberConfig struct {
+	ReadTimeout     time.Duration `value:"${web.server.fiber.read-timeout:=0s}"`       // 读取请求的超时时间，0 表示不限制
+	WriteTimeout    time.Duration `value:"${web.server.fiber.write-timeout:=0s}"`      // 写入响应的超时时间，0 表示不限制
+	IdleTimeout     time.Duration `value:"${web.server.fiber.idle-timeout:=0s}"`       // 长连接的空闲时间，0 表示使用读取超时
+	BodyLimit       int           `value:"${web.server.fiber.body-limit:=4194304}"`    // 请求体的最大字节数
+	Concurrency     int           `value:"${web.server.fiber.concurrency:=262144}"`    // 最大并发连接数
+	ReadBufferSize  int           `value:"${web.server.fiber.read-buffer-size:=4096}"` // 读取请求头的缓冲区大小
+	ShutdownTimeout time.Duration `value:"${web.server.fiber.shutdown-timeout:=10s}"`  // 优雅退出的超时时间
+}
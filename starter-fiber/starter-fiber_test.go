@@ -0,0 +1,125 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package FiberStarter
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-spring/go-spring-web/spring-web"
+	"github.com/magiconair/properties/assert"
+)
+
+// pathFilter 记录经过的请求路径
+type pathFilter struct {
+	paths []string
+}
+
+func (f *pathFilter) Invoke(ctx SpringWeb.WebContext, chain SpringWeb.FilterChain) {
+	f.paths = append(f.paths, ctx.Request().URL.Path)
+	chain.Next(ctx)
+}
+
+func TestContainer(t *testing.T) {
+
+	// 获取一个空闲的端口
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Equal(t, err, nil)
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	c := NewContainer(SpringWeb.ContainerConfig{Port: port}, FiberConfig{
+		BodyLimit:       1024,
+		ShutdownTimeout: time.Second,
+	})
+
+	// 容器级别的过滤器，路由不存在时也会调用
+	filter := new(pathFilter)
+	c.AddFilter(filter)
+
+	c.HandleGet("/hello/:name", SpringWeb.FUNC(func(ctx SpringWeb.WebContext) {
+		assert.Equal(t, FiberContext(ctx) != nil, true)
+		ctx.String(http.StatusOK, "hello "+ctx.PathParam("name")+ctx.QueryParam("suffix"))
+	}))
+
+	c.HandleGet("/files/*", SpringWeb.FUNC(func(ctx SpringWeb.WebContext) {
+		ctx.JSON(http.StatusOK, map[string]string{"path": ctx.PathParam("*")})
+	}))
+
+	c.HandleGet("/http", SpringWeb.HTTP(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Engine", "fiber")
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprint(w, r.URL.Path)
+	}))
+
+	mappers := c.Mappers()
+	assert.Equal(t, len(mappers), 3)
+
+	c.Start()
+	defer c.Stop(context.Background())
+
+	// 等待容器启动
+	for i := 0; i < 50; i++ {
+		if conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port)); err == nil {
+			conn.Close()
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	get := func(path string) (int, string, http.Header) {
+		resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d%s", port, path))
+		assert.Equal(t, err, nil)
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.Equal(t, err, nil)
+		return resp.StatusCode, string(body), resp.Header
+	}
+
+	code, body, _ := get("/hello/fiber?suffix=!")
+	assert.Equal(t, code, http.StatusOK)
+	assert.Equal(t, body, "hello fiber!")
+
+	code, body, header := get("/files/a/b.txt")
+	assert.Equal(t, code, http.StatusOK)
+	assert.Equal(t, body, `{"path":"a/b.txt"}`)
+	assert.Equal(t, header.Get("Content-Type"), "application/json; charset=utf-8")
+
+	code, body, header = get("/http")
+	assert.Equal(t, code, http.StatusAccepted)
+	assert.Equal(t, body, "/http")
+	assert.Equal(t, header.Get("X-Engine"), "fiber")
+
+	code, body, _ = get("/missing")
+	assert.Equal(t, code, http.StatusNotFound)
+	assert.Equal(t, body, "Cannot GET /missing")
+
+	assert.Equal(t, filter.paths, []string{"/hello/fiber", "/files/a/b.txt", "/http", "/missing"})
+
+	// 超过 body-limit 的请求由 fiber 直接拒绝
+	url := fmt.Sprintf("http://127.0.0.1:%d/hello/fiber", port)
+	resp, err := http.Post(url, "text/plain", strings.NewReader(strings.Repeat("x", 2048)))
+	assert.Equal(t, err, nil)
+	resp.Body.Close()
+	assert.Equal(t, resp.StatusCode, http.StatusRequestEntityTooLarge)
+}
@@ -24,13 +24,17 @@ import (
 	"github.com/go-spring/go-spring/starter-web"
 )
 
+// 同时引入多个 Web starter 时通过 web.server.engine 选择生效的 Web 容器
 func init() {
 
+	WebStarter.RegisterEngine("gin")
+
 	SpringBoot.RegisterNameBeanFn("gin-web-container", func(config WebStarter.WebServerConfig) SpringWeb.WebContainer {
 		return SpringGin.NewContainer(SpringWeb.ContainerConfig{
 			Port: config.Port,
 		})
-	}).ConditionOnPropertyValue("web.server.enable", true, SpringCore.MatchIfMissing(true))
+	}).ConditionOnPropertyValue("web.server.enable", true, SpringCore.MatchIfMissing(true)).
+		ConditionOn(WebStarter.OnEngine("gin"))
 
 	SpringBoot.RegisterNameBeanFn("gin-ssl-web-container", func(config WebStarter.WebServerConfig) SpringWeb.WebContainer {
 		return SpringGin.NewContainer(SpringWeb.ContainerConfig{
@@ -39,5 +43,6 @@ func init() {
 			KeyFile:   config.SSLKey,
 			CertFile:  config.SSLCert,
 		})
-	}).ConditionOnPropertyValue("web.server.ssl.enable", true)
+	}).ConditionOnPropertyValue("web.server.ssl.enable", true).
+		ConditionOn(WebStarter.OnEngine("gin"))
 }
@@ -0,0 +1,74 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package GinStarter_test
+
+import (
+	"testing"
+
+	"github.com/go-spring/go-spring/spring-boot"
+	"github.com/go-spring/go-spring/spring-core"
+	_ "github.com/go-spring/go-spring/starter-echo"
+	_ "github.com/go-spring/go-spring/starter-gin"
+	"github.com/magiconair/properties/assert"
+)
+
+// findBeanDefinition 查找 starter 注册的 Bean
+func findBeanDefinition(t *testing.T, name string) *SpringCore.BeanDefinition {
+	for _, bd := range SpringBoot.GetBeanDefinitions() {
+		if bd.Name() == name {
+			return bd
+		}
+	}
+	t.Fatalf("can't find bean %s", name)
+	return nil
+}
+
+func TestWebEngineCondition(t *testing.T) {
+
+	matches := func(name string, props map[string]interface{}) bool {
+		ctx := SpringCore.NewDefaultSpringContext()
+		for k, v := range props {
+			ctx.SetProperty(k, v)
+		}
+		return findBeanDefinition(t, name).Conditional().Matches(ctx)
+	}
+
+	t.Run("gin", func(t *testing.T) {
+		props := map[string]interface{}{"web.server.engine": "gin"}
+		assert.Equal(t, matches("gin-web-container", props), true)
+		assert.Equal(t, matches("echo-web-container", props), false)
+	})
+
+	t.Run("echo", func(t *testing.T) {
+		props := map[string]interface{}{"web.server.engine": "echo"}
+		assert.Equal(t, matches("gin-web-container", props), false)
+		assert.Equal(t, matches("echo-web-container", props), true)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		props := map[string]interface{}{"web.server.engine": "gin", "web.server.enable": false}
+		assert.Equal(t, matches("gin-web-container", props), false)
+	})
+
+	t.Run("ambiguous", func(t *testing.T) {
+		for _, name := range []string{"gin-web-container", "echo-web-container"} {
+			assert.Panic(t, func() {
+				matches(name, nil)
+			}, "found web engines \\[echo, gin\\], set web.server.engine to choose one")
+		}
+	})
+}
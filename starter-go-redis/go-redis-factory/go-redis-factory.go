@@ -17,23 +17,80 @@
 package GoRedisFactory
 
 import (
-	"fmt"
 	"github.com/go-redis/redis"
+	"github.com/go-spring/go-spring/spring-core"
 	StarterRedis "github.com/go-spring/go-spring/starter-redis"
 )
 
-// NewGoRedisClient 创建 redis 客户端
+// NewGoRedisClient 创建 redis 客户端，Redis 不可用时返回错误
 func NewGoRedisClient(config StarterRedis.RedisConfig) (redis.Cmdable, error) {
+	client := NewClient(config)
+	if err := client.Ping().Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return client, nil
+}
 
-	address := fmt.Sprintf("%s:%d", config.Host, config.Port)
-	client := redis.NewClient(&redis.Options{
-		Addr:     address,
+// NewClient 创建单机模式的 redis 客户端
+func NewClient(config StarterRedis.RedisConfig) *redis.Client {
+	return redis.NewClient(&redis.Options{
+		Addr:     config.Addr(),
 		Password: config.Password,
 		DB:       config.Database,
+		PoolSize: config.PoolSize,
 	})
+}
 
-	if err := client.Ping().Err(); err != nil {
-		return nil, err
+// NewClusterClient 创建集群模式的 redis 客户端，集群模式不支持选择数据库
+func NewClusterClient(config StarterRedis.RedisConfig) *redis.ClusterClient {
+
+	var addrs []string
+	for _, addr := range config.ClusterAddrs {
+		if addr != "" { // 属性的默认值会产生空字符串
+			addrs = append(addrs, addr)
+		}
 	}
-	return client, nil
+
+	if len(addrs) == 0 {
+		addrs = []string{config.Addr()}
+	}
+
+	return redis.NewClusterClient(&redis.ClusterOptions{
+		Addrs:    addrs,
+		Password: config.Password,
+		PoolSize: config.PoolSize,
+	})
+}
+
+// NewUniversalClient 根据是否启用集群模式创建 redis 客户端
+func NewUniversalClient(config StarterRedis.RedisConfig) redis.UniversalClient {
+	if config.ClusterEnable {
+		return NewClusterClient(config)
+	}
+	return NewClient(config)
+}
+
+// redisAvailableCondition 使用和健康检查相同的方式判断 Redis 是否可用
+type redisAvailableCondition struct{}
+
+// Matches 成功返回 true，失败返回 false
+func (c *redisAvailableCondition) Matches(ctx SpringCore.SpringContext) bool {
+	var config StarterRedis.RedisConfig
+	ctx.BindProperty("", &config)
+
+	client := NewUniversalClient(config)
+	defer client.Close()
+
+	return StarterRedis.Ping(client, config.Timeout()) == nil
+}
+
+// String 返回条件的描述
+func (c *redisAvailableCondition) String() string {
+	return "redis-available"
+}
+
+// OnRedisAvailable 返回 Redis 可用时成立的 Condition
+func OnRedisAvailable() SpringCore.Condition {
+	return &redisAvailableCondition{}
 }
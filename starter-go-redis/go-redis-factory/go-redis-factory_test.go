@@ -0,0 +1,124 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package GoRedisFactory
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/go-redis/redis"
+	"github.com/go-spring/go-spring/spring-core"
+	StarterRedis "github.com/go-spring/go-spring/starter-redis"
+	"github.com/magiconair/properties/assert"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// unusedPort 返回一个没有监听的端口
+func unusedPort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Equal(t, err, nil)
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func TestRedisUnavailable(t *testing.T) {
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.SetProperty("redis.port", unusedPort(t))
+	ctx.SetProperty("redis.ping-timeout", 200)
+
+	ctx.RegisterNameBeanFn("redis-client", NewClient).
+		Export((*redis.UniversalClient)(nil)).
+		Destroy(func(client *redis.Client) { StarterRedis.CloseClient(client) })
+	ctx.RegisterNameBean("redis-health-check", new(StarterRedis.RedisHealthCheck)).
+		Init((*StarterRedis.RedisHealthCheck).Check)
+	ctx.RegisterNameBean("i", new(int)).ConditionOn(OnRedisAvailable())
+
+	// 健康检查失败时只打印警告
+	ctx.AutoWireBeans()
+	defer ctx.Close()
+
+	var check *StarterRedis.RedisHealthCheck
+	assert.Equal(t, ctx.GetBean(&check), true)
+	assert.Equal(t, check.Config.PoolSize, 10)
+
+	// 没有配置集群节点时使用 host:port
+	cluster := NewClusterClient(check.Config)
+	defer cluster.Close()
+	assert.Equal(t, cluster.Options().Addrs, []string{check.Config.Addr()})
+
+	config := check.Config
+	config.ClusterEnable = true
+	universal := NewUniversalClient(config)
+	defer universal.Close()
+	_, ok := universal.(*redis.ClusterClient)
+	assert.Equal(t, ok, true)
+
+	var i *int
+	assert.Equal(t, ctx.GetBean(&i), false)
+}
+
+func TestRedisContainer(t *testing.T) {
+
+	c := context.Background()
+
+	// 没有可用的 Docker 环境时跳过集成测试
+	provider, err := testcontainers.NewDockerProvider()
+	if err == nil {
+		err = provider.Health(c)
+	}
+	if err != nil {
+		t.Skip("docker is unavailable: ", err)
+	}
+
+	container, err := testcontainers.GenericContainer(c, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "redis:6-alpine",
+			ExposedPorts: []string{"6379/tcp"},
+			WaitingFor:   wait.ForLog("Ready to accept connections"),
+		},
+		Started: true,
+	})
+	assert.Equal(t, err, nil)
+	defer container.Terminate(c)
+
+	host, err := container.Host(c)
+	assert.Equal(t, err, nil)
+	port, err := container.MappedPort(c, "6379/tcp")
+	assert.Equal(t, err, nil)
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.SetProperty("redis.host", host)
+	ctx.SetProperty("redis.port", port.Int())
+
+	ctx.RegisterNameBeanFn("redis-client", NewClient).
+		Export((*redis.UniversalClient)(nil)).
+		Destroy(func(client *redis.Client) { StarterRedis.CloseClient(client) })
+	ctx.RegisterNameBean("i", new(int)).ConditionOn(OnRedisAvailable())
+	ctx.AutoWireBeans()
+	defer ctx.Close()
+
+	var i *int
+	assert.Equal(t, ctx.GetBean(&i), true)
+
+	var client redis.UniversalClient
+	assert.Equal(t, ctx.GetBean(&client), true)
+	assert.Equal(t, client.Set("spring", "go", 0).Err(), nil)
+	assert.Equal(t, client.Get("spring").Val(), "go")
+}
@@ -19,10 +19,33 @@ package StarterGoRedis
 import (
 	"github.com/go-redis/redis"
 	"github.com/go-spring/go-spring/spring-boot"
+	"github.com/go-spring/go-spring/spring-core"
 	GoRedisFactory "github.com/go-spring/go-spring/starter-go-redis/go-redis-factory"
+	StarterRedis "github.com/go-spring/go-spring/starter-redis"
 )
 
 func GoInit() {
+
+	SpringBoot.RegisterNameBeanFn("redis-client", GoRedisFactory.NewClient).
+		ConditionOnPropertyValue("redis.enable", true).
+		ConditionOnPropertyValue("redis.cluster.enable", false, SpringCore.MatchIfMissing(true)).
+		Export((*redis.UniversalClient)(nil), (*redis.Cmdable)(nil)).
+		Destroy(func(client *redis.Client) { StarterRedis.CloseClient(client) })
+
+	SpringBoot.RegisterNameBeanFn("redis-cluster-client", GoRedisFactory.NewClusterClient).
+		ConditionOnPropertyValue("redis.enable", true).
+		ConditionOnPropertyValue("redis.cluster.enable", true).
+		Export((*redis.UniversalClient)(nil), (*redis.Cmdable)(nil)).
+		Destroy(func(client *redis.ClusterClient) { StarterRedis.CloseClient(client) })
+
+	SpringBoot.RegisterNameBean("redis-health-check", new(StarterRedis.RedisHealthCheck)).
+		ConditionOnPropertyValue("redis.enable", true).
+		Init((*StarterRedis.RedisHealthCheck).Check)
+
+	SpringBoot.RegisterNameBeanFn("redis-health-checker", StarterRedis.RedisChecker).
+		ConditionOnPropertyValue("redis.enable", true).
+		Export((*SpringBoot.HealthChecker)(nil))
+
 	SpringBoot.RegisterNameBeanFn("std-go-redis-client", GoRedisFactory.NewGoRedisClient).
 		ConditionOnMissingBean((*redis.Cmdable)(nil))
 }
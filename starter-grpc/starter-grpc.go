@@ -0,0 +1,105 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package GrpcStarter
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/go-spring/go-spring-parent/spring-logger"
+	"github.com/go-spring/go-spring-parent/spring-utils"
+	"github.com/go-spring/go-spring/spring-boot"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+func init() {
+
+	SpringBoot.RegisterNameBeanFn("grpc-server", NewGrpcServer).
+		ConditionOnPropertyValue("grpc.server.enable", true)
+
+	SpringBoot.RegisterNameBean("grpc-server-starter", new(GrpcServerStarter)).
+		ConditionOnPropertyValue("grpc.server.enable", true)
+}
+
+// GrpcServerConfig gRPC 服务器配置
+type GrpcServerConfig struct {
+	Port      int    `value:"${grpc.server.port:=9090}"`        // 监听端口
+	EnableTLS bool   `value:"${grpc.server.tls.enable:=false}"` // 是否启用 TLS
+	TLSCert   string `value:"${grpc.server.tls.cert:=}"`        // TLS 证书
+	TLSKey    string `value:"${grpc.server.tls.key:=}"`         // TLS 秘钥
+}
+
+// NewGrpcServer 根据配置创建 gRPC 服务器
+func NewGrpcServer(config GrpcServerConfig) *grpc.Server {
+	var opts []grpc.ServerOption
+	if config.EnableTLS {
+		creds, err := credentials.NewServerTLSFromFile(config.TLSCert, config.TLSKey)
+		SpringUtils.Panic(err).When(err != nil)
+		opts = append(opts, grpc.Creds(creds))
+	}
+	return grpc.NewServer(opts...)
+}
+
+// RegisterGrpcService 向 gRPC 服务器注册服务，需要在应用启动之前调用，
+// 例如在服务 Bean 的 Init 函数中调用。
+func RegisterGrpcService(server *grpc.Server, desc *grpc.ServiceDesc, impl interface{}) {
+	server.RegisterService(desc, impl)
+}
+
+// GrpcServerStarter gRPC 服务器启动器
+type GrpcServerStarter struct {
+	_ SpringBoot.ApplicationEvent `export:""`
+
+	Server *grpc.Server     `autowire:""`
+	Config GrpcServerConfig // 服务器配置
+
+	listener net.Listener
+}
+
+// Addr 返回服务器的监听地址，未启动时返回 nil
+func (starter *GrpcServerStarter) Addr() net.Addr {
+	if starter.listener == nil {
+		return nil
+	}
+	return starter.listener.Addr()
+}
+
+// listen 监听配置的端口，端口为 0 时使用随机端口
+func (starter *GrpcServerStarter) listen() {
+	l, err := net.Listen("tcp", fmt.Sprintf(":%d", starter.Config.Port))
+	SpringUtils.Panic(err).When(err != nil)
+	starter.listener = l
+}
+
+// serve 在监听的端口上处理请求，直到服务器停止
+func (starter *GrpcServerStarter) serve() {
+	SpringLogger.Info("grpc server started on ", starter.Addr())
+	if err := starter.Server.Serve(starter.listener); err != nil {
+		SpringLogger.Error("grpc server error: ", err)
+	}
+}
+
+func (starter *GrpcServerStarter) OnStartApplication(ctx SpringBoot.ApplicationContext) {
+	starter.listen()
+	ctx.SafeGoroutine(starter.serve)
+}
+
+// OnStopApplication 停止接收新的请求并等待正在处理的请求结束
+func (starter *GrpcServerStarter) OnStopApplication(ctx SpringBoot.ApplicationContext) {
+	starter.Server.GracefulStop()
+}
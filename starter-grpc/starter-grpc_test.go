@@ -0,0 +1,83 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package GrpcStarter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/magiconair/properties/assert"
+	"google.golang.org/grpc"
+)
+
+type EchoService interface {
+	Echo(ctx context.Context, in *wrappers.StringValue) (*wrappers.StringValue, error)
+}
+
+type echoService struct{}
+
+func (s *echoService) Echo(ctx context.Context, in *wrappers.StringValue) (*wrappers.StringValue, error) {
+	return &wrappers.StringValue{Value: "echo: " + in.Value}, nil
+}
+
+var echoServiceDesc = grpc.ServiceDesc{
+	ServiceName: "test.EchoService",
+	HandlerType: (*EchoService)(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "Echo",
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			in := new(wrappers.StringValue)
+			if err := dec(in); err != nil {
+				return nil, err
+			}
+			return srv.(EchoService).Echo(ctx, in)
+		},
+	}},
+}
+
+func TestGrpcServerStarter(t *testing.T) {
+
+	server := NewGrpcServer(GrpcServerConfig{})
+	RegisterGrpcService(server, &echoServiceDesc, new(echoService))
+
+	starter := &GrpcServerStarter{Server: server}
+	assert.Equal(t, starter.Addr(), nil)
+
+	starter.listen() // 端口为 0 时使用随机端口
+	done := make(chan struct{})
+	go func() {
+		starter.serve()
+		close(done)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, starter.Addr().String(), grpc.WithInsecure(), grpc.WithBlock())
+	assert.Equal(t, err, nil)
+	defer conn.Close()
+
+	out := new(wrappers.StringValue)
+	err = conn.Invoke(ctx, "/test.EchoService/Echo", &wrappers.StringValue{Value: "hello"}, out)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, out.Value, "echo: hello")
+
+	starter.OnStopApplication(nil)
+	<-done
+}
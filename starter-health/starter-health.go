@@ -0,0 +1,273 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package HealthStarter
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-spring/go-spring-parent/spring-logger"
+	"github.com/go-spring/go-spring-parent/spring-utils"
+	"github.com/go-spring/go-spring/spring-boot"
+	"github.com/go-spring/go-spring/spring-core"
+)
+
+func init() {
+
+	SpringBoot.RegisterNameBeanFn("health-server", NewHealthServer, "", "[]?").
+		ConditionOnPropertyValue("health.enable", true, SpringCore.MatchIfMissing(true)).
+		Export((*SpringBoot.ApplicationEvent)(nil))
+
+	SpringBoot.RegisterNameBeanFn("disk-health-checker", NewDiskSpaceChecker).
+		ConditionOnPropertyValue("health.enable", true, SpringCore.MatchIfMissing(true)).
+		ConditionOnPropertyValue("health.disk.enable", true, SpringCore.MatchIfMissing(true)).
+		Export((*SpringBoot.HealthChecker)(nil))
+}
+
+// HealthConfig 健康检查配置
+type HealthConfig struct {
+	Port            int           `value:"${health.port:=8888}"`              // 健康检查服务的端口
+	Path            string        `value:"${health.path:=/healthz}"`          // 健康检查服务的路径
+	Timeout         time.Duration `value:"${health.timeout:=3s}"`             // 单次检查的超时时间
+	ShutdownTimeout time.Duration `value:"${health.shutdown-timeout:=5s}"`    // 关闭服务的超时时间
+	DiskPath        string        `value:"${health.disk.path:=.}"`            // 检查剩余空间的路径
+	DiskMinFree     uint64        `value:"${health.disk.min-free:=10485760}"` // 最少的剩余空间，单位字节
+}
+
+// CheckResult 单项健康检查的结果
+type CheckResult struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// HealthReport 所有健康检查的汇总结果，全部检查通过时状态为 UP
+type HealthReport struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// Healthy 返回是否所有的检查都通过
+func (r *HealthReport) Healthy() bool {
+	return r.Status == "UP"
+}
+
+// RunChecks 并发执行所有的健康检查，每项检查最多等待 timeout
+func RunChecks(ctx context.Context, checkers map[string]SpringBoot.HealthChecker, timeout time.Duration) *HealthReport {
+
+	var (
+		mutex sync.Mutex
+		wg    sync.WaitGroup
+	)
+
+	report := &HealthReport{Status: "UP", Checks: make(map[string]CheckResult)}
+
+	for name, checker := range checkers {
+		wg.Add(1)
+		go func(name string, checker SpringBoot.HealthChecker) {
+			defer wg.Done()
+
+			err := check(ctx, checker, timeout)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if err != nil {
+				report.Status = "DOWN"
+				report.Checks[name] = CheckResult{Status: "DOWN", Error: err.Error()}
+			} else {
+				report.Checks[name] = CheckResult{Status: "UP"}
+			}
+		}(name, checker)
+	}
+
+	wg.Wait()
+	return report
+}
+
+// check 在超时时间内执行一项健康检查，检查过程中的 panic 视为检查失败
+func check(ctx context.Context, checker SpringBoot.HealthChecker, timeout time.Duration) error {
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- checker.Check(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("health check timeout after %v", timeout)
+	}
+}
+
+// HealthServer 在单独的端口上提供健康检查服务，全部检查通过时返回 200，否则返回 503
+type HealthServer struct {
+	config   HealthConfig
+	checkers map[string]SpringBoot.HealthChecker
+	server   *http.Server
+}
+
+// NewHealthServer HealthServer 的构造函数
+func NewHealthServer(config HealthConfig, checkers map[string]SpringBoot.HealthChecker) *HealthServer {
+	s := &HealthServer{config: config, checkers: checkers}
+	mux := http.NewServeMux()
+	mux.Handle(config.Path, s)
+	s.server = &http.Server{Addr: fmt.Sprintf(":%d", config.Port), Handler: mux}
+	return s
+}
+
+// ServeHTTP 执行所有的健康检查并以 JSON 格式返回结果
+func (s *HealthServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	report := RunChecks(r.Context(), s.checkers, s.config.Timeout)
+
+	w.Header().Set("Content-Type", "application/json")
+	if report.Healthy() {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(report)
+}
+
+// OnStartApplication 应用启动的事件
+func (s *HealthServer) OnStartApplication(ctx SpringBoot.ApplicationContext) {
+	SpringLogger.Infof("health server started on %s%s", s.server.Addr, s.config.Path)
+	ctx.SafeGoroutine(func() {
+		err := s.server.ListenAndServe()
+		SpringLogger.Infof("exit health server on %s return %s", s.server.Addr, SpringUtils.ErrorToString(err))
+	})
+}
+
+// OnStopApplication 应用停止的事件
+func (s *HealthServer) OnStopApplication(ctx SpringBoot.ApplicationContext) {
+	c, cancel := context.WithTimeout(context.Background(), s.config.ShutdownTimeout)
+	defer cancel()
+	err := s.server.Shutdown(c)
+	SpringLogger.Infof("shutdown health server on %s return %s", s.server.Addr, SpringUtils.ErrorToString(err))
+}
+
+// DatabaseChecker 返回通过 Ping 检查数据库是否可用的 HealthChecker
+func DatabaseChecker(db *sql.DB) SpringBoot.HealthChecker {
+	return SpringBoot.HealthCheckerFunc(db.PingContext)
+}
+
+// NewDiskSpaceChecker 返回检查磁盘剩余空间的 HealthChecker
+func NewDiskSpaceChecker(config HealthConfig) SpringBoot.HealthChecker {
+	return DiskSpaceChecker(config.DiskPath, config.DiskMinFree)
+}
+
+// DiskSpaceChecker 返回检查 path 所在磁盘的剩余空间不少于 minFree 字节的 HealthChecker
+func DiskSpaceChecker(path string, minFree uint64) SpringBoot.HealthChecker {
+	return SpringBoot.HealthCheckerFunc(func(ctx context.Context) error {
+		free, err := diskFree(path)
+		if err != nil {
+			return err
+		}
+		if free < minFree {
+			return fmt.Errorf("free disk space %d bytes below %d bytes", free, minFree)
+		}
+		return nil
+	})
+}
+
+var healthCheckerType = reflect.TypeOf((*SpringBoot.HealthChecker)(nil)).Elem()
+
+// healthyCondition 执行所有注册的健康检查，全部通过时成立
+type healthyCondition struct {
+	timeout time.Duration
+	mutex   sync.Mutex
+	checked map[SpringCore.SpringContext]bool // 已经决议过条件的上下文
+}
+
+// Matches 成功返回 true，失败返回 false。容器决议判断条件时通过函数注册的健康检查
+// 还没有创建，这时读取容器的运行时间使容器将 Bean 保留为候选 Bean，获取 Bean 时
+// 重新计算条件，此时再创建这些健康检查并执行。
+func (c *healthyCondition) Matches(ctx SpringCore.SpringContext) bool {
+
+	c.mutex.Lock()
+	resolving := !c.checked[ctx]
+	c.checked[ctx] = true
+	c.mutex.Unlock()
+
+	pending := false
+	checkers := make(map[string]SpringBoot.HealthChecker)
+	for _, bd := range ctx.GetBeanDefinitions() {
+		if !bd.Type().Implements(healthCheckerType) {
+			continue
+		}
+		if v := bd.Value(); v.IsValid() && !SpringUtils.IsNil(v) {
+			checkers[bd.Name()] = v.Interface().(SpringBoot.HealthChecker)
+			continue
+		}
+		if resolving {
+			pending = true
+			continue
+		}
+		var checker SpringBoot.HealthChecker
+		if ctx.GetBean(&checker, SpringCore.BeanSelector(bd.BeanId())) {
+			checkers[bd.Name()] = checker
+		}
+	}
+
+	if pending {
+		ctx.Uptime()
+		return false
+	}
+
+	report := RunChecks(ctx.Context(), checkers, c.timeout)
+	if !report.Healthy() {
+		var names []string
+		for name, r := range report.Checks {
+			if r.Status != "UP" {
+				names = append(names, fmt.Sprintf("%s: %s", name, r.Error))
+			}
+		}
+		sort.Strings(names)
+		SpringLogger.Warnf("unhealthy at startup %v", names)
+	}
+	return report.Healthy()
+}
+
+// String 返回条件的描述
+func (c *healthyCondition) String() string {
+	return "healthy"
+}
+
+// OnHealthy 返回所有已经注册的健康检查都通过时成立的 Condition，包括通过函数注册的
+// 健康检查，这时条件推迟到获取 Bean 时计算。
+func OnHealthy() SpringCore.Condition {
+	return &healthyCondition{
+		timeout: 3 * time.Second,
+		checked: make(map[SpringCore.SpringContext]bool),
+	}
+}
@@ -0,0 +1,164 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package HealthStarter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-spring/go-spring/spring-boot"
+	"github.com/go-spring/go-spring/spring-core"
+	"github.com/magiconair/properties/assert"
+)
+
+// freePort 获取一个空闲的端口
+func freePort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Equal(t, err, nil)
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// toggleChecker 可以切换检查结果的 HealthChecker
+type toggleChecker struct {
+	err error
+}
+
+func (c *toggleChecker) Check(ctx context.Context) error {
+	return c.err
+}
+
+// healthProbe 通过函数注册的 HealthChecker 依赖的 Bean
+type healthProbe struct {
+	err error
+}
+
+func TestRunChecks(t *testing.T) {
+
+	checkers := map[string]SpringBoot.HealthChecker{
+		"ok":   SpringBoot.HealthCheckerFunc(func(ctx context.Context) error { return nil }),
+		"fail": SpringBoot.HealthCheckerFunc(func(ctx context.Context) error { return errors.New("boom") }),
+		"slow": SpringBoot.HealthCheckerFunc(func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		}),
+		"panic": SpringBoot.HealthCheckerFunc(func(ctx context.Context) error { panic("oops") }),
+	}
+
+	report := RunChecks(context.Background(), checkers, 50*time.Millisecond)
+	assert.Equal(t, report.Healthy(), false)
+	assert.Equal(t, report.Checks["ok"], CheckResult{Status: "UP"})
+	assert.Equal(t, report.Checks["fail"], CheckResult{Status: "DOWN", Error: "boom"})
+	assert.Equal(t, report.Checks["slow"], CheckResult{Status: "DOWN", Error: "health check timeout after 50ms"})
+	assert.Equal(t, report.Checks["panic"], CheckResult{Status: "DOWN", Error: "panic: oops"})
+}
+
+func TestDiskSpaceChecker(t *testing.T) {
+	assert.Equal(t, DiskSpaceChecker(".", 1).Check(context.Background()), nil)
+	assert.Matches(t, DiskSpaceChecker(".", 1<<62).Check(context.Background()).Error(), "free disk space \\d+ bytes below")
+}
+
+func TestOnHealthy(t *testing.T) {
+
+	checker := &toggleChecker{}
+
+	newCtx := func() SpringCore.SpringContext {
+		ctx := SpringCore.NewDefaultSpringContext()
+		ctx.RegisterNameBean("db", checker).Export((*SpringBoot.HealthChecker)(nil))
+		ctx.RegisterNameBean("cache", new(int)).ConditionOn(OnHealthy())
+		ctx.AutoWireBeans()
+		return ctx
+	}
+
+	var i *int
+	assert.Equal(t, newCtx().GetBean(&i, "cache"), true)
+
+	checker.err = errors.New("db down")
+	assert.Equal(t, newCtx().GetBean(&i, "cache"), false)
+
+	t.Run("function checker", func(t *testing.T) {
+
+		probe := &healthProbe{}
+
+		newCtx := func() SpringCore.SpringContext {
+			ctx := SpringCore.NewDefaultSpringContext()
+			ctx.RegisterNameBean("probe", probe)
+			ctx.RegisterNameBeanFn("fn", func(p *healthProbe) SpringBoot.HealthChecker {
+				return SpringBoot.HealthCheckerFunc(func(ctx context.Context) error { return p.err })
+			})
+			ctx.RegisterNameBean("cache", new(int)).ConditionOn(OnHealthy())
+			ctx.AutoWireBeans()
+			return ctx
+		}
+
+		var i *int
+		assert.Equal(t, newCtx().GetBean(&i, "cache"), true)
+
+		probe.err = errors.New("fn down")
+		assert.Equal(t, newCtx().GetBean(&i, "cache"), false)
+	})
+}
+
+func TestHealthServer(t *testing.T) {
+
+	port := freePort(t)
+	checker := &toggleChecker{}
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.SetProperty("health.port", port)
+	ctx.RegisterNameBean("db", checker).Export((*SpringBoot.HealthChecker)(nil))
+	ctx.RegisterNameBeanFn("health-server", NewHealthServer, "", "[]?")
+	ctx.AutoWireBeans()
+
+	var server *HealthServer
+	assert.Equal(t, ctx.GetBean(&server), true)
+
+	server.OnStartApplication(ctx)
+	defer ctx.Close(func() { server.OnStopApplication(ctx) })
+
+	get := func() (int, HealthReport) {
+		url := fmt.Sprintf("http://127.0.0.1:%d/healthz", port)
+		for i := 0; ; i++ {
+			resp, err := http.Get(url)
+			if err != nil && i < 50 {
+				time.Sleep(20 * time.Millisecond)
+				continue
+			}
+			assert.Equal(t, err, nil)
+			defer resp.Body.Close()
+			var report HealthReport
+			assert.Equal(t, json.NewDecoder(resp.Body).Decode(&report), nil)
+			return resp.StatusCode, report
+		}
+	}
+
+	code, report := get()
+	assert.Equal(t, code, http.StatusOK)
+	assert.Equal(t, report.Checks["db"].Status, "UP")
+
+	checker.err = errors.New("db down")
+	code, report = get()
+	assert.Equal(t, code, http.StatusServiceUnavailable)
+	assert.Equal(t, report.Status, "DOWN")
+	assert.Equal(t, report.Checks["db"].Error, "db down")
+}
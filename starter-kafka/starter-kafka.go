@@ -0,0 +1,291 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package StarterKafka
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/Shopify/sarama"
+	"github.com/go-spring/go-spring-parent/spring-logger"
+	"github.com/go-spring/go-spring/spring-boot"
+	"github.com/go-spring/go-spring/spring-core"
+)
+
+func init() {
+
+	SpringBoot.RegisterNameBeanFn("kafka-sync-producer", NewSyncProducer).
+		ConditionOnPropertyValue("kafka.enable", true).
+		Destroy(closeProducer)
+
+	SpringBoot.RegisterNameBeanFn("kafka-consumer-group", NewConsumerGroup).
+		ConditionOnPropertyValue("kafka.enable", true).
+		ConditionOnProperty("kafka.consumer.group-id").
+		Export((*sarama.ConsumerGroup)(nil)).
+		Destroy(closeConsumerGroup)
+
+	SpringBoot.RegisterNameBean("kafka-consumer-starter", new(ConsumerGroupStarter)).
+		ConditionOnBean((*ConsumerGroup)(nil))
+}
+
+// KafkaConfig Kafka 配置
+type KafkaConfig struct {
+	Brokers  []string `value:"${kafka.brokers:=127.0.0.1:9092}"` // Broker 地址列表
+	Version  string   `value:"${kafka.version:=}"`               // Kafka 版本，为空时使用 sarama 的默认值
+	ClientID string   `value:"${kafka.client-id:=go-spring}"`    // 客户端 ID
+
+	GroupID string   `value:"${kafka.consumer.group-id:=}"` // 消费者组 ID
+	Topics  []string `value:"${kafka.consumer.topics:=}"`   // 消费的主题列表
+
+	SSLEnable   bool   `value:"${kafka.ssl.enable:=false}"`               // 是否启用 SSL
+	SSLCert     string `value:"${kafka.ssl.cert:=}"`                      // 客户端证书
+	SSLKey      string `value:"${kafka.ssl.key:=}"`                       // 客户端秘钥
+	SSLCA       string `value:"${kafka.ssl.ca:=}"`                        // CA 证书
+	SSLInsecure bool   `value:"${kafka.ssl.insecure-skip-verify:=false}"` // 是否跳过证书校验
+}
+
+// NewConfig 根据配置创建 sarama 的配置
+func NewConfig(config KafkaConfig) (*sarama.Config, error) {
+
+	cfg := sarama.NewConfig()
+	cfg.ClientID = config.ClientID
+
+	if config.Version != "" {
+		v, err := sarama.ParseKafkaVersion(config.Version)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Version = v
+	}
+
+	if config.SSLEnable {
+		tlsConfig, err := newTLSConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Net.TLS.Enable = true
+		cfg.Net.TLS.Config = tlsConfig
+	}
+
+	return cfg, nil
+}
+
+// newTLSConfig 根据证书文件创建 TLS 配置
+func newTLSConfig(config KafkaConfig) (*tls.Config, error) {
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: config.SSLInsecure}
+
+	if config.SSLCert != "" {
+		cert, err := tls.LoadX509KeyPair(config.SSLCert, config.SSLKey)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if config.SSLCA != "" {
+		data, err := ioutil.ReadFile(config.SSLCA)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificate found in %s", config.SSLCA)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
+}
+
+// brokers 返回非空的 Broker 地址，属性的默认值可能产生空字符串
+func (config KafkaConfig) brokers() []string {
+	var result []string
+	for _, s := range config.Brokers {
+		if s != "" {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// NewSyncProducer 创建同步的消息生产者
+func NewSyncProducer(config KafkaConfig) (sarama.SyncProducer, error) {
+
+	cfg, err := NewConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// 同步生产者必须返回发送成功的结果
+	cfg.Producer.Return.Successes = true
+
+	SpringLogger.Info("open kafka producer ", config.brokers())
+	return sarama.NewSyncProducer(config.brokers(), cfg)
+}
+
+// closeProducer 关闭消息生产者
+func closeProducer(producer sarama.SyncProducer) {
+	SpringLogger.Info("close kafka producer")
+	if err := producer.Close(); err != nil {
+		SpringLogger.Error(err)
+	}
+}
+
+// ConsumerGroup 封装 sarama.ConsumerGroup，记录需要消费的主题
+type ConsumerGroup struct {
+	sarama.ConsumerGroup
+	Topics []string
+}
+
+// NewConsumerGroup 创建消费者组
+func NewConsumerGroup(config KafkaConfig) (*ConsumerGroup, error) {
+
+	cfg, err := NewConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// 消费者组的错误通过 Errors 通道返回
+	cfg.Consumer.Return.Errors = true
+
+	group, err := sarama.NewConsumerGroup(config.brokers(), config.GroupID, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var topics []string
+	for _, s := range config.Topics {
+		if s != "" {
+			topics = append(topics, s)
+		}
+	}
+
+	SpringLogger.Info("open kafka consumer group ", config.GroupID)
+	return &ConsumerGroup{ConsumerGroup: group, Topics: topics}, nil
+}
+
+// Start 使用 handler 持续消费主题，每次重新平衡之后重新加入消费者组，直到 ctx
+// 结束或者出现错误，ctx 结束时返回 nil。
+func (g *ConsumerGroup) Start(ctx context.Context, handler sarama.ConsumerGroupHandler) error {
+	for {
+		if err := g.Consume(ctx, g.Topics, handler); err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// closeConsumerGroup 关闭消费者组
+func closeConsumerGroup(group *ConsumerGroup) {
+	SpringLogger.Info("close kafka consumer group")
+	if err := group.Close(); err != nil {
+		SpringLogger.Error(err)
+	}
+}
+
+// ConsumerGroupStarter 在应用启动之后开始消费，应用停止时 ApplicationContext 的
+// Context 结束，消费随之停止。
+type ConsumerGroupStarter struct {
+	_ SpringBoot.ApplicationEvent `export:""`
+
+	Group   *ConsumerGroup              `autowire:""`
+	Handler sarama.ConsumerGroupHandler `autowire:"?"`
+}
+
+func (starter *ConsumerGroupStarter) OnStartApplication(ctx SpringBoot.ApplicationContext) {
+
+	if starter.Handler == nil {
+		SpringLogger.Warn("no kafka consumer group handler found")
+		return
+	}
+
+	ctx.SafeGoroutine(func() {
+		if err := starter.Group.Start(ctx.Context(), starter.Handler); err != nil {
+			SpringLogger.Error("kafka consumer group error: ", err)
+		}
+	})
+
+	// 消费者组在容器销毁 Bean 时才关闭，所以不能等待 Errors 通道关闭
+	ctx.SafeGoroutine(func() {
+		for {
+			select {
+			case <-ctx.Context().Done():
+				return
+			case err, ok := <-starter.Group.Errors():
+				if !ok {
+					return
+				}
+				SpringLogger.Error("kafka consumer error: ", err)
+			}
+		}
+	})
+}
+
+func (starter *ConsumerGroupStarter) OnStopApplication(ctx SpringBoot.ApplicationContext) {}
+
+// kafkaTopicCondition 检查 Broker 的元数据中是否存在指定的主题
+type kafkaTopicCondition struct {
+	topic string
+}
+
+// Matches 成功返回 true，失败返回 false
+func (c *kafkaTopicCondition) Matches(ctx SpringCore.SpringContext) bool {
+
+	var config KafkaConfig
+	ctx.BindProperty("", &config)
+
+	cfg, err := NewConfig(config)
+	if err != nil {
+		panic(err)
+	}
+
+	client, err := sarama.NewClient(config.brokers(), cfg)
+	if err != nil {
+		SpringLogger.Warnf("kafka is unreachable: %v", err)
+		return false
+	}
+	defer client.Close()
+
+	topics, err := client.Topics()
+	if err != nil {
+		SpringLogger.Warnf("get kafka topics error: %v", err)
+		return false
+	}
+
+	for _, topic := range topics {
+		if topic == c.topic {
+			return true
+		}
+	}
+	return false
+}
+
+// String 返回条件的描述
+func (c *kafkaTopicCondition) String() string {
+	return fmt.Sprintf("kafka-topic:%s", c.topic)
+}
+
+// OnKafkaTopic 返回 Broker 中存在指定主题时成立的 Condition
+func OnKafkaTopic(topic string) SpringCore.Condition {
+	return &kafkaTopicCondition{topic: topic}
+}
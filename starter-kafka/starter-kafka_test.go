@@ -0,0 +1,151 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package StarterKafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/go-spring/go-spring/spring-boot"
+	"github.com/go-spring/go-spring/spring-core"
+	"github.com/magiconair/properties/assert"
+)
+
+// newMockBroker 创建包含 orders 主题的 Mock Broker
+func newMockBroker(t *testing.T) *sarama.MockBroker {
+	broker := sarama.NewMockBroker(t, 1)
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("orders", 0, broker.BrokerID()),
+		// sarama 默认的 Kafka 版本使用 v3 的 ProduceRequest
+		"ProduceRequest": sarama.NewMockProduceResponse(t).SetVersion(3),
+	})
+	return broker
+}
+
+func TestOnKafkaTopic(t *testing.T) {
+
+	broker := newMockBroker(t)
+	defer broker.Close()
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.SetProperty("kafka.brokers", broker.Addr())
+
+	assert.Equal(t, OnKafkaTopic("orders").Matches(ctx), true)
+	assert.Equal(t, OnKafkaTopic("payments").Matches(ctx), false)
+	assert.Equal(t, OnKafkaTopic("orders").(fmt.Stringer).String(), "kafka-topic:orders")
+}
+
+func TestSyncProducer(t *testing.T) {
+
+	broker := newMockBroker(t)
+	defer broker.Close()
+
+	ctx := SpringBoot.CopyContext()
+	ctx.SetProperty("kafka.enable", true)
+	ctx.SetProperty("kafka.brokers", broker.Addr())
+	ctx.AutoWireBeans()
+	defer ctx.Close()
+
+	var producer sarama.SyncProducer
+	assert.Equal(t, ctx.GetBean(&producer), true)
+
+	// 没有设置消费者组 ID 时不创建消费者组
+	var group *ConsumerGroup
+	assert.Equal(t, ctx.GetBean(&group), false)
+
+	partition, _, err := producer.SendMessage(&sarama.ProducerMessage{
+		Topic: "orders",
+		Value: sarama.StringEncoder("order-1"),
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, partition, int32(0))
+}
+
+func TestConsumerGroup(t *testing.T) {
+
+	broker := newMockBroker(t)
+	defer broker.Close()
+
+	ctx := SpringBoot.CopyContext()
+	ctx.SetProperty("kafka.enable", true)
+	ctx.SetProperty("kafka.brokers", broker.Addr())
+	ctx.SetProperty("kafka.consumer.group-id", "order-service")
+	ctx.SetProperty("kafka.consumer.topics", "orders")
+	ctx.AutoWireBeans()
+	defer ctx.Close()
+
+	var group *ConsumerGroup
+	assert.Equal(t, ctx.GetBean(&group), true)
+	assert.Equal(t, group.Topics, []string{"orders"})
+
+	var starter *ConsumerGroupStarter
+	assert.Equal(t, ctx.GetBean(&starter), true)
+	assert.Equal(t, starter.Group, group)
+}
+
+func TestKafkaDisabled(t *testing.T) {
+
+	ctx := SpringBoot.CopyContext()
+	ctx.AutoWireBeans()
+	defer ctx.Close()
+
+	var producer sarama.SyncProducer
+	assert.Equal(t, ctx.GetBean(&producer), false)
+}
+
+// fakeConsumerGroup 记录 Consume 调用次数的消费者组
+type fakeConsumerGroup struct {
+	sarama.ConsumerGroup
+	consume func(topics []string) error
+}
+
+func (g *fakeConsumerGroup) Consume(ctx context.Context, topics []string, handler sarama.ConsumerGroupHandler) error {
+	return g.consume(topics)
+}
+
+func TestConsumerGroup_Start(t *testing.T) {
+
+	t.Run("rejoin until done", func(t *testing.T) {
+		c, cancel := context.WithCancel(context.Background())
+
+		calls := 0
+		group := &ConsumerGroup{Topics: []string{"orders"}}
+		group.ConsumerGroup = &fakeConsumerGroup{consume: func(topics []string) error {
+			assert.Equal(t, topics, []string{"orders"})
+			if calls++; calls == 3 {
+				cancel()
+			}
+			return nil
+		}}
+
+		assert.Equal(t, group.Start(c, nil), nil)
+		assert.Equal(t, calls, 3)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		group := &ConsumerGroup{}
+		group.ConsumerGroup = &fakeConsumerGroup{consume: func(topics []string) error {
+			return errors.New("rebalance error")
+		}}
+		assert.Equal(t, group.Start(context.Background(), nil), errors.New("rebalance error"))
+	})
+}
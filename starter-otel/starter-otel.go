@@ -0,0 +1,141 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package OtelStarter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/go-spring/go-spring/spring-boot"
+	"github.com/go-spring/go-spring/spring-core"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/jaeger"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func init() {
+	SpringBoot.RegisterNameBeanFn("otel-tracer-provider", NewTracerProvider).
+		ConditionOn(OnTracingEnabled()).
+		Export((*trace.TracerProvider)(nil)).
+		Init(func(tp *sdktrace.TracerProvider) { otel.SetTracerProvider(tp) }).
+		Destroy(Shutdown)
+}
+
+// OtelConfig OpenTelemetry 链路追踪配置
+type OtelConfig struct {
+	Exporter        string        `value:"${tracing.otel.exporter:=stdout}"`        // 导出器，stdout、otlp 或者 jaeger
+	Endpoint        string        `value:"${tracing.otel.endpoint:=}"`              // otlp 或 jaeger collector 的地址，为空时使用默认地址
+	Insecure        bool          `value:"${tracing.otel.insecure:=true}"`          // otlp 是否使用非加密连接
+	ServiceName     string        `value:"${tracing.otel.service-name:=go-spring}"` // 服务名称
+	SampleRatio     float64       `value:"${tracing.otel.sample-ratio:=1}"`         // 采样比例
+	ShutdownTimeout time.Duration `value:"${tracing.otel.shutdown-timeout:=5s}"`    // 关闭时导出剩余 Span 的超时时间
+}
+
+// OnTracingEnabled 返回启用了链路追踪时成立的 Condition
+func OnTracingEnabled() SpringCore.Condition {
+	return SpringCore.NewPropertyValueCondition("tracing.otel.enable", true)
+}
+
+// NewExporter 根据配置创建 Span 导出器，stdout 导出器输出到标准输出
+func NewExporter(config OtelConfig) (sdktrace.SpanExporter, error) {
+	return newExporter(config, os.Stdout)
+}
+
+func newExporter(config OtelConfig, w io.Writer) (sdktrace.SpanExporter, error) {
+	switch config.Exporter {
+	case "stdout":
+		return stdouttrace.New(stdouttrace.WithWriter(w))
+	case "otlp":
+		var opts []otlptracegrpc.Option
+		if config.Endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(config.Endpoint))
+		}
+		if config.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		return otlptracegrpc.New(context.Background(), opts...)
+	case "jaeger":
+		var opts []jaeger.CollectorEndpointOption
+		if config.Endpoint != "" {
+			opts = append(opts, jaeger.WithEndpoint(config.Endpoint))
+		}
+		return jaeger.New(jaeger.WithCollectorEndpoint(opts...))
+	}
+	return nil, fmt.Errorf("unsupported otel exporter %q", config.Exporter)
+}
+
+// NewTracerProvider 根据配置创建 TracerProvider
+func NewTracerProvider(config OtelConfig) (*sdktrace.TracerProvider, error) {
+	exporter, err := NewExporter(config)
+	if err != nil {
+		return nil, err
+	}
+	return newTracerProvider(config, exporter), nil
+}
+
+func newTracerProvider(config OtelConfig, exporter sdktrace.SpanExporter) *sdktrace.TracerProvider {
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", config.ServiceName))),
+	)
+}
+
+// Shutdown 导出剩余的 Span 并关闭 TracerProvider，最多等待 ShutdownTimeout
+func Shutdown(tp *sdktrace.TracerProvider, config OtelConfig) error {
+	ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+	return tp.Shutdown(ctx)
+}
+
+var tracerType = reflect.TypeOf((*trace.Tracer)(nil)).Elem()
+
+// InjectTracer 将 Option 函数 fn 包装成 NewOptionArg 可以使用的函数，fn 的参数是
+// instrumentation 名称为 name 的 trace.Tracer，例如:
+//
+//	RegisterBeanFn(NewServer).Options(SpringCore.NewOptionArg(InjectTracer("server", WithTracer)))
+func InjectTracer(name string, fn interface{}) interface{} {
+
+	fnType := reflect.TypeOf(fn)
+	if fnType == nil || fnType.Kind() != reflect.Func || fnType.NumIn() != 1 || fnType.In(0) != tracerType {
+		panic(errors.New("fn must be func(trace.Tracer)option or func(trace.Tracer)(option, error)"))
+	}
+
+	out := make([]reflect.Type, fnType.NumOut())
+	for i := range out {
+		out[i] = fnType.Out(i)
+	}
+
+	in := []reflect.Type{reflect.TypeOf((*trace.TracerProvider)(nil)).Elem()}
+	t := reflect.FuncOf(in, out, false)
+
+	fnValue := reflect.ValueOf(fn)
+	return reflect.MakeFunc(t, func(args []reflect.Value) []reflect.Value {
+		tp := args[0].Interface().(trace.TracerProvider)
+		return fnValue.Call([]reflect.Value{reflect.ValueOf(tp.Tracer(name))})
+	}).Interface()
+}
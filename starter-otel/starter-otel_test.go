@@ -0,0 +1,118 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package OtelStarter
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-spring/go-spring/spring-core"
+	"github.com/magiconair/properties/assert"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestOnTracingEnabled(t *testing.T) {
+	ctx := SpringCore.NewDefaultSpringContext()
+	assert.Equal(t, OnTracingEnabled().Matches(ctx), false)
+	ctx.SetProperty("tracing.otel.enable", true)
+	assert.Equal(t, OnTracingEnabled().Matches(ctx), true)
+}
+
+func TestNewExporter(t *testing.T) {
+	_, err := NewExporter(OtelConfig{Exporter: "zipkin"})
+	assert.Equal(t, err.Error(), `unsupported otel exporter "zipkin"`)
+}
+
+func TestTracerProvider(t *testing.T) {
+
+	config := OtelConfig{
+		Exporter:        "stdout",
+		ServiceName:     "orders",
+		SampleRatio:     1,
+		ShutdownTimeout: time.Second,
+	}
+
+	var buf bytes.Buffer
+	exporter, err := newExporter(config, &buf)
+	assert.Equal(t, err, nil)
+
+	tp := newTracerProvider(config, exporter)
+	_, span := tp.Tracer("test").Start(context.Background(), "create-order")
+	assert.Equal(t, span.IsRecording(), true)
+	span.End()
+
+	// 批量导出的 Span 在关闭时才会输出
+	assert.Equal(t, buf.Len(), 0)
+	assert.Equal(t, Shutdown(tp, config), nil)
+
+	out := buf.String()
+	assert.Equal(t, strings.Contains(out, `"Name":"create-order"`), true)
+	assert.Equal(t, strings.Contains(out, `"Value":"orders"`), true)
+
+	// 关闭之后不再导出 Span
+	_, span = tp.Tracer("test").Start(context.Background(), "after-shutdown")
+	span.End()
+	_ = tp.ForceFlush(context.Background())
+	assert.Equal(t, buf.String(), out)
+}
+
+type tracedServer struct {
+	tracer trace.Tracer
+}
+
+type serverOption func(*tracedServer)
+
+func withTracer(tracer trace.Tracer) serverOption {
+	return func(s *tracedServer) { s.tracer = tracer }
+}
+
+func newTracedServer(opts ...serverOption) *tracedServer {
+	s := &tracedServer{}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func TestInjectTracer(t *testing.T) {
+
+	assert.Panic(t, func() {
+		InjectTracer("server", func(string) serverOption { return nil })
+	}, "fn must be func\\(trace.Tracer\\)option")
+
+	tp := sdktrace.NewTracerProvider()
+	defer tp.Shutdown(context.Background())
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.RegisterBean(tp).Export((*trace.TracerProvider)(nil))
+	ctx.RegisterBeanFn(newTracedServer).Options(
+		SpringCore.NewOptionArg(InjectTracer("server", withTracer)),
+	)
+	ctx.AutoWireBeans()
+
+	var s *tracedServer
+	assert.Equal(t, ctx.GetBean(&s), true)
+	assert.Equal(t, s.tracer != nil, true)
+
+	_, span := s.tracer.Start(context.Background(), "handle")
+	defer span.End()
+	assert.Equal(t, span.IsRecording(), true)
+}
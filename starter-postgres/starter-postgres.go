@@ -0,0 +1,97 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package PostgresStarter
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-spring/go-spring-parent/spring-logger"
+	"github.com/go-spring/go-spring/spring-boot"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+func init() {
+	SpringBoot.RegisterNameBeanFn("postgres-pool", newPool, "", "[]?").
+		ConditionOnPropertyValue("datasource.postgres.enable", true).
+		Destroy(closePool)
+
+	SpringBoot.RegisterNameBeanFn("postgres-health-checker", PoolChecker).
+		ConditionOnPropertyValue("datasource.postgres.enable", true).
+		Export((*SpringBoot.HealthChecker)(nil))
+}
+
+// PoolChecker 返回通过 Ping 检查 Postgres 是否可用的 HealthChecker
+func PoolChecker(pool *pgxpool.Pool) SpringBoot.HealthChecker {
+	return SpringBoot.HealthCheckerFunc(pool.Ping)
+}
+
+// PostgresConfig Postgres 连接池配置，数值为 0 时使用 pgx 的默认值
+type PostgresConfig struct {
+	Url             string        `value:"${datasource.postgres.url}"`                         // 连接地址
+	MaxConns        int           `value:"${datasource.postgres.pool.max-conns:=0}"`           // 最大连接数
+	MinConns        int           `value:"${datasource.postgres.pool.min-conns:=0}"`           // 最小连接数
+	MaxConnIdleTime time.Duration `value:"${datasource.postgres.pool.max-conn-idle-time:=0s}"` // 连接的最大空闲时间
+}
+
+// PoolOption 在连接池创建之前定制连接池的配置
+type PoolOption func(*pgxpool.Config)
+
+// BeforePoolCreated 返回在连接池创建之前定制配置的 PoolOption，可以注册为 Bean 作用于
+// 启动器创建的连接池，也可以作为 NewPool 的参数。
+func BeforePoolCreated(fn func(*pgxpool.Config)) PoolOption {
+	return fn
+}
+
+// NewPool 根据配置创建 *pgxpool.Pool 连接池
+func NewPool(config PostgresConfig, options ...PoolOption) (*pgxpool.Pool, error) {
+
+	cfg, err := pgxpool.ParseConfig(config.Url)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.MaxConns > 0 {
+		cfg.MaxConns = int32(config.MaxConns)
+	}
+
+	if config.MinConns > 0 {
+		cfg.MinConns = int32(config.MinConns)
+	}
+
+	if config.MaxConnIdleTime > 0 {
+		cfg.MaxConnIdleTime = config.MaxConnIdleTime
+	}
+
+	for _, opt := range options {
+		opt(cfg)
+	}
+
+	SpringLogger.Info("open postgres pool ", cfg.ConnConfig.Host)
+	return pgxpool.ConnectConfig(context.Background(), cfg)
+}
+
+// newPool 收集注册为 Bean 的 PoolOption 并创建连接池
+func newPool(config PostgresConfig, options []PoolOption) (*pgxpool.Pool, error) {
+	return NewPool(config, options...)
+}
+
+// closePool 关闭连接池
+func closePool(pool *pgxpool.Pool) {
+	SpringLogger.Info("close postgres pool")
+	pool.Close()
+}
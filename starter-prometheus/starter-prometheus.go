@@ -0,0 +1,135 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package PrometheusStarter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-spring/go-spring-parent/spring-logger"
+	"github.com/go-spring/go-spring-parent/spring-utils"
+	"github.com/go-spring/go-spring/spring-boot"
+	"github.com/go-spring/go-spring/spring-core"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+func init() {
+
+	SpringBoot.RegisterNameBeanFn("prometheus-registry", NewRegistry).
+		ConditionOn(OnMetricsEnabled()).
+		Export((*prometheus.Gatherer)(nil))
+
+	SpringBoot.RegisterNameBeanFn("prometheus-registerer", NewRegisterer, "", "", "[]?").
+		ConditionOn(OnMetricsEnabled())
+
+	SpringBoot.RegisterNameBeanFn("prometheus-handler", NewHandler).
+		ConditionOn(OnMetricsEnabled())
+
+	SpringBoot.RegisterNameBeanFn("prometheus-server", NewMetricsServer).
+		ConditionOn(OnMetricsEnabled()).
+		Export((*SpringBoot.ApplicationEvent)(nil))
+}
+
+// PrometheusConfig Prometheus 配置
+type PrometheusConfig struct {
+	Port      int               `value:"${metrics.prometheus.port:=9464}"`           // 指标服务的端口，和业务端口以及 gRPC 的默认端口 9090 分开
+	Path      string            `value:"${metrics.prometheus.path:=/metrics}"`       // 指标服务的路径
+	Namespace string            `value:"${metrics.prometheus.namespace:=}"`          // 指标名称的前缀
+	Labels    map[string]string `value:"${metrics.prometheus.labels:={}}"`           // 所有指标都带有的标签
+	Runtime   bool              `value:"${metrics.prometheus.runtime:=true}"`        // 是否采集 Go 运行时和进程的指标
+	Timeout   time.Duration     `value:"${metrics.prometheus.shutdown-timeout:=5s}"` // 关闭指标服务的超时时间
+}
+
+// OnMetricsEnabled 返回启用了 Prometheus 指标时成立的 Condition，可以用于
+// 按条件注册自定义的指标采集器。
+func OnMetricsEnabled() SpringCore.Condition {
+	return SpringCore.NewPropertyValueCondition("metrics.prometheus.enable", true)
+}
+
+// NewRegistry 创建 Prometheus 注册表，根据配置注册运行时的指标采集器
+func NewRegistry(config PrometheusConfig) *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	if config.Runtime {
+		registry.MustRegister(collectors.NewGoCollector())
+		registry.MustRegister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	}
+	return registry
+}
+
+// NewRegisterer 返回添加了指标前缀和默认标签的注册器，并注册容器中所有的指标采集器。
+// 应用的指标应该通过该注册器注册，运行时的指标不受前缀和默认标签的影响。
+func NewRegisterer(registry *prometheus.Registry, config PrometheusConfig,
+	collectors []prometheus.Collector) prometheus.Registerer {
+
+	var registerer prometheus.Registerer = registry
+
+	if len(config.Labels) > 0 {
+		registerer = prometheus.WrapRegistererWith(config.Labels, registerer)
+	}
+
+	if config.Namespace != "" {
+		registerer = prometheus.WrapRegistererWithPrefix(config.Namespace+"_", registerer)
+	}
+
+	registerer.MustRegister(collectors...)
+	return registerer
+}
+
+// NewHandler 创建输出指标的 HTTP 处理函数
+func NewHandler(registry *prometheus.Registry) http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// MetricsServer 在单独的端口上提供指标服务，和业务流量隔离
+type MetricsServer struct {
+	config PrometheusConfig
+	server *http.Server
+}
+
+// NewMetricsServer MetricsServer 的构造函数
+func NewMetricsServer(config PrometheusConfig, handler http.Handler) *MetricsServer {
+	mux := http.NewServeMux()
+	mux.Handle(config.Path, handler)
+	return &MetricsServer{
+		config: config,
+		server: &http.Server{
+			Addr:    fmt.Sprintf(":%d", config.Port),
+			Handler: mux,
+		},
+	}
+}
+
+// OnStartApplication 应用启动的事件
+func (s *MetricsServer) OnStartApplication(ctx SpringBoot.ApplicationContext) {
+	SpringLogger.Infof("metrics server started on %s%s", s.server.Addr, s.config.Path)
+	ctx.SafeGoroutine(func() {
+		err := s.server.ListenAndServe()
+		SpringLogger.Infof("exit metrics server on %s return %s", s.server.Addr, SpringUtils.ErrorToString(err))
+	})
+}
+
+// OnStopApplication 应用停止的事件
+func (s *MetricsServer) OnStopApplication(ctx SpringBoot.ApplicationContext) {
+	c, cancel := context.WithTimeout(context.Background(), s.config.Timeout)
+	defer cancel()
+	err := s.server.Shutdown(c)
+	SpringLogger.Infof("shutdown metrics server on %s return %s", s.server.Addr, SpringUtils.ErrorToString(err))
+}
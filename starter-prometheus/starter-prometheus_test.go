@@ -0,0 +1,108 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package PrometheusStarter
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-spring/go-spring/spring-core"
+	"github.com/magiconair/properties/assert"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// freePort 获取一个空闲的端口
+func freePort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Equal(t, err, nil)
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// scrape 等待指标服务启动并获取指标
+func scrape(t *testing.T, port int) string {
+	url := fmt.Sprintf("http://127.0.0.1:%d/metrics", port)
+	for i := 0; ; i++ {
+		resp, err := http.Get(url)
+		if err != nil && i < 50 {
+			time.Sleep(20 * time.Millisecond)
+			continue
+		}
+		assert.Equal(t, err, nil)
+		defer resp.Body.Close()
+		b, _ := ioutil.ReadAll(resp.Body)
+		return string(b)
+	}
+}
+
+func TestOnMetricsEnabled(t *testing.T) {
+	ctx := SpringCore.NewDefaultSpringContext()
+	assert.Equal(t, OnMetricsEnabled().Matches(ctx), false)
+	ctx.SetProperty("metrics.prometheus.enable", true)
+	assert.Equal(t, OnMetricsEnabled().Matches(ctx), true)
+}
+
+func TestMetricsServer(t *testing.T) {
+
+	port := freePort(t)
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	ctx.SetProperty("metrics.prometheus.enable", true)
+	ctx.SetProperty("metrics.prometheus.port", port)
+	ctx.SetProperty("metrics.prometheus.namespace", "shop")
+	ctx.SetProperty("metrics.prometheus.labels.region", "east")
+
+	counter := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "orders_total",
+		Help: "Total number of orders.",
+	})
+
+	ctx.RegisterBean(counter).Export((*prometheus.Collector)(nil)).ConditionOn(OnMetricsEnabled())
+	ctx.RegisterNameBeanFn("prometheus-registry", NewRegistry).ConditionOn(OnMetricsEnabled())
+	ctx.RegisterNameBeanFn("prometheus-registerer", NewRegisterer, "", "", "[]?").ConditionOn(OnMetricsEnabled())
+	ctx.RegisterNameBeanFn("prometheus-handler", NewHandler).ConditionOn(OnMetricsEnabled())
+	ctx.RegisterNameBeanFn("prometheus-server", NewMetricsServer).ConditionOn(OnMetricsEnabled())
+	ctx.AutoWireBeans()
+
+	var server *MetricsServer
+	assert.Equal(t, ctx.GetBean(&server), true)
+
+	server.OnStartApplication(ctx)
+	defer ctx.Close(func() { server.OnStopApplication(ctx) })
+
+	counter.Add(3)
+
+	body := scrape(t, port)
+	assert.Equal(t, strings.Contains(body, `shop_orders_total{region="east"} 3`), true)
+	assert.Equal(t, strings.Contains(body, "go_goroutines"), true)
+}
+
+func TestPrometheusConfig(t *testing.T) {
+	ctx := SpringCore.NewDefaultSpringContext()
+
+	var config PrometheusConfig
+	ctx.BindProperty("", &config)
+
+	// 默认端口不能和 gRPC 服务的默认端口 9090 冲突
+	assert.Equal(t, config.Port, 9464)
+	assert.Equal(t, config.Path, "/metrics")
+}
@@ -16,10 +16,76 @@
 
 package StarterRedis
 
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/go-spring/go-spring-parent/spring-logger"
+	"github.com/go-spring/go-spring/spring-boot"
+)
+
 // RedisConfig redis 配置
 type RedisConfig struct {
-	Host     string `value:"${redis.host:=127.0.0.1}"`
-	Port     int    `value:"${redis.port:=6379}"`
-	Password string `value:"${redis.password:=}"`
-	Database int    `value:"${redis.database:=0}"`
+	Host          string   `value:"${redis.host:=127.0.0.1}"`
+	Port          int      `value:"${redis.port:=6379}"`
+	Password      string   `value:"${redis.password:=}"`
+	Database      int      `value:"${redis.database:=0}"`
+	PoolSize      int      `value:"${redis.pool-size:=10}"`         // 连接池大小
+	ClusterEnable bool     `value:"${redis.cluster.enable:=false}"` // 是否使用集群模式
+	ClusterAddrs  []string `value:"${redis.cluster.addrs:=}"`       // 集群节点的地址，为空时使用 host:port
+	PingTimeout   int      `value:"${redis.ping-timeout:=1000}"`    // 健康检查的超时时间，单位毫秒
+}
+
+// Addr 返回单机模式下 Redis 的地址
+func (config RedisConfig) Addr() string {
+	return fmt.Sprintf("%s:%d", config.Host, config.Port)
+}
+
+// Timeout 返回健康检查的超时时间
+func (config RedisConfig) Timeout() time.Duration {
+	return time.Duration(config.PingTimeout) * time.Millisecond
+}
+
+// CloseClient 关闭 redis 客户端
+func CloseClient(client redis.UniversalClient) {
+	SpringLogger.Info("close redis client")
+	if err := client.Close(); err != nil {
+		SpringLogger.Error(err)
+	}
+}
+
+// Ping 在超时时间内检查 Redis 是否可用
+func Ping(client redis.UniversalClient, timeout time.Duration) error {
+
+	done := make(chan error, 1)
+	go func() { done <- client.Ping().Err() }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		return fmt.Errorf("ping redis timeout after %v", timeout)
+	}
+}
+
+// RedisHealthCheck 启动时检查 Redis 是否可用，不可用时只打印警告而不中止启动
+type RedisHealthCheck struct {
+	Client redis.UniversalClient `autowire:""`
+	Config RedisConfig
+}
+
+// Check 检查 Redis 是否可用
+func (h *RedisHealthCheck) Check() {
+	if err := Ping(h.Client, h.Config.Timeout()); err != nil {
+		SpringLogger.Warnf("redis is unreachable: %v", err)
+	}
+}
+
+// RedisChecker 返回通过 Ping 检查 Redis 是否可用的 HealthChecker
+func RedisChecker(client redis.UniversalClient, config RedisConfig) SpringBoot.HealthChecker {
+	return SpringBoot.HealthCheckerFunc(func(ctx context.Context) error {
+		return Ping(client, config.Timeout())
+	})
 }
@@ -21,10 +21,15 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-spring/go-spring-parent/spring-utils"
 	"github.com/go-spring/go-spring-web/spring-web"
 	"github.com/go-spring/go-spring/spring-boot"
+	"github.com/go-spring/go-spring/spring-core"
 )
 
 func init() {
@@ -35,14 +40,49 @@ func init() {
 	SpringBoot.RegisterNameBean("web-server-starter", new(WebServerStarter))
 }
 
+var (
+	engineMutex sync.Mutex
+	engines     []string
+)
+
+// RegisterEngine 注册 Web 引擎，Web starter 在 init 函数中调用
+func RegisterEngine(engine string) {
+	engineMutex.Lock()
+	defer engineMutex.Unlock()
+	engines = append(engines, engine)
+}
+
+// OnEngine 返回 Web 引擎的生效条件。设置了 web.server.engine 时只有同名的引擎生效，
+// 否则只引入了一个 Web 引擎时该引擎生效，引入了多个 Web 引擎时 panic。
+func OnEngine(engine string) SpringCore.Condition {
+	return SpringCore.NewFunctionCondition(func(ctx SpringCore.SpringContext) bool {
+		if s := ctx.GetStringProperty("web.server.engine"); s != "" {
+			return s == engine
+		}
+
+		engineMutex.Lock()
+		defer engineMutex.Unlock()
+
+		if len(engines) > 1 {
+			names := append([]string(nil), engines...)
+			sort.Strings(names)
+			panic(fmt.Errorf("found web engines [%s], set web.server.engine to choose one", strings.Join(names, ", ")))
+		}
+		return true
+	})
+}
+
 // WebServerConfig Web 服务器配置
 type WebServerConfig struct {
+	Engine      string `value:"${web.server.engine:=}"`          // Web 引擎，同时引入多个 Web starter 时用于选择
 	EnableHTTP  bool   `value:"${web.server.enable:=true}"`      // 是否启用 HTTP
 	Port        int    `value:"${web.server.port:=8080}"`        // HTTP 端口
 	EnableHTTPS bool   `value:"${web.server.ssl.enable:=false}"` // 是否启用 HTTPS
 	SSLPort     int    `value:"${web.server.ssl.port:=8443}"`    // SSL 端口
 	SSLCert     string `value:"${web.server.ssl.cert:=}"`        // SSL 证书
 	SSLKey      string `value:"${web.server.ssl.key:=}"`         // SSL 秘钥
+	H2CEnable   bool   `value:"${web.server.h2c.enable:=false}"` // 是否启用 HTTP/2 明文传输
+	H2CPort     int    `value:"${web.server.h2c.port:=8081}"`    // HTTP/2 明文传输端口
 }
 
 // WebServerStarter Web 容器启动器
@@ -51,6 +91,11 @@ type WebServerStarter struct {
 
 	WebServer  *SpringWeb.WebServer     `autowire:""`
 	Containers []SpringWeb.WebContainer `autowire:"[]?"`
+
+	SessionCookie      string        `value:"${web.server.session.cookie:=SPRING_SESSION_ID}"` // 会话 Cookie 名称
+	SessionSecure      bool          `value:"${web.server.session.secure:=false}"`             // 会话 Cookie 是否只通过 HTTPS 传输
+	SessionExpiry      time.Duration `value:"${web.server.session.expiry:=30m}"`               // 会话空闲有效期
+	SessionMaxSessions int           `value:"${web.server.session.max-sessions:=10000}"`       // 最多保存的会话数量
 }
 
 func (starter *WebServerStarter) OnStartApplication(ctx SpringBoot.ApplicationContext) {
@@ -83,11 +128,21 @@ func (starter *WebServerStarter) OnStartApplication(ctx SpringBoot.ApplicationCo
 		return
 	}
 
-	// 处理 WebServer 的过滤器
+	// 处理 WebServer 的过滤器，请求作用域的过滤器排在最前面
 	{
 		filters := starter.WebServer.Filters()
 		resolved := resolveFilters(filters)
-		starter.WebServer.ResetFilters(resolved)
+		scopeFilters := []SpringWeb.Filter{SpringBoot.RequestScopeFilter(ctx)}
+		if hasSessionScopedBean(ctx) { // 存在会话作用域的 Bean 时才启用会话
+			sessionFilter := SpringBoot.SessionScopeFilter(ctx, SpringBoot.SessionConfig{
+				CookieName:  starter.SessionCookie,
+				Secure:      starter.SessionSecure,
+				Expiry:      starter.SessionExpiry,
+				MaxSessions: starter.SessionMaxSessions,
+			})
+			scopeFilters = append(scopeFilters, sessionFilter)
+		}
+		starter.WebServer.ResetFilters(append(scopeFilters, resolved...))
 	}
 
 	// 处理 WebContainer 的过滤器
@@ -143,3 +198,13 @@ func (starter *WebServerStarter) OnStartApplication(ctx SpringBoot.ApplicationCo
 func (starter *WebServerStarter) OnStopApplication(ctx SpringBoot.ApplicationContext) {
 	starter.WebServer.Stop(context.Background())
 }
+
+// hasSessionScopedBean 返回是否存在会话作用域的 Bean
+func hasSessionScopedBean(ctx SpringBoot.ApplicationContext) bool {
+	for _, bd := range ctx.GetBeanDefinitions() {
+		if bd.Scope() == SpringCore.SessionScope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,174 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ZapStarter
+
+import (
+	"github.com/go-spring/go-spring-parent/spring-logger"
+	"github.com/go-spring/go-spring/spring-boot"
+	"github.com/go-spring/go-spring/spring-core"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func init() {
+	SpringBoot.RegisterNameBeanFn("zap-logger", NewLogger).
+		ConditionOn(OnZapAvailable()).
+		Init(func(logger *zap.Logger) { SpringLogger.SetLogger(NewStdLogger(logger)) }).
+		Destroy(func(logger *zap.Logger) { _ = logger.Sync() })
+}
+
+// ZapConfig zap 配置
+type ZapConfig struct {
+	Level            string   `value:"${log.level:=info}"`                // 日志级别
+	Encoding         string   `value:"${log.encoding:=console}"`          // 编码格式，json 或者 console
+	OutputPaths      []string `value:"${log.output-paths:=stdout}"`       // 日志的输出路径
+	ErrorOutputPaths []string `value:"${log.error-output-paths:=stderr}"` // zap 内部错误的输出路径
+}
+
+// OnZapAvailable 返回使用 zap 作为日志引擎时成立的 Condition，log.engine
+// 不存在时默认使用 zap。
+func OnZapAvailable() SpringCore.Condition {
+	return SpringCore.NewPropertyValueCondition("log.engine", "zap", SpringCore.MatchIfMissing(true))
+}
+
+// NewLogger 根据配置创建 zap 日志
+func NewLogger(config ZapConfig) (*zap.Logger, error) {
+
+	level := zap.NewAtomicLevel()
+	if err := level.UnmarshalText([]byte(config.Level)); err != nil {
+		return nil, err
+	}
+
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	if config.Encoding == "console" {
+		encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+	}
+
+	cfg := zap.Config{
+		Level:            level,
+		Encoding:         config.Encoding,
+		EncoderConfig:    encoderConfig,
+		OutputPaths:      nonEmpty(config.OutputPaths),
+		ErrorOutputPaths: nonEmpty(config.ErrorOutputPaths),
+	}
+
+	return cfg.Build()
+}
+
+// nonEmpty 去掉属性默认值产生的空字符串
+func nonEmpty(paths []string) []string {
+	var result []string
+	for _, path := range paths {
+		if path != "" {
+			result = append(result, path)
+		}
+	}
+	return result
+}
+
+// StdLogger 将 zap 日志适配为 SpringLogger 的后端，zap 没有 TRACE 级别，
+// 使用 DEBUG 级别输出。
+type StdLogger struct {
+	l *zap.SugaredLogger
+}
+
+// NewStdLogger StdLogger 的构造函数
+func NewStdLogger(logger *zap.Logger) *StdLogger {
+	// 跳过 SpringLogger 和 StdLogger 的两层调用
+	return &StdLogger{l: logger.WithOptions(zap.AddCallerSkip(2)).Sugar()}
+}
+
+// Trace 打印 TRACE 日志
+func (s *StdLogger) Trace(args ...interface{}) {
+	s.l.Debug(args...)
+}
+
+// Tracef 打印 TRACE 日志
+func (s *StdLogger) Tracef(format string, args ...interface{}) {
+	s.l.Debugf(format, args...)
+}
+
+// Debug 打印 DEBUG 日志
+func (s *StdLogger) Debug(args ...interface{}) {
+	s.l.Debug(args...)
+}
+
+// Debugf 打印 DEBUG 日志
+func (s *StdLogger) Debugf(format string, args ...interface{}) {
+	s.l.Debugf(format, args...)
+}
+
+// Info 打印 INFO 日志
+func (s *StdLogger) Info(args ...interface{}) {
+	s.l.Info(args...)
+}
+
+// Infof 打印 INFO 日志
+func (s *StdLogger) Infof(format string, args ...interface{}) {
+	s.l.Infof(format, args...)
+}
+
+// Warn 打印 WARN 日志
+func (s *StdLogger) Warn(args ...interface{}) {
+	s.l.Warn(args...)
+}
+
+// Warnf 打印 WARN 日志
+func (s *StdLogger) Warnf(format string, args ...interface{}) {
+	s.l.Warnf(format, args...)
+}
+
+// Error 打印 ERROR 日志
+func (s *StdLogger) Error(args ...interface{}) {
+	s.l.Error(args...)
+}
+
+// Errorf 打印 ERROR 日志
+func (s *StdLogger) Errorf(format string, args ...interface{}) {
+	s.l.Errorf(format, args...)
+}
+
+// Panic 打印 PANIC 日志
+func (s *StdLogger) Panic(args ...interface{}) {
+	s.l.Panic(args...)
+}
+
+// Panicf 打印 PANIC 日志
+func (s *StdLogger) Panicf(format string, args ...interface{}) {
+	s.l.Panicf(format, args...)
+}
+
+// Fatal 打印 FATAL 日志
+func (s *StdLogger) Fatal(args ...interface{}) {
+	s.l.Fatal(args...)
+}
+
+// Fatalf 打印 FATAL 日志
+func (s *StdLogger) Fatalf(format string, args ...interface{}) {
+	s.l.Fatalf(format, args...)
+}
+
+// Print 打印 INFO 日志
+func (s *StdLogger) Print(args ...interface{}) {
+	s.l.Info(args...)
+}
+
+// Printf 打印 INFO 日志
+func (s *StdLogger) Printf(format string, args ...interface{}) {
+	s.l.Infof(format, args...)
+}
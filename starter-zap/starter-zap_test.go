@@ -0,0 +1,103 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ZapStarter
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-spring/go-spring-parent/spring-logger"
+	"github.com/go-spring/go-spring/spring-boot"
+	"github.com/go-spring/go-spring/spring-core"
+	"github.com/magiconair/properties/assert"
+	"go.uber.org/zap"
+)
+
+func TestOnZapAvailable(t *testing.T) {
+
+	ctx := SpringCore.NewDefaultSpringContext()
+	assert.Equal(t, OnZapAvailable().Matches(ctx), true)
+
+	ctx.SetProperty("log.engine", "logrus")
+	assert.Equal(t, OnZapAvailable().Matches(ctx), false)
+
+	ctx.SetProperty("log.engine", "zap")
+	assert.Equal(t, OnZapAvailable().Matches(ctx), true)
+}
+
+func TestZapLogger(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "starter-zap")
+	assert.Equal(t, err, nil)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "app.log")
+
+	// Init 函数替换了全局的日志，测试结束时恢复默认的日志
+	defer SpringLogger.SetLogger(SpringLogger.NewConsole(SpringLogger.InfoLevel))
+
+	ctx := SpringBoot.CopyContext()
+	ctx.SetProperty("log.encoding", "json")
+	ctx.SetProperty("log.level", "debug")
+	ctx.SetProperty("log.output-paths", file)
+	ctx.AutoWireBeans()
+
+	var logger *zap.Logger
+	assert.Equal(t, ctx.GetBean(&logger), true)
+
+	SpringLogger.Debugf("hello %s", "zap")
+	SpringLogger.Trace("trace")
+	ctx.Close() // Destroy 函数刷新日志缓冲
+
+	b, err := ioutil.ReadFile(file)
+	assert.Equal(t, err, nil)
+
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		var entry map[string]interface{}
+		assert.Equal(t, json.Unmarshal([]byte(line), &entry), nil)
+		if strings.HasPrefix(entry["caller"].(string), "starter-zap/") {
+			lines = append(lines, entry)
+		}
+	}
+
+	assert.Equal(t, len(lines), 2)
+	assert.Equal(t, lines[0]["level"], "debug")
+	assert.Equal(t, lines[0]["msg"], "hello zap")
+	assert.Matches(t, lines[0]["caller"].(string), "^starter-zap/starter-zap_test.go:\\d+$")
+	assert.Equal(t, lines[1]["msg"], "trace")
+}
+
+func TestZapLogger_Disabled(t *testing.T) {
+
+	ctx := SpringBoot.CopyContext()
+	ctx.SetProperty("log.engine", "logrus")
+	ctx.AutoWireBeans()
+	defer ctx.Close()
+
+	var logger *zap.Logger
+	assert.Equal(t, ctx.GetBean(&logger), false)
+}
+
+func TestNewLogger_BadLevel(t *testing.T) {
+	_, err := NewLogger(ZapConfig{Level: "verbose", Encoding: "json"})
+	assert.Equal(t, err != nil, true)
+}